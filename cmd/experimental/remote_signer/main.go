@@ -0,0 +1,101 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// remote_signer is a signing service holding a log private key, so that
+// internet-facing TesseraCT servers don't need access to it.
+//
+// It signs any digest it is sent, so any client allowed to reach it can get
+// SCTs and checkpoints signed for arbitrary content. Clients must present a
+// certificate issued by a CA dedicated to the signing service, whose public
+// key is listed in --allowed_client_spki_sha256.
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/remotesigner"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+var (
+	httpEndpoint    = flag.String("http_endpoint", "localhost:6963", "Endpoint for HTTPS (host:port).")
+	privKeyFile     = flag.String("private_key", "", "Location of the log private key file, in PEM format.")
+	tlsCertFile     = flag.String("tls_cert_file", "", "Path to the PEM encoded TLS certificate of the service.")
+	tlsKeyFile      = flag.String("tls_key_file", "", "Path to the PEM encoded TLS private key of the service.")
+	tlsClientCAFile = flag.String("tls_client_ca_file", "", "Path to the PEM encoded CA certificates that client certificates must chain to. This CA must be dedicated to the signing service.")
+	allowedClients  = flag.String("allowed_client_spki_sha256", "", "Comma separated list of the hex encoded SHA-256 hashes of the DER encoded SubjectPublicKeyInfo of the client certificates allowed to request signatures.")
+	maxBatchSize    = flag.Int("max_batch_size", remotesigner.DefaultMaxBatchSize, "Maximum number of digests signed in a single request.")
+	slogLevel       = flag.Int("slog_level", 0, "The cut-off threshold for structured logging. See cmd/tesseract/README.md#Logging.")
+)
+
+func main() {
+	flag.Parse()
+	ctx := context.Background()
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.Level(*slogLevel)})))
+
+	r, err := os.ReadFile(*privKeyFile)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read private key", slog.String("path", *privKeyFile), slog.Any("error", err))
+		os.Exit(1)
+	}
+	block, _ := pem.Decode(r)
+	if block == nil {
+		slog.ErrorContext(ctx, "Failed to parse PEM private key", slog.String("path", *privKeyFile))
+		os.Exit(1)
+	}
+	signer, err := x509util.PrivateKeyFromPEMBlock(block)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse private key", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if *tlsClientCAFile == "" {
+		slog.ErrorContext(ctx, "Must specify --tls_client_ca_file, with a CA dedicated to the signing service")
+		os.Exit(1)
+	}
+	if *allowedClients == "" {
+		slog.ErrorContext(ctx, "Must specify --allowed_client_spki_sha256, with the public keys of the log servers' client certificates")
+		os.Exit(1)
+	}
+	tlsCfg, err := remotesigner.ServerTLSConfig(*tlsCertFile, *tlsKeyFile, *tlsClientCAFile)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set up mTLS", slog.Any("error", err))
+		os.Exit(1)
+	}
+	h, err := remotesigner.NewHandler(signer, *maxBatchSize, strings.Split(*allowedClients, ","))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create signing handler", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	srv := &http.Server{
+		Addr:              *httpEndpoint,
+		Handler:           h,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	slog.InfoContext(ctx, "Serving signing requests", slog.String("endpoint", *httpEndpoint))
+	if err := srv.ListenAndServeTLS("", ""); !errors.Is(err, http.ErrServerClosed) {
+		slog.ErrorContext(ctx, "Server exited", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
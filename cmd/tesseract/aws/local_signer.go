@@ -16,17 +16,18 @@ package main
 
 import (
 	"crypto"
-	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
+
+	"github.com/transparency-dev/tesseract/internal/x509util"
 )
 
-// NewLocalSigner creates a new signer that uses the ECDSA P-256 key pair from
+// NewLocalSigner creates a new signer that uses the ECDSA or RSA key pair from
 // local disk files for signing digests.
-func NewLocalSigner(publicKeyFile, privateKeyFile string) (*ECDSAWithSHA256Signer, error) {
+func NewLocalSigner(publicKeyFile, privateKeyFile string) (*SHA256Signer, error) {
 	// Read public key
 	publicKeyPEM, err := os.ReadFile(publicKeyFile)
 	if err != nil {
@@ -52,11 +53,6 @@ func NewLocalSigner(publicKeyFile, privateKeyFile string) (*ECDSAWithSHA256Signe
 		return nil, err
 	}
 
-	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("the public key is not an ECDSA key")
-	}
-
 	// Read private key
 	privateKeyPEM, err := os.ReadFile(privateKeyFile)
 	if err != nil {
@@ -71,24 +67,18 @@ func NewLocalSigner(publicKeyFile, privateKeyFile string) (*ECDSAWithSHA256Signe
 		return nil, fmt.Errorf("extra data after decoding private key PEM: %v", rest)
 	}
 
-	var ecdsaPrivateKey *ecdsa.PrivateKey
-	switch privatePemBlock.Type {
-	case "EC PRIVATE KEY":
-		ecdsaPrivateKey, err = x509.ParseECPrivateKey(privatePemBlock.Bytes)
-	default:
-		return nil, fmt.Errorf("unsupported private key PEM type: %s", privatePemBlock.Type)
-	}
+	privateKey, err := x509util.PrivateKeyFromPEMBlock(privatePemBlock)
 	if err != nil {
 		return nil, err
 	}
 
 	// Verify the correctness of the signer key pair
-	if !ecdsaPrivateKey.PublicKey.Equal(ecdsaPublicKey) {
-		return nil, errors.New("signer key pair doesn't match")
+	if err := x509util.MatchesPublicKey(privateKey, publicKey); err != nil {
+		return nil, err
 	}
 
-	return &ECDSAWithSHA256Signer{
-		publicKey:  ecdsaPublicKey,
-		privateKey: ecdsaPrivateKey,
+	return &SHA256Signer{
+		publicKey:  publicKey,
+		privateKey: privateKey,
 	}, nil
 }
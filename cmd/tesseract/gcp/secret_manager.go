@@ -17,7 +17,6 @@ package main
 import (
 	"context"
 	"crypto"
-	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -29,24 +28,26 @@ import (
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/transparency-dev/tesseract/internal/x509util"
 	"golang.org/x/mod/sumdb/note"
 )
 
-// TODO: Move ECDSAWithSHA256Signer to internal signer package.
-// ECDSAWithSHA256Signer implements crypto.Signer using Google Cloud Secret Manager.
-// Only crypto.SHA256 and ECDSA are supported.
-type ECDSAWithSHA256Signer struct {
-	publicKey  *ecdsa.PublicKey
-	privateKey *ecdsa.PrivateKey
+// TODO: Move SHA256Signer to internal signer package.
+// SHA256Signer implements crypto.Signer using Google Cloud Secret Manager.
+// Only crypto.SHA256 with ECDSA or RSA keys is supported.
+type SHA256Signer struct {
+	publicKey  crypto.PublicKey
+	privateKey crypto.Signer
 }
 
 // Public returns the public key stored in the Signer object.
-func (s *ECDSAWithSHA256Signer) Public() crypto.PublicKey {
+func (s *SHA256Signer) Public() crypto.PublicKey {
 	return s.publicKey
 }
 
 // Sign signs digest with the private key stored in Google Cloud Secret Manager.
-func (s *ECDSAWithSHA256Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+// RSA keys produce PKCS #1 v1.5 signatures.
+func (s *SHA256Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
 	// Verify hash function and digest bytes length.
 	if opts == nil {
 		return nil, errors.New("opts cannot be nil")
@@ -58,12 +59,12 @@ func (s *ECDSAWithSHA256Signer) Sign(rand io.Reader, digest []byte, opts crypto.
 		return nil, fmt.Errorf("digest bytes length %d does not match hash function bytes length %d", len(digest), opts.HashFunc().Size())
 	}
 
-	return ecdsa.SignASN1(rand, s.privateKey, digest)
+	return s.privateKey.Sign(rand, digest, crypto.SHA256)
 }
 
-// NewSecretManagerSigner creates a new signer that uses the ECDSA P-256 key pair in
+// NewSecretManagerSigner creates a new signer that uses the ECDSA or RSA key pair in
 // Google Cloud Secret Manager for signing digests.
-func NewSecretManagerSigner(ctx context.Context, publicKeySecretName, privateKeySecretName string) (*ECDSAWithSHA256Signer, error) {
+func NewSecretManagerSigner(ctx context.Context, publicKeySecretName, privateKeySecretName string) (*SHA256Signer, error) {
 	client, err := secretmanager.NewClient(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create secret manager client: %w", err)
@@ -93,36 +94,25 @@ func NewSecretManagerSigner(ctx context.Context, publicKeySecretName, privateKey
 	if err != nil {
 		return nil, err
 	}
-	var ecdsaPublicKey *ecdsa.PublicKey
-	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("the public key stored in Secret Manager is not an ECDSA key")
-	}
 
 	// Private Key
-	var ecdsaPrivateKey *ecdsa.PrivateKey
 	pemBlock, err = secretPEM(ctx, client, privateKeySecretName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get private key secret PEM (%s): %w", privateKeySecretName, err)
 	}
-	switch pemBlock.Type {
-	case "EC PRIVATE KEY":
-		ecdsaPrivateKey, err = x509.ParseECPrivateKey(pemBlock.Bytes)
-	default:
-		return nil, fmt.Errorf("unsupported PEM type: %s", pemBlock.Type)
-	}
+	privateKey, err := x509util.PrivateKeyFromPEMBlock(pemBlock)
 	if err != nil {
 		return nil, err
 	}
 
 	// Verify the correctness of the signer key pair
-	if !ecdsaPrivateKey.PublicKey.Equal(ecdsaPublicKey) {
-		return nil, errors.New("signer key pair doesn't match")
+	if err := x509util.MatchesPublicKey(privateKey, publicKey); err != nil {
+		return nil, err
 	}
 
-	return &ECDSAWithSHA256Signer{
-		publicKey:  ecdsaPublicKey,
-		privateKey: ecdsaPrivateKey,
+	return &SHA256Signer{
+		publicKey:  publicKey,
+		privateKey: privateKey,
 	}, nil
 }
 
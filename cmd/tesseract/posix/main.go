@@ -18,11 +18,11 @@ package main
 import (
 	"context"
 	"crypto"
-	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	_ "net/http/pprof"
@@ -42,6 +42,10 @@ import (
 	tposix "github.com/transparency-dev/tessera/storage/posix"
 	tposix_as "github.com/transparency-dev/tessera/storage/posix/antispam"
 	"github.com/transparency-dev/tesseract"
+	"github.com/transparency-dev/tesseract/internal/httpserver"
+	"github.com/transparency-dev/tesseract/internal/pkcs11"
+	"github.com/transparency-dev/tesseract/internal/remotesigner"
+	"github.com/transparency-dev/tesseract/internal/x509util"
 	"github.com/transparency-dev/tesseract/storage"
 	"github.com/transparency-dev/tesseract/storage/posix"
 	"golang.org/x/mod/sumdb/note"
@@ -72,23 +76,57 @@ var (
 	// Functionality flags
 	httpEndpoint             = flag.String("http_endpoint", "localhost:6962", "Endpoint for HTTP (host:port).")
 	maxCertChainBytes        = flag.Int64("max_cert_chain_bytes", 512<<10, "Maximum size of certificate chain in bytes for add-chain and add-pre-chain endpoints (default: 512 KiB)")
+	enableRFC6962ReadAPI     = flag.Bool("enable_rfc6962_read_api", false, "Serve RFC 6962 read endpoints (get-sth, get-sth-consistency, get-proof-by-hash, get-entries, get-entry-and-proof) from the log data.")
+	enableValidateChain      = flag.Bool("enable_validate_chain", false, "Serve the non-standard validate-chain endpoint, which runs add-chain and add-pre-chain checks on a chain without adding it to the log, and returns why it would be rejected.")
 	maskInternalErrors       = flag.Bool("mask_internal_errors", false, "Don't return error strings with Internal Server Error HTTP responses.")
+	jsonErrors               = flag.Bool("json_errors", false, "If true, error responses have a JSON body with a stable error code, rather than a plain text one.")
+	adminTokenFile           = flag.String("admin_token_file", "", "Path to a file containing bearer tokens, one per line, which authenticate requests to the admin API. If unset, the admin API is disabled.")
+	adminHTTPEndpoint        = flag.String("admin_http_endpoint", "", "Endpoint for the admin API (host:port). Required by admin_token_file: the admin API is never served on http_endpoint.")
+	logState                 = flag.String("log_state", "", "Lifecycle state the log must be in at startup: usable, read_only or retired. The log fails to start if its persisted state, usable if it has none, is different, unless --set_log_state is true. If unset, the log keeps its persisted state.")
+	setLogState              = flag.Bool("set_log_state", false, "If true, moves the log to --log_state at startup and persists it, for all the instances of the log. Only set it for the restart which changes the state.")
 	origin                   = flag.String("origin", "", "Origin of the log, for checkpoints. This MUST match the log's submission prefix as per https://c2sp.org/static-ct-api.")
 	pathPrefix               = flag.String("path_prefix", "", "Prefix to use on endpoints URL paths: HOST:PATH_PREFIX/ct/v1/ENDPOINT.")
 	rootsPemFile             = flag.String("roots_pem_file", "", "Path to the file containing root certificates that are acceptable to the log.")
 	rootsRemoteFetchInterval = flag.Duration("roots_remote_fetch_interval", time.Duration(0), "Interval between two fetches from roots_fetch_url, e.g. \"1h\". Set to \"0s\" to disable.")
+	rootsReloadInterval      = flag.Duration("roots_reload_interval", time.Duration(0), "Interval between two reloads of the trusted roots from roots_pem_file and the remote roots backup, e.g. \"1h\". Roots that are no longer there are removed. Roots are also reloaded on SIGHUP. Set to \"0s\" to disable periodic reloads.")
 	rejectExpired            = flag.Bool("reject_expired", false, "If true then the certificate validity period will be checked against the current time during the validation of submissions. This will cause expired certificates to be rejected.")
 	rejectUnexpired          = flag.Bool("reject_unexpired", false, "If true then TesseraCT rejects certificates that are either currently valid or not yet valid.")
 	extKeyUsages             = flag.String("ext_key_usages", "", "If set, will restrict the set of such usages that the server will accept. By default only 'ServerAuth' certs are accepted. Set to 'Any' to accept all chain. Accepted values are defined in internal/ct.")
 	rejectExtensions         = flag.String("reject_extension", "", "A list of X.509 extension OIDs, in dotted string form (e.g. '2.3.4.5') which, if present, should cause submissions to be rejected.")
+	submissionPolicyFile     = flag.String("submission_policy_file", "", "Path to a JSON file listing additional policies that submissions must comply with. See cmd/tesseract/README.md for the format.")
+	issuerFilterFile         = flag.String("issuer_filter_file", "", "Path to a JSON file listing intermediates that are allowed or denied, by fingerprint or SPKI hash. Reloaded on SIGHUP. See cmd/tesseract/README.md for the format.")
+	filterReloadInterval     = flag.Duration("issuer_filter_reload_interval", time.Duration(0), "Interval between two reloads of issuer_filter_file, e.g. \"1h\". Set to \"0s\" to disable periodic reloads.")
+	verifiedPathCacheSize    = flag.Int("verified_path_cache_size", 4096, "Number of verified paths from issuers to roots to cache, so that chains sharing their issuers with a chain verified earlier only need their leaf to be checked. Set to 0 to disable.")
+	dedupCacheSize           = flag.Int("dedup_cache_size", 16384, "Number of recently submitted chains for which to remember the SCT input, so that their resubmissions get an SCT without being validated again. Resubmissions answered from this cache are subject to rate_limit_dedup and issuer quotas, and the cache is invalidated when roots or the issuer filter change. Set to 0 to disable.")
+	lintSeverities           = flag.String("lints", "", "Comma separated list of <lint>=<severity> setting the severity of built-in certificate lints: off, warn or reject. The special 'all' lint sets the severity of lints that are not listed. E.g. 'all=warn,serial_not_positive=reject'. All lints are off by default.")
 	acceptSHA1               = flag.Bool("accept_sha1_signing_algorithms", true, "If true, accept chains that use SHA-1 based signing algorithms. This flag will eventually be removed, and such algorithms will be rejected.")
+	strictPrecerts           = flag.Bool("strict_precert_validation", false, "If true, rejects precertificate chains which do not follow the structure defined by RFC 6962 section 3.1, and final certificates issued by precertificate signing certificates.")
+	enforceNameConstraints   = flag.Bool("enforce_name_constraints", false, "If true, rejects chains with names which are not allowed by the name constraints of their intermediates or root.")
+	enforcePathLength        = flag.Bool("enforce_path_length", false, "If true, rejects chains with more intermediates than allowed by the path length constraints of their intermediates or root. Precertificate signing certificates are not counted.")
 	enablePublicationAwaiter = flag.Bool("enable_publication_awaiter", true, "If true, waits for the submitted certificate to be covered by a published checkpoint before responding to an add-* request.")
+	enableLeafIndex          = flag.Bool("enable_leaf_index", false, "If true, maintains a leaf hash to index lookup table in a Badger database next to the antispam one, under storage_dir/.state/leafindex, used by the RFC 6962 get-proof-by-hash endpoint, which is not served without it. Existing entries are backfilled.")
 	witnessPolicyFile        = flag.String("witness_policy_file", "", "(Optional) Path to the file containing the witness policy in the format described at https://git.glasklar.is/sigsum/core/sigsum-go/-/blob/main/doc/policy.md")
 	witnessTimeout           = flag.Duration("witness_timeout", tessera.DefaultWitnessTimeout, "Maximum time to wait for witness responses.")
 	notBeforeRL              = flag.String("rate_limit_old_not_before", "28h:500", "Optionally rate limits submissions with old notBefore dates. Expects a value of with the format: \"<go duration>:<rate limit>\", e.g. \"30d:50\" would impose a limit of 50 certs/s on submissions whose notBefore date is >= 30days old.")
+	quotasFile               = flag.String("rate_limit_quotas_file", "", "Path to a JSON file configuring per client IP, client identity, and issuer rate limits on submissions. See cmd/tesseract/README.md for the format.")
+	authFile                 = flag.String("submission_auth_file", "", "Path to a JSON file listing the API keys and TLS client certificates of the clients allowed to submit to the log. When set, other clients are rejected. Client certificates are only seen if the log serves HTTPS itself, with tls_cert_file or tls_self_signed_hosts, and tls_client_ca_file or tls_request_client_cert. See cmd/tesseract/README.md for the format.")
+	tlsCertFile              = flag.String("tls_cert_file", "", "Path to the PEM encoded certificate chain of the server. When set, the log serves HTTPS on http_endpoint. The certificate and tls_key_file are reloaded when they change, and on SIGHUP.")
+	tlsKeyFile               = flag.String("tls_key_file", "", "Path to the PEM encoded private key of the server.")
+	tlsSelfSignedHosts       = flag.String("tls_self_signed_hosts", "", "Comma separated list of DNS names and IP addresses of a self-signed certificate generated at startup. When set, the log serves HTTPS on http_endpoint. Can't be used with tls_cert_file.")
+	tlsClientCAFile          = flag.String("tls_client_ca_file", "", "Path to PEM encoded CA certificates, against which client certificates are verified if clients present one.")
+	tlsRequestClientCert     = flag.Bool("tls_request_client_cert", false, "If true, asks clients for a certificate without verifying it, e.g. to identify clients by the hash of their public key. Ignored if tls_client_ca_file is set.")
+	tlsReloadInterval        = flag.Duration("tls_reload_interval", httpserver.DefaultTLSReloadInterval, "Interval between two checks for new tls_cert_file and tls_key_file.")
 
 	// Performance flags
 	httpDeadline                = flag.Duration("http_deadline", time.Second*10, "Deadline for HTTP requests.")
+	httpReadHeaderTimeout       = flag.Duration("http_read_header_timeout", httpserver.DefaultReadHeaderTimeout, "Maximum duration for reading the headers of a request.")
+	httpReadTimeout             = flag.Duration("http_read_timeout", 0, "Maximum duration for reading a whole request, including its body. Set to 0 for no timeout.")
+	httpWriteTimeout            = flag.Duration("http_write_timeout", 0, "Maximum duration for writing a response, from the end of the request headers. Must be longer than http_deadline. Set to 0 for no timeout.")
+	httpIdleTimeout             = flag.Duration("http_idle_timeout", 0, "Maximum duration to wait for the next request on a keep-alive connection. Set to 0 to use http_read_timeout.")
+	http2MaxConcurrentStreams   = flag.Int("http2_max_concurrent_streams", 0, "Maximum number of concurrent streams per HTTP/2 connection. Set to 0 to use the net/http default.")
+	http2MaxReadFrameSize       = flag.Int("http2_max_read_frame_size", 0, "Largest HTTP/2 frame the server reads, in bytes. Set to 0 to use the net/http default.")
+	http2SendPingTimeout        = flag.Duration("http2_send_ping_timeout", 0, "Idle duration after which the server pings HTTP/2 connections to check their health. Set to 0 to disable.")
+	http2Unencrypted            = flag.Bool("http2_unencrypted", false, "If true, serves unencrypted HTTP/2 (h2c) in addition to HTTP/1, e.g. for load balancers talking HTTP/2 to their backends. Can't be used with TLS.")
 	inMemoryAntispamCacheSize   = flag.String("inmemory_antispam_cache_size", "256k", "Maximum number of entries to keep in the in-memory antispam cache. Unitless with SI metric prefixes, such as '256k'.")
 	checkpointInterval          = flag.Duration("checkpoint_interval", 1500*time.Millisecond, "Interval between publishing checkpoints when the log has grown")
 	checkpointRepublishInterval = flag.Duration("checkpoint_republish_interval", 30*time.Second, "Interval between republishing a checkpoint for a log which hasn't grown since the previous checkpoint was published")
@@ -96,6 +134,14 @@ var (
 	batchMaxAge                 = flag.Duration("batch_max_age", tessera.DefaultBatchMaxAge, "Maximum age of entries in a single sequencing batch.")
 	pushbackMaxOutstanding      = flag.Uint("pushback_max_outstanding", tessera.DefaultPushbackMaxOutstanding, "Maximum number of in-flight add requests - i.e. the number of entries with sequence numbers assigned, but which are not yet integrated into the log.")
 	pushbackMaxAntispamLag      = flag.Uint("pushback_max_antispam_lag", tposix_as.DefaultPushbackThreshold, "Maximum permitted lag for antispam follower, before log starts returning pushback.")
+	adaptiveMaxQPS              = flag.Float64("pushback_adaptive_max_qps", 0, "Highest number of submissions per second admitted by adaptive pushback, which lowers this rate when the log shows signs of overload. Set to 0 to disable adaptive pushback.")
+	adaptiveMinQPS              = flag.Float64("pushback_adaptive_min_qps", 1, "Lowest number of submissions per second admitted by adaptive pushback.")
+	adaptiveMaxAwaitLatency     = flag.Duration("pushback_adaptive_max_await_latency", 5*time.Second, "Adaptive pushback lowers its admission rate when entries take longer than this to be published. Only observed with enable_publication_awaiter, or for duplicate entries. Set to 0 to ignore.")
+	adaptiveMaxCheckpointAge    = flag.Duration("pushback_adaptive_max_checkpoint_age", 30*time.Second, "Adaptive pushback lowers its admission rate when the checkpoint hasn't grown for this long while entries are waiting to be integrated. Set to 0 to ignore.")
+	lanesConcurrency            = flag.Int("lanes_concurrency", 0, "Number of submissions that can be added to storage at once, across all priority lanes. When reached, submissions wait in their lane: precert, fresh, backfill or duplicate. Set to 0 to disable priority lanes.")
+	lanesWeights                = flag.String("lanes_weights", "precert=8,fresh=8,duplicate=2,backfill=1", "Comma separated list of <lane>=<weight>, setting the share of storage each priority lane gets when submissions are waiting in several lanes.")
+	lanesMaxQueue               = flag.Int("lanes_max_queue", 1000, "Number of submissions that can wait in each priority lane. Submissions to a full lane are pushed back.")
+	lanesBackfillAge            = flag.Duration("lanes_backfill_age", 28*time.Hour, "Certificates whose notBefore date is at least this old go to the backfill priority lane.")
 	clientHTTPTimeout           = flag.Duration("client_http_timeout", 5*time.Second, "Timeout for outgoing HTTP requests")
 	clientHTTPMaxIdle           = flag.Int("client_http_max_idle", 20, "Maximum number of idle HTTP connections for outgoing requests.")
 	clientHTTPMaxIdlePerHost    = flag.Int("client_http_max_idle_per_host", 10, "Maximum number of idle HTTP connections per host for outgoing requests.")
@@ -110,10 +156,20 @@ var (
 	awaiterPollInterval         = flag.Duration("awaiter_poll_interval", storage.DefaultAwaiterPollInterval, "Interval between two checkpoint polls by the awaiter. Used for antispam, and if enable_publication_awaiter is set, to block add-* requests responses. Must be strictly positive or defaults to DefaultAwaiterPollInterval.")
 
 	// Infrastructure setup flags
-	storageDir    = flag.String("storage_dir", "", "Path to root of log storage.")
-	privKeyFile   = flag.String("private_key", "", "Location of private key file. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
-	traceFraction = flag.Float64("trace_fraction", 0, "Fraction of open-telemetry span traces to sample")
-	slogLevel     = flag.Int("slog_level", 0, "The cut-off threshold for structured logging. See cmd/tesseract/README.md#Logging.")
+	storageDir              = flag.String("storage_dir", "", "Path to root of log storage.")
+	privKeyFile             = flag.String("private_key", "", "Location of private key file. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
+	pkcs11Module            = flag.String("pkcs11_module", "", "Path to a PKCS #11 module shared library. If set, the log signs with a key held in a PKCS #11 token instead of private_key. Requires a binary built with cgo.")
+	pkcs11Token             = flag.String("pkcs11_token_label", "", "Label of the PKCS #11 token holding the log key.")
+	pkcs11Key               = flag.String("pkcs11_key_label", "", "Label of the PKCS #11 private and public key objects.")
+	pkcs11PINFile           = flag.String("pkcs11_pin_file", "", "Path to a file containing the PKCS #11 token user PIN. If unset, uses the contents of the PKCS11_PIN environment variable.")
+	remoteSignerURL         = flag.String("remote_signer_url", "", "URL of a remote signing service holding the log key, e.g. https://signer.example.com:6963. If set, the log signs with it instead of private_key.")
+	remoteSignerCertFile    = flag.String("remote_signer_cert_file", "", "Path to the PEM encoded TLS client certificate presented to the remote signing service.")
+	remoteSignerKeyFile     = flag.String("remote_signer_key_file", "", "Path to the PEM encoded TLS client private key presented to the remote signing service.")
+	remoteSignerCAFile      = flag.String("remote_signer_ca_file", "", "Path to the PEM encoded CA certificates trusted to authenticate the remote signing service.")
+	remoteSignerTimeout     = flag.Duration("remote_signer_timeout", remotesigner.DefaultTimeout, "Maximum duration of a signing request to the remote signing service.")
+	remoteSignerBatchWindow = flag.Duration("remote_signer_batch_window", 0, "How long to wait for more digests before sending a batch to the remote signing service. When 0, batches are sent as soon as possible.")
+	traceFraction           = flag.Float64("trace_fraction", 0, "Fraction of open-telemetry span traces to sample")
+	slogLevel               = flag.Int("slog_level", 0, "The cut-off threshold for structured logging. See cmd/tesseract/README.md#Logging.")
 )
 
 func main() {
@@ -123,7 +179,7 @@ func main() {
 
 	shutdownOTel := initOTel(ctx, *traceFraction, *origin)
 	defer shutdownOTel(ctx)
-	signer := signerFromFlags()
+	signer, signerCloser := signerFromFlags()
 
 	fetchedRootsBackupStorage, err := posix.NewRootsStorage(ctx, *storageDir)
 	if err != nil {
@@ -135,19 +191,34 @@ func main() {
 		rootsRemoteFetchURLs = []string{"https://ccadb.my.salesforce-sites.com/ccadb/RootCACertificatesIncludedByRSReportCSV"}
 	}
 
+	// Only listen for issuer filter reloads if there's a filter to reload.
+	var issuerFilterReload <-chan struct{}
+	if *issuerFilterFile != "" {
+		issuerFilterReload = awaitReloadSignal("issuer filter")
+	}
 	chainValidationConfig := tesseract.ChainValidationConfig{
-		RootsPEMFile:             *rootsPemFile,
-		RootsRemoteFetchURLs:     rootsRemoteFetchURLs,
-		RootsRemoteFetchInterval: *rootsRemoteFetchInterval,
-		RootsRemoteFetchBackup:   fetchedRootsBackupStorage,
-		RejectExpired:            *rejectExpired,
-		RejectUnexpired:          *rejectUnexpired,
-		ExtKeyUsages:             *extKeyUsages,
-		RejectExtensions:         *rejectExtensions,
-		NotAfterStart:            notAfterStart.t,
-		NotAfterLimit:            notAfterLimit.t,
-		AcceptSHA1:               *acceptSHA1,
-		RejectRoots:              rootsRejectFingerprints,
+		RootsPEMFile:               *rootsPemFile,
+		RootsRemoteFetchURLs:       rootsRemoteFetchURLs,
+		RootsRemoteFetchInterval:   *rootsRemoteFetchInterval,
+		RootsRemoteFetchBackup:     fetchedRootsBackupStorage,
+		RejectExpired:              *rejectExpired,
+		RejectUnexpired:            *rejectUnexpired,
+		ExtKeyUsages:               *extKeyUsages,
+		RejectExtensions:           *rejectExtensions,
+		PolicyFile:                 *submissionPolicyFile,
+		IssuerFilterFile:           *issuerFilterFile,
+		IssuerFilterReloadInterval: *filterReloadInterval,
+		IssuerFilterReload:         issuerFilterReload,
+		VerifiedPathCacheSize:      *verifiedPathCacheSize,
+		NotAfterStart:              notAfterStart.t,
+		NotAfterLimit:              notAfterLimit.t,
+		AcceptSHA1:                 *acceptSHA1,
+		StrictPrecertValidation:    *strictPrecerts,
+		EnforceNameConstraints:     *enforceNameConstraints,
+		EnforcePathLength:          *enforcePathLength,
+		RejectRoots:                rootsRejectFingerprints,
+		RootsReloadInterval:        *rootsReloadInterval,
+		RootsReload:                awaitReloadSignal("roots"),
 	}
 	if *acceptSHA1 {
 		slog.InfoContext(ctx, `**** WARNING **** This server will accept chains signed
@@ -156,12 +227,46 @@ submitted by Chrome's Merge Delay Monitor Root for the time being, but will
 eventually go away. See /internal/lax509/README.md for more information.`)
 	}
 
-	hOpts := tesseract.LogHandlerOpts{
-		NotBeforeRL:       notBeforeRLFromFlags(),
-		DedupRL:           dedupRL,
-		MaxCertChainBytes: *maxCertChainBytes,
+	adminOpts, err := adminOptsFromFlags()
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid admin API flags", slog.Any("error", err))
+		os.Exit(1)
+	}
+	lints, err := tesseract.ParseLintSeverities(*lintSeverities)
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid lints flag", slog.Any("error", err))
+		os.Exit(1)
+	}
+	lanes, err := lanesFromFlags()
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid lanes flags", slog.Any("error", err))
+		os.Exit(1)
 	}
-	logHandler, err := tesseract.NewLogHandler(ctx, *origin, signer, chainValidationConfig, newStorage, *httpDeadline, *maskInternalErrors, *pathPrefix, hOpts)
+	hOpts := tesseract.LogHandlerOpts{
+		NotBeforeRL:          notBeforeRLFromFlags(),
+		DedupRL:              dedupRL,
+		DedupCacheSize:       *dedupCacheSize,
+		QuotasFile:           *quotasFile,
+		AuthFile:             *authFile,
+		AdaptivePushback:     adaptivePushbackFromFlags(),
+		Lanes:                lanes,
+		MaxCertChainBytes:    *maxCertChainBytes,
+		EnableRFC6962ReadAPI: *enableRFC6962ReadAPI,
+		EnableValidateChain:  *enableValidateChain,
+		JSONErrors:           *jsonErrors,
+		Admin:                adminOpts,
+		State:                tesseract.LogState(*logState),
+		PersistState:         *setLogState,
+		Lints:                lints,
+	}
+	// Keep hold of the storage to close it on shutdown.
+	var ctStorage *storage.CTStorage
+	keepStorage := func(ctx context.Context, signer note.Signer) (*storage.CTStorage, error) {
+		st, err := newStorage(ctx, signer)
+		ctStorage = st
+		return st, err
+	}
+	logHandler, err := tesseract.NewLogHandler(ctx, *origin, signer, chainValidationConfig, keepStorage, *httpDeadline, *maskInternalErrors, *pathPrefix, hOpts)
 	if err != nil {
 		slog.ErrorContext(ctx, "Can't initialize CT HTTP Server", slog.Any("error", err))
 		os.Exit(1)
@@ -171,11 +276,24 @@ eventually go away. See /internal/lax509/README.md for more information.`)
 	http.Handle("/", logHandler)
 
 	// Bring up the HTTP server and serve until we get a signal not to.
-	srv := http.Server{
-		Addr: *httpEndpoint,
-		// Set timeout for reading headers to avoid a slowloris attack.
-		ReadHeaderTimeout: 5 * time.Second,
-		MaxHeaderBytes:    8 << 10, // 8 KiB
+	srv, err := httpserver.New(ctx, *httpEndpoint, nil, serverOptsFromFlags())
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid HTTP server flags", slog.Any("error", err))
+		os.Exit(1)
+	}
+	var adminSrv *http.Server
+	if adminOpts != nil {
+		adminSrv = &http.Server{
+			Addr:              *adminHTTPEndpoint,
+			Handler:           adminOpts.Mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			MaxHeaderBytes:    8 << 10, // 8 KiB
+		}
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != http.ErrServerClosed {
+				slog.ErrorContext(ctx, "Admin server exited", slog.Any("error", err))
+			}
+		}()
 	}
 	shutdownWG := new(sync.WaitGroup)
 	shutdownWG.Add(1)
@@ -189,7 +307,22 @@ eventually go away. See /internal/lax509/README.md for more information.`)
 		if err := srv.Shutdown(ctx); err != nil {
 			slog.ErrorContext(ctx, "srv.Shutdown()", slog.Any("error", err))
 		}
+		if adminSrv != nil {
+			if err := adminSrv.Shutdown(ctx); err != nil {
+				slog.ErrorContext(ctx, "adminSrv.Shutdown()", slog.Any("error", err))
+			}
+		}
 		slog.InfoContext(ctx, "HTTP server shutdown")
+		if ctStorage != nil {
+			if err := ctStorage.Close(); err != nil {
+				slog.ErrorContext(ctx, "ctStorage.Close()", slog.Any("error", err))
+			}
+		}
+		if signerCloser != nil {
+			if err := signerCloser.Close(); err != nil {
+				slog.ErrorContext(ctx, "signer.Close()", slog.Any("error", err))
+			}
+		}
 	})
 
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
@@ -200,6 +333,91 @@ eventually go away. See /internal/lax509/README.md for more information.`)
 	shutdownWG.Wait()
 }
 
+// adminOptsFromFlags returns the admin API options, or nil if the admin API
+// is disabled.
+func adminOptsFromFlags() (*tesseract.AdminOpts, error) {
+	if *adminTokenFile == "" {
+		if *adminHTTPEndpoint != "" {
+			return nil, errors.New("admin_http_endpoint requires admin_token_file")
+		}
+		return nil, nil
+	}
+	if *adminHTTPEndpoint == "" {
+		return nil, errors.New("admin_token_file requires admin_http_endpoint")
+	}
+	b, err := os.ReadFile(*adminTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin tokens from %q: %v", *adminTokenFile, err)
+	}
+	var tokens []string
+	for _, l := range strings.Split(string(b), "\n") {
+		if t := strings.TrimSpace(l); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no admin token in %q", *adminTokenFile)
+	}
+	return &tesseract.AdminOpts{Tokens: tokens, Mux: http.NewServeMux()}, nil
+}
+
+// serverOptsFromFlags returns the options of the HTTP server.
+func serverOptsFromFlags() httpserver.Opts {
+	opts := httpserver.Opts{
+		ReadHeaderTimeout:         *httpReadHeaderTimeout,
+		ReadTimeout:               *httpReadTimeout,
+		WriteTimeout:              *httpWriteTimeout,
+		IdleTimeout:               *httpIdleTimeout,
+		HTTP2MaxConcurrentStreams: *http2MaxConcurrentStreams,
+		HTTP2MaxReadFrameSize:     *http2MaxReadFrameSize,
+		HTTP2SendPingTimeout:      *http2SendPingTimeout,
+		UnencryptedHTTP2:          *http2Unencrypted,
+	}
+	if *tlsCertFile == "" && *tlsSelfSignedHosts == "" {
+		return opts
+	}
+	var hosts []string
+	for _, h := range strings.Split(*tlsSelfSignedHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	opts.TLS = &httpserver.TLSOpts{
+		CertFile:          *tlsCertFile,
+		KeyFile:           *tlsKeyFile,
+		SelfSignedHosts:   hosts,
+		ClientCAFile:      *tlsClientCAFile,
+		RequestClientCert: *tlsRequestClientCert,
+		ReloadInterval:    *tlsReloadInterval,
+	}
+	// Self-signed certificates are never reloaded.
+	if *tlsCertFile != "" {
+		opts.TLS.Reload = awaitReloadSignal("TLS certificate")
+	}
+	return opts
+}
+
+// awaitReloadSignal returns a channel which receives a value every time the
+// process receives a SIGHUP, to reload what.
+//
+// Signals received while a reload is already pending are dropped, so that
+// a channel nobody reads from never blocks.
+func awaitReloadSignal(what string) <-chan struct{} {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	reload := make(chan struct{}, 1)
+	go func() {
+		for sig := range sigs {
+			slog.InfoContext(context.Background(), "Signal received, reloading "+what, slog.Any("signal", sig))
+			select {
+			case reload <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return reload
+}
+
 // awaitSignal waits for standard termination signals, then runs the given
 // function; it should be run as a separate goroutine.
 func awaitSignal(doneFn func()) {
@@ -249,10 +467,10 @@ func newStorage(ctx context.Context, signer note.Signer) (st *storage.CTStorage,
 		CompactionInterval: *antispamCompactionInterval,
 		BadgerOptions: func(o badger.Options) badger.Options {
 			return o.
-				WithCompression(options.None).				// Off as this appears to cause memory issues when compacting large indices.
-				WithMemTableSize(*antispamMemTableSize).	// Default tunes memtables for high write throughput
-				WithBaseTableSize(*antispamBaseTableSize).	// Default tunes to reduce file count
-				WithNumCompactors(*antispamNumCompactors).	// Default tunes to be able keep up with high throughput of LSM merges
+				WithCompression(options.None).             // Off as this appears to cause memory issues when compacting large indices.
+				WithMemTableSize(*antispamMemTableSize).   // Default tunes memtables for high write throughput
+				WithBaseTableSize(*antispamBaseTableSize). // Default tunes to reduce file count
+				WithNumCompactors(*antispamNumCompactors). // Default tunes to be able keep up with high throughput of LSM merges
 				WithIndexCacheSize(int64(antispamIndexCacheBytes)).
 				WithBlockCacheSize(int64(antispamBlockCacheBytes))
 		},
@@ -309,7 +527,7 @@ func newStorage(ctx context.Context, signer note.Signer) (st *storage.CTStorage,
 
 	// TODO(phbnf): figure out the best way to thread the `shutdown` func NewAppends returns back out to main so we can cleanly close Tessera down
 	// when it's time to exit.
-	appender, _, reader, err := tessera.NewAppender(ctx, driver, opts)
+	appender, appenderShutdown, reader, err := tessera.NewAppender(ctx, driver, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize POSIX Tessera appender: %v", err)
 	}
@@ -319,13 +537,27 @@ func newStorage(ctx context.Context, signer note.Signer) (st *storage.CTStorage,
 		return nil, fmt.Errorf("failed to initialize POSIX issuer storage: %v", err)
 	}
 
+	logStateStorage, err := posix.NewLogStateStorage(ctx, *storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize POSIX log state storage: %v", err)
+	}
+
 	sopts := storage.CTStorageOptions{
 		Appender:            appender,
 		Reader:              reader,
 		IssuerStorage:       issuerStorage,
+		LogStateStorage:     logStateStorage,
+		AppenderShutdown:    appenderShutdown,
 		AwaiterPollInterval: *awaiterPollInterval,
 		EnablePubAwaiter:    *enablePublicationAwaiter,
 	}
+	if *enableLeafIndex {
+		leafIndex, err := posix.NewLeafIndexStorage(ctx, filepath.Join(*storageDir, ".state", "leafindex"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize POSIX leaf index: %v", err)
+		}
+		sopts.LeafIndexStorage = leafIndex
+	}
 	return storage.NewCTStorage(ctx, &sopts)
 }
 
@@ -364,7 +596,21 @@ func noteSignerFromFile(path string) (note.Signer, error) {
 	return s, nil
 }
 
-func signerFromFlags() crypto.Signer {
+// signerFromFlags returns the log signer, and an io.Closer to release it on
+// shutdown, nil if there is nothing to release.
+func signerFromFlags() (crypto.Signer, io.Closer) {
+	if err := checkSignerFlags(); err != nil {
+		slog.ErrorContext(context.Background(), "Invalid signer flags", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if *pkcs11Module != "" {
+		s := pkcs11SignerFromFlags()
+		return s, s
+	}
+	if *remoteSignerURL != "" {
+		s := remoteSignerFromFlags()
+		return s, s
+	}
 	kf := *privKeyFile
 	if kf == "" {
 		kf = os.Getenv("LOG_PRIVATE_KEY")
@@ -379,16 +625,53 @@ func signerFromFlags() crypto.Signer {
 		os.Exit(1)
 	}
 	block, _ := pem.Decode(r)
-	if err != nil {
-		slog.ErrorContext(context.Background(), "Failed to parse PEM private key", slog.Any("error", err))
+	if block == nil {
+		slog.ErrorContext(context.Background(), "Failed to parse PEM private key", slog.String("path", kf))
 		os.Exit(1)
 	}
-	k, err := x509.ParseECPrivateKey(block.Bytes)
+	k, err := x509util.PrivateKeyFromPEMBlock(block)
 	if err != nil {
 		slog.ErrorContext(context.Background(), "Failed to parse private key", slog.Any("error", err))
 		os.Exit(1)
 	}
-	return k
+	return k, nil
+}
+
+// checkSignerFlags returns an error if flags configure more than one log key.
+func checkSignerFlags() error {
+	var set []string
+	if *pkcs11Module != "" {
+		set = append(set, "--pkcs11_module")
+	}
+	if *remoteSignerURL != "" {
+		set = append(set, "--remote_signer_url")
+	}
+	if *privKeyFile != "" {
+		set = append(set, "--private_key")
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("only one log key can be configured, got %s", strings.Join(set, ", "))
+	}
+	return nil
+}
+
+func pkcs11SignerFromFlags() *pkcs11.Signer {
+	s, err := pkcs11.NewFromFlags(*pkcs11Module, *pkcs11Token, *pkcs11Key, *pkcs11PINFile)
+	if err != nil {
+		slog.ErrorContext(context.Background(), "Failed to load PKCS #11 signer", slog.Any("error", err))
+		os.Exit(1)
+	}
+	return s
+}
+
+func remoteSignerFromFlags() *remotesigner.Client {
+	ctx := context.Background()
+	s, err := remotesigner.NewClientFromFlags(ctx, *remoteSignerURL, *remoteSignerCertFile, *remoteSignerKeyFile, *remoteSignerCAFile, *remoteSignerTimeout, *remoteSignerBatchWindow)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create remote signer client", slog.Any("error", err))
+		os.Exit(1)
+	}
+	return s
 }
 
 // multiStringFlag allows a flag to be specified multiple times on the command
@@ -404,6 +687,38 @@ func (ms *multiStringFlag) Set(w string) error {
 	return nil
 }
 
+// adaptivePushbackFromFlags returns the adaptive pushback configuration, or
+// nil if it is disabled.
+func adaptivePushbackFromFlags() *tesseract.AdaptivePushbackConfig {
+	if *adaptiveMaxQPS == 0 {
+		return nil
+	}
+	return &tesseract.AdaptivePushbackConfig{
+		MaxQPS:           *adaptiveMaxQPS,
+		MinQPS:           *adaptiveMinQPS,
+		MaxAwaitLatency:  *adaptiveMaxAwaitLatency,
+		MaxCheckpointAge: *adaptiveMaxCheckpointAge,
+	}
+}
+
+// lanesFromFlags returns the priority lanes configuration, or nil if they
+// are disabled.
+func lanesFromFlags() (*tesseract.LanesConfig, error) {
+	if *lanesConcurrency == 0 {
+		return nil, nil
+	}
+	weights, err := tesseract.ParseLaneWeights(*lanesWeights)
+	if err != nil {
+		return nil, err
+	}
+	return &tesseract.LanesConfig{
+		Concurrency: *lanesConcurrency,
+		Weights:     weights,
+		MaxQueue:    *lanesMaxQueue,
+		BackfillAge: *lanesBackfillAge,
+	}, nil
+}
+
 func notBeforeRLFromFlags() *tesseract.NotBeforeRL {
 	if *notBeforeRL == "" {
 		return nil
@@ -15,6 +15,7 @@
 package tesseract
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/sha256"
@@ -26,20 +27,26 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/transparency-dev/tesseract/internal/ccadb"
 	"github.com/transparency-dev/tesseract/internal/ct"
 	"github.com/transparency-dev/tesseract/internal/x509util"
 	"github.com/transparency-dev/tesseract/storage"
+	"golang.org/x/mod/sumdb/note"
 )
 
 // ChainValidationConfig contains parameters to configure chain validation.
 type ChainValidationConfig struct {
 	// RootsPEMFile is the path to the file containing root certificates that
 	// are acceptable to the log. The certs are served through get-roots
-	// endpoint.
+	// endpoint. They are trusted even if remotely fetched trust bits say
+	// otherwise.
 	RootsPEMFile string
 	// RootsRemoteFetchURLs configures an endpoint to fetch additional roots from.
 	RootsRemoteFetchURLs []string
@@ -52,6 +59,16 @@ type ChainValidationConfig struct {
 	// RejectRoots is a list of hex-encoded SHA-256 fingerprints of ASN.1 DER
 	// encoded root certificates that should never be trusted.
 	RejectRoots []string
+	// RootsReloadInterval configures the frequency at which trusted roots are
+	// reloaded from RootsPEMFile and RootsRemoteFetchBackup. Roots that are no
+	// longer there are removed, including remotely fetched roots if
+	// RootsRemoteFetchBackup is not set, until they are fetched again.
+	// Set to 0 to disable.
+	RootsReloadInterval time.Duration
+	// RootsReload triggers a reload of the trusted roots, as with
+	// RootsReloadInterval, every time it receives a value. Each value
+	// triggers a single reload, so logs must not share this channel.
+	RootsReload <-chan struct{}
 	// RejectExpired controls if true then the certificate validity period will be
 	// checked against the current time during the validation of submissions.
 	// This will cause expired certificates to be rejected.
@@ -81,8 +98,49 @@ type ChainValidationConfig struct {
 	// CAUTION: This is a temporary solution and it will eventually be removed.
 	// DO NOT depend on it.
 	AcceptSHA1 bool
+	// PolicyFile is the path to a JSON file configuring submission policies.
+	// See ct.ParsePolicies for its format.
+	PolicyFile string
+	// Policies are checked in order, after the policies of PolicyFile.
+	Policies []Policy
+	// IssuerFilterFile is the path to a JSON file listing intermediates that
+	// are allowed or denied, by fingerprint or SPKI hash. See
+	// ct.ParseIssuerFilter for its format. It is checked before other
+	// policies.
+	IssuerFilterFile string
+	// IssuerFilterReloadInterval configures the frequency at which
+	// IssuerFilterFile is reloaded. Set to 0 to disable.
+	IssuerFilterReloadInterval time.Duration
+	// IssuerFilterReload triggers a reload of IssuerFilterFile every time it
+	// receives a value. Each value triggers a single reload, so logs must not
+	// share this channel.
+	IssuerFilterReload <-chan struct{}
+	// VerifiedPathCacheSize is the number of verified paths from issuers to
+	// roots to cache, so that chains sharing their issuers with a chain
+	// verified earlier only need their leaf to be checked. Set to 0 to
+	// disable.
+	VerifiedPathCacheSize int
+	// StrictPrecertValidation rejects precertificate chains which do not
+	// follow the structure defined by RFC 6962 section 3.1: critical ASN.1
+	// NULL poison extension, precertificate signing certificates which are
+	// CAs, directly issue the leaf, and are issued by the final certificate
+	// issuer. It also rejects final certificates issued by precertificate
+	// signing certificates.
+	StrictPrecertValidation bool
+	// EnforceNameConstraints rejects chains with names which are not allowed
+	// by the name constraints of their intermediates or root.
+	EnforceNameConstraints bool
+	// EnforcePathLength rejects chains with more intermediates than allowed
+	// by the path length constraints of their intermediates or root.
+	// Precertificate signing certificates are not counted.
+	EnforcePathLength bool
 }
 
+// Policy decides whether a log accepts a certificate chain. Policies are
+// checked after the chain has been verified: chain starts with the leaf,
+// and ends with a trusted root.
+type Policy = ct.Policy
+
 // systemTimeSource implements ct.TimeSource.
 type systemTimeSource struct{}
 
@@ -93,9 +151,255 @@ func (s systemTimeSource) Now() time.Time {
 
 var sysTimeSource = systemTimeSource{}
 
+// rootConstraintsKeyInfix separates the fingerprint of a root from the
+// version of its constraints in RootsRemoteFetchBackup keys, which are
+// <hex fingerprint>.constraints.<version>. Versions are zero padded Unix
+// times in nanoseconds, so that later versions sort last.
+const rootConstraintsKeyInfix = ".constraints."
+
+// remoteRootsConstraints holds the latest constraints of remotely fetched
+// roots, and persists them in backup, if set, so that they still apply after
+// a restart. It also holds the remotely fetched roots, so that reloads keep
+// them until the next fetch, even without a backup.
+type remoteRootsConstraints struct {
+	backup storage.RootsStorage
+
+	mu sync.Mutex
+	// roots maps from sha-256 to the constraints of a root, without its PEM.
+	roots map[[sha256.Size]byte]versionedRoot
+	// local holds the sha-256 of the roots last read from RootsPEMFile,
+	// which are never left out for not being trusted anymore.
+	local map[[sha256.Size]byte]bool
+	// applied maps from sha-256 to the version of the constraints of a root
+	// last returned to be set on the roots pool, so that unchanged
+	// constraints don't needlessly rebuild it.
+	applied map[[sha256.Size]byte]string
+	// fetched maps from sha-256 to the roots fetched remotely since the
+	// process started, which are trusted for server authentication.
+	fetched map[[sha256.Size]byte]*x509.Certificate
+}
+
+// versionedRoot holds the constraints of a root, and their version.
+type versionedRoot struct {
+	root    ccadb.Root
+	version string
+}
+
+func newRemoteRootsConstraints(backup storage.RootsStorage) *remoteRootsConstraints {
+	return &remoteRootsConstraints{
+		backup:  backup,
+		roots:   make(map[[sha256.Size]byte]versionedRoot),
+		applied: make(map[[sha256.Size]byte]string),
+		fetched: make(map[[sha256.Size]byte]*x509.Certificate),
+	}
+}
+
+// load keeps the latest constraints found in kvs, loaded from the backup,
+// and returns the other key values, which hold roots.
+func (c *remoteRootsConstraints) load(ctx context.Context, kvs []storage.KV) []storage.KV {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rest := make([]storage.KV, 0, len(kvs))
+	for _, kv := range kvs {
+		fingerprint, version, ok := strings.Cut(string(kv.K), rootConstraintsKeyInfix)
+		if !ok {
+			rest = append(rest, kv)
+			continue
+		}
+		b, err := hex.DecodeString(fingerprint)
+		if err != nil || len(b) != sha256.Size {
+			slog.ErrorContext(ctx, "Invalid root constraints key", slog.String("key", string(kv.K)))
+			continue
+		}
+		sha := [sha256.Size]byte(b)
+		if prev, ok := c.roots[sha]; ok && prev.version >= version {
+			continue
+		}
+		var r ccadb.Root
+		if err := r.UnmarshalConstraints(kv.V); err != nil {
+			slog.ErrorContext(ctx, "Couldn't parse root constraints", slog.String("key", string(kv.K)), slog.Any("error", err))
+			continue
+		}
+		c.roots[sha] = versionedRoot{root: r, version: version}
+	}
+	return rest
+}
+
+// update records the constraints of a fetched root, and persists them if
+// they changed. They are not recorded if they can't be persisted, so that
+// the next fetch tries again.
+//
+// It returns true if the constraints of the root differ from the ones last
+// set on the roots pool, in which case they must be set on it again.
+func (c *remoteRootsConstraints) update(ctx context.Context, fingerprint [sha256.Size]byte, r ccadb.Root) (bool, error) {
+	r.PEM = nil
+	data, err := r.MarshalConstraints()
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal root constraints: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.roots[fingerprint]; ok {
+		if prevData, err := prev.root.MarshalConstraints(); err == nil && bytes.Equal(prevData, data) {
+			return c.markApplied(fingerprint, prev.version), nil
+		}
+	}
+	version := fmt.Sprintf("%020d", time.Now().UnixNano())
+	if c.backup != nil {
+		key := []byte(hex.EncodeToString(fingerprint[:]) + rootConstraintsKeyInfix + version)
+		if err := c.backup.AddIfNotExist(ctx, []storage.KV{{K: key, V: data}}); err != nil {
+			return true, fmt.Errorf("failed to store root constraints under %q: %v", key, err)
+		}
+	}
+	c.roots[fingerprint] = versionedRoot{root: r, version: version}
+	return c.markApplied(fingerprint, version), nil
+}
+
+// markApplied records that version of the constraints of a root is set on
+// the roots pool, and returns false if it already was. c.mu must be held.
+func (c *remoteRootsConstraints) markApplied(fingerprint [sha256.Size]byte, version string) bool {
+	if c.applied[fingerprint] == version {
+		return false
+	}
+	c.applied[fingerprint] = version
+	return true
+}
+
+// apply removes roots which are not trusted for server authentication
+// anymore from remote, and returns them after local, together with the
+// constraints of the roots which have been fetched remotely and changed since
+// they were last set on the roots pool, to be set on it. local roots are
+// never removed, and are remembered as such.
+func (c *remoteRootsConstraints) apply(local, remote []*x509.Certificate) ([]*x509.Certificate, map[[sha256.Size]byte]func([]*x509.Certificate) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.local = make(map[[sha256.Size]byte]bool, len(local))
+	for _, cert := range local {
+		c.local[sha256.Sum256(cert.Raw)] = true
+	}
+	trusted := make([]*x509.Certificate, 0, len(local)+len(remote))
+	constraints := make(map[[sha256.Size]byte]func([]*x509.Certificate) error)
+	for _, cert := range slices.Concat(local, remote) {
+		sha := sha256.Sum256(cert.Raw)
+		vr, ok := c.roots[sha]
+		if !ok {
+			trusted = append(trusted, cert)
+			continue
+		}
+		if !vr.root.TrustedForServerAuth() {
+			if c.local[sha] {
+				trusted = append(trusted, cert)
+			}
+			continue
+		}
+		// Set nil constraints too, so that a root which is not
+		// distrusted anymore gets its constraint lifted.
+		if c.markApplied(sha, vr.version) {
+			constraints[sha] = vr.root.Constraint()
+		}
+		trusted = append(trusted, cert)
+	}
+	return trusted, constraints
+}
+
+// setFetched records whether the remotely fetched root cert is trusted for
+// server authentication, to keep it on reloads.
+func (c *remoteRootsConstraints) setFetched(cert *x509.Certificate, trusted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sha := sha256.Sum256(cert.Raw)
+	if !trusted {
+		delete(c.fetched, sha)
+		return
+	}
+	c.fetched[sha] = cert
+}
+
+// fetchedRoots returns the remotely fetched roots which are trusted for
+// server authentication.
+func (c *remoteRootsConstraints) fetchedRoots() []*x509.Certificate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	certs := make([]*x509.Certificate, 0, len(c.fetched))
+	for _, cert := range c.fetched {
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+// isLocal returns true if the root whose sha-256 is fingerprint was read
+// from RootsPEMFile.
+func (c *remoteRootsConstraints) isLocal(fingerprint [sha256.Size]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.local[fingerprint]
+}
+
+// loadRoots reads the trusted roots from the PEM file and the remote roots
+// backup storage, together with the constraints of remotely fetched roots.
+// Roots fetched remotely since the process started are kept, in case they
+// couldn't be backed up, or there's no backup. Remote roots which are not
+// trusted for server authentication anymore are left out.
+func loadRoots(ctx context.Context, cfg ChainValidationConfig, rc *remoteRootsConstraints) ([]*x509.Certificate, map[[sha256.Size]byte]func([]*x509.Certificate) error, error) {
+	pemData, err := os.ReadFile(cfg.RootsPEMFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read trusted roots from %q: failed to load PEM certs file: %v", cfg.RootsPEMFile, err)
+	}
+	certs := x509util.ParsePEMCerts(pemData)
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("failed to read trusted roots from %q: failed to parse PEM certs file", cfg.RootsPEMFile)
+	}
+
+	var backupCerts []*x509.Certificate
+	if cfg.RootsRemoteFetchBackup != nil {
+		kvs, err := cfg.RootsRemoteFetchBackup.LoadAll(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load previously remotely fetched root from remote root backup storage: %v", err)
+		}
+		kvs = rc.load(ctx, kvs)
+		pems := make([][]byte, 0, len(kvs))
+		for _, kv := range kvs {
+			pems = append(pems, kv.V)
+		}
+		backupCerts = x509util.ParsePEMCerts(pems...)
+		slog.InfoContext(ctx, "Fetched roots from remote root backup storage", slog.Int("fetched", len(pems)), slog.Int("parsed", len(backupCerts)))
+	}
+	certs, constraints := rc.apply(certs, append(backupCerts, rc.fetchedRoots()...))
+	return certs, constraints, nil
+}
+
+// rootsLoader returns a ct.RootsLoader reading roots with loadRoots, which
+// sets the constraints of remotely fetched roots on pool.
+func rootsLoader(cfg ChainValidationConfig, pool *x509util.PEMCertPool, rc *remoteRootsConstraints) ct.RootsLoader {
+	return func(ctx context.Context) ([]*x509.Certificate, error) {
+		certs, constraints, err := loadRoots(ctx, cfg, rc)
+		if err != nil {
+			return nil, err
+		}
+		pool.SetConstraints(constraints)
+		return certs, nil
+	}
+}
+
+// chainValidation holds a ChainValidator, and what's needed to operate it.
+type chainValidation struct {
+	validator ct.ChainValidator
+	// roots is the pool of trusted roots used by validator.
+	roots *x509util.PEMCertPool
+	// loadRoots loads the roots validator is configured with.
+	loadRoots ct.RootsLoader
+	// reloadIssuerFilter reloads the issuer filter, nil if there's none.
+	reloadIssuerFilter func(context.Context) error
+}
+
 // newChainValidator checks that a chain validation config is valid,
 // parses it, and loads resources to validate chains.
-func newChainValidator(ctx context.Context, cfg ChainValidationConfig) (ct.ChainValidator, error) {
+//
+// origin is only used to attribute roots reloads and metrics.
+func newChainValidator(ctx context.Context, origin string, cfg ChainValidationConfig) (*chainValidation, error) {
 	// Load the trusted roots.
 	if cfg.RootsPEMFile == "" {
 		return nil, errors.New("empty rootsPemFile")
@@ -105,9 +409,13 @@ func newChainValidator(ctx context.Context, cfg ChainValidationConfig) (ct.Chain
 	if err != nil {
 		return nil, fmt.Errorf("failed to create roots pool: %v", err)
 	}
-	if err := roots.AppendCertsFromPEMFile(cfg.RootsPEMFile); err != nil {
-		return nil, fmt.Errorf("failed to read trusted roots from %q: %v", cfg.RootsPEMFile, err)
+	rc := newRemoteRootsConstraints(cfg.RootsRemoteFetchBackup)
+	load := rootsLoader(cfg, roots, rc)
+	certs, err := load(ctx)
+	if err != nil {
+		return nil, err
 	}
+	slog.InfoContext(ctx, "Loaded roots", slog.String("origin", origin), slog.Int("parsed", len(certs)), slog.Int("added", roots.AddCerts(certs)))
 
 	if cfg.RejectExpired && cfg.RejectUnexpired {
 		return nil, errors.New("configuration would reject all certificates")
@@ -138,47 +446,92 @@ func newChainValidator(ctx context.Context, cfg ChainValidationConfig) (ct.Chain
 		}
 	}
 
-	if cfg.RootsRemoteFetchBackup != nil {
-		kvs, err := cfg.RootsRemoteFetchBackup.LoadAll(ctx)
+	var policies []ct.Policy
+	if cfg.PolicyFile != "" {
+		data, err := os.ReadFile(cfg.PolicyFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load previously remotely fetched root from remote root backup storage: %v", err)
+			return nil, fmt.Errorf("failed to read policy file %q: %v", cfg.PolicyFile, err)
 		}
-		certs := make([][]byte, 0, len(kvs))
-		for _, kv := range kvs {
-			certs = append(certs, kv.V)
+		policies, err = ct.ParsePolicies(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policies from %q: %v", cfg.PolicyFile, err)
+		}
+	}
+	policies = append(policies, cfg.Policies...)
+
+	var reloadFilter func(context.Context) error
+	if cfg.IssuerFilterFile != "" {
+		data, err := os.ReadFile(cfg.IssuerFilterFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read issuer filter file %q: %v", cfg.IssuerFilterFile, err)
+		}
+		issuerFilter, err := ct.ParseIssuerFilter(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load issuer filter from %q: %v", cfg.IssuerFilterFile, err)
+		}
+		policies = append([]ct.Policy{issuerFilter}, policies...)
+		reloadFilter = func(ctx context.Context) error {
+			return reloadIssuerFilter(ctx, origin, issuerFilter, cfg.IssuerFilterFile)
+		}
+		if cfg.IssuerFilterReloadInterval > 0 || cfg.IssuerFilterReload != nil {
+			go watchIssuerFilter(ctx, reloadFilter, cfg)
 		}
-		parsed, added := roots.AppendCertsFromPEMs(certs...)
-		slog.InfoContext(ctx, "Fetched roots from remote root backup storage", slog.Int("fetched", len(certs)), slog.Int("parsed", parsed), slog.Int("added", added))
 	}
 
 	if cfg.RootsRemoteFetchInterval > 0 && len(cfg.RootsRemoteFetchURLs) > 0 {
 		fetchAndAppendRemoteRoots := func(url string) {
-			rr, err := ccadb.Fetch(ctx, url, []string{ccadb.ColPEM})
+			rr, err := ccadb.FetchRoots(ctx, url)
 			if err != nil {
 				slog.ErrorContext(ctx, "Couldn't fetch roots", slog.String("url", url), slog.Any("error", err))
 				return
 			}
 			pems := make([][]byte, 0, len(rr))
+			constraints := make(map[[sha256.Size]byte]func([]*x509.Certificate) error)
 			for _, r := range rr {
-				if len(r) < 1 {
-					slog.ErrorContext(ctx, "Couldn't parse root: empty row", slog.String("url", url))
+				if len(r.PEM) == 0 {
+					slog.ErrorContext(ctx, "Couldn't parse root: empty PEM", slog.String("url", url))
 					continue
 				}
-				pems = append(pems, r[0])
-				if cfg.RootsRemoteFetchBackup != nil {
-					block, _ := pem.Decode(r[0])
-					if block == nil {
-						slog.ErrorContext(ctx, "Failed to decode PEM block in fetched data", slog.String("url", url))
-						continue
+				block, _ := pem.Decode(r.PEM)
+				if block == nil {
+					slog.ErrorContext(ctx, "Failed to decode PEM block in fetched data", slog.String("url", url))
+					continue
+				}
+				sha := sha256.Sum256(block.Bytes)
+				// Persist constraints before the root itself, so that a
+				// backed up root is never loaded without them.
+				changed, err := rc.update(ctx, sha, r)
+				if err != nil {
+					slog.ErrorContext(ctx, "Couldn't store root constraints", slog.String("url", url), slog.Any("error", err))
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					slog.ErrorContext(ctx, "Couldn't parse root", slog.String("url", url), slog.Any("error", err))
+					continue
+				}
+				rc.setFetched(cert, r.TrustedForServerAuth())
+				if !r.TrustedForServerAuth() {
+					slog.InfoContext(ctx, "Skipping root not trusted for server authentication", slog.String("url", url), slog.Any("trustBits", r.TrustBits))
+					if !rc.isLocal(sha) {
+						removeUntrustedRoot(ctx, roots, cert)
 					}
-					sha := sha256.Sum256(block.Bytes)
+					continue
+				}
+				// Set nil constraints too, so that a root which is not
+				// distrusted anymore gets its constraint lifted.
+				if changed {
+					constraints[sha] = r.Constraint()
+				}
+				pems = append(pems, r.PEM)
+				if cfg.RootsRemoteFetchBackup != nil {
 					key := []byte(hex.EncodeToString(sha[:]))
-					if err := cfg.RootsRemoteFetchBackup.AddIfNotExist(ctx, []storage.KV{{K: key, V: r[0]}}); err != nil {
+					if err := cfg.RootsRemoteFetchBackup.AddIfNotExist(ctx, []storage.KV{{K: key, V: r.PEM}}); err != nil {
 						slog.ErrorContext(ctx, "Couldn't store roots", slog.String("key", string(key)), slog.Any("error", err))
 						continue
 					}
 				}
 			}
+			roots.SetConstraints(constraints)
 			parsed, added := roots.AppendCertsFromPEMs(pems...)
 			slog.InfoContext(ctx, "Fetched roots", slog.Int("fetched", len(pems)), slog.Int("parsed", parsed), slog.Int("added", added), slog.String("url", url))
 		}
@@ -203,9 +556,115 @@ func newChainValidator(ctx context.Context, cfg ChainValidationConfig) (ct.Chain
 		}()
 	}
 
-	cv := ct.NewChainValidator(roots, cfg.RejectExpired, cfg.RejectUnexpired, cfg.NotAfterStart, cfg.NotAfterLimit, extKeyUsages, rejectExtIds, cfg.AcceptSHA1)
+	if cfg.RootsReloadInterval > 0 || cfg.RootsReload != nil {
+		go reloadRoots(ctx, origin, roots, load, cfg)
+	}
+
+	var pathCache *ct.VerifiedPathCache
+	if cfg.VerifiedPathCacheSize > 0 {
+		pathCache = ct.NewVerifiedPathCache(origin, cfg.VerifiedPathCacheSize)
+	}
+
+	cv := ct.NewChainValidator(ct.ChainValidatorOptions{
+		TrustedRoots:           roots,
+		RejectExpired:          cfg.RejectExpired,
+		RejectUnexpired:        cfg.RejectUnexpired,
+		NotAfterStart:          cfg.NotAfterStart,
+		NotAfterLimit:          cfg.NotAfterLimit,
+		ExtKeyUsages:           extKeyUsages,
+		RejectExtIds:           rejectExtIds,
+		AcceptSHA1:             cfg.AcceptSHA1,
+		Policies:               policies,
+		PathCache:              pathCache,
+		StrictPrecerts:         cfg.StrictPrecertValidation,
+		EnforceNameConstraints: cfg.EnforceNameConstraints,
+		EnforcePathLength:      cfg.EnforcePathLength,
+	})
+
+	return &chainValidation{
+		validator:          cv,
+		roots:              roots,
+		loadRoots:          load,
+		reloadIssuerFilter: reloadFilter,
+	}, nil
+}
+
+// removeUntrustedRoot removes the remotely fetched root cert from pool, if
+// it is there, once it is not trusted for server authentication anymore.
+func removeUntrustedRoot(ctx context.Context, pool *x509util.PEMCertPool, cert *x509.Certificate) {
+	sha := sha256.Sum256(cert.Raw)
+	if !pool.Included(cert) {
+		return
+	}
+	if _, err := pool.RemoveCert(sha); err != nil {
+		slog.ErrorContext(ctx, "Couldn't remove root not trusted for server authentication", slog.String("fingerprint", hex.EncodeToString(sha[:])), slog.Any("error", err))
+		return
+	}
+	slog.InfoContext(ctx, "Removed root not trusted for server authentication", slog.String("subject", cert.Subject.String()), slog.String("fingerprint", hex.EncodeToString(sha[:])))
+}
+
+// reloadRoots reloads the trusted roots in pool with load every
+// cfg.RootsReloadInterval, and every time cfg.RootsReload fires, until ctx is
+// done.
+func reloadRoots(ctx context.Context, origin string, pool *x509util.PEMCertPool, load ct.RootsLoader, cfg ChainValidationConfig) {
+	var tick <-chan time.Time
+	if cfg.RootsReloadInterval > 0 {
+		ticker := time.NewTicker(cfg.RootsReloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case _, ok := <-cfg.RootsReload:
+			if !ok {
+				return
+			}
+		}
+		// Errors are already logged, and the previous roots are kept.
+		_ = ct.ReloadRoots(ctx, origin, pool, load)
+	}
+}
 
-	return cv, nil
+// watchIssuerFilter calls reload every cfg.IssuerFilterReloadInterval, and
+// every time cfg.IssuerFilterReload fires, until ctx is done.
+func watchIssuerFilter(ctx context.Context, reload func(context.Context) error, cfg ChainValidationConfig) {
+	var tick <-chan time.Time
+	if cfg.IssuerFilterReloadInterval > 0 {
+		ticker := time.NewTicker(cfg.IssuerFilterReloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case _, ok := <-cfg.IssuerFilterReload:
+			if !ok {
+				return
+			}
+		}
+		// Errors are already logged, and the previous lists are kept.
+		_ = reload(ctx)
+	}
+}
+
+// reloadIssuerFilter replaces the lists of issuerFilter with the content of
+// path. The previous lists are kept if path can't be loaded.
+func reloadIssuerFilter(ctx context.Context, origin string, issuerFilter *ct.IssuerFilter, path string) error {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = issuerFilter.Set(data)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reload issuer filter, keeping the previous one", slog.String("origin", origin), slog.String("path", path), slog.Any("error", err))
+		return fmt.Errorf("failed to reload issuer filter from %q: %v", path, err)
+	}
+	slog.InfoContext(ctx, "Reloaded issuer filter", slog.String("origin", origin), slog.String("path", path))
+	return nil
 }
 
 // NotBeforeRL configures rate limits based on certificate not_before's age.
@@ -218,6 +677,125 @@ type LogHandlerOpts struct {
 	NotBeforeRL       *NotBeforeRL
 	DedupRL           float64
 	MaxCertChainBytes int64
+	// EnableRFC6962ReadAPI serves RFC 6962 read endpoints (get-sth,
+	// get-sth-consistency, get-proof-by-hash, get-entries and
+	// get-entry-and-proof) from the static-ct-api log data.
+	EnableRFC6962ReadAPI bool
+	// EnableValidateChain serves the validate-chain endpoint, which runs
+	// add-chain and add-pre-chain checks on a chain without adding it to the
+	// log, and returns why it would be rejected.
+	EnableValidateChain bool
+	// JSONErrors returns error responses with a JSON body holding a stable
+	// error code, rather than a plain text one. See ct.ErrorResponse.
+	JSONErrors bool
+	// Admin, if set, serves the admin API. It is only used by
+	// NewLogHandler, NewMultiLogHandler uses MultiLogHandlerOpts.Admin.
+	Admin *AdminOpts
+	// State, if set, is the lifecycle state the log must be in at startup.
+	// Creating the log fails if its persisted state, usable if it has none,
+	// is different, unless PersistState is set. If unset, the log keeps its
+	// persisted state.
+	State LogState
+	// PersistState moves the log to State at startup, and persists it,
+	// rather than failing if the log is in another state. Since the state is
+	// shared by all the instances of a log, it should only be set once.
+	PersistState bool
+	// DedupCacheSize is the number of recently submitted chains for which
+	// to remember the SCT input, so that their resubmissions get an SCT
+	// without being validated again. Set to 0 to disable.
+	DedupCacheSize int
+	// QuotasFile is the path to a JSON file configuring per client IP,
+	// client identity, and issuer rate limits. See ct.ParseQuotas.
+	QuotasFile string
+	// AdaptivePushback, if set, sheds submissions when the log shows signs of
+	// overload, before its MMD is at risk.
+	AdaptivePushback *AdaptivePushbackConfig
+	// Lanes, if set, schedules submissions into storage by lane, so that
+	// backfills and duplicates can't delay fresh submissions.
+	Lanes *LanesConfig
+	// AuthFile, if set, is the path to a JSON file listing the API keys and
+	// TLS client certificates of the clients allowed to submit to the log.
+	// See ct.ParseAuthConfig. Submissions from other clients are rejected.
+	// Client certificates are only seen if the http.Server serving the log
+	// terminates TLS and requests them, see internal/httpserver.
+	AuthFile string
+	// Lints sets the severity of built-in certificate lints, by lint name.
+	// The "all" name sets the severity of lints that are not listed. Lints
+	// are off by default.
+	Lints map[string]LintSeverity
+}
+
+// AdaptivePushbackConfig configures an AIMD controller which lowers the rate
+// of accepted submissions when entries take too long to be published, when the
+// checkpoint stops growing, or when Tessera pushes back.
+type AdaptivePushbackConfig = ct.AdaptivePushbackConfig
+
+// LanesConfig configures the priority lanes submissions wait in before
+// getting to storage.
+type LanesConfig = ct.LanesConfig
+
+// Lane is a class of submissions, with its own admission queue into storage.
+type Lane = ct.Lane
+
+// Lanes of submissions.
+const (
+	// LaneFresh holds certificates issued recently.
+	LaneFresh = ct.LaneFresh
+	// LanePrecert holds precertificates.
+	LanePrecert = ct.LanePrecert
+	// LaneBackfill holds certificates issued before the backfill age.
+	LaneBackfill = ct.LaneBackfill
+	// LaneDuplicate holds duplicate submissions.
+	LaneDuplicate = ct.LaneDuplicate
+)
+
+// ParseLaneWeights parses a comma separated list of <lane>=<weight>, such as
+// "precert=8,fresh=8,duplicate=2,backfill=1", into LanesConfig.Weights.
+func ParseLaneWeights(s string) (map[Lane]int, error) {
+	return ct.ParseLaneWeights(s)
+}
+
+// LintSeverity defines what happens to submissions failing a lint.
+type LintSeverity = ct.LintSeverity
+
+// Lint severities.
+const (
+	// LintOff disables a lint.
+	LintOff = ct.LintOff
+	// LintWarn logs, and counts submissions failing a lint, but accepts them.
+	LintWarn = ct.LintWarn
+	// LintReject rejects submissions failing a lint.
+	LintReject = ct.LintReject
+)
+
+// ParseLintSeverities parses a comma separated list of <lint>=<severity>,
+// such as "all=warn,serial_not_positive=reject", into LogHandlerOpts.Lints.
+func ParseLintSeverities(s string) (map[string]LintSeverity, error) {
+	return ct.ParseLintSeverities(s)
+}
+
+// LogState is the lifecycle state of a log.
+type LogState = ct.LogState
+
+// Lifecycle states of a log, as defined by CT log policies.
+const (
+	// LogStateUsable logs accept submissions.
+	LogStateUsable = ct.LogStateUsable
+	// LogStateReadOnly logs reject submissions, but keep publishing
+	// checkpoints and serving their data.
+	LogStateReadOnly = ct.LogStateReadOnly
+	// LogStateRetired logs reject submissions for good.
+	LogStateRetired = ct.LogStateRetired
+)
+
+// AdminOpts configures the admin API, which lets operators inspect and
+// change the state of running logs.
+type AdminOpts struct {
+	// Tokens lists the bearer tokens which authenticate admin requests.
+	Tokens []string
+	// Mux serves the admin API. It must not be served on the same listener
+	// as the log handler, so that the admin API is not publicly exposed.
+	Mux *http.ServeMux
 }
 
 // NewLogHandler creates a Tessera based CT log plugged into HTTP handlers.
@@ -229,41 +807,474 @@ type LogHandlerOpts struct {
 // be served independently, either through the storage's system serving
 // infrastructure directly (GCS over HTTPS for instance), or with an
 // independent serving stack of your choice.
+//
+// If opts.EnableRFC6962ReadAPI is set, it also serves RFC 6962 read endpoints
+// on top of static-ct-api data, for clients that have not migrated yet.
+//
+// If opts.EnableValidateChain is set, it also serves a non-standard
+// validate-chain endpoint.
 func NewLogHandler(ctx context.Context, origin string, signer crypto.Signer, cfg ChainValidationConfig, cs storage.CreateStorage, httpDeadline time.Duration, maskInternalErrors bool, pathPrefix string, opts LogHandlerOpts) (http.Handler, error) {
-	cv, err := newChainValidator(ctx, cfg)
+	return NewMultiLogHandler(ctx, []LogConfig{{
+		Origin:                origin,
+		Signer:                signer,
+		ChainValidationConfig: cfg,
+		CreateStorage:         cs,
+		PathPrefix:            pathPrefix,
+		Opts:                  opts,
+	}}, httpDeadline, maskInternalErrors, MultiLogHandlerOpts{Admin: opts.Admin})
+}
+
+// LogConfig configures a single log served by NewMultiLogHandler.
+type LogConfig struct {
+	// Origin is the log's origin, which must be unique across logs.
+	Origin string
+	// Signer signs the log's SCTs and checkpoints.
+	Signer crypto.Signer
+	// ChainValidationConfig configures which chains the log accepts. Temporal
+	// shards use distinct NotAfterStart and NotAfterLimit values.
+	ChainValidationConfig ChainValidationConfig
+	// CreateStorage instantiates the log's storage.
+	CreateStorage storage.CreateStorage
+	// PathPrefix prefixes the log's endpoint paths, and must be unique
+	// across logs.
+	PathPrefix string
+	// Opts holds the log's optional handler settings.
+	Opts LogHandlerOpts
+}
+
+// RemoteShard is a log served by another server, to which the router
+// forwards submissions over HTTP.
+type RemoteShard struct {
+	// Origin identifies the shard in logs and metrics.
+	Origin string
+	// SubmissionPrefix is the URL prefix of the shard's submission endpoints,
+	// e.g. https://ct.example.com/2026h1.
+	SubmissionPrefix string
+	// NotAfterStart is the start of the shard's NotAfter range, inclusive.
+	NotAfterStart *time.Time
+	// NotAfterLimit is the end of the shard's NotAfter range, exclusive.
+	NotAfterLimit *time.Time
+}
+
+// MultiLogHandlerOpts holds optional settings for NewMultiLogHandler.
+type MultiLogHandlerOpts struct {
+	// RouterPathPrefix, if set, serves add-chain and add-pre-chain endpoints
+	// under this prefix, which forward submissions to the log whose NotAfter
+	// range contains the submitted certificate's NotAfter.
+	RouterPathPrefix string
+	// RemoteShards lists logs served by other servers that the router also
+	// forwards submissions to.
+	RemoteShards []RemoteShard
+	// MaxCertChainBytes limits the size of requests to the router.
+	MaxCertChainBytes int64
+	// JSONErrors returns router error responses with a JSON body holding a
+	// stable error code, rather than a plain text one.
+	JSONErrors bool
+	// Admin, if set, serves the admin API for all the logs.
+	Admin *AdminOpts
+}
+
+// NewMultiLogHandler creates multiple Tessera based CT logs, such as the
+// temporal shards of a log set, and serves them all on a single HTTP handler.
+//
+// Each log is configured and served as with NewLogHandler, under its own path
+// prefix. Metrics are labelled with the origin of the log they relate to.
+//
+// If opts.RouterPathPrefix is set, it also serves submission endpoints which
+// route requests to the right shard, giving submitters a single stable URL.
+//
+// If opts.Admin is set, opts.Admin.Mux serves the admin API under
+// ct.AdminPathPrefix.
+func NewMultiLogHandler(ctx context.Context, logs []LogConfig, httpDeadline time.Duration, maskInternalErrors bool, opts MultiLogHandlerOpts) (http.Handler, error) {
+	if len(logs) == 0 && len(opts.RemoteShards) == 0 {
+		return nil, errors.New("no log to serve")
+	}
+	if len(opts.RemoteShards) > 0 && opts.RouterPathPrefix == "" {
+		return nil, errors.New("remote shards require a router path prefix")
+	}
+	origins := make(map[string]bool, len(logs))
+	prefixes := make(map[string]bool, len(logs))
+	for _, l := range logs {
+		if origins[l.Origin] {
+			return nil, fmt.Errorf("duplicate origin %q", l.Origin)
+		}
+		origins[l.Origin] = true
+		prefix := "/" + strings.Trim(l.PathPrefix, "/")
+		if prefixes[prefix] {
+			return nil, fmt.Errorf("duplicate path prefix %q for origin %q", l.PathPrefix, l.Origin)
+		}
+		prefixes[prefix] = true
+	}
+	if opts.RouterPathPrefix != "" && prefixes["/"+strings.Trim(opts.RouterPathPrefix, "/")] {
+		return nil, fmt.Errorf("router path prefix %q is already used by a log", opts.RouterPathPrefix)
+	}
+
+	if opts.Admin != nil && len(logs) == 0 {
+		return nil, errors.New("the admin API requires at least one local log")
+	}
+	if opts.Admin != nil && opts.Admin.Mux == nil {
+		return nil, errors.New("the admin API requires its own mux")
+	}
+
+	// Stop the logs created so far, and close their storage, if any part of
+	// the handler fails to be set up.
+	ctx, cancel := context.WithCancel(ctx)
+	var storages []*storage.CTStorage
+	ok := false
+	defer func() {
+		if ok {
+			return
+		}
+		cancel()
+		for _, s := range storages {
+			if err := s.Close(); err != nil {
+				slog.WarnContext(ctx, "Failed to close storage", slog.Any("error", err))
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	// Register handlers for all the configured logs.
+	admins := make([]*ct.LogAdmin, 0, len(logs))
+	for _, l := range logs {
+		createStorage := l.CreateStorage
+		l.CreateStorage = func(ctx context.Context, signer note.Signer) (*storage.CTStorage, error) {
+			s, err := createStorage(ctx, signer)
+			if s != nil {
+				storages = append(storages, s)
+			}
+			return s, err
+		}
+		handlers, admin, err := newLogPathHandlers(ctx, l, httpDeadline, maskInternalErrors)
+		if err != nil {
+			return nil, fmt.Errorf("log %q: %v", l.Origin, err)
+		}
+		for path, handler := range handlers {
+			mux.Handle(path, http.MaxBytesHandler(handler, l.Opts.MaxCertChainBytes))
+		}
+		admins = append(admins, admin)
+	}
+
+	if opts.Admin != nil {
+		h, err := ct.NewAdminHandler(opts.Admin.Tokens, admins)
+		if err != nil {
+			return nil, fmt.Errorf("NewAdminHandler(): %v", err)
+		}
+		opts.Admin.Mux.Handle(ct.AdminPathPrefix+"/", h)
+	}
+
+	if opts.RouterPathPrefix != "" {
+		routerOpts := &ct.HandlerOptions{
+			Deadline:           httpDeadline,
+			MaskInternalErrors: maskInternalErrors,
+			JSONErrors:         opts.JSONErrors,
+		}
+		shards := make([]ct.RouterShard, 0, len(logs)+len(opts.RemoteShards))
+		for _, l := range logs {
+			shards = append(shards, ct.RouterShard{
+				Origin:        l.Origin,
+				PathPrefix:    l.PathPrefix,
+				NotAfterStart: l.ChainValidationConfig.NotAfterStart,
+				NotAfterLimit: l.ChainValidationConfig.NotAfterLimit,
+				Handler:       mux,
+			})
+		}
+		for _, rs := range opts.RemoteShards {
+			shard, err := newRemoteRouterShard(routerOpts, rs)
+			if err != nil {
+				return nil, fmt.Errorf("remote shard %q: %v", rs.Origin, err)
+			}
+			shards = append(shards, shard)
+		}
+		handlers, err := ct.NewRouterPathHandlers(ctx, routerOpts, opts.RouterPathPrefix, shards)
+		if err != nil {
+			return nil, fmt.Errorf("NewRouterPathHandlers(): %v", err)
+		}
+		for path, handler := range handlers {
+			mux.Handle(path, http.MaxBytesHandler(handler, opts.MaxCertChainBytes))
+		}
+	}
+
+	// Health checking endpoint.
+	mux.HandleFunc("/healthz", func(resp http.ResponseWriter, req *http.Request) {
+		_, _ = fmt.Fprint(resp, "ok")
+	})
+
+	ok = true
+	return mux, nil
+}
+
+// newRemoteRouterShard returns a router shard which forwards requests to
+// rs over HTTP.
+func newRemoteRouterShard(opts *ct.HandlerOptions, rs RemoteShard) (ct.RouterShard, error) {
+	u, err := url.Parse(rs.SubmissionPrefix)
 	if err != nil {
-		return nil, fmt.Errorf("newCertValidationOpts(): %v", err)
+		return ct.RouterShard{}, fmt.Errorf("can't parse submission prefix %q: %v", rs.SubmissionPrefix, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return ct.RouterShard{}, fmt.Errorf("submission prefix %q is not an absolute URL", rs.SubmissionPrefix)
+	}
+	target := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	return ct.RouterShard{
+		Origin:        rs.Origin,
+		PathPrefix:    u.Path,
+		NotAfterStart: rs.NotAfterStart,
+		NotAfterLimit: rs.NotAfterLimit,
+		Handler:       ct.NewRemoteShardHandler(opts, target),
+	}, nil
+}
+
+// newLogPathHandlers creates a log from l, and returns its HTTP handlers
+// keyed by path, and its admin controls.
+func newLogPathHandlers(ctx context.Context, l LogConfig, httpDeadline time.Duration, maskInternalErrors bool) (map[string]http.Handler, *ct.LogAdmin, error) {
+	cv, err := newChainValidator(ctx, l.Origin, l.ChainValidationConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("newCertValidationOpts(): %v", err)
+	}
+	var linter *ct.Linter
+	if len(l.Opts.Lints) > 0 {
+		linter, err = ct.NewLinter(l.Opts.Lints)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid lints: %v", err)
+		}
 	}
-	log, err := ct.NewLog(ctx, origin, signer, cv, cs, sysTimeSource)
+	log, err := ct.NewLog(ctx, l.Origin, l.Signer, cv.validator, l.CreateStorage, sysTimeSource)
 	if err != nil {
-		return nil, fmt.Errorf("newLog(): %v", err)
+		return nil, nil, fmt.Errorf("newLog(): %v", err)
+	}
+	if l.Opts.State != "" {
+		if _, err := ct.ParseLogState(string(l.Opts.State)); err != nil {
+			return nil, nil, err
+		}
+		if l.Opts.PersistState {
+			if err := log.SetState(ctx, l.Opts.State); err != nil {
+				return nil, nil, fmt.Errorf("failed to set log state: %v", err)
+			}
+		} else if st := log.State(); st != l.Opts.State {
+			return nil, nil, fmt.Errorf("log is %s, not %s: persist the new state to change it", st, l.Opts.State)
+		}
 	}
 
 	ctOpts := &ct.HandlerOptions{
 		Deadline:           httpDeadline,
 		RequestLog:         &ct.DefaultRequestLog{},
 		MaskInternalErrors: maskInternalErrors,
+		JSONErrors:         l.Opts.JSONErrors,
 		TimeSource:         sysTimeSource,
-		PathPrefix:         pathPrefix,
+		PathPrefix:         l.PathPrefix,
+		Linter:             linter,
 	}
-	if opts.NotBeforeRL != nil {
-		ctOpts.RateLimits.NotBefore(opts.NotBeforeRL.AgeThreshold, opts.NotBeforeRL.RateLimit)
+	if l.Opts.NotBeforeRL != nil {
+		ctOpts.RateLimits.NotBefore(l.Opts.NotBeforeRL.AgeThreshold, l.Opts.NotBeforeRL.RateLimit)
 	}
-	if opts.DedupRL >= 0 {
-		ctOpts.RateLimits.Dedup(opts.DedupRL)
+	if l.Opts.DedupRL >= 0 {
+		ctOpts.RateLimits.Dedup(l.Opts.DedupRL)
+	}
+	if l.Opts.QuotasFile != "" {
+		data, err := os.ReadFile(l.Opts.QuotasFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read quotas file %q: %v", l.Opts.QuotasFile, err)
+		}
+		quotas, err := ct.ParseQuotas(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load quotas from %q: %v", l.Opts.QuotasFile, err)
+		}
+		if err := ctOpts.RateLimits.Quotas(quotas); err != nil {
+			return nil, nil, fmt.Errorf("failed to configure quotas: %v", err)
+		}
+	}
+	if l.Opts.AdaptivePushback != nil {
+		ctOpts.AdaptivePushback, err = ct.NewAdaptivePushback(ctx, log, *l.Opts.AdaptivePushback)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to configure adaptive pushback: %v", err)
+		}
+	}
+	if l.Opts.Lanes != nil {
+		ctOpts.Lanes, err = ct.NewLanes(l.Origin, *l.Opts.Lanes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to configure lanes: %v", err)
+		}
+	}
+	if l.Opts.DedupCacheSize > 0 {
+		ctOpts.DedupCache = ct.NewDedupCache(l.Origin, l.Opts.DedupCacheSize)
 	}
 
-	handlers := ct.NewPathHandlers(ctx, ctOpts, log)
-	mux := http.NewServeMux()
-	// Register handlers for all the configured logs.
-	for path, handler := range handlers {
-		mux.Handle(path, http.MaxBytesHandler(handler, opts.MaxCertChainBytes))
+	// authenticate wraps submission handlers if submissions are authenticated.
+	authenticate := func(h http.Handler) http.Handler { return h }
+	if l.Opts.AuthFile != "" {
+		data, err := os.ReadFile(l.Opts.AuthFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read authentication file %q: %v", l.Opts.AuthFile, err)
+		}
+		authCfg, err := ct.ParseAuthConfig(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load authentication config from %q: %v", l.Opts.AuthFile, err)
+		}
+		auth, err := ct.NewAuthenticator(authCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to configure authentication: %v", err)
+		}
+		authenticate = func(h http.Handler) http.Handler { return ct.NewAuthHandler(ctOpts, l.Origin, auth, h) }
 	}
 
-	// Health checking endpoint.
-	mux.HandleFunc("/healthz", func(resp http.ResponseWriter, req *http.Request) {
-		_, _ = fmt.Fprint(resp, "ok")
-	})
+	handlers := map[string]http.Handler{}
+	for path, h := range ct.NewPathHandlers(ctx, ctOpts, log) {
+		handlers[path] = authenticate(h)
+	}
+	if l.Opts.EnableRFC6962ReadAPI {
+		for path, h := range ct.NewReadPathHandlers(ctx, ctOpts, log) {
+			handlers[path] = h
+		}
+	}
+	if l.Opts.EnableValidateChain {
+		for path, h := range ct.NewValidateChainPathHandlers(ctx, ctOpts, log) {
+			handlers[path] = authenticate(h)
+		}
+	}
 
-	return mux, nil
+	cfg, err := newEffectiveConfig(l, httpDeadline, maskInternalErrors)
+	if err != nil {
+		return nil, nil, fmt.Errorf("newEffectiveConfig(): %v", err)
+	}
+	admin := ct.NewLogAdmin(log, ctOpts, cv.roots, cv.loadRoots, cv.reloadIssuerFilter, cfg)
+
+	return handlers, admin, nil
+}
+
+// effectiveConfig is the configuration of a log, as served by the admin API.
+//
+// It must never hold secrets.
+type effectiveConfig struct {
+	Origin     string `json:"origin"`
+	PathPrefix string `json:"path_prefix"`
+	// PublicKey is the DER encoded public key of the log.
+	PublicKey            []byte `json:"public_key"`
+	HTTPDeadline         string `json:"http_deadline"`
+	MaskInternalErrors   bool   `json:"mask_internal_errors"`
+	MaxCertChainBytes    int64  `json:"max_cert_chain_bytes"`
+	EnableRFC6962ReadAPI bool   `json:"enable_rfc6962_read_api"`
+	EnableValidateChain  bool   `json:"enable_validate_chain"`
+	JSONErrors           bool   `json:"json_errors"`
+	DedupCacheSize       int    `json:"dedup_cache_size"`
+	QuotasFile           string `json:"quotas_file"`
+	AuthFile             string `json:"auth_file"`
+	// AdaptivePushback is nil if adaptive pushback is disabled.
+	AdaptivePushback *adaptivePushbackConfig `json:"adaptive_pushback"`
+	// Lanes is nil if priority lanes are disabled.
+	Lanes *lanesConfig `json:"lanes"`
+	// Lints holds the lint severities the log was configured with.
+	Lints map[string]LintSeverity `json:"lints"`
+
+	RootsPEMFile               string     `json:"roots_pem_file"`
+	RootsRemoteFetchURLs       []string   `json:"roots_remote_fetch_urls"`
+	RootsRemoteFetchInterval   string     `json:"roots_remote_fetch_interval"`
+	RootsRemoteFetchBackup     bool       `json:"roots_remote_fetch_backup"`
+	RootsReloadInterval        string     `json:"roots_reload_interval"`
+	RejectRoots                []string   `json:"reject_roots"`
+	RejectExpired              bool       `json:"reject_expired"`
+	RejectUnexpired            bool       `json:"reject_unexpired"`
+	ExtKeyUsages               string     `json:"ext_key_usages"`
+	RejectExtensions           string     `json:"reject_extensions"`
+	NotAfterStart              *time.Time `json:"not_after_start"`
+	NotAfterLimit              *time.Time `json:"not_after_limit"`
+	AcceptSHA1                 bool       `json:"accept_sha1"`
+	PolicyFile                 string     `json:"policy_file"`
+	IssuerFilterFile           string     `json:"issuer_filter_file"`
+	IssuerFilterReloadInterval string     `json:"issuer_filter_reload_interval"`
+	VerifiedPathCacheSize      int        `json:"verified_path_cache_size"`
+	StrictPrecertValidation    bool       `json:"strict_precert_validation"`
+	EnforceNameConstraints     bool       `json:"enforce_name_constraints"`
+	EnforcePathLength          bool       `json:"enforce_path_length"`
+	// Policies lists the names of the policies passed programmatically.
+	Policies []string `json:"policies"`
+}
+
+// adaptivePushbackConfig is the adaptive pushback configuration of a log, as
+// served by the admin API.
+type adaptivePushbackConfig struct {
+	MaxQPS           float64 `json:"max_qps"`
+	MinQPS           float64 `json:"min_qps"`
+	MaxAwaitLatency  string  `json:"max_await_latency"`
+	MaxCheckpointAge string  `json:"max_checkpoint_age"`
+}
+
+// lanesConfig is the priority lanes configuration of a log, as served by the
+// admin API.
+type lanesConfig struct {
+	Concurrency int          `json:"concurrency"`
+	Weights     map[Lane]int `json:"weights"`
+	MaxQueue    int          `json:"max_queue"`
+	BackfillAge string       `json:"backfill_age"`
+}
+
+// newEffectiveConfig returns the configuration l is served with.
+//
+// Rate limits and the lifecycle state can change at runtime, and are served by
+// dedicated admin endpoints.
+func newEffectiveConfig(l LogConfig, httpDeadline time.Duration, maskInternalErrors bool) (effectiveConfig, error) {
+	der, err := x509.MarshalPKIXPublicKey(l.Signer.Public())
+	if err != nil {
+		return effectiveConfig{}, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	cv := l.ChainValidationConfig
+	policies := make([]string, 0, len(cv.Policies))
+	for _, p := range cv.Policies {
+		policies = append(policies, p.Name())
+	}
+	var pushback *adaptivePushbackConfig
+	if p := l.Opts.AdaptivePushback; p != nil {
+		pushback = &adaptivePushbackConfig{
+			MaxQPS:           p.MaxQPS,
+			MinQPS:           p.MinQPS,
+			MaxAwaitLatency:  p.MaxAwaitLatency.String(),
+			MaxCheckpointAge: p.MaxCheckpointAge.String(),
+		}
+	}
+	var lanes *lanesConfig
+	if c := l.Opts.Lanes; c != nil {
+		lanes = &lanesConfig{
+			Concurrency: c.Concurrency,
+			Weights:     c.Weights,
+			MaxQueue:    c.MaxQueue,
+			BackfillAge: c.BackfillAge.String(),
+		}
+	}
+	return effectiveConfig{
+		Origin:                     l.Origin,
+		PathPrefix:                 l.PathPrefix,
+		PublicKey:                  der,
+		HTTPDeadline:               httpDeadline.String(),
+		MaskInternalErrors:         maskInternalErrors,
+		MaxCertChainBytes:          l.Opts.MaxCertChainBytes,
+		EnableRFC6962ReadAPI:       l.Opts.EnableRFC6962ReadAPI,
+		EnableValidateChain:        l.Opts.EnableValidateChain,
+		JSONErrors:                 l.Opts.JSONErrors,
+		DedupCacheSize:             l.Opts.DedupCacheSize,
+		QuotasFile:                 l.Opts.QuotasFile,
+		AuthFile:                   l.Opts.AuthFile,
+		AdaptivePushback:           pushback,
+		Lanes:                      lanes,
+		Lints:                      l.Opts.Lints,
+		RootsPEMFile:               cv.RootsPEMFile,
+		RootsRemoteFetchURLs:       cv.RootsRemoteFetchURLs,
+		RootsRemoteFetchInterval:   cv.RootsRemoteFetchInterval.String(),
+		RootsRemoteFetchBackup:     cv.RootsRemoteFetchBackup != nil,
+		RootsReloadInterval:        cv.RootsReloadInterval.String(),
+		RejectRoots:                cv.RejectRoots,
+		RejectExpired:              cv.RejectExpired,
+		RejectUnexpired:            cv.RejectUnexpired,
+		ExtKeyUsages:               cv.ExtKeyUsages,
+		RejectExtensions:           cv.RejectExtensions,
+		NotAfterStart:              cv.NotAfterStart,
+		NotAfterLimit:              cv.NotAfterLimit,
+		AcceptSHA1:                 cv.AcceptSHA1,
+		PolicyFile:                 cv.PolicyFile,
+		IssuerFilterFile:           cv.IssuerFilterFile,
+		IssuerFilterReloadInterval: cv.IssuerFilterReloadInterval.String(),
+		VerifiedPathCacheSize:      cv.VerifiedPathCacheSize,
+		StrictPrecertValidation:    cv.StrictPrecertValidation,
+		EnforceNameConstraints:     cv.EnforceNameConstraints,
+		EnforcePathLength:          cv.EnforcePathLength,
+		Policies:                   policies,
+	}, nil
 }
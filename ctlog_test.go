@@ -16,23 +16,37 @@ package tesseract
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
+	"fmt"
+	"io"
 	"maps"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/transparency-dev/tessera"
+	tposix "github.com/transparency-dev/tessera/storage/posix"
 	"github.com/transparency-dev/tesseract/internal/ccadb"
+	"github.com/transparency-dev/tesseract/internal/ct"
 	"github.com/transparency-dev/tesseract/internal/testdata"
 	"github.com/transparency-dev/tesseract/storage"
+	"github.com/transparency-dev/tesseract/storage/posix"
+	"golang.org/x/mod/sumdb/note"
 )
 
 func TestNewCertValidationOpts(t *testing.T) {
@@ -145,6 +159,52 @@ func TestNewCertValidationOpts(t *testing.T) {
 				NotAfterLimit: &t200,
 			},
 		},
+		{
+			desc: "ok-policy-file",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile: "./internal/testdata/fake-ca.cert",
+				PolicyFile:   "./internal/testdata/policies.json",
+			},
+		},
+		{
+			desc:    "missing-policy-file",
+			wantErr: "failed to read policy file",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile: "./internal/testdata/fake-ca.cert",
+				PolicyFile:   "./internal/testdata/bogus.json",
+			},
+		},
+		{
+			desc:    "invalid-policy-file",
+			wantErr: "failed to load policies",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile: "./internal/testdata/fake-ca.cert",
+				PolicyFile:   "./internal/testdata/fake-ca.cert",
+			},
+		},
+		{
+			desc: "ok-issuer-filter-file",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile:     "./internal/testdata/fake-ca.cert",
+				IssuerFilterFile: "./internal/testdata/issuer_filter.json",
+			},
+		},
+		{
+			desc:    "missing-issuer-filter-file",
+			wantErr: "failed to read issuer filter file",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile:     "./internal/testdata/fake-ca.cert",
+				IssuerFilterFile: "./internal/testdata/bogus.json",
+			},
+		},
+		{
+			desc:    "invalid-issuer-filter-file",
+			wantErr: "failed to load issuer filter",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile:     "./internal/testdata/fake-ca.cert",
+				IssuerFilterFile: "./internal/testdata/policies.json",
+			},
+		},
 		{
 			desc:    "invalid-reject-roots",
 			wantErr: "failed to create roots pool",
@@ -155,7 +215,7 @@ func TestNewCertValidationOpts(t *testing.T) {
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			vc, err := newChainValidator(t.Context(), tc.cvCfg)
+			vc, _, _, _, err := newChainValidator(t.Context(), "example.com", tc.cvCfg)
 			if len(tc.wantErr) == 0 && err != nil {
 				t.Errorf("ValidateLogConfig()=%v, want nil", err)
 			}
@@ -172,6 +232,9 @@ func TestNewCertValidationOpts(t *testing.T) {
 type ccadbRsp struct {
 	code int
 	crts []string
+	// trustBits and distrustAfter are only written out if set.
+	trustBits     string
+	distrustAfter string
 }
 
 func newCCADBTestServer(t *testing.T, rsps []ccadbRsp) *httptest.Server {
@@ -195,12 +258,20 @@ func newCCADBTestServer(t *testing.T, rsps []ccadbRsp) *httptest.Server {
 		}
 
 		cw := csv.NewWriter(w)
-		records := [][]string{
-			{ccadb.ColIssuer, ccadb.ColSHA, ccadb.ColSubject, ccadb.ColPEM, ccadb.ColUseCase},
+		header := []string{ccadb.ColIssuer, ccadb.ColSHA, ccadb.ColSubject, ccadb.ColPEM, ccadb.ColUseCase}
+		extra := []string{}
+		if rsp.trustBits != "" {
+			header = append(header, ccadb.ColTrustBits)
+			extra = append(extra, rsp.trustBits)
 		}
+		if rsp.distrustAfter != "" {
+			header = append(header, ccadb.ColTLSDistrustAfter)
+			extra = append(extra, rsp.distrustAfter)
+		}
+		records := [][]string{header}
 		for _, c := range rsp.crts {
 			cert := parsePEM(t, c)
-			records = append(records, []string{cert.Issuer.String(), "dum", cert.Subject.String(), c, ccadb.UseCaseServerAuth})
+			records = append(records, append([]string{cert.Issuer.String(), "dum", cert.Subject.String(), c, ccadb.UseCaseServerAuth}, extra...))
 		}
 
 		for _, record := range records {
@@ -344,6 +415,48 @@ func TestNewChainValidatorRootsRemoteFetch(t *testing.T) {
 			},
 			wantNRoots: 3, // one from file, one from server 1, one from server 2
 		},
+		{
+			desc: "root-not-trusted-for-tls",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile:             "./internal/testdata/fake-ca.cert",
+				RootsRemoteFetchInterval: fetchInterval,
+			},
+			rsps: []ccadbRsp{
+				{
+					code: 200,
+					crts: []string{
+						testdata.CACertPEM,
+					},
+					trustBits: "Secure Email",
+				},
+			},
+			wantNRoots: 1,
+		},
+		{
+			desc: "local-root-not-trusted-for-tls",
+			cvCfg: ChainValidationConfig{
+				RootsPEMFile:             "./internal/testdata/fake-ca.cert",
+				RootsRemoteFetchInterval: fetchInterval,
+			},
+			rsps: []ccadbRsp{
+				{
+					code: 200,
+					crts: []string{
+						testdata.CACertPEM,
+					},
+				},
+			},
+			rsps2: []ccadbRsp{
+				{
+					code: 200,
+					crts: []string{
+						testdata.FakeRootCACertPEM, // Also in the roots file.
+					},
+					trustBits: "Secure Email",
+				},
+			},
+			wantNRoots: 2, // roots from the file are kept
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			ts := newCCADBTestServer(t, tc.rsps)
@@ -357,7 +470,7 @@ func TestNewChainValidatorRootsRemoteFetch(t *testing.T) {
 				urls = append(urls, ts2.URL)
 			}
 			tc.cvCfg.RootsRemoteFetchURLs = urls
-			cv, err := newChainValidator(t.Context(), tc.cvCfg)
+			cv, _, _, _, err := newChainValidator(t.Context(), "example.com", tc.cvCfg)
 			if err == nil && cv == nil {
 				t.Error("err and ValidatedLogConfig are both nil")
 			}
@@ -370,6 +483,171 @@ func TestNewChainValidatorRootsRemoteFetch(t *testing.T) {
 	}
 }
 
+func TestNewChainValidatorRootsRemoteFetchDistrust(t *testing.T) {
+	fetchInterval := 20 * time.Millisecond
+	// The leaf was issued on 2024-12-05.
+	leaf := parsePEM(t, testdata.TestCertPEM)
+
+	for _, tc := range []struct {
+		desc string
+		rsps []ccadbRsp
+		// wantErr and wantErrLater are for validation after the first, and
+		// after the last fetch.
+		wantErr      bool
+		wantErrLater bool
+	}{
+		{
+			desc: "no-distrust",
+			rsps: []ccadbRsp{{code: 200, crts: []string{testdata.CACertPEM}}},
+		},
+		{
+			desc: "distrusted-after-issuance",
+			rsps: []ccadbRsp{{code: 200, crts: []string{testdata.CACertPEM}, distrustAfter: "2024.12.05"}},
+		},
+		{
+			desc:         "distrusted-before-issuance",
+			rsps:         []ccadbRsp{{code: 200, crts: []string{testdata.CACertPEM}, distrustAfter: "2024.12.04"}},
+			wantErr:      true,
+			wantErrLater: true,
+		},
+		{
+			desc: "distrust-lifted",
+			rsps: []ccadbRsp{
+				{code: 200, crts: []string{testdata.CACertPEM}, distrustAfter: "2024.12.04"},
+				{code: 200, crts: []string{testdata.CACertPEM}},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := newCCADBTestServer(t, tc.rsps)
+			ts.Start()
+			defer ts.Close()
+			cvCfg := ChainValidationConfig{
+				RootsPEMFile:             "./internal/testdata/fake-ca.cert",
+				RootsRemoteFetchInterval: fetchInterval,
+				RootsRemoteFetchURLs:     []string{ts.URL},
+			}
+			cv, _, _, _, err := newChainValidator(t.Context(), "example.com", cvCfg)
+			if err != nil {
+				t.Fatalf("newChainValidator()=%v", err)
+			}
+			if _, err := cv.Validate([]*x509.Certificate{leaf}, false); (err != nil) != tc.wantErr {
+				t.Errorf("Validate()=%v, want err: %t", err, tc.wantErr)
+			}
+			time.Sleep(10 * fetchInterval)
+			if _, err := cv.Validate([]*x509.Certificate{leaf}, false); (err != nil) != tc.wantErrLater {
+				t.Errorf("Validate() after the last fetch=%v, want err: %t", err, tc.wantErrLater)
+			}
+		})
+	}
+}
+
+func TestNewChainValidatorRootsConstraintsBackup(t *testing.T) {
+	// The leaf was issued on 2024-12-05, by CACertPEM.
+	leaf := parsePEM(t, testdata.TestCertPEM)
+	backup := &memoryRootsStorage{m: make(map[string][]byte)}
+
+	// newValidator fetches roots from rsp if set, and only loads them from
+	// the backup otherwise, as after a restart.
+	newValidator := func(rsp *ccadbRsp) ct.ChainValidator {
+		t.Helper()
+		ctx, cancel := context.WithCancel(t.Context())
+		t.Cleanup(cancel)
+		cvCfg := ChainValidationConfig{
+			RootsPEMFile:           "./internal/testdata/fake-ca.cert",
+			RootsRemoteFetchBackup: backup,
+		}
+		if rsp != nil {
+			ts := newCCADBTestServer(t, []ccadbRsp{*rsp})
+			ts.Start()
+			t.Cleanup(ts.Close)
+			cvCfg.RootsRemoteFetchURLs = []string{ts.URL}
+			cvCfg.RootsRemoteFetchInterval = time.Hour
+		}
+		cv, _, _, _, err := newChainValidator(ctx, "example.com", cvCfg)
+		if err != nil {
+			t.Fatalf("newChainValidator()=%v", err)
+		}
+		return cv
+	}
+
+	newValidator(&ccadbRsp{code: 200, crts: []string{testdata.CACertPEM}, distrustAfter: "2024.12.04"})
+	cv := newValidator(nil)
+	if _, err := cv.Validate([]*x509.Certificate{leaf}, false); err == nil {
+		t.Error("Validate() after a restart got no error for a chain rooted at a distrusted root")
+	}
+
+	cv = newValidator(&ccadbRsp{code: 200, crts: []string{testdata.CACertPEM}, trustBits: "Secure Email"})
+	if got := len(cv.Roots()); got != 1 {
+		t.Errorf("ChainValidator has %d roots once a root is not trusted for TLS anymore, want 1", got)
+	}
+	cv = newValidator(nil)
+	if got := len(cv.Roots()); got != 1 {
+		t.Errorf("ChainValidator has %d roots after a restart, want 1", got)
+	}
+
+	newValidator(&ccadbRsp{code: 200, crts: []string{testdata.CACertPEM}})
+	cv = newValidator(nil)
+	if got := len(cv.Roots()); got != 2 {
+		t.Errorf("ChainValidator has %d roots after a restart once a root is trusted again, want 2", got)
+	}
+	if _, err := cv.Validate([]*x509.Certificate{leaf}, false); err != nil {
+		t.Errorf("Validate() after a restart once constraints are lifted: %v", err)
+	}
+
+	newValidator(&ccadbRsp{code: 200, crts: []string{testdata.FakeRootCACertPEM}, trustBits: "Secure Email"})
+	cv = newValidator(nil)
+	if got := len(cv.Roots()); got != 2 {
+		t.Errorf("ChainValidator has %d roots after a restart once a root from the roots file is not trusted for TLS anymore, want 2", got)
+	}
+}
+
+func TestNewChainValidatorRootsConstraintsUnchanged(t *testing.T) {
+	fetchInterval := 20 * time.Millisecond
+
+	for _, tc := range []struct {
+		desc          string
+		distrustAfter string
+	}{
+		{
+			desc: "no-constraint",
+		},
+		{
+			desc:          "constraint",
+			distrustAfter: "2024.12.05",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := newCCADBTestServer(t, []ccadbRsp{{code: 200, crts: []string{testdata.CACertPEM}, distrustAfter: tc.distrustAfter}})
+			ts.Start()
+			defer ts.Close()
+			cvCfg := ChainValidationConfig{
+				RootsPEMFile:             "./internal/testdata/fake-ca.cert",
+				RootsRemoteFetchInterval: fetchInterval,
+				RootsRemoteFetchURLs:     []string{ts.URL},
+				RootsRemoteFetchBackup:   &memoryRootsStorage{m: make(map[string][]byte)},
+			}
+			_, pool, load, _, err := newChainValidator(t.Context(), "example.com", cvCfg)
+			if err != nil {
+				t.Fatalf("newChainValidator()=%v", err)
+			}
+			_, g := pool.CertPoolGeneration()
+
+			time.Sleep(10 * fetchInterval)
+			if _, got := pool.CertPoolGeneration(); got != g {
+				t.Errorf("CertPoolGeneration() after fetching unchanged roots=%d, want %d", got, g)
+			}
+			if _, err := load(t.Context()); err != nil {
+				t.Fatalf("load()=%v", err)
+			}
+			if _, got := pool.CertPoolGeneration(); got != g {
+				t.Errorf("CertPoolGeneration() after reloading unchanged roots=%d, want %d", got, g)
+			}
+		})
+	}
+}
+
 func parsePEM(t *testing.T, pemCert string) *x509.Certificate {
 	var block *pem.Block
 	block, _ = pem.Decode([]byte(pemCert))
@@ -504,7 +782,7 @@ func TestNewChainValidatorRootsFiltering(t *testing.T) {
 			if err := tc.cvCfg.RootsRemoteFetchBackup.AddIfNotExist(t.Context(), tc.backupRoots); err != nil {
 				t.Fatalf("Can't initialize root backup storage: %v", err)
 			}
-			cv, err := newChainValidator(t.Context(), tc.cvCfg)
+			cv, _, _, _, err := newChainValidator(t.Context(), "example.com", tc.cvCfg)
 			if err != nil {
 				t.Fatalf("newChainValidator()=%v", err)
 			}
@@ -535,6 +813,9 @@ func TestNewChainValidatorRootsFiltering(t *testing.T) {
 			}
 			gotBackupRootsByFP := make(map[string][]byte)
 			for _, root := range gotBackupRoots {
+				if strings.Contains(string(root.K), rootConstraintsKeyInfix) {
+					continue
+				}
 				gotBackupRootsByFP[string(root.K)] = root.V
 			}
 			for _, wantFP := range tc.wantBackupRootsFP {
@@ -551,3 +832,498 @@ func TestNewChainValidatorRootsFiltering(t *testing.T) {
 		})
 	}
 }
+
+func TestNewChainValidatorRootsReloadKeepsFetchedRoots(t *testing.T) {
+	ts := newCCADBTestServer(t, []ccadbRsp{{code: 200, crts: []string{testdata.CACertPEM}}})
+	ts.Start()
+	defer ts.Close()
+	cv, pool, load, _, err := newChainValidator(t.Context(), "example.com", ChainValidationConfig{
+		RootsPEMFile:             "./internal/testdata/fake-ca.cert",
+		RootsRemoteFetchInterval: time.Hour,
+		RootsRemoteFetchURLs:     []string{ts.URL},
+	})
+	if err != nil {
+		t.Fatalf("newChainValidator()=%v", err)
+	}
+	if got := len(cv.Roots()); got != 2 {
+		t.Fatalf("ChainValidator has %d roots after fetching roots, want 2", got)
+	}
+	if err := ct.ReloadRoots(t.Context(), "example.com", pool, load); err != nil {
+		t.Fatalf("ReloadRoots()=%v", err)
+	}
+	if got := len(cv.Roots()); got != 2 {
+		t.Errorf("ChainValidator has %d roots after reloading roots without a backup, want 2", got)
+	}
+}
+
+func TestNewChainValidatorRootsReload(t *testing.T) {
+	fakeRoot := parsePEM(t, testdata.FakeRootCACertPEM)
+	caRoot := parsePEM(t, testdata.CACertPEM)
+	fakeCA := parsePEM(t, testdata.FakeCACertPEM)
+	rejectedSHA256 := sha256.Sum256(fakeCA.Raw)
+
+	rootsFile := filepath.Join(t.TempDir(), "roots.pem")
+	writeRoots := func(pems ...string) {
+		t.Helper()
+		if err := os.WriteFile(rootsFile, []byte(strings.Join(pems, "\n")), 0o644); err != nil {
+			t.Fatalf("Failed to write roots file: %v", err)
+		}
+	}
+	writeRoots(testdata.FakeRootCACertPEM, testdata.CACertPEM)
+
+	reload := make(chan struct{})
+	cv, _, _, _, err := newChainValidator(t.Context(), "example.com", ChainValidationConfig{
+		RootsPEMFile: rootsFile,
+		RejectRoots:  []string{hex.EncodeToString(rejectedSHA256[:])},
+		RootsReload:  reload,
+	})
+	if err != nil {
+		t.Fatalf("newChainValidator()=%v", err)
+	}
+
+	// Note: tests are cumulative
+	for _, tc := range []struct {
+		desc      string
+		pems      []string
+		wantRoots []*x509.Certificate
+	}{
+		{
+			desc:      "remove",
+			pems:      []string{testdata.CACertPEM},
+			wantRoots: []*x509.Certificate{caRoot},
+		},
+		{
+			desc:      "add-and-reject",
+			pems:      []string{testdata.CACertPEM, testdata.FakeRootCACertPEM, testdata.FakeCACertPEM},
+			wantRoots: []*x509.Certificate{caRoot, fakeRoot},
+		},
+		{
+			desc:      "invalid-file-keeps-roots",
+			pems:      []string{"not a PEM file"},
+			wantRoots: []*x509.Certificate{caRoot, fakeRoot},
+		},
+		{
+			desc:      "swap",
+			pems:      []string{testdata.FakeRootCACertPEM},
+			wantRoots: []*x509.Certificate{fakeRoot},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			writeRoots(tc.pems...)
+			reload <- struct{}{}
+			equal := func() bool {
+				roots := cv.Roots()
+				if len(roots) != len(tc.wantRoots) {
+					return false
+				}
+				for i, r := range tc.wantRoots {
+					if !roots[i].Equal(r) {
+						return false
+					}
+				}
+				return true
+			}
+			// Sending on reload only guarantees that the reload started.
+			for range 100 {
+				if equal() {
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			t.Errorf("ChainValidator has %d roots, want %d", len(cv.Roots()), len(tc.wantRoots))
+		})
+	}
+}
+
+func TestNewChainValidatorIssuerFilterReload(t *testing.T) {
+	chain := []*x509.Certificate{parsePEM(t, testdata.CertFromIntermediate), parsePEM(t, testdata.IntermediateFromRoot)}
+	intermediateSHA256 := sha256.Sum256(chain[1].Raw)
+
+	filterFile := filepath.Join(t.TempDir(), "issuer_filter.json")
+	writeFilter := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(filterFile, []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write issuer filter file: %v", err)
+		}
+	}
+	writeFilter(fmt.Sprintf(`{"deny_fingerprints": [%q]}`, hex.EncodeToString(intermediateSHA256[:])))
+
+	reload := make(chan struct{})
+	cv, _, _, reloadFilter, err := newChainValidator(t.Context(), "example.com", ChainValidationConfig{
+		RootsPEMFile:       "./internal/testdata/test_root_ca_cert.pem",
+		IssuerFilterFile:   filterFile,
+		IssuerFilterReload: reload,
+	})
+	if err != nil {
+		t.Fatalf("newChainValidator()=%v", err)
+	}
+
+	// Note: tests are cumulative
+	for _, tc := range []struct {
+		desc    string
+		data    string
+		wantErr bool
+	}{
+		{
+			desc:    "initial",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid-file-keeps-lists",
+			data:    "{",
+			wantErr: true,
+		},
+		{
+			desc: "cleared",
+			data: "{}",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.data != "" {
+				writeFilter(tc.data)
+				reload <- struct{}{}
+			}
+			// Sending on reload only guarantees that the reload started.
+			var err error
+			for range 100 {
+				if _, err = cv.Validate(chain, false); (err != nil) == tc.wantErr {
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			t.Errorf("Validate()=%v, want err: %t", err, tc.wantErr)
+		})
+	}
+
+	// The returned function reloads the filter on demand, as the admin API does.
+	writeFilter("{")
+	if err := reloadFilter(t.Context()); err == nil {
+		t.Error("reloadFilter() with an invalid file: got nil error, want error")
+	}
+	writeFilter(fmt.Sprintf(`{"deny_fingerprints": [%q]}`, hex.EncodeToString(intermediateSHA256[:])))
+	if err := reloadFilter(t.Context()); err != nil {
+		t.Errorf("reloadFilter()=%v", err)
+	}
+	if _, err := cv.Validate(chain, false); err == nil {
+		t.Error("Validate() after reloadFilter(): got nil error, want error")
+	}
+}
+
+func newPOSIXStorageFunc(t *testing.T, root string) storage.CreateStorage {
+	t.Helper()
+
+	return func(ctx context.Context, signer note.Signer) (*storage.CTStorage, error) {
+		driver, err := tposix.New(ctx, tposix.Config{Path: root})
+		if err != nil {
+			t.Fatalf("Failed to initialize POSIX Tessera storage driver: %v", err)
+		}
+		opts := tessera.NewAppendOptions().
+			WithCheckpointSigner(signer).
+			WithCTLayout()
+		appender, _, reader, err := tessera.NewAppender(ctx, driver, opts)
+		if err != nil {
+			t.Fatalf("Failed to initialize POSIX Tessera appender: %v", err)
+		}
+		issuerStorage, err := posix.NewIssuerStorage(ctx, root)
+		if err != nil {
+			t.Fatalf("Failed to initialize POSIX issuer storage: %v", err)
+		}
+		logStateStorage, err := posix.NewLogStateStorage(ctx, root)
+		if err != nil {
+			t.Fatalf("Failed to initialize POSIX log state storage: %v", err)
+		}
+		return storage.NewCTStorage(ctx, &storage.CTStorageOptions{
+			Appender:        appender,
+			Reader:          reader,
+			IssuerStorage:   issuerStorage,
+			LogStateStorage: logStateStorage,
+		})
+	}
+}
+
+// newLogConfig returns the configuration of a POSIX log with a fresh key.
+func newLogConfig(t *testing.T, origin, prefix string) LogConfig {
+	t.Helper()
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return LogConfig{
+		Origin: origin,
+		Signer: signer,
+		ChainValidationConfig: ChainValidationConfig{
+			RootsPEMFile: "./internal/testdata/test_root_ca_cert.pem",
+		},
+		CreateStorage: newPOSIXStorageFunc(t, t.TempDir()),
+		PathPrefix:    prefix,
+	}
+}
+
+func TestNewMultiLogHandler(t *testing.T) {
+
+	for _, tc := range []struct {
+		desc    string
+		logs    func(t *testing.T) []LogConfig
+		wantErr string
+	}{
+		{
+			desc:    "no-log",
+			logs:    func(t *testing.T) []LogConfig { return nil },
+			wantErr: "no log to serve",
+		},
+		{
+			desc: "duplicate-origin",
+			logs: func(t *testing.T) []LogConfig {
+				return []LogConfig{
+					newLogConfig(t, "example.com/2025h1", "2025h1"),
+					newLogConfig(t, "example.com/2025h1", "2025h2"),
+				}
+			},
+			wantErr: "duplicate origin",
+		},
+		{
+			desc: "duplicate-prefix",
+			logs: func(t *testing.T) []LogConfig {
+				return []LogConfig{
+					newLogConfig(t, "example.com/2025h1", "/2025h1/"),
+					newLogConfig(t, "example.com/2025h2", "2025h1"),
+				}
+			},
+			wantErr: "duplicate path prefix",
+		},
+		{
+			desc: "invalid-log",
+			logs: func(t *testing.T) []LogConfig {
+				invalid := newLogConfig(t, "example.com/2025h2", "2025h2")
+				invalid.ChainValidationConfig.RootsPEMFile = ""
+				return []LogConfig{newLogConfig(t, "example.com/2025h1", "2025h1"), invalid}
+			},
+			wantErr: "empty rootsPemFile",
+		},
+		{
+			desc: "ok",
+			logs: func(t *testing.T) []LogConfig {
+				return []LogConfig{
+					newLogConfig(t, "example.com/2025h1", "2025h1"),
+					newLogConfig(t, "example.com/2025h2", "2025h2"),
+				}
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			logs := tc.logs(t)
+			h, err := NewMultiLogHandler(t.Context(), logs, time.Second, false, MultiLogHandlerOpts{})
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("NewMultiLogHandler()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewMultiLogHandler()=%v", err)
+			}
+
+			s := httptest.NewServer(h)
+			defer s.Close()
+			for _, l := range logs {
+				rsp, err := http.Get(s.URL + "/" + l.PathPrefix + "/ct/v1/get-roots")
+				if err != nil {
+					t.Fatalf("get-roots for %q: %v", l.Origin, err)
+				}
+				_ = rsp.Body.Close()
+				if rsp.StatusCode != http.StatusOK {
+					t.Errorf("get-roots for %q returned status %d, want %d", l.Origin, rsp.StatusCode, http.StatusOK)
+				}
+			}
+			rsp, err := http.Get(s.URL + "/ct/v1/get-roots")
+			if err != nil {
+				t.Fatalf("get-roots without prefix: %v", err)
+			}
+			_ = rsp.Body.Close()
+			if rsp.StatusCode != http.StatusNotFound {
+				t.Errorf("get-roots without prefix returned status %d, want %d", rsp.StatusCode, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestNewMultiLogHandlerRemoteShard(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Host+r.URL.Path)
+	}))
+	defer remote.Close()
+
+	h, err := NewMultiLogHandler(t.Context(), nil, time.Second, false, MultiLogHandlerOpts{
+		RouterPathPrefix:  "router",
+		MaxCertChainBytes: 1 << 20,
+		RemoteShards:      []RemoteShard{{Origin: "example.com/2025h1", SubmissionPrefix: remote.URL + "/2025h1"}},
+	})
+	if err != nil {
+		t.Fatalf("NewMultiLogHandler()=%v", err)
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	block, _ := pem.Decode([]byte(testdata.CertFromIntermediate))
+	body := fmt.Sprintf(`{"chain":[%q]}`, base64.StdEncoding.EncodeToString(block.Bytes))
+	rsp, err := http.Post(s.URL+"/router/ct/v1/add-chain", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("add-chain: %v", err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+	got, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	if want := strings.TrimPrefix(remote.URL, "http://") + "/2025h1/ct/v1/add-chain"; string(got) != want {
+		t.Errorf("add-chain forwarded to %q, want %q", got, want)
+	}
+}
+
+func TestNewMultiLogHandlerState(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		state    LogState
+		persist  bool
+		wantCode int
+	}{
+		{desc: "unset", wantCode: http.StatusBadRequest},
+		{desc: "usable", state: LogStateUsable, wantCode: http.StatusBadRequest},
+		{desc: "read-only", state: LogStateReadOnly, persist: true, wantCode: http.StatusForbidden},
+		{desc: "retired", state: LogStateRetired, persist: true, wantCode: http.StatusGone},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l := newLogConfig(t, "example.com/2025h1", "2025h1")
+			l.Opts.State = tc.state
+			l.Opts.PersistState = tc.persist
+			l.Opts.MaxCertChainBytes = 1 << 20
+			h, err := NewMultiLogHandler(t.Context(), []LogConfig{l}, time.Second, false, MultiLogHandlerOpts{})
+			if err != nil {
+				t.Fatalf("NewMultiLogHandler()=%v", err)
+			}
+			s := httptest.NewServer(h)
+			defer s.Close()
+			// An empty chain is rejected by usable logs only.
+			rsp, err := http.Post(s.URL+"/2025h1/ct/v1/add-chain", "application/json", strings.NewReader(`{"chain": []}`))
+			if err != nil {
+				t.Fatalf("http.Post(): %v", err)
+			}
+			_ = rsp.Body.Close()
+			if rsp.StatusCode != tc.wantCode {
+				t.Errorf("add-chain returned status %d, want %d", rsp.StatusCode, tc.wantCode)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		l := newLogConfig(t, "example.com/2025h1", "2025h1")
+		l.Opts.State = "frozen"
+		if _, err := NewMultiLogHandler(t.Context(), []LogConfig{l}, time.Second, false, MultiLogHandlerOpts{}); err == nil {
+			t.Errorf("NewMultiLogHandler()=nil, want error for an invalid state")
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		l := newLogConfig(t, "example.com/2025h1", "2025h1")
+		l.Opts.State = LogStateReadOnly
+		if _, err := NewMultiLogHandler(t.Context(), []LogConfig{l}, time.Second, false, MultiLogHandlerOpts{}); err == nil {
+			t.Errorf("NewMultiLogHandler()=nil, want error for a log in another state")
+		}
+	})
+}
+
+func TestNewMultiLogHandlerLints(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		lints   string
+		wantErr string
+	}{
+		{desc: "none"},
+		{desc: "valid", lints: "all=warn,san_missing=reject"},
+		{desc: "unknown-lint", lints: "nope=warn", wantErr: "unknown lint"},
+		{desc: "unknown-severity", lints: "san_missing=nope", wantErr: "invalid severity"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			lints, err := ParseLintSeverities(tc.lints)
+			if err != nil {
+				t.Fatalf("ParseLintSeverities(): %v", err)
+			}
+			l := newLogConfig(t, "example.com/2025h1", "2025h1")
+			l.Opts.Lints = lints
+			_, err = NewMultiLogHandler(t.Context(), []LogConfig{l}, time.Second, false, MultiLogHandlerOpts{})
+			if tc.wantErr == "" && err != nil {
+				t.Errorf("NewMultiLogHandler()=%v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("NewMultiLogHandler()=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewMultiLogHandlerAdmin(t *testing.T) {
+	logs := []LogConfig{
+		newLogConfig(t, "example.com/2025h1", "2025h1"),
+		newLogConfig(t, "example.com/2025h2", "2025h2"),
+	}
+	adminGet := func(t *testing.T, h http.Handler, path string) (int, string) {
+		t.Helper()
+		s := httptest.NewServer(h)
+		defer s.Close()
+		req, err := http.NewRequest(http.MethodGet, s.URL+path, nil)
+		if err != nil {
+			t.Fatalf("http.NewRequest(): %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = rsp.Body.Close() }()
+		body, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+		return rsp.StatusCode, string(body)
+	}
+
+	t.Run("no-mux", func(t *testing.T) {
+		if _, err := NewMultiLogHandler(t.Context(), logs, time.Second, false, MultiLogHandlerOpts{Admin: &AdminOpts{Tokens: []string{"token"}}}); err == nil {
+			t.Error("NewMultiLogHandler()=nil, want error")
+		}
+	})
+
+	t.Run("separate-mux", func(t *testing.T) {
+		adminMux := http.NewServeMux()
+		h, err := NewMultiLogHandler(t.Context(), logs, time.Second, false, MultiLogHandlerOpts{Admin: &AdminOpts{Tokens: []string{"token"}, Mux: adminMux}})
+		if err != nil {
+			t.Fatalf("NewMultiLogHandler()=%v", err)
+		}
+		if code, _ := adminGet(t, h, "/admin/v1/logs"); code != http.StatusNotFound {
+			t.Errorf("GET logs on the log handler returned status %d, want %d", code, http.StatusNotFound)
+		}
+		if code, body := adminGet(t, adminMux, "/admin/v1/logs"); code != http.StatusOK {
+			t.Errorf("GET logs on the admin mux returned status %d: %s", code, body)
+		}
+		code, body := adminGet(t, adminMux, "/admin/v1/config?origin=example.com/2025h2")
+		if code != http.StatusOK {
+			t.Fatalf("GET config returned status %d: %s", code, body)
+		}
+		var cfg effectiveConfig
+		if err := json.Unmarshal([]byte(body), &cfg); err != nil {
+			t.Fatalf("json.Unmarshal(%q): %v", body, err)
+		}
+		if cfg.Origin != "example.com/2025h2" || cfg.PathPrefix != "2025h2" || len(cfg.PublicKey) == 0 {
+			t.Errorf("got config %+v, want example.com/2025h2 served under 2025h2 with a public key", cfg)
+		}
+		if code, body := adminGet(t, adminMux, "/admin/v1/roots?origin=example.com/2025h1"); code != http.StatusOK {
+			t.Errorf("GET roots returned status %d: %s", code, body)
+		}
+	})
+
+	t.Run("no-token", func(t *testing.T) {
+		if _, err := NewMultiLogHandler(t.Context(), logs, time.Second, false, MultiLogHandlerOpts{Admin: &AdminOpts{Mux: http.NewServeMux()}}); err == nil {
+			t.Error("NewMultiLogHandler()=nil, want error")
+		}
+	})
+}
@@ -29,6 +29,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/johannesboyne/gofakes3 v1.2.0
 	github.com/kylelemons/godebug v1.1.0
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/rivo/tview v0.42.0
 	github.com/transparency-dev/formats v0.1.2-0.20260629100010-fa283eb7462a
 	github.com/transparency-dev/merkle v0.0.3-0.20260629095233-a1adddb6323b
@@ -16,7 +16,9 @@ package ccadb
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -33,8 +35,142 @@ var (
 	ColUseCase        = "Intended Use Case(s) Served"
 	UseCaseServerAuth = "Server Authentication (TLS) 1.3.6.1.5.5.7.3.1"
 	KnownHeaders      = []string{ColSubject, ColIssuer, ColPEM, ColSHA, ColUseCase}
+
+	// Optional columns, which constrain the chains a root is trusted for.
+	ColTrustBits         = "Derived Trust Bits"
+	TrustBitServerAuth   = "Server Authentication"
+	ColTLSDistrustAfter  = "Distrust for TLS After Date"
+	distrustAfterLayouts = []string{"2006.01.02", "2006-01-02"}
+	constraintHeaders    = []string{ColTrustBits, ColTLSDistrustAfter}
 )
 
+// Root is a root fetched from CCADB, with the constraints it comes with.
+type Root struct {
+	// PEM is the PEM encoded root certificate.
+	PEM []byte
+	// TrustBits lists the trust bits of the root, or is nil if the CSV does
+	// not have a trust bits column.
+	TrustBits []string
+	// TLSDistrustAfter is the day after which TLS certificates issued under
+	// this root are distrusted, or zero if they are not.
+	TLSDistrustAfter time.Time
+}
+
+// TrustedForServerAuth returns false if the root's trust bits are known, and
+// do not include server authentication.
+func (r Root) TrustedForServerAuth() bool {
+	if r.TrustBits == nil {
+		return true
+	}
+	for _, b := range r.TrustBits {
+		if strings.EqualFold(b, TrustBitServerAuth) {
+			return true
+		}
+	}
+	return false
+}
+
+// Constraint returns a function rejecting chains that the root is not
+// trusted for, to be used with lax509.CertPool.AddCertWithConstraint, or nil
+// if the root has no constraint.
+//
+// Chains rooted at a root distrusted for TLS after a day are only trusted if
+// their leaf was issued by the end of that day, in UTC.
+func (r Root) Constraint() func([]*x509.Certificate) error {
+	if r.TLSDistrustAfter.IsZero() {
+		return nil
+	}
+	limit := r.TLSDistrustAfter.AddDate(0, 0, 1)
+	return func(chain []*x509.Certificate) error {
+		if len(chain) > 0 && !chain[0].NotBefore.Before(limit) {
+			return fmt.Errorf("root is distrusted for TLS certificates issued after %s, leaf NotBefore is %v", r.TLSDistrustAfter.Format(time.DateOnly), chain[0].NotBefore)
+		}
+		return nil
+	}
+}
+
+// rootConstraints is the JSON encoding of the constraints of a Root.
+type rootConstraints struct {
+	// TrustBits is null if the trust bits of the root are unknown.
+	TrustBits        []string `json:"trust_bits"`
+	TLSDistrustAfter string   `json:"tls_distrust_after,omitempty"`
+}
+
+// MarshalConstraints returns the constraints of r, without its PEM, so that
+// they can be persisted alongside it.
+func (r Root) MarshalConstraints() ([]byte, error) {
+	c := rootConstraints{TrustBits: r.TrustBits}
+	if !r.TLSDistrustAfter.IsZero() {
+		c.TLSDistrustAfter = r.TLSDistrustAfter.Format(time.DateOnly)
+	}
+	return json.Marshal(c)
+}
+
+// UnmarshalConstraints sets the constraints of r from data returned by
+// MarshalConstraints.
+func (r *Root) UnmarshalConstraints(data []byte) error {
+	var c rootConstraints
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("failed to parse root constraints: %v", err)
+	}
+	r.TrustBits = c.TrustBits
+	r.TLSDistrustAfter = time.Time{}
+	if c.TLSDistrustAfter != "" {
+		t, err := time.Parse(time.DateOnly, c.TLSDistrustAfter)
+		if err != nil {
+			return fmt.Errorf("invalid TLS distrust date %q: %v", c.TLSDistrustAfter, err)
+		}
+		r.TLSDistrustAfter = t
+	}
+	return nil
+}
+
+// FetchRoots retrieves a CCADB CSV and returns roots with a Use Case set to
+// Server Authentication, together with their constraints.
+//
+// In addition to the columns required by Fetch, it uses the following
+// columns if they are present:
+//
+//	Derived Trust Bits, Distrust for TLS After Date
+func FetchRoots(ctx context.Context, url string) ([]Root, error) {
+	rows, err := fetch(ctx, url, []string{ColPEM}, constraintHeaders)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]Root, 0, len(rows))
+	for _, row := range rows {
+		r := Root{PEM: row[0]}
+		if row[1] != nil {
+			r.TrustBits = []string{}
+			for b := range strings.SplitSeq(string(row[1]), ";") {
+				if b = strings.TrimSpace(b); b != "" {
+					r.TrustBits = append(r.TrustBits, b)
+				}
+			}
+		}
+		if d := strings.TrimSpace(string(row[2])); d != "" {
+			r.TLSDistrustAfter, err = parseDate(d)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q value %q: %v", ColTLSDistrustAfter, d, err)
+			}
+		}
+		roots = append(roots, r)
+	}
+	return roots, nil
+}
+
+// parseDate parses a CCADB date.
+func parseDate(d string) (time.Time, error) {
+	var err error
+	for _, layout := range distrustAfterLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, d); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
 // Fetch retrieves a CCADB CSV and returns rows with a Use Case set to Server Authentication.
 //
 // It expects the CSV to have a header with at least the following columns:
@@ -43,6 +179,13 @@ var (
 //
 // Callers chose which columns are returned, and can request additional ones.
 func Fetch(ctx context.Context, url string, fetchHeaders []string) ([][][]byte, error) {
+	return fetch(ctx, url, fetchHeaders, nil)
+}
+
+// fetch retrieves a CCADB CSV and returns rows with a Use Case set to Server
+// Authentication, with fetchHeaders columns, followed by optionalHeaders
+// columns. Optional columns are nil if they are not in the CSV.
+func fetch(ctx context.Context, url string, fetchHeaders, optionalHeaders []string) ([][][]byte, error) {
 	// 1. Fetch the CSV content from the URL
 	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -129,6 +272,13 @@ func Fetch(ctx context.Context, url string, fetchHeaders []string) ([][][]byte,
 		for _, col := range fetchHeaders {
 			elems = append(elems, []byte(row[indices[col]]))
 		}
+		for _, col := range optionalHeaders {
+			if i, found := indices[col]; found && i < len(row) {
+				elems = append(elems, []byte(row[i]))
+			} else {
+				elems = append(elems, nil)
+			}
+		}
 		rows = append(rows, elems)
 	}
 
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/transparency-dev/tesseract/internal/testdata"
 )
@@ -35,6 +36,8 @@ type ccadbRsp struct {
 	code    int
 	crts    []string
 	useCase string
+	// extra holds additional columns, and their value for every row.
+	extra map[string]string
 }
 
 func NewTestServer(t *testing.T, rsp ccadbRsp) *httptest.Server {
@@ -47,10 +50,16 @@ func NewTestServer(t *testing.T, rsp ccadbRsp) *httptest.Server {
 		}
 
 		cw := csv.NewWriter(w)
-		records := [][]string{{ColIssuer, ColSHA, ColSubject, ColPEM, ColUseCase}}
+		header := []string{ColIssuer, ColSHA, ColSubject, ColPEM, ColUseCase}
+		extra := []string{}
+		for col, v := range rsp.extra {
+			header = append(header, col)
+			extra = append(extra, v)
+		}
+		records := [][]string{header}
 		for _, c := range rsp.crts {
 			cert := parsePEM(t, c)
-			records = append(records, []string{cert.Issuer.String(), testSHA256, cert.Subject.String(), c, rsp.useCase})
+			records = append(records, append([]string{cert.Issuer.String(), testSHA256, cert.Subject.String(), c, rsp.useCase}, extra...))
 		}
 
 		for _, record := range records {
@@ -180,3 +189,128 @@ func TestFetch(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchRoots(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   map[string]string
+		want    []Root
+		wantErr bool
+	}{
+		{
+			name: "no-constraint-columns",
+			want: []Root{{PEM: []byte(testdata.CACertPEM)}},
+		},
+		{
+			name:  "empty-constraint-columns",
+			extra: map[string]string{ColTrustBits: "", ColTLSDistrustAfter: ""},
+			want:  []Root{{PEM: []byte(testdata.CACertPEM), TrustBits: []string{}}},
+		},
+		{
+			name:  "trust-bits",
+			extra: map[string]string{ColTrustBits: "Client Authentication; Server Authentication;Secure Email"},
+			want:  []Root{{PEM: []byte(testdata.CACertPEM), TrustBits: []string{"Client Authentication", TrustBitServerAuth, "Secure Email"}}},
+		},
+		{
+			name:  "distrust-after-dots",
+			extra: map[string]string{ColTLSDistrustAfter: "2024.10.31"},
+			want:  []Root{{PEM: []byte(testdata.CACertPEM), TLSDistrustAfter: time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "distrust-after-dashes",
+			extra: map[string]string{ColTLSDistrustAfter: "2024-10-31"},
+			want:  []Root{{PEM: []byte(testdata.CACertPEM), TLSDistrustAfter: time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:    "invalid-distrust-after",
+			extra:   map[string]string{ColTLSDistrustAfter: "31/10/2024"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTestServer(t, ccadbRsp{code: 200, crts: []string{testdata.CACertPEM}, useCase: UseCaseServerAuth, extra: tc.extra})
+			ts.Start()
+			defer ts.Close()
+			got, gotErr := FetchRoots(t.Context(), ts.URL)
+			if gotErr != nil {
+				if !tc.wantErr {
+					t.Errorf("FetchRoots() failed: %v", gotErr)
+				}
+				return
+			}
+			if tc.wantErr {
+				t.Fatal("FetchRoots() succeeded unexpectedly")
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FetchRoots()=%+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRootTrustedForServerAuth(t *testing.T) {
+	for _, tc := range []struct {
+		trustBits []string
+		want      bool
+	}{
+		{trustBits: nil, want: true},
+		{trustBits: []string{}, want: false},
+		{trustBits: []string{"Secure Email"}, want: false},
+		{trustBits: []string{"Secure Email", strings.ToLower(TrustBitServerAuth)}, want: true},
+	} {
+		if got := (Root{TrustBits: tc.trustBits}).TrustedForServerAuth(); got != tc.want {
+			t.Errorf("Root{TrustBits: %q}.TrustedForServerAuth()=%v, want %v", tc.trustBits, got, tc.want)
+		}
+	}
+}
+
+func TestRootConstraint(t *testing.T) {
+	if c := (Root{}).Constraint(); c != nil {
+		t.Errorf("Root{}.Constraint() is not nil")
+	}
+
+	distrustAfter := time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)
+	c := Root{TLSDistrustAfter: distrustAfter}.Constraint()
+	for _, tc := range []struct {
+		notBefore time.Time
+		wantErr   bool
+	}{
+		{notBefore: distrustAfter.Add(-time.Hour)},
+		{notBefore: distrustAfter.Add(23 * time.Hour)},
+		{notBefore: distrustAfter.Add(24 * time.Hour), wantErr: true},
+		{notBefore: distrustAfter.AddDate(1, 0, 0), wantErr: true},
+	} {
+		err := c([]*x509.Certificate{{NotBefore: tc.notBefore}})
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("Constraint()(leaf with NotBefore %v)=%v, want err: %t", tc.notBefore, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRootMarshalConstraints(t *testing.T) {
+	for _, r := range []Root{
+		{},
+		{TrustBits: []string{}},
+		{TrustBits: []string{TrustBitServerAuth, "Secure Email"}},
+		{TLSDistrustAfter: time.Date(2024, 12, 4, 0, 0, 0, 0, time.UTC)},
+	} {
+		data, err := r.MarshalConstraints()
+		if err != nil {
+			t.Fatalf("MarshalConstraints(): %v", err)
+		}
+		got := Root{PEM: []byte("pem"), TrustBits: []string{"stale"}, TLSDistrustAfter: time.Now()}
+		if err := got.UnmarshalConstraints(data); err != nil {
+			t.Fatalf("UnmarshalConstraints(%s): %v", data, err)
+		}
+		want := r
+		want.PEM = got.PEM
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("UnmarshalConstraints(%s)=%+v, want %+v", data, got, want)
+		}
+	}
+	var r Root
+	if err := r.UnmarshalConstraints([]byte(`{"tls_distrust_after": "04/12/2024"}`)); err == nil {
+		t.Error("UnmarshalConstraints() with an invalid date: got no error")
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+const (
+	// AdminPathPrefix prefixes all the admin API paths.
+	AdminPathPrefix = "/admin/v1"
+
+	adminLogsPath       = AdminPathPrefix + "/logs"
+	adminStatusPath     = AdminPathPrefix + "/status"
+	adminConfigPath     = AdminPathPrefix + "/config"
+	adminRootsPath      = AdminPathPrefix + "/roots"
+	adminRootsReload    = AdminPathPrefix + "/roots/reload"
+	adminFilterReload   = AdminPathPrefix + "/issuer-filter/reload"
+	adminRateLimitsPath = AdminPathPrefix + "/rate-limits"
+	adminStatePath      = AdminPathPrefix + "/state"
+
+	// maxAdminBodyBytes limits the size of admin requests, which can hold
+	// a bundle of roots.
+	maxAdminBodyBytes = 4 << 20
+)
+
+// LogAdmin gives operators control over a running log.
+type LogAdmin struct {
+	log   *log
+	opts  *HandlerOptions
+	roots *x509util.PEMCertPool
+	// loadRoots loads the roots the log is configured with, nil if they
+	// can't be reloaded.
+	loadRoots RootsLoader
+	// reloadIssuerFilter reloads the issuer filter of the log, nil if it
+	// doesn't have one.
+	reloadIssuerFilter func(context.Context) error
+	// config is the effective configuration of the log, served as JSON.
+	config any
+}
+
+// NewLogAdmin returns a LogAdmin for log, served with opts, and which trusts
+// roots.
+//
+// loadRoots, if not nil, returns the roots the log is configured with, to
+// reload them on demand. reloadIssuerFilter, if not nil, reloads the issuer
+// filter of the log from its source. config is the effective configuration of
+// the log, which must marshal to JSON, and must not contain any secret.
+func NewLogAdmin(log *log, opts *HandlerOptions, roots *x509util.PEMCertPool, loadRoots RootsLoader, reloadIssuerFilter func(context.Context) error, config any) *LogAdmin {
+	return &LogAdmin{
+		log:                log,
+		opts:               opts,
+		roots:              roots,
+		loadRoots:          loadRoots,
+		reloadIssuerFilter: reloadIssuerFilter,
+		config:             config,
+	}
+}
+
+// LogStatus describes the state of a running log.
+type LogStatus struct {
+	Origin string   `json:"origin"`
+	State  LogState `json:"state"`
+	// CheckpointSize is the size of the latest published checkpoint.
+	CheckpointSize uint64 `json:"checkpoint_size"`
+	// CheckpointRootHash is the root hash of the latest published checkpoint.
+	CheckpointRootHash []byte `json:"checkpoint_root_hash"`
+	// NextIndex is one more than the largest index assigned by this
+	// instance of the log since it started, 0 if none.
+	NextIndex uint64 `json:"next_index"`
+	// IntegrationLag is the number of entries sequenced by this instance
+	// of the log which are not yet in the latest checkpoint.
+	IntegrationLag uint64 `json:"integration_lag"`
+	// Error is set if the status could not be fully determined.
+	Error string `json:"error,omitempty"`
+}
+
+// Status returns the status of the log.
+func (a *LogAdmin) Status(ctx context.Context) LogStatus {
+	s := LogStatus{
+		Origin:    a.log.origin,
+		State:     a.log.State(),
+		NextIndex: a.log.nextIndex.Load(),
+	}
+	cp, _, err := a.log.latestCheckpoint(ctx)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.CheckpointSize = cp.Size
+	s.CheckpointRootHash = cp.Hash
+	if s.NextIndex > cp.Size {
+		s.IntegrationLag = s.NextIndex - cp.Size
+	}
+	return s
+}
+
+// Root describes a trusted root.
+type Root struct {
+	Subject string `json:"subject"`
+	// Fingerprint is the hex-encoded SHA-256 of the root's DER encoding.
+	Fingerprint string    `json:"fingerprint"`
+	NotAfter    time.Time `json:"not_after"`
+}
+
+// Roots returns the roots currently trusted by the log.
+func (a *LogAdmin) Roots() []Root {
+	certs := a.roots.RawCertificates()
+	roots := make([]Root, 0, len(certs))
+	for _, c := range certs {
+		roots = append(roots, Root{Subject: c.Subject.String(), Fingerprint: rootFingerprint(c), NotAfter: c.NotAfter})
+	}
+	return roots
+}
+
+// AddRoots adds roots to the log, and returns the ones that were added.
+// Roots which are already trusted, or rejected, are skipped.
+//
+// Added roots are not persisted, and are dropped by the next roots reload
+// unless they've also been added to the log's roots sources.
+func (a *LogAdmin) AddRoots(ctx context.Context, certs []*x509.Certificate) []Root {
+	added := a.roots.AddCertsReturningAdded(certs)
+	roots := make([]Root, 0, len(added))
+	for _, c := range added {
+		slog.InfoContext(ctx, "Admin added root", slog.String("origin", a.log.origin), slog.String("subject", c.Subject.String()), slog.String("fingerprint", rootFingerprint(c)))
+		roots = append(roots, Root{Subject: c.Subject.String(), Fingerprint: rootFingerprint(c), NotAfter: c.NotAfter})
+	}
+	return roots
+}
+
+// RemoveRoot stops trusting the root with the given hex-encoded SHA-256
+// fingerprint.
+//
+// Removals are not persisted, and are reverted by the next roots reload
+// unless the root has also been removed from the log's roots sources.
+func (a *LogAdmin) RemoveRoot(ctx context.Context, fingerprint string) error {
+	fp, err := hex.DecodeString(fingerprint)
+	if err != nil || len(fp) != sha256.Size {
+		return fmt.Errorf("invalid fingerprint %q", fingerprint)
+	}
+	c, err := a.roots.RemoveCert([sha256.Size]byte(fp))
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Admin removed root", slog.String("origin", a.log.origin), slog.String("subject", c.Subject.String()), slog.String("fingerprint", rootFingerprint(c)))
+	return nil
+}
+
+// ReloadRoots reloads the roots from the log's roots sources, as with
+// ReloadRoots.
+func (a *LogAdmin) ReloadRoots(ctx context.Context) error {
+	if a.loadRoots == nil {
+		return errors.New("roots reloading is not configured")
+	}
+	return ReloadRoots(ctx, a.log.origin, a.roots, a.loadRoots)
+}
+
+// ReloadIssuerFilter reloads the issuer filter of the log from its source.
+// The previous filter is kept if it can't be reloaded.
+func (a *LogAdmin) ReloadIssuerFilter(ctx context.Context) error {
+	if a.reloadIssuerFilter == nil {
+		return errors.New("the log has no issuer filter")
+	}
+	return a.reloadIssuerFilter(ctx)
+}
+
+// NewAdminHandler returns an HTTP handler serving the admin API for logs.
+//
+// Requests must be authenticated with an "Authorization: Bearer <token>"
+// header, where token is one of tokens.
+//
+// Endpoints which relate to a single log take an "origin" query parameter,
+// which can be omitted if there's only one log.
+func NewAdminHandler(tokens []string, logs []*LogAdmin) (http.Handler, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("no admin token")
+	}
+	for _, t := range tokens {
+		if t == "" {
+			return nil, errors.New("empty admin token")
+		}
+	}
+	if len(logs) == 0 {
+		return nil, errors.New("no log to administer")
+	}
+	h := &adminHandler{tokens: tokens, logs: make(map[string]*LogAdmin, len(logs))}
+	for _, l := range logs {
+		if _, ok := h.logs[l.log.origin]; ok {
+			return nil, fmt.Errorf("duplicate origin %q", l.log.origin)
+		}
+		h.logs[l.log.origin] = l
+		h.origins = append(h.origins, l.log.origin)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+adminLogsPath, h.getLogs)
+	mux.HandleFunc("GET "+adminStatusPath, h.withLog(h.getStatus))
+	mux.HandleFunc("GET "+adminConfigPath, h.withLog(h.getConfig))
+	mux.HandleFunc("GET "+adminRootsPath, h.withLog(h.getRoots))
+	mux.HandleFunc("POST "+adminRootsPath, h.withLog(h.addRoots))
+	mux.HandleFunc("DELETE "+adminRootsPath, h.withLog(h.removeRoot))
+	mux.HandleFunc("POST "+adminRootsReload, h.withLog(h.reloadRoots))
+	mux.HandleFunc("POST "+adminFilterReload, h.withLog(h.reloadIssuerFilter))
+	mux.HandleFunc("GET "+adminRateLimitsPath, h.withLog(h.getRateLimits))
+	mux.HandleFunc("PUT "+adminRateLimitsPath, h.withLog(h.setRateLimits))
+	mux.HandleFunc("GET "+adminStatePath, h.withLog(h.getState))
+	mux.HandleFunc("PUT "+adminStatePath, h.withLog(h.setState))
+	h.mux = mux
+	return h, nil
+}
+
+type adminHandler struct {
+	tokens []string
+	logs   map[string]*LogAdmin
+	// origins lists logs in the order they were configured.
+	origins []string
+	mux     *http.ServeMux
+}
+
+// ServeHTTP authenticates r, and serves it.
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticated(r) {
+		slog.WarnContext(r.Context(), "Unauthenticated admin request", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("remote_addr", r.RemoteAddr))
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		slog.InfoContext(r.Context(), "Admin request", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("query", r.URL.RawQuery), slog.String("remote_addr", r.RemoteAddr))
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdminBodyBytes)
+	h.mux.ServeHTTP(w, r)
+}
+
+// authenticated returns true if r holds one of the admin tokens.
+func (h *adminHandler) authenticated(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	match := 0
+	// Compare against all the tokens to not leak which one matched.
+	for _, t := range h.tokens {
+		match |= subtle.ConstantTimeCompare([]byte(token), []byte(t))
+	}
+	return match == 1
+}
+
+// withLog resolves the log targeted by a request, and passes it to f.
+func (h *adminHandler) withLog(f func(http.ResponseWriter, *http.Request, *LogAdmin)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.URL.Query().Get("origin")
+		if origin == "" {
+			if len(h.origins) != 1 {
+				http.Error(w, "missing origin parameter", http.StatusBadRequest)
+				return
+			}
+			origin = h.origins[0]
+		}
+		l, ok := h.logs[origin]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown origin %q", origin), http.StatusNotFound)
+			return
+		}
+		f(w, r, l)
+	}
+}
+
+func (h *adminHandler) getLogs(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]LogStatus, 0, len(h.origins))
+	for _, o := range h.origins {
+		statuses = append(statuses, h.logs[o].Status(r.Context()))
+	}
+	writeAdminResponse(w, statuses)
+}
+
+func (h *adminHandler) getStatus(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	writeAdminResponse(w, l.Status(r.Context()))
+}
+
+func (h *adminHandler) getConfig(w http.ResponseWriter, _ *http.Request, l *LogAdmin) {
+	writeAdminResponse(w, l.config)
+}
+
+func (h *adminHandler) getRoots(w http.ResponseWriter, _ *http.Request, l *LogAdmin) {
+	writeAdminResponse(w, l.Roots())
+}
+
+// addRoots adds the PEM encoded roots in the request body.
+func (h *adminHandler) addRoots(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	certs := x509util.ParsePEMCerts(body)
+	if len(certs) == 0 {
+		http.Error(w, "no PEM certificate in body", http.StatusBadRequest)
+		return
+	}
+	writeAdminResponse(w, l.AddRoots(r.Context(), certs))
+}
+
+func (h *adminHandler) removeRoot(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	fingerprint := r.URL.Query().Get("fingerprint")
+	if b, err := hex.DecodeString(fingerprint); err != nil || len(b) != 32 {
+		http.Error(w, fmt.Sprintf("fingerprint %q is not a hex-encoded SHA-256 hash", fingerprint), http.StatusBadRequest)
+		return
+	}
+	if err := l.RemoveRoot(r.Context(), fingerprint); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeAdminResponse(w, l.Roots())
+}
+
+func (h *adminHandler) reloadRoots(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	if err := l.ReloadRoots(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeAdminResponse(w, l.Roots())
+}
+
+func (h *adminHandler) reloadIssuerFilter(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	if err := l.ReloadIssuerFilter(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler) getRateLimits(w http.ResponseWriter, _ *http.Request, l *LogAdmin) {
+	writeAdminResponse(w, l.opts.RateLimits.Config())
+}
+
+// setRateLimits replaces all the rate limits of a log. Limits missing from
+// the request are disabled.
+func (h *adminHandler) setRateLimits(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	var cfg RateLimitsConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse rate limits: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := l.opts.RateLimits.SetConfig(cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeAdminResponse(w, l.opts.RateLimits.Config())
+}
+
+type stateJSON struct {
+	State LogState `json:"state"`
+}
+
+func (h *adminHandler) getState(w http.ResponseWriter, _ *http.Request, l *LogAdmin) {
+	writeAdminResponse(w, stateJSON{State: l.log.State()})
+}
+
+// setState changes the lifecycle state of a log. It returns once in-flight
+// submissions have been sequenced.
+func (h *adminHandler) setState(w http.ResponseWriter, r *http.Request, l *LogAdmin) {
+	var req stateJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	st, err := ParseLogState(string(req.State))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := l.log.SetState(r.Context(), st); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	h.getState(w, r, l)
+}
+
+// writeAdminResponse writes v as an indented JSON response.
+func writeAdminResponse(w http.ResponseWriter, v any) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	_, _ = w.Write(append(b, '\n'))
+}
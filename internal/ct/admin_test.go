@@ -0,0 +1,452 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+const adminToken = "s3cr3t"
+
+// setupAdminTestServer creates a test TesseraCT server serving static-ct-api
+// submission endpoints, and the admin API.
+func setupAdminTestServer(t *testing.T) (*LogAdmin, *httptest.Server) {
+	t.Helper()
+	signer, err := loadPEMPrivateKey("../testdata/test_ct_server_ecdsa_private_key.pem")
+	if err != nil {
+		t.Fatalf("Can't open key: %v", err)
+	}
+	log, _ := setupTestLogWithSigner(t, signer, false)
+	opts := hOpts()
+
+	admin := NewLogAdmin(log, opts, log.chainValidator.(chainValidator).trustedRoots, nil, nil, map[string]string{"origin": origin})
+	h, err := NewAdminHandler([]string{"other", adminToken}, []*LogAdmin{admin})
+	if err != nil {
+		t.Fatalf("NewAdminHandler(): %v", err)
+	}
+	mux := http.NewServeMux()
+	for p, h := range NewPathHandlers(t.Context(), opts, log) {
+		mux.Handle(p, h)
+	}
+	mux.Handle(AdminPathPrefix+"/", h)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return admin, server
+}
+
+// adminRequest sends an authenticated admin request, and returns the
+// response status code and body.
+func adminRequest(t *testing.T, server *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.NewRequest(): %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("io.ReadAll(): %v", err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestNewAdminHandler(t *testing.T) {
+	log, _ := setupTestLog(t)
+	admin := NewLogAdmin(log, hOpts(), nil, nil, nil, nil)
+	for _, tc := range []struct {
+		desc    string
+		tokens  []string
+		logs    []*LogAdmin
+		wantErr bool
+	}{
+		{desc: "ok", tokens: []string{"token"}, logs: []*LogAdmin{admin}},
+		{desc: "no-token", logs: []*LogAdmin{admin}, wantErr: true},
+		{desc: "empty-token", tokens: []string{"token", ""}, logs: []*LogAdmin{admin}, wantErr: true},
+		{desc: "no-log", tokens: []string{"token"}, wantErr: true},
+		{desc: "duplicate-log", tokens: []string{"token"}, logs: []*LogAdmin{admin, admin}, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := NewAdminHandler(tc.tokens, tc.logs)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("NewAdminHandler()=%v, want err %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdminAuthentication(t *testing.T) {
+	_, server := setupAdminTestServer(t)
+	for _, tc := range []struct {
+		desc     string
+		header   string
+		wantCode int
+	}{
+		{desc: "no-header", wantCode: http.StatusUnauthorized},
+		{desc: "wrong-scheme", header: "Basic " + adminToken, wantCode: http.StatusUnauthorized},
+		{desc: "wrong-token", header: "Bearer nope", wantCode: http.StatusUnauthorized},
+		{desc: "token-prefix", header: "Bearer " + adminToken[:3], wantCode: http.StatusUnauthorized},
+		{desc: "first-token", header: "Bearer other", wantCode: http.StatusOK},
+		{desc: "second-token", header: "Bearer " + adminToken, wantCode: http.StatusOK},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+adminStatePath, nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest(): %v", err)
+			}
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.Get(): %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestAdminOrigin(t *testing.T) {
+	_, server := setupAdminTestServer(t)
+	for _, tc := range []struct {
+		desc     string
+		query    string
+		wantCode int
+	}{
+		{desc: "implicit", wantCode: http.StatusOK},
+		{desc: "explicit", query: "?origin=" + origin, wantCode: http.StatusOK},
+		{desc: "unknown", query: "?origin=unknown.com", wantCode: http.StatusNotFound},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if code, body := adminRequest(t, server, http.MethodGet, adminConfigPath+tc.query, ""); code != tc.wantCode {
+				t.Errorf("got status %d, want %d: %s", code, tc.wantCode, body)
+			}
+		})
+	}
+}
+
+func TestAdminState(t *testing.T) {
+	_, server := setupAdminTestServer(t)
+	addChain := func() int {
+		t.Helper()
+		chain := createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}))
+		resp, err := http.Post(server.URL+prefix+rfc6962.AddChainPath, "application/json", chain)
+		if err != nil {
+			t.Fatalf("http.Post(%s): %v", rfc6962.AddChainPath, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, tc := range []struct {
+		body        string
+		wantCode    int
+		wantState   LogState
+		wantAddCode int
+	}{
+		{body: `{"state": "read_only"}`, wantCode: http.StatusOK, wantState: LogStateReadOnly, wantAddCode: http.StatusForbidden},
+		{body: `{}`, wantCode: http.StatusBadRequest, wantState: LogStateReadOnly, wantAddCode: http.StatusForbidden},
+		{body: `{"state": "frozen"}`, wantCode: http.StatusBadRequest, wantState: LogStateReadOnly, wantAddCode: http.StatusForbidden},
+		{body: `{"state": "usable"}`, wantCode: http.StatusOK, wantState: LogStateUsable, wantAddCode: http.StatusOK},
+		{body: `{"state": "retired"}`, wantCode: http.StatusOK, wantState: LogStateRetired, wantAddCode: http.StatusGone},
+		{body: `{"state": "usable"}`, wantCode: http.StatusConflict, wantState: LogStateRetired, wantAddCode: http.StatusGone},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			if code, body := adminRequest(t, server, http.MethodPut, adminStatePath, tc.body); code != tc.wantCode {
+				t.Fatalf("PUT state: got status %d, want %d: %s", code, tc.wantCode, body)
+			}
+			_, body := adminRequest(t, server, http.MethodGet, adminStatePath, "")
+			var got stateJSON
+			if err := json.Unmarshal([]byte(body), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%q): %v", body, err)
+			}
+			if got.State != tc.wantState {
+				t.Errorf("state=%q, want %q", got.State, tc.wantState)
+			}
+			if code := addChain(); code != tc.wantAddCode {
+				t.Errorf("add-chain: got status %d, want %d", code, tc.wantAddCode)
+			}
+		})
+	}
+}
+
+func TestAdminRateLimits(t *testing.T) {
+	admin, server := setupAdminTestServer(t)
+	dedup := 10.0
+	for _, tc := range []struct {
+		desc     string
+		body     string
+		wantCode int
+		want     RateLimitsConfig
+	}{
+		{
+			desc:     "enable",
+			body:     `{"not_before": {"age": "28h", "qps": 50}, "dedup_qps": 10}`,
+			wantCode: http.StatusOK,
+			want:     RateLimitsConfig{NotBefore: &NotBeforeRateLimit{Age: 28 * time.Hour, QPS: 50}, DedupQPS: &dedup},
+		},
+		{
+			desc:     "invalid-age",
+			body:     `{"not_before": {"age": "28", "qps": 50}}`,
+			wantCode: http.StatusBadRequest,
+			want:     RateLimitsConfig{NotBefore: &NotBeforeRateLimit{Age: 28 * time.Hour, QPS: 50}, DedupQPS: &dedup},
+		},
+		{
+			desc:     "negative-qps",
+			body:     `{"dedup_qps": -1}`,
+			wantCode: http.StatusBadRequest,
+			want:     RateLimitsConfig{NotBefore: &NotBeforeRateLimit{Age: 28 * time.Hour, QPS: 50}, DedupQPS: &dedup},
+		},
+		{
+			desc:     "disable-not-before",
+			body:     `{"dedup_qps": 10}`,
+			wantCode: http.StatusOK,
+			want:     RateLimitsConfig{DedupQPS: &dedup},
+		},
+		{
+			desc:     "disable-all",
+			body:     `{}`,
+			wantCode: http.StatusOK,
+			want:     RateLimitsConfig{},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if code, body := adminRequest(t, server, http.MethodPut, adminRateLimitsPath, tc.body); code != tc.wantCode {
+				t.Fatalf("PUT rate-limits: got status %d, want %d: %s", code, tc.wantCode, body)
+			}
+			_, body := adminRequest(t, server, http.MethodGet, adminRateLimitsPath, "")
+			var got RateLimitsConfig
+			if err := json.Unmarshal([]byte(body), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%q): %v", body, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("rate limits diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, admin.opts.RateLimits.Config()); diff != "" {
+				t.Errorf("RateLimits.Config() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminRoots(t *testing.T) {
+	_, server := setupAdminTestServer(t)
+	getRoots := func() []Root {
+		t.Helper()
+		code, body := adminRequest(t, server, http.MethodGet, adminRootsPath, "")
+		if code != http.StatusOK {
+			t.Fatalf("GET roots: got status %d: %s", code, body)
+		}
+		var roots []Root
+		if err := json.Unmarshal([]byte(body), &roots); err != nil {
+			t.Fatalf("json.Unmarshal(%q): %v", body, err)
+		}
+		return roots
+	}
+
+	initial := getRoots()
+	if len(initial) == 0 {
+		t.Fatal("no initial root")
+	}
+	newRoot := pemToCert(t, testdata.FakeRootCACertPEM)
+	fp := rootFingerprint(newRoot)
+
+	t.Run("add", func(t *testing.T) {
+		code, body := adminRequest(t, server, http.MethodPost, adminRootsPath, testdata.FakeRootCACertPEM)
+		if code != http.StatusOK {
+			t.Fatalf("POST roots: got status %d: %s", code, body)
+		}
+		var added []Root
+		if err := json.Unmarshal([]byte(body), &added); err != nil {
+			t.Fatalf("json.Unmarshal(%q): %v", body, err)
+		}
+		if len(added) != 1 || added[0].Fingerprint != fp {
+			t.Errorf("added %v, want root with fingerprint %s", added, fp)
+		}
+		if got, want := len(getRoots()), len(initial)+1; got != want {
+			t.Errorf("got %d roots, want %d", got, want)
+		}
+	})
+
+	t.Run("add-again", func(t *testing.T) {
+		code, body := adminRequest(t, server, http.MethodPost, adminRootsPath, testdata.FakeRootCACertPEM)
+		if code != http.StatusOK || strings.TrimSpace(body) != "[]" {
+			t.Errorf("POST roots: got (%d, %s), want (%d, [])", code, body, http.StatusOK)
+		}
+	})
+
+	t.Run("add-garbage", func(t *testing.T) {
+		if code, body := adminRequest(t, server, http.MethodPost, adminRootsPath, "not a cert"); code != http.StatusBadRequest {
+			t.Errorf("POST roots: got status %d, want %d: %s", code, http.StatusBadRequest, body)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if code, body := adminRequest(t, server, http.MethodDelete, adminRootsPath+"?fingerprint="+strings.ToUpper(fp), ""); code != http.StatusOK {
+			t.Fatalf("DELETE roots: got status %d: %s", code, body)
+		}
+		for _, r := range getRoots() {
+			if r.Fingerprint == fp {
+				t.Errorf("root %s was not removed", fp)
+			}
+		}
+	})
+
+	t.Run("remove-unknown", func(t *testing.T) {
+		if code, body := adminRequest(t, server, http.MethodDelete, adminRootsPath+"?fingerprint="+fp, ""); code != http.StatusBadRequest {
+			t.Errorf("DELETE roots: got status %d, want %d: %s", code, http.StatusBadRequest, body)
+		}
+	})
+
+	t.Run("remove-invalid-fingerprint", func(t *testing.T) {
+		if code, body := adminRequest(t, server, http.MethodDelete, adminRootsPath+"?fingerprint=abcd", ""); code != http.StatusBadRequest {
+			t.Errorf("DELETE roots: got status %d, want %d: %s", code, http.StatusBadRequest, body)
+		}
+	})
+
+	t.Run("reload-not-configured", func(t *testing.T) {
+		if code, body := adminRequest(t, server, http.MethodPost, adminRootsReload, ""); code != http.StatusInternalServerError {
+			t.Errorf("POST roots/reload: got status %d, want %d: %s", code, http.StatusInternalServerError, body)
+		}
+	})
+}
+
+func TestAdminRemoveLastRoot(t *testing.T) {
+	log, _ := setupTestLog(t)
+	pool, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool(): %v", err)
+	}
+	root := pemToCert(t, testdata.FakeRootCACertPEM)
+	pool.AddCerts([]*x509.Certificate{root})
+	admin := NewLogAdmin(log, hOpts(), pool, nil, nil, nil)
+	if err := admin.RemoveRoot(t.Context(), rootFingerprint(root)); err == nil {
+		t.Error("RemoveRoot()=nil, want error")
+	}
+	if got := len(pool.RawCertificates()); got != 1 {
+		t.Errorf("got %d roots, want 1", got)
+	}
+}
+
+func TestAdminIssuerFilterReload(t *testing.T) {
+	t.Run("not-configured", func(t *testing.T) {
+		_, server := setupAdminTestServer(t)
+		if code, body := adminRequest(t, server, http.MethodPost, adminFilterReload, ""); code != http.StatusInternalServerError {
+			t.Errorf("POST issuer-filter/reload: got status %d, want %d: %s", code, http.StatusInternalServerError, body)
+		}
+	})
+
+	for _, tc := range []struct {
+		desc     string
+		err      error
+		wantCode int
+	}{
+		{desc: "reloaded", wantCode: http.StatusNoContent},
+		{desc: "failed", err: errors.New("boom"), wantCode: http.StatusInternalServerError},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			reloads := 0
+			reload := func(context.Context) error {
+				reloads++
+				return tc.err
+			}
+			h, err := NewAdminHandler([]string{adminToken}, []*LogAdmin{NewLogAdmin(log, hOpts(), nil, nil, reload, nil)})
+			if err != nil {
+				t.Fatalf("NewAdminHandler(): %v", err)
+			}
+			server := httptest.NewServer(h)
+			defer server.Close()
+
+			if code, body := adminRequest(t, server, http.MethodPost, adminFilterReload, ""); code != tc.wantCode {
+				t.Errorf("POST issuer-filter/reload: got status %d, want %d: %s", code, tc.wantCode, body)
+			}
+			if reloads != 1 {
+				t.Errorf("got %d reloads, want 1", reloads)
+			}
+		})
+	}
+}
+
+func TestAdminStatus(t *testing.T) {
+	_, server := setupAdminTestServer(t)
+	defer timeSource.Reset()
+
+	chains := [][]string{
+		{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM},
+		{testdata.TestCertPEM, testdata.CACertPEM},
+	}
+	for _, c := range chains {
+		timeSource.Add1m()
+		resp, err := http.Post(server.URL+prefix+rfc6962.AddChainPath, "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, c)))
+		if err != nil {
+			t.Fatalf("http.Post(%s): %v", rfc6962.AddChainPath, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("http.Post(%s): got status %d", rfc6962.AddChainPath, resp.StatusCode)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		code, body := adminRequest(t, server, http.MethodGet, adminLogsPath, "")
+		if code != http.StatusOK {
+			t.Fatalf("GET logs: got status %d: %s", code, body)
+		}
+		var statuses []LogStatus
+		if err := json.Unmarshal([]byte(body), &statuses); err != nil {
+			t.Fatalf("json.Unmarshal(%q): %v", body, err)
+		}
+		if len(statuses) != 1 {
+			t.Fatalf("got %d log statuses, want 1", len(statuses))
+		}
+		s := statuses[0]
+		if s.Origin != origin || s.NextIndex != uint64(len(chains)) {
+			t.Fatalf("got status %+v, want origin %q and next index %d", s, origin, len(chains))
+		}
+		if s.Error == "" && s.CheckpointSize == uint64(len(chains)) {
+			if s.IntegrationLag != 0 {
+				t.Errorf("IntegrationLag=%d, want 0", s.IntegrationLag)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the log to integrate entries, last status: %+v", s)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Authentication results, used as metric attributes.
+const (
+	authResultOK              = "authenticated"
+	authResultUnauthenticated = "unauthenticated"
+	authResultForbidden       = "forbidden"
+)
+
+// AuthConfig lists the clients allowed to submit to a log.
+type AuthConfig struct {
+	// APIKeys authenticate clients sending an "Authorization: Bearer <key>"
+	// header.
+	APIKeys []APIKey `json:"api_keys"`
+	// ClientCerts authenticate clients presenting a TLS client certificate.
+	// They require TLS to be terminated by the server serving the log, which
+	// must request client certificates: behind a TLS terminating proxy or
+	// load balancer, requests never carry one.
+	ClientCerts []ClientCert `json:"client_certs"`
+}
+
+// APIKey is the API key of a client.
+type APIKey struct {
+	// Identity is the name of the client.
+	Identity string `json:"identity"`
+	// SHA256 is the hex encoded SHA-256 hash of the key, so that keys are not
+	// stored in the clear.
+	SHA256 string `json:"sha256"`
+}
+
+// ClientCert identifies a client by its TLS client certificate.
+//
+// Exactly one of SPKISHA256 and CommonName must be set.
+type ClientCert struct {
+	// Identity is the name of the client.
+	Identity string `json:"identity"`
+	// SPKISHA256 is the hex encoded SHA-256 hash of the DER encoded
+	// SubjectPublicKeyInfo of the client certificate.
+	SPKISHA256 string `json:"spki_sha256,omitempty"`
+	// CommonName is the subject common name of the client certificate. It is
+	// only matched if the certificate was verified by the TLS server.
+	CommonName string `json:"common_name,omitempty"`
+}
+
+// ParseAuthConfig parses a JSON authentication file, such as:
+//
+//	{
+//	  "api_keys": [{"identity": "ca-1", "sha256": "<hex SHA-256 of the key>"}],
+//	  "client_certs": [
+//	    {"identity": "ca-2", "spki_sha256": "<hex SHA-256 of the SPKI>"},
+//	    {"identity": "ca-3", "common_name": "submitter.ca-3.example"}
+//	  ]
+//	}
+func ParseAuthConfig(data []byte) (AuthConfig, error) {
+	var cfg AuthConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return AuthConfig{}, fmt.Errorf("failed to parse authentication config: %v", err)
+	}
+	if _, err := NewAuthenticator(cfg); err != nil {
+		return AuthConfig{}, err
+	}
+	return cfg, nil
+}
+
+// Authenticator authenticates clients with API keys or TLS client
+// certificates. Client certificates are read from the TLS connection state
+// of requests, so they're only seen if the server serving the log terminates
+// TLS itself.
+type Authenticator struct {
+	// keys maps the SHA-256 hash of API keys to client identities.
+	keys map[[sha256.Size]byte]string
+	// spkis maps the SHA-256 hash of client certificate SPKIs to client
+	// identities.
+	spkis map[[sha256.Size]byte]string
+	// commonNames maps verified client certificate common names to client
+	// identities.
+	commonNames map[string]string
+}
+
+// NewAuthenticator returns an Authenticator accepting the clients of cfg.
+func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
+	if len(cfg.APIKeys) == 0 && len(cfg.ClientCerts) == 0 {
+		return nil, errors.New("no API key nor client certificate")
+	}
+	a := &Authenticator{
+		keys:        make(map[[sha256.Size]byte]string),
+		spkis:       make(map[[sha256.Size]byte]string),
+		commonNames: make(map[string]string),
+	}
+	for i, k := range cfg.APIKeys {
+		if k.Identity == "" {
+			return nil, fmt.Errorf("API key at index %d has no identity", i)
+		}
+		h, err := parseSHA256(k.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key hash for %q: %v", k.Identity, err)
+		}
+		a.keys[h] = k.Identity
+	}
+	for i, c := range cfg.ClientCerts {
+		if c.Identity == "" {
+			return nil, fmt.Errorf("client certificate at index %d has no identity", i)
+		}
+		switch {
+		case c.SPKISHA256 != "" && c.CommonName == "":
+			h, err := parseSHA256(c.SPKISHA256)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate SPKI hash for %q: %v", c.Identity, err)
+			}
+			a.spkis[h] = c.Identity
+		case c.SPKISHA256 == "" && c.CommonName != "":
+			a.commonNames[c.CommonName] = c.Identity
+		default:
+			return nil, fmt.Errorf("client certificate for %q must have exactly one of spki_sha256 and common_name", c.Identity)
+		}
+	}
+	return a, nil
+}
+
+// parseSHA256 parses a hex encoded SHA-256 hash.
+func parseSHA256(s string) ([sha256.Size]byte, error) {
+	var h [sha256.Size]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != sha256.Size {
+		return h, fmt.Errorf("got %d bytes, want %d", len(b), sha256.Size)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// authenticate returns the identity of the client that sent r.
+//
+// It returns an error with a 401 status code if r has no credentials, or
+// invalid ones, and with a 403 status code if r has a client certificate
+// which isn't allowed.
+func (a *Authenticator) authenticate(r *http.Request) (string, int, error) {
+	if v := r.Header.Get("Authorization"); v != "" {
+		key, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok {
+			return "", http.StatusUnauthorized, errors.New("authorization header is not a bearer token")
+		}
+		if id, ok := a.keys[sha256.Sum256([]byte(key))]; ok {
+			return id, http.StatusOK, nil
+		}
+		return "", http.StatusUnauthorized, errors.New("unknown API key")
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		leaf := r.TLS.PeerCertificates[0]
+		if id, ok := a.spkis[sha256.Sum256(leaf.RawSubjectPublicKeyInfo)]; ok {
+			return id, http.StatusOK, nil
+		}
+		if len(r.TLS.VerifiedChains) > 0 {
+			if id, ok := a.commonNames[leaf.Subject.CommonName]; ok {
+				return id, http.StatusOK, nil
+			}
+		}
+		return "", http.StatusForbidden, errors.New("client certificate is not allowed to submit")
+	}
+	return "", http.StatusUnauthorized, errors.New("no API key nor client certificate")
+}
+
+// NewAuthHandler returns a handler which only passes requests from clients
+// authenticated by a on to h, with their identity in the request context.
+// See ClientIDFromContext.
+//
+// Other requests get a 401 or 403 error, sent as per opts. Metrics are
+// attributed to the log identified by origin, with an empty client identity
+// for requests which are not authenticated.
+func NewAuthHandler(opts *HandlerOptions, origin string, a *Authenticator, h http.Handler) http.Handler {
+	once.Do(func() { setupMetrics() })
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, status, err := a.authenticate(r)
+		if err != nil {
+			result := authResultUnauthenticated
+			code := ErrorCodeUnauthenticated
+			if status == http.StatusForbidden {
+				result, code = authResultForbidden, ErrorCodeForbidden
+			} else {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			authRequests.Add(r.Context(), 1, metric.WithAttributes(originKey.String(origin), authResultKey.String(result), clientIDKey.String("")))
+			opts.sendHTTPError(w, status, withCode(code, err))
+			return
+		}
+		authRequests.Add(r.Context(), 1, metric.WithAttributes(originKey.String(origin), authResultKey.String(authResultOK), clientIDKey.String(id)))
+		h.ServeHTTP(w, r.WithContext(ContextWithClientID(r.Context(), id)))
+	})
+}
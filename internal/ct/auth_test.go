@@ -0,0 +1,234 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+// hexSHA256 returns the hex encoded SHA-256 hash of b.
+func hexSHA256(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func TestParseAuthConfig(t *testing.T) {
+	hash := hexSHA256([]byte("key"))
+	for _, tc := range []struct {
+		desc    string
+		data    string
+		wantErr string
+	}{
+		{
+			desc: "valid",
+			data: `{"api_keys": [{"identity": "ca-1", "sha256": "` + hash + `"}], "client_certs": [{"identity": "ca-2", "spki_sha256": "` + hash + `"}, {"identity": "ca-3", "common_name": "ca-3.example"}]}`,
+		},
+		{
+			desc:    "unknown-field",
+			data:    `{"api_keys": [{"identity": "ca-1", "key": "secret"}]}`,
+			wantErr: "unknown field",
+		},
+		{
+			desc:    "empty",
+			data:    `{}`,
+			wantErr: "no API key nor client certificate",
+		},
+		{
+			desc:    "no-identity",
+			data:    `{"api_keys": [{"sha256": "` + hash + `"}]}`,
+			wantErr: "has no identity",
+		},
+		{
+			desc:    "invalid-hash",
+			data:    `{"api_keys": [{"identity": "ca-1", "sha256": "abcd"}]}`,
+			wantErr: "invalid API key hash",
+		},
+		{
+			desc:    "spki-and-common-name",
+			data:    `{"client_certs": [{"identity": "ca-2", "spki_sha256": "` + hash + `", "common_name": "ca-2.example"}]}`,
+			wantErr: "exactly one of",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseAuthConfig([]byte(tc.data))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseAuthConfig(): %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("ParseAuthConfig() err=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	known := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("known"), Subject: pkix.Name{CommonName: "ca-3.example"}}
+	unknown := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("unknown"), Subject: pkix.Name{CommonName: "other.example"}}
+	named := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("named"), Subject: pkix.Name{CommonName: "ca-3.example"}}
+	a, err := NewAuthenticator(AuthConfig{
+		APIKeys: []APIKey{{Identity: "ca-1", SHA256: hexSHA256([]byte("secret"))}},
+		ClientCerts: []ClientCert{
+			{Identity: "ca-2", SPKISHA256: hexSHA256(known.RawSubjectPublicKeyInfo)},
+			{Identity: "ca-3", CommonName: "ca-3.example"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator(): %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		auth       string
+		tls        *tls.ConnectionState
+		wantID     string
+		wantStatus int
+	}{
+		{desc: "api-key", auth: "Bearer secret", wantID: "ca-1", wantStatus: http.StatusOK},
+		{desc: "unknown-api-key", auth: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{desc: "not-bearer", auth: "Basic c2VjcmV0", wantStatus: http.StatusUnauthorized},
+		{desc: "no-credentials", wantStatus: http.StatusUnauthorized},
+		{
+			desc:       "client-cert-spki",
+			tls:        &tls.ConnectionState{PeerCertificates: []*x509.Certificate{known}},
+			wantID:     "ca-2",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "client-cert-common-name",
+			tls:        &tls.ConnectionState{PeerCertificates: []*x509.Certificate{named}, VerifiedChains: [][]*x509.Certificate{{named}}},
+			wantID:     "ca-3",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "unverified-client-cert-common-name",
+			tls:        &tls.ConnectionState{PeerCertificates: []*x509.Certificate{named}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			desc:       "unknown-client-cert",
+			tls:        &tls.ConnectionState{PeerCertificates: []*x509.Certificate{unknown}, VerifiedChains: [][]*x509.Certificate{{unknown}}},
+			wantStatus: http.StatusForbidden,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.auth != "" {
+				r.Header.Set("Authorization", tc.auth)
+			}
+			r.TLS = tc.tls
+			id, status, err := a.authenticate(r)
+			if id != tc.wantID || status != tc.wantStatus {
+				t.Errorf("authenticate()=(%q, %d, %v), want (%q, %d, _)", id, status, err, tc.wantID, tc.wantStatus)
+			}
+			if (err == nil) != (tc.wantStatus == http.StatusOK) {
+				t.Errorf("authenticate() err=%v with status %d", err, status)
+			}
+		})
+	}
+}
+
+func TestNewAuthHandler(t *testing.T) {
+	a, err := NewAuthenticator(AuthConfig{APIKeys: []APIKey{{Identity: "ca-1", SHA256: hexSHA256([]byte("secret"))}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator(): %v", err)
+	}
+	var gotID string
+	h := NewAuthHandler(&HandlerOptions{JSONErrors: true}, "example.com", a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = ClientIDFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || gotID != "ca-1" {
+		t.Errorf("authenticated request: got status %d and identity %q, want %d and %q", w.Code, gotID, http.StatusOK, "ca-1")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request: got status %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != "Bearer" {
+		t.Errorf("WWW-Authenticate=%q, want %q", got, "Bearer")
+	}
+	var rsp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&rsp); err != nil {
+		t.Fatalf("json.Decode(): %v", err)
+	}
+	if rsp.Code != ErrorCodeUnauthenticated {
+		t.Errorf("got error code %q, want %q", rsp.Code, ErrorCodeUnauthenticated)
+	}
+}
+
+func TestAddChainAuthenticatedQuota(t *testing.T) {
+	log, _ := setupTestLog(t)
+	a, err := NewAuthenticator(AuthConfig{APIKeys: []APIKey{{Identity: "ca-1", SHA256: hexSHA256([]byte("secret"))}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator(): %v", err)
+	}
+	opts := hOpts()
+	opts.JSONErrors = true
+	if err := opts.RateLimits.Quotas(QuotasConfig{ClientID: &QuotaConfig{QPS: 1}}); err != nil {
+		t.Fatalf("Quotas(): %v", err)
+	}
+	addChainPath := path.Join(prefix, rfc6962.AddChainPath)
+	server := httptest.NewServer(NewAuthHandler(opts, log.origin, a, NewPathHandlers(t.Context(), opts, log)[addChainPath]))
+	defer server.Close()
+
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	for i, wantStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+addChainPath, createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+		if err != nil {
+			t.Fatalf("http.NewRequest(): %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("http.Do(): %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("submission %d: got status %d, want %d", i, resp.StatusCode, wantStatus)
+		}
+		if wantStatus != http.StatusTooManyRequests {
+			continue
+		}
+		var rsp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+			t.Fatalf("json.Decode(): %v", err)
+		}
+		if rsp.Code != ErrorCodeRateLimitedClientID {
+			t.Errorf("got error code %q, want %q", rsp.Code, ErrorCodeRateLimitedClientID)
+		}
+	}
+}
@@ -110,18 +110,72 @@ type chainValidator struct {
 	rejectExtIds []asn1.ObjectIdentifier
 	// acceptSHA1 specifies whether cert chains using SHA-1 based signing algorithms are allowed.
 	acceptSHA1 bool
+	// policies are checked in order, after built-in checks and path building.
+	policies []Policy
+	// pathCache, if set, caches verified paths from issuers to roots.
+	pathCache *VerifiedPathCache
+	// strictPrecerts enables strict validation of the structure of
+	// precertificates and precertificate signing certificates.
+	strictPrecerts bool
+	// enforceNameConstraints specifies whether name constraints are enforced.
+	enforceNameConstraints bool
+	// enforcePathLength specifies whether path length constraints are enforced.
+	enforcePathLength bool
 }
 
-func NewChainValidator(trustedRoots *x509util.PEMCertPool, rejectExpired, rejectUnexpired bool, notAfterStart, notAfterLimit *time.Time, extKeyUsages []x509.ExtKeyUsage, rejectExtIds []asn1.ObjectIdentifier, acceptSHA1 bool) *chainValidator {
+// errCertPrecertMismatch is returned when a certificate is submitted as a
+// precertificate, or the other way around.
+var errCertPrecertMismatch = errors.New("cert / precert mismatch")
+
+// ChainValidatorOptions describes how a chain validator validates chains.
+type ChainValidatorOptions struct {
+	// TrustedRoots is a pool of certificates that defines the roots the CT log will accept.
+	TrustedRoots *x509util.PEMCertPool
+	// RejectExpired indicates that expired certificates will be rejected.
+	RejectExpired bool
+	// RejectUnexpired indicates that certificates that are currently valid or not yet valid will be rejected.
+	RejectUnexpired bool
+	// NotAfterStart is the earliest notAfter date which will be accepted.
+	// nil means no lower bound on the accepted range.
+	NotAfterStart *time.Time
+	// NotAfterLimit defines the cut off point of notAfter dates - only notAfter
+	// dates strictly *before* NotAfterLimit will be accepted.
+	// nil means no upper bound on the accepted range.
+	NotAfterLimit *time.Time
+	// ExtKeyUsages contains the list of EKUs to use during chain verification.
+	ExtKeyUsages []x509.ExtKeyUsage
+	// RejectExtIds contains a list of X.509 extension IDs to reject during chain verification.
+	RejectExtIds []asn1.ObjectIdentifier
+	// AcceptSHA1 specifies whether cert chains using SHA-1 based signing algorithms are allowed.
+	AcceptSHA1 bool
+	// Policies are checked in order, after built-in checks and path building.
+	Policies []Policy
+	// PathCache, if set, caches verified paths from issuers to roots.
+	PathCache *VerifiedPathCache
+	// StrictPrecerts enables strict validation of the structure of
+	// precertificates and precertificate signing certificates.
+	StrictPrecerts bool
+	// EnforceNameConstraints specifies whether name constraints are enforced.
+	EnforceNameConstraints bool
+	// EnforcePathLength specifies whether path length constraints are enforced.
+	EnforcePathLength bool
+}
+
+func NewChainValidator(opts ChainValidatorOptions) *chainValidator {
 	return &chainValidator{
-		trustedRoots:    trustedRoots,
-		rejectExpired:   rejectExpired,
-		rejectUnexpired: rejectUnexpired,
-		notAfterStart:   notAfterStart,
-		notAfterLimit:   notAfterLimit,
-		extKeyUsages:    extKeyUsages,
-		rejectExtIds:    rejectExtIds,
-		acceptSHA1:      acceptSHA1,
+		trustedRoots:           opts.TrustedRoots,
+		rejectExpired:          opts.RejectExpired,
+		rejectUnexpired:        opts.RejectUnexpired,
+		notAfterStart:          opts.NotAfterStart,
+		notAfterLimit:          opts.NotAfterLimit,
+		extKeyUsages:           opts.ExtKeyUsages,
+		rejectExtIds:           opts.RejectExtIds,
+		acceptSHA1:             opts.AcceptSHA1,
+		policies:               opts.Policies,
+		pathCache:              opts.PathCache,
+		strictPrecerts:         opts.StrictPrecerts,
+		enforceNameConstraints: opts.EnforceNameConstraints,
+		enforcePathLength:      opts.EnforcePathLength,
 	}
 }
 
@@ -149,67 +203,42 @@ func parseChain(rawChain [][]byte) ([]*x509.Certificate, error) {
 // a trusted root cert, possibly using the intermediates supplied in the chain. Then applies the
 // RFC requirement that the path must involve all the submitted chain in the order of
 // submission.
-func (cv chainValidator) validate(chain []*x509.Certificate) ([]*x509.Certificate, error) {
+//
+// It also checks that the chain is accepted by all the policies of the log.
+func (cv chainValidator) validate(chain []*x509.Certificate, isPrecert bool) ([]*x509.Certificate, error) {
 	if len(chain) == 0 {
 		return nil, errors.New("empty certificate chain")
 	}
 
-	naStart := cv.notAfterStart
-	naLimit := cv.notAfterLimit
-	cert := chain[0]
-
-	// Check whether the expiry date of the cert is within the acceptable range.
-	if naStart != nil && cert.NotAfter.Before(*naStart) {
-		return nil, fmt.Errorf("certificate NotAfter (%v) < %v", cert.NotAfter, *naStart)
-	}
-	if naLimit != nil && !cert.NotAfter.Before(*naLimit) {
-		return nil, fmt.Errorf("certificate NotAfter (%v) >= %v", cert.NotAfter, *naLimit)
-	}
-
-	now := cv.currentTime
-	if now.IsZero() {
-		now = time.Now()
-	}
-	expired := now.After(cert.NotAfter)
-	if cv.rejectExpired && expired {
-		return nil, errors.New("rejecting expired certificate")
-	}
-	if cv.rejectUnexpired && !expired {
-		return nil, errors.New("rejecting unexpired certificate")
+	// Built-in policies only look at the leaf, run them first to reject
+	// chains without building a path.
+	if err := checkPolicies(cv.builtinPolicies(), chain, isPrecert); err != nil {
+		return nil, err
 	}
 
-	// Check for unwanted extension types, if required.
-	// TODO(al): Refactor CertValidationOpts c'tor to a builder pattern and
-	// pre-calc this in there
-	if len(cv.rejectExtIds) != 0 {
-		badIDs := make(map[string]bool)
-		for _, id := range cv.rejectExtIds {
-			badIDs[id.String()] = true
+	roots, generation := cv.trustedRoots.CertPoolGeneration()
+	// Chains without issuers can't be cached: their path depends on the
+	// issuer of the leaf, which is not part of the key.
+	var key pathKey
+	cacheable := cv.pathCache != nil && len(chain) > 1
+	if cacheable {
+		key = cv.pathCache.key(chain[1:], generation)
+		var verifiedChain []*x509.Certificate
+		if p := cv.pathCache.get(key); p != nil {
+			verifiedChain = p.verifyLeaf(chain[0])
 		}
-		for idx, ext := range cert.Extensions {
-			extOid := ext.Id.String()
-			if _, ok := badIDs[extOid]; ok {
-				return nil, fmt.Errorf("rejecting certificate containing extension %v at index %d", extOid, idx)
+		cv.pathCache.recordLookup(verifiedChain != nil)
+		if verifiedChain != nil {
+			// Name constraints depend on the leaf, they can't be cached.
+			if cv.enforceNameConstraints {
+				if err := lax509.CheckNameConstraints(verifiedChain); err != nil {
+					return nil, fmt.Errorf("failed to verify chain: %v", err)
+				}
 			}
-		}
-	}
-
-	// TODO(al): Refactor CertValidationOpts c'tor to a builder pattern and
-	// pre-calc this in there too.
-	if len(cv.extKeyUsages) > 0 {
-		acceptEKUs := make(map[x509.ExtKeyUsage]bool)
-		for _, eku := range cv.extKeyUsages {
-			acceptEKUs[eku] = true
-		}
-		good := false
-		for _, certEKU := range cert.ExtKeyUsage {
-			if _, ok := acceptEKUs[certEKU]; ok {
-				good = true
-				break
+			if err := checkPolicies(cv.policies, verifiedChain, isPrecert); err != nil {
+				return nil, err
 			}
-		}
-		if !good {
-			return nil, fmt.Errorf("rejecting certificate without EKU in %v", cv.extKeyUsages)
+			return verifiedChain, nil
 		}
 	}
 
@@ -228,10 +257,13 @@ func (cv chainValidator) validate(chain []*x509.Certificate) ([]*x509.Certificat
 	//  - allow certificate without policing them since this is not CT's responsibility
 	// See /internal/lax509/README.md for further information.
 	verifyOpts := lax509.VerifyOptions{
-		Roots:         cv.trustedRoots.CertPool(),
+		Roots:         roots,
 		Intermediates: intermediatePool.CertPool(),
 		KeyUsages:     cv.extKeyUsages,
 		AcceptSHA1:    cv.acceptSHA1,
+		// Both are disabled by default, see /internal/lax509/README.md.
+		EnforceNameConstraints: cv.enforceNameConstraints,
+		EnforcePathLength:      cv.enforcePathLength,
 	}
 
 	crtsh := make([]string, len(chain))
@@ -239,9 +271,9 @@ func (cv chainValidator) validate(chain []*x509.Certificate) ([]*x509.Certificat
 		crtsh[i] = fmt.Sprintf("https://crt.sh/?sha256=%x", sha256.Sum256(c.Raw))
 	}
 
-	verifiedChains, err := lax509.Verify(cert, verifyOpts)
+	verifiedChains, err := lax509.Verify(chain[0], verifyOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify chain: %v: %s", err, strings.Join(crtsh, ", "))
+		return nil, fmt.Errorf("failed to verify chain: %w: %s", err, strings.Join(crtsh, ", "))
 	}
 
 	if len(verifiedChains) == 0 {
@@ -253,6 +285,13 @@ func (cv chainValidator) validate(chain []*x509.Certificate) ([]*x509.Certificat
 	// requirements detailed in Section 3.1.
 	for _, verifiedChain := range verifiedChains {
 		if chainsEquivalent(chain, verifiedChain) {
+			if cacheable {
+				root := verifiedChain[len(verifiedChain)-1]
+				cv.pathCache.add(key, &verifiedPath{path: verifiedChain[1:], constraint: cv.trustedRoots.Constraint(root)})
+			}
+			if err := checkPolicies(cv.policies, verifiedChain, isPrecert); err != nil {
+				return nil, err
+			}
 			return verifiedChain, nil
 		}
 	}
@@ -269,11 +308,18 @@ func (cv chainValidator) Validate(unverifiedChain []*x509.Certificate, expecting
 	if len(unverifiedChain) == 0 {
 		return nil, errors.New("empty chain")
 	}
-	validPath, err := cv.validate(unverifiedChain)
+	validPath, err := cv.validate(unverifiedChain, expectingPrecert)
 	if err != nil {
 		// We rejected it because the cert failed checks or we could not find a path to a root etc.
 		// Lots of possible causes for errors
-		return nil, fmt.Errorf("chain failed to validate: %s", err)
+		return nil, fmt.Errorf("chain failed to validate: %w", err)
+	}
+
+	// Run before IsPrecertificate to report precise errors.
+	if cv.strictPrecerts {
+		if err := checkPrecertStructure(validPath, expectingPrecert); err != nil {
+			return nil, err
+		}
 	}
 
 	isPrecert, err := x509util.IsPrecertificate(validPath[0])
@@ -288,7 +334,7 @@ func (cv chainValidator) Validate(unverifiedChain []*x509.Certificate, expecting
 		} else {
 			slog.WarnContext(context.Background(), "Precert (or cert with invalid CT ext) submitted as cert chain", slog.Any("chain", unverifiedChain))
 		}
-		return nil, fmt.Errorf("cert / precert mismatch: %T", expectingPrecert)
+		return nil, fmt.Errorf("%w: %T", errCertPrecertMismatch, expectingPrecert)
 	}
 
 	return validPath, nil
@@ -298,6 +344,24 @@ func (cv chainValidator) Roots() []*x509.Certificate {
 	return cv.trustedRoots.RawCertificates()
 }
 
+// generationPolicy is implemented by policies which can change at runtime.
+type generationPolicy interface {
+	// Generation changes every time the policy changes.
+	Generation() uint64
+}
+
+// Generation implements ChainValidator. Since generations only increase,
+// their sum changes every time one of them does.
+func (cv chainValidator) Generation() uint64 {
+	_, g := cv.trustedRoots.CertPoolGeneration()
+	for _, p := range cv.policies {
+		if gp, ok := p.(generationPolicy); ok {
+			g += gp.Generation()
+		}
+	}
+	return g
+}
+
 func chainsEquivalent(inChain []*x509.Certificate, verifiedChain []*x509.Certificate) bool {
 	// The verified chain includes a root, but the input chain may or may not include a
 	// root (RFC 6962 s4.1/ s4.2 "the last [certificate] is either the root certificate
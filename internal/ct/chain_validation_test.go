@@ -404,7 +404,7 @@ func TestValidate(t *testing.T) {
 			if err != nil {
 				t.Fatalf("parseChain()=%v", err)
 			}
-			gotPath, err := cvv.validate(chain)
+			gotPath, err := cvv.validate(chain, false)
 			if err != nil {
 				if !test.wantErr {
 					t.Errorf("chainValidator.validate()=%v,%v; want _,nil", gotPath, err)
@@ -509,7 +509,7 @@ func TestNotAfterRange(t *testing.T) {
 			if err != nil {
 				t.Fatalf("parseChain()=%v", err)
 			}
-			gotPath, err := cv.validate(chain)
+			gotPath, err := cv.validate(chain, false)
 			if err != nil {
 				if !test.wantErr {
 					t.Errorf("chainValidate.validate()=%v,%v; want _,nil", gotPath, err)
@@ -628,7 +628,7 @@ func TestRejectExpiredUnexpired(t *testing.T) {
 			cv.currentTime = tc.now
 			cv.rejectExpired = tc.rejectExpired
 			cv.rejectUnexpired = tc.rejectUnexpired
-			_, err := cv.validate(chain)
+			_, err := cv.validate(chain, false)
 			if err != nil {
 				if len(tc.wantErr) == 0 {
 					t.Errorf("chainValidate.validate()=_,%v; want _,nil", err)
@@ -748,7 +748,7 @@ func TestPreIssuedCert(t *testing.T) {
 			if err != nil {
 				t.Fatalf("parseChain()=%v", err)
 			}
-			chain, err = cv.validate(chain)
+			chain, err = cv.validate(chain, false)
 			if err != nil {
 				t.Fatalf("failed to chainValidate.validate: %v", err)
 			}
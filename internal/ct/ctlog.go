@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -11,11 +12,14 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/ctonly"
 	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
 	"github.com/transparency-dev/tesseract/storage"
+	"golang.org/x/mod/sumdb/note"
 )
 
 // log provides objects and functions to implement static-ct-api write api.
@@ -30,6 +34,33 @@ type log struct {
 	chainValidator ChainValidator
 	// storage stores certificate data.
 	storage Storage
+	// reader reads log data back, to serve RFC 6962 read endpoints.
+	reader Reader
+	// cpVerifier verifies the log's checkpoints.
+	cpVerifier note.Verifier
+	// stateStorage persists the lifecycle state of the log.
+	stateStorage StateStorage
+	// state is the LogState of the log.
+	state atomic.Value
+	// stateMu is held for reading while new entries are being sequenced,
+	// and for writing while the state changes.
+	stateMu sync.RWMutex
+	// stopAppendingOnce stops the storage of retired logs from accepting
+	// new entries.
+	stopAppendingOnce sync.Once
+	// nextIndex is one more than the largest index assigned by this
+	// instance of the log, 0 if none.
+	nextIndex atomic.Uint64
+}
+
+// recordIndex records that index was assigned to a new entry.
+func (l *log) recordIndex(index uint64) {
+	for {
+		next := l.nextIndex.Load()
+		if index < next || l.nextIndex.CompareAndSwap(next, index+1) {
+			return
+		}
+	}
 }
 
 // signSCT builds an SCT for a leaf.
@@ -38,17 +69,39 @@ type signSCT func(sctInput rfc6962.CertificateTimestamp) (*rfc6962.SignedCertifi
 // Storage provides functions to store certificates in a static-ct-api log.
 type Storage interface {
 	// Add assigns an index to the provided Entry, stages the entry for integration, and returns a future for the assigned index.
+	// It doesn't wait for the entry to be published.
 	Add(context.Context, *ctonly.Entry) (tessera.IndexFuture, error)
+	// AwaitPublication waits for the entry of a future returned by Add to be
+	// published, if the storage waits for publication before SCTs are returned.
+	AwaitPublication(context.Context, tessera.IndexFuture) error
 	// DedupFuture fetches the SCT input fields for a duplicate entry from the log.
 	DedupFuture(context.Context, tessera.IndexFuture) (rfc6962.CertificateTimestamp, error)
 	// AddIssuerChain stores every the chain certificate in a content-addressable store under their sha256 hash.
 	AddIssuerChain(context.Context, []*x509.Certificate) error
 }
 
+// Reader provides functions to read static-ct-api log data back from storage.
+type Reader interface {
+	// ReadCheckpoint returns the latest checkpoint published by the log.
+	ReadCheckpoint(ctx context.Context) ([]byte, error)
+	// ReadTile returns the hash tile at the given level and index, p is the size of partial tiles.
+	ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error)
+	// ReadEntryBundle returns the entry bundle at the given index, p is the size of partial bundles.
+	ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error)
+	// ReadIssuer returns the issuer certificate whose sha256 is fingerprint.
+	ReadIssuer(ctx context.Context, fingerprint [32]byte) ([]byte, error)
+	// LeafIndex returns the index of the entry whose Merkle leaf hash is leafHash.
+	// It returns storage.ErrNoLeafIndex if the log doesn't maintain a leaf index.
+	LeafIndex(ctx context.Context, leafHash [32]byte) (uint64, error)
+}
+
 // ChainValidator provides functions to validate incoming chains.
 type ChainValidator interface {
 	Validate(chain []*x509.Certificate, expectingPrecert bool) ([]*x509.Certificate, error)
 	Roots() []*x509.Certificate
+	// Generation changes every time the roots, root constraints or policies
+	// used by Validate change.
+	Generation() uint64
 }
 
 // isValidOrigin returns nil if the origin complies with https://c2sp.org/static-ct-api.
@@ -77,6 +130,9 @@ func isValidOrigin(origin string) error {
 //   - SCT signer
 //   - storage, used to persist chains
 func NewLog(ctx context.Context, origin string, signer crypto.Signer, cv ChainValidator, cs storage.CreateStorage, ts TimeSource) (*log, error) {
+	// The log state is recorded as soon as it changes, which may happen
+	// before any handler is created.
+	once.Do(func() { setupMetrics() })
 	log := &log{}
 
 	if err := isValidOrigin(origin); err != nil {
@@ -84,12 +140,16 @@ func NewLog(ctx context.Context, origin string, signer crypto.Signer, cv ChainVa
 	}
 	log.origin = origin
 
-	// Validate signer that only ECDSA is supported.
+	// Validate signer that only ECDSA and RSA are supported.
 	if signer == nil {
 		return nil, errors.New("empty signer")
 	}
 	switch keyType := signer.Public().(type) {
 	case *ecdsa.PublicKey:
+	case *rsa.PublicKey:
+		if keyType.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key is %d bits, want at least %d", keyType.N.BitLen(), minRSAKeyBits)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported key type: %v", keyType)
 	}
@@ -111,6 +171,18 @@ func NewLog(ctx context.Context, origin string, signer crypto.Signer, cv ChainVa
 		os.Exit(1)
 	}
 	log.storage = storage
+	log.reader = storage
+	log.stateStorage = storage
+	if err := log.loadState(ctx); err != nil {
+		return nil, err
+	}
+	go log.watchState(ctx, logStateRefreshInterval)
+
+	cpVerifier, err := NewCpVerifier(signer.Public(), origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint Verifier: %v", err)
+	}
+	log.cpVerifier = cpVerifier
 
 	return log, nil
 }
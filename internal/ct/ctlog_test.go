@@ -5,6 +5,7 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -29,6 +30,14 @@ func TestNewLog(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to generate RSA key: %v", err)
 	}
+	smallRSASigner, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	_, ed25519Signer, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
 	roots, err := x509util.NewPEMCertPool(nil)
 	if err != nil {
 		t.Fatalf("NewPEMCertPool() err=%v", err)
@@ -64,13 +73,30 @@ func TestNewLog(t *testing.T) {
 			},
 			signer: ecdsaSigner,
 		},
+		{
+			desc:   "ok-rsa",
+			origin: "testlog",
+			cv: chainValidator{
+				trustedRoots: roots,
+			},
+			signer: rsaSigner,
+		},
+		{
+			desc:   "rsa-key-too-small",
+			origin: "testlog",
+			cv: chainValidator{
+				trustedRoots: roots,
+			},
+			signer:  smallRSASigner,
+			wantErr: "RSA key is 1024 bits",
+		},
 		{
 			desc:   "incorrect-signer-type",
 			origin: "testlog",
 			cv: chainValidator{
 				trustedRoots: roots,
 			},
-			signer:  rsaSigner,
+			signer:  ed25519Signer,
 			wantErr: "unsupported key type",
 		},
 	} {
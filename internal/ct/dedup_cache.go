@@ -0,0 +1,121 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DedupCache is a bounded LRU cache of the SCT inputs issued for recently
+// submitted chains, keyed by the hash of the raw submitted chain.
+//
+// It lets add-chain and add-pre-chain answer resubmissions of a chain
+// without parsing, validating, or looking it up in storage again: a new SCT
+// is signed straight away over the cached SCT input, which is the one of the
+// entry already in the log. Resubmissions answered from the cache are
+// subject to RateLimits.AcceptNotBefore and RateLimits.AcceptDedup, like
+// other duplicates, and to the quota of their issuer.
+//
+// Keys include the generation of the log's ChainValidator, so that once
+// roots, root constraints or the issuer filter change, resubmissions are
+// validated again. Entries whose leaf has expired since they were cached are
+// not used either, so that resubmissions are checked against the expiry
+// policy again. Lints and other policies don't depend on time, and can't
+// change at runtime.
+type DedupCache struct {
+	origin string
+	scts   *lru[[sha256.Size]byte, dedupEntry]
+}
+
+// dedupEntry is the cached result of a submission.
+type dedupEntry struct {
+	sctInput rfc6962.CertificateTimestamp
+	// issuer is the key of the issuer of the chain, for issuer quotas, if
+	// the chain has an issuer.
+	issuer string
+	// notBefore and notAfter are the validity bounds of the leaf.
+	notBefore time.Time
+	notAfter  time.Time
+	// expired is true if the leaf had expired when it was cached.
+	expired bool
+}
+
+// newDedupEntry returns the entry to cache for a chain, whose leaf is first,
+// which was given sctInput at time now.
+func newDedupEntry(chain []*x509.Certificate, sctInput rfc6962.CertificateTimestamp, now time.Time) dedupEntry {
+	issuer, _ := issuerKey(chain)
+	return dedupEntry{
+		sctInput:  sctInput,
+		issuer:    issuer,
+		notBefore: chain[0].NotBefore,
+		notAfter:  chain[0].NotAfter,
+		expired:   now.After(chain[0].NotAfter),
+	}
+}
+
+// NewDedupCache returns a cache holding up to size SCT inputs. origin is
+// only used to attribute metrics.
+func NewDedupCache(origin string, size int) *DedupCache {
+	once.Do(func() { setupMetrics() })
+	return &DedupCache{
+		origin: origin,
+		scts:   newLRU[[sha256.Size]byte, dedupEntry](size),
+	}
+}
+
+// key returns the key of a raw submitted chain, validated by a ChainValidator
+// of the given generation. Chains submitted to add-chain and add-pre-chain
+// have different keys.
+func (c *DedupCache) key(rawChain [][]byte, isPrecert bool, generation uint64) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(binary.BigEndian.AppendUint64(nil, generation))
+	if isPrecert {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	for _, der := range rawChain {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(der))))
+		h.Write(der)
+	}
+	return [sha256.Size]byte(h.Sum(nil))
+}
+
+// get returns the entry cached under k, unless its leaf has expired by now
+// since it was cached, and records the lookup.
+func (c *DedupCache) get(ctx context.Context, k [sha256.Size]byte, now time.Time) (dedupEntry, bool) {
+	e, ok := c.scts.get(k)
+	result := "miss"
+	switch {
+	case ok && !e.expired && now.After(e.notAfter):
+		result, ok = "expired", false
+	case ok:
+		result = "hit"
+	}
+	dedupCacheLookups.Add(ctx, 1, metric.WithAttributes(originKey.String(c.origin), dedupCacheResultKey.String(result)))
+	return e, ok
+}
+
+// add caches e under k.
+func (c *DedupCache) add(k [sha256.Size]byte, e dedupEntry) {
+	c.scts.add(k, e)
+}
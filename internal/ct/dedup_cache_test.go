@@ -0,0 +1,149 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+func TestDedupCacheKey(t *testing.T) {
+	c := NewDedupCache("example.com", 1)
+	a, b := []byte("a"), []byte("b")
+	keys := map[[32]byte]string{}
+	for desc, k := range map[string][32]byte{
+		"cert":           c.key([][]byte{a, b}, false, 0),
+		"precert":        c.key([][]byte{a, b}, true, 0),
+		"merged-certs":   c.key([][]byte{append(a, b...)}, false, 0),
+		"reversed":       c.key([][]byte{b, a}, false, 0),
+		"shorter-chain":  c.key([][]byte{a}, false, 0),
+		"new-generation": c.key([][]byte{a, b}, false, 1),
+	} {
+		if other, ok := keys[k]; ok {
+			t.Errorf("key() of %s and %s are equal", desc, other)
+		}
+		keys[k] = desc
+	}
+	if c.key([][]byte{a, b}, false, 0) != c.key([][]byte{[]byte("a"), []byte("b")}, false, 0) {
+		t.Errorf("key() of identical chains are different")
+	}
+}
+
+// rejectingValidator rejects all chains. Its generation is the one of the
+// wrapped ChainValidator plus generation.
+type rejectingValidator struct {
+	ChainValidator
+	generation uint64
+}
+
+func (v rejectingValidator) Generation() uint64 {
+	return v.ChainValidator.Generation() + v.generation
+}
+
+func (rejectingValidator) Validate([]*x509.Certificate, bool) ([]*x509.Certificate, error) {
+	return nil, errors.New("rejected")
+}
+
+func TestAddChainDedupCache(t *testing.T) {
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	defer timeSource.Reset()
+
+	for _, tc := range []struct {
+		desc       string
+		dedupRL    float64
+		quotas     QuotasConfig
+		generation uint64
+		// oldCertRL rate limits resubmissions of old certs, if set.
+		oldCertRL bool
+		// expire makes the leaf of the cached entry expire.
+		expire   bool
+		wantCode int
+	}{
+		{desc: "hit", dedupRL: 10, wantCode: http.StatusOK},
+		{desc: "rate-limited", dedupRL: 0, wantCode: http.StatusTooManyRequests},
+		{desc: "issuer-over-quota", dedupRL: 10, quotas: QuotasConfig{Issuer: &QuotaConfig{QPS: 1}}, wantCode: http.StatusTooManyRequests},
+		{desc: "validator-changed", dedupRL: 10, generation: 1, wantCode: http.StatusBadRequest},
+		{desc: "old-cert-rate-limited", dedupRL: 10, oldCertRL: true, wantCode: http.StatusTooManyRequests},
+		{desc: "expired-since-cached", dedupRL: 10, expire: true, wantCode: http.StatusBadRequest},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			opts := hOpts()
+			opts.DedupCache = NewDedupCache(log.origin, 10)
+			opts.RateLimits.Dedup(tc.dedupRL)
+			if err := opts.RateLimits.Quotas(tc.quotas); err != nil {
+				t.Fatalf("Quotas(): %v", err)
+			}
+			server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+			defer server.Close()
+			post := func() (int, rfc6962.AddChainResponse) {
+				t.Helper()
+				resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+				if err != nil {
+					t.Fatalf("http.Post(): %v", err)
+				}
+				defer func() { _ = resp.Body.Close() }()
+				var rsp rfc6962.AddChainResponse
+				if resp.StatusCode == http.StatusOK {
+					if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+						t.Fatalf("json.Decode()=%v", err)
+					}
+				}
+				return resp.StatusCode, rsp
+			}
+
+			code, first := post()
+			if code != http.StatusOK {
+				t.Fatalf("first submission got status %d, want %d", code, http.StatusOK)
+			}
+			// Resubmissions answered from the cache are neither validated
+			// again, nor given a new timestamp, unless the validator has
+			// changed since.
+			if tc.expire {
+				var rawChain [][]byte
+				for _, c := range loadCertsIntoPoolOrDie(t, chain).RawCertificates() {
+					rawChain = append(rawChain, c.Raw)
+				}
+				k := opts.DedupCache.key(rawChain, false, log.chainValidator.Generation())
+				e, ok := opts.DedupCache.get(t.Context(), k, time.Now())
+				if !ok {
+					t.Fatal("first submission was not cached")
+				}
+				e.notAfter = time.Now().Add(-time.Minute)
+				opts.DedupCache.add(k, e)
+			}
+			if tc.oldCertRL {
+				opts.RateLimits.NotBefore(0, 0)
+			}
+			log.chainValidator = rejectingValidator{ChainValidator: log.chainValidator, generation: tc.generation}
+			timeSource.Add1m()
+			code, second := post()
+			if code != tc.wantCode {
+				t.Fatalf("resubmission got status %d, want %d", code, tc.wantCode)
+			}
+			if code == http.StatusOK && (second.Timestamp != first.Timestamp || second.Extensions != first.Extensions) {
+				t.Errorf("resubmission got SCT with timestamp %d and extensions %q, want %d and %q", second.Timestamp, second.Extensions, first.Timestamp, first.Extensions)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/transparency-dev/tesseract/internal/lax509"
+)
+
+// ErrorCode identifies why a request failed in JSON error responses.
+//
+// Error codes are stable: clients can rely on them, new ones might be added,
+// but existing ones will not be renamed.
+type ErrorCode string
+
+// Error codes.
+const (
+	// Requests which can't be parsed.
+	ErrorCodeMalformedRequest   = ErrorCode("malformed_request")
+	ErrorCodeRequestTooLarge    = ErrorCode("request_too_large")
+	ErrorCodeMethodNotAllowed   = ErrorCode("method_not_allowed")
+	ErrorCodeInvalidCertificate = ErrorCode("invalid_certificate")
+	// Requests from clients which are not allowed to submit.
+	ErrorCodeUnauthenticated = ErrorCode("unauthenticated")
+	ErrorCodeForbidden       = ErrorCode("forbidden")
+	// Chains which are rejected by the log.
+	ErrorCodeUnknownRoot         = ErrorCode("unknown_root")
+	ErrorCodeInvalidChain        = ErrorCode("invalid_chain")
+	ErrorCodeCertPrecertMismatch = ErrorCode("cert_precert_mismatch")
+	ErrorCodeNotAfterOutOfRange  = ErrorCode("not_after_out_of_range")
+	ErrorCodeExpiryRejected      = ErrorCode("expiry_rejected")
+	ErrorCodeExtensionRejected   = ErrorCode("extension_rejected")
+	ErrorCodeIssuerRejected      = ErrorCode("issuer_rejected")
+	ErrorCodePolicyRejected      = ErrorCode("policy_rejected")
+	ErrorCodePrecertInvalid      = ErrorCode("precert_invalid")
+	ErrorCodeLintRejected        = ErrorCode("lint_rejected")
+	ErrorCodeLogReadOnly         = ErrorCode("log_read_only")
+	ErrorCodeLogRetired          = ErrorCode("log_retired")
+	ErrorCodeRateLimitedOldCert  = ErrorCode("rate_limited_old_cert")
+	ErrorCodeRateLimitedDedup    = ErrorCode("rate_limited_dedup")
+	ErrorCodeRateLimitedClientIP = ErrorCode("rate_limited_client_ip")
+	ErrorCodeRateLimitedClientID = ErrorCode("rate_limited_client_id")
+	ErrorCodeRateLimitedIssuer   = ErrorCode("rate_limited_issuer")
+	ErrorCodePushbackAntispam    = ErrorCode("pushback_antispam")
+	ErrorCodePushbackIntegration = ErrorCode("pushback_integration")
+	ErrorCodePushbackOther       = ErrorCode("pushback_other")
+	ErrorCodePushbackAdaptive    = ErrorCode("pushback_adaptive")
+	ErrorCodePushbackLane        = ErrorCode("pushback_lane")
+	ErrorCodeRateLimited         = ErrorCode("rate_limited")
+	ErrorCodeBadRequest          = ErrorCode("bad_request")
+	ErrorCodeClientClosedRequest = ErrorCode("client_closed_request")
+	ErrorCodeInternalError       = ErrorCode("internal_error")
+)
+
+// ErrorResponse is the body of JSON error responses.
+type ErrorResponse struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// codedError associates an ErrorCode with an error.
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withCode returns err, associated with code.
+func withCode(code ErrorCode, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the code associated with err, or a generic one derived
+// from statusCode.
+func errorCode(statusCode int, err error) ErrorCode {
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrorCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthenticated
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusMethodNotAllowed:
+		return ErrorCodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case ClientClosedRequestStatus:
+		return ErrorCodeClientClosedRequest
+	}
+	return ErrorCodeInternalError
+}
+
+// policyErrorCodes maps built-in policy names to error codes. Other policies
+// map to ErrorCodePolicyRejected.
+var policyErrorCodes = map[string]ErrorCode{
+	notAfterPolicy{}.Name():         ErrorCodeNotAfterOutOfRange,
+	expiryPolicy{}.Name():           ErrorCodeExpiryRejected,
+	rejectExtensionsPolicy{}.Name(): ErrorCodeExtensionRejected,
+	(&IssuerFilter{}).Name():        ErrorCodeIssuerRejected,
+}
+
+// validationErrorCode returns the code of an error returned by
+// ChainValidator.Validate.
+func validationErrorCode(err error) ErrorCode {
+	var pre *PolicyRejectedError
+	var pve *PrecertValidationError
+	var uae lax509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &pre):
+		if code, ok := policyErrorCodes[pre.Policy]; ok {
+			return code
+		}
+		return ErrorCodePolicyRejected
+	case errors.As(err, &pve):
+		return ErrorCodePrecertInvalid
+	case errors.Is(err, errCertPrecertMismatch):
+		return ErrorCodeCertPrecertMismatch
+	case errors.As(err, &uae):
+		return ErrorCodeUnknownRoot
+	}
+	return ErrorCodeInvalidChain
+}
+
+// writeJSONError writes an ErrorResponse to w.
+func writeJSONError(w http.ResponseWriter, statusCode int, code ErrorCode, message string) {
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: message})
+}
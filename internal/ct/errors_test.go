@@ -0,0 +1,197 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+func TestSendHTTPError(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		jsonErrors bool
+		mask       bool
+		statusCode int
+		err        error
+		wantType   string
+		wantBody   string
+	}{
+		{
+			desc:       "text",
+			statusCode: http.StatusBadRequest,
+			err:        withCode(ErrorCodeUnknownRoot, errors.New("boom")),
+			wantType:   "text/plain; charset=utf-8",
+			wantBody:   "Bad Request\nboom\n",
+		},
+		{
+			desc:       "text-masked",
+			mask:       true,
+			statusCode: http.StatusInternalServerError,
+			err:        errors.New("boom"),
+			wantType:   "text/plain; charset=utf-8",
+			wantBody:   "Internal Server Error\n",
+		},
+		{
+			desc:       "json",
+			jsonErrors: true,
+			statusCode: http.StatusBadRequest,
+			err:        fmt.Errorf("wrapped: %w", withCode(ErrorCodeUnknownRoot, errors.New("boom"))),
+			wantType:   contentTypeJSON,
+			wantBody:   `{"code":"unknown_root","message":"wrapped: boom"}` + "\n",
+		},
+		{
+			desc:       "json-status-code",
+			jsonErrors: true,
+			statusCode: http.StatusTooManyRequests,
+			err:        errors.New("boom"),
+			wantType:   contentTypeJSON,
+			wantBody:   `{"code":"rate_limited","message":"boom"}` + "\n",
+		},
+		{
+			desc:       "json-internal",
+			jsonErrors: true,
+			statusCode: http.StatusInternalServerError,
+			err:        errors.New("boom"),
+			wantType:   contentTypeJSON,
+			wantBody:   `{"code":"internal_error","message":"boom"}` + "\n",
+		},
+		{
+			desc:       "json-masked",
+			jsonErrors: true,
+			mask:       true,
+			statusCode: http.StatusInternalServerError,
+			err:        errors.New("boom"),
+			wantType:   contentTypeJSON,
+			wantBody:   `{"code":"internal_error","message":"Internal Server Error"}` + "\n",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			opts := &HandlerOptions{JSONErrors: tc.jsonErrors, MaskInternalErrors: tc.mask}
+			opts.sendHTTPError(w, tc.statusCode, tc.err)
+			if w.Code != tc.statusCode {
+				t.Errorf("got status %d, want %d", w.Code, tc.statusCode)
+			}
+			if got := w.Header().Get(contentTypeHeader); got != tc.wantType {
+				t.Errorf("got content type %q, want %q", got, tc.wantType)
+			}
+			if got := w.Body.String(); got != tc.wantBody {
+				t.Errorf("got body %q, want %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestValidationErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want ErrorCode
+	}{
+		{err: &PolicyRejectedError{Policy: "not_after", Err: errors.New("boom")}, want: ErrorCodeNotAfterOutOfRange},
+		{err: &PolicyRejectedError{Policy: "expiry", Err: errors.New("boom")}, want: ErrorCodeExpiryRejected},
+		{err: &PolicyRejectedError{Policy: "reject_extensions", Err: errors.New("boom")}, want: ErrorCodeExtensionRejected},
+		{err: &PolicyRejectedError{Policy: "issuer_filter", Err: &IssuerRejectedError{Reason: IssuerDenied}}, want: ErrorCodeIssuerRejected},
+		{err: &PolicyRejectedError{Policy: "custom", Err: errors.New("boom")}, want: ErrorCodePolicyRejected},
+		{err: &PrecertValidationError{Reason: PreIssuerNotCA}, want: ErrorCodePrecertInvalid},
+		{err: fmt.Errorf("%w: bool", errCertPrecertMismatch), want: ErrorCodeCertPrecertMismatch},
+		{err: errors.New("boom"), want: ErrorCodeInvalidChain},
+	} {
+		if got := validationErrorCode(tc.err); got != tc.want {
+			t.Errorf("validationErrorCode(%v)=%s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestAddChainJSONErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		body     io.Reader
+		readOnly bool
+		wantCode int
+		want     ErrorCode
+	}{
+		{
+			desc:     "malformed-request",
+			body:     bytes.NewBufferString("not json"),
+			wantCode: http.StatusBadRequest,
+			want:     ErrorCodeMalformedRequest,
+		},
+		{
+			desc:     "invalid-certificate",
+			body:     bytes.NewBufferString(`{"chain": ["AAAA"]}`),
+			wantCode: http.StatusBadRequest,
+			want:     ErrorCodeInvalidCertificate,
+		},
+		{
+			desc:     "unknown-root",
+			body:     createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.CertFromIntermediate})),
+			wantCode: http.StatusBadRequest,
+			want:     ErrorCodeUnknownRoot,
+		},
+		{
+			desc:     "cert-precert-mismatch",
+			body:     createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.PreCertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM})),
+			wantCode: http.StatusBadRequest,
+			want:     ErrorCodeCertPrecertMismatch,
+		},
+		{
+			desc:     "log-read-only",
+			body:     createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM})),
+			readOnly: true,
+			wantCode: http.StatusForbidden,
+			want:     ErrorCodeLogReadOnly,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			if tc.readOnly {
+				if err := log.SetState(t.Context(), LogStateReadOnly); err != nil {
+					t.Fatalf("SetState(): %v", err)
+				}
+			}
+			opts := hOpts()
+			opts.JSONErrors = true
+			server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", tc.body)
+			if err != nil {
+				t.Fatalf("http.Post(): %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+			var rsp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+				t.Fatalf("json.Decode(): %v", err)
+			}
+			if rsp.Code != tc.want || rsp.Message == "" {
+				t.Errorf("got error response %+v, want code %s and a message", rsp, tc.want)
+			}
+		})
+	}
+}
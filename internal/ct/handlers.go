@@ -83,6 +83,25 @@ var (
 	reqDuration            metric.Float64Histogram // origin, op, code => value
 	rateLimitedRequests    metric.Int64Counter     // origin, reason
 	notBeforeAgeUnverified metric.Float64Histogram // origin ==> value
+	routedRequests         metric.Int64Counter     // origin, op, code => value
+	rootsReloads           metric.Int64Counter     // origin, result => value
+	rootsAdded             metric.Int64Counter     // origin => value
+	rootsRemoved           metric.Int64Counter     // origin => value
+	rootsCount             metric.Int64Gauge       // origin => value
+	logStateGauge          metric.Int64Gauge       // origin, state => value
+	lintFindings           metric.Int64Counter     // origin, lint, severity => value
+	issuerRejections       metric.Int64Counter     // origin, reason => value
+	pathCacheLookups       metric.Int64Counter     // origin, result => value
+	dedupCacheLookups      metric.Int64Counter     // origin, result => value
+	precertRejections      metric.Int64Counter     // origin, reason => value
+	pushbackOverloaded     metric.Int64Gauge       // origin => value
+	pushbackAdmissionRate  metric.Float64Gauge     // origin => value
+	pushbackAwaitLatency   metric.Float64Gauge     // origin => value
+	pushbackCheckpointAge  metric.Float64Gauge     // origin => value
+	pushbackIntegrationLag metric.Int64Gauge       // origin => value
+	laneWaitDuration       metric.Float64Histogram // origin, lane => value
+	laneRejections         metric.Int64Counter     // origin, lane => value
+	authRequests           metric.Int64Counter     // origin, result, client_id => value
 )
 
 // setupMetrics initializes all the exported metrics.
@@ -121,6 +140,81 @@ func setupMetrics() {
 	rateLimitedRequests = mustCreate(meter.Int64Counter("tesseract.http.request.ratelimited.count",
 		metric.WithDescription("CT HTTP rate-limited requests"),
 		metric.WithUnit("{request}")))
+
+	routedRequests = mustCreate(meter.Int64Counter("tesseract.router.request.count",
+		metric.WithDescription("CT HTTP submissions handled by the router, by shard origin and response code"),
+		metric.WithUnit("{request}")))
+
+	rootsReloads = mustCreate(meter.Int64Counter("tesseract.roots.reload.count",
+		metric.WithDescription("Trusted roots reloads"),
+		metric.WithUnit("{reload}")))
+
+	rootsAdded = mustCreate(meter.Int64Counter("tesseract.roots.added.count",
+		metric.WithDescription("Trusted roots added by reloads"),
+		metric.WithUnit("{certificate}")))
+
+	rootsRemoved = mustCreate(meter.Int64Counter("tesseract.roots.removed.count",
+		metric.WithDescription("Trusted roots removed by reloads"),
+		metric.WithUnit("{certificate}")))
+
+	rootsCount = mustCreate(meter.Int64Gauge("tesseract.roots.count",
+		metric.WithDescription("Number of trusted roots"),
+		metric.WithUnit("{certificate}")))
+
+	logStateGauge = mustCreate(meter.Int64Gauge("tesseract.log.state",
+		metric.WithDescription("Set to 1 for the current lifecycle state of the log, 0 for other states")))
+
+	lintFindings = mustCreate(meter.Int64Counter("tesseract.lint.findings.count",
+		metric.WithDescription("Submitted certificates failing a lint"),
+		metric.WithUnit("{certificate}")))
+
+	issuerRejections = mustCreate(meter.Int64Counter("tesseract.issuers.rejected.count",
+		metric.WithDescription("Submissions rejected because of their intermediates, by reason"),
+		metric.WithUnit("{request}")))
+
+	pathCacheLookups = mustCreate(meter.Int64Counter("tesseract.path_cache.lookup.count",
+		metric.WithDescription("Verified path cache lookups, by result"),
+		metric.WithUnit("{lookup}")))
+
+	dedupCacheLookups = mustCreate(meter.Int64Counter("tesseract.dedup_cache.lookup.count",
+		metric.WithDescription("Submission dedup cache lookups, by result"),
+		metric.WithUnit("{lookup}")))
+
+	precertRejections = mustCreate(meter.Int64Counter("tesseract.precert.rejected.count",
+		metric.WithDescription("Submissions rejected by strict precertificate validation, by reason"),
+		metric.WithUnit("{request}")))
+
+	pushbackOverloaded = mustCreate(meter.Int64Gauge("tesseract.pushback.adaptive.overloaded",
+		metric.WithDescription("Set to 1 when adaptive pushback considers the log overloaded, 0 otherwise")))
+
+	pushbackAdmissionRate = mustCreate(meter.Float64Gauge("tesseract.pushback.adaptive.admission_rate",
+		metric.WithDescription("Submissions admitted per second by adaptive pushback"),
+		metric.WithUnit("{request}/s")))
+
+	pushbackAwaitLatency = mustCreate(meter.Float64Gauge("tesseract.pushback.adaptive.await_latency",
+		metric.WithDescription("Largest publication awaiter latency over the last adaptive pushback interval"),
+		metric.WithUnit("s")))
+
+	pushbackCheckpointAge = mustCreate(meter.Float64Gauge("tesseract.pushback.adaptive.checkpoint_age",
+		metric.WithDescription("Time since the published checkpoint last grew"),
+		metric.WithUnit("s")))
+
+	pushbackIntegrationLag = mustCreate(meter.Int64Gauge("tesseract.pushback.adaptive.integration_lag",
+		metric.WithDescription("Entries sequenced by this instance which are not in the published checkpoint yet"),
+		metric.WithUnit("{entry}")))
+
+	laneWaitDuration = mustCreate(meter.Float64Histogram("tesseract.lane.wait.duration",
+		metric.WithDescription("Time submissions wait in their lane before getting to storage, by lane"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(otel.SubSecondLatencyHistogramBuckets...)))
+
+	laneRejections = mustCreate(meter.Int64Counter("tesseract.lane.rejected.count",
+		metric.WithDescription("Submissions rejected because their lane was full, or timed out in their lane, by lane"),
+		metric.WithUnit("{request}")))
+
+	authRequests = mustCreate(meter.Int64Counter("tesseract.auth.request.count",
+		metric.WithDescription("Requests to authenticated endpoints, by origin, result and client identity"),
+		metric.WithUnit("{request}")))
 }
 
 // entrypoints is a list of entrypoint names as exposed in statistics/logging.
@@ -199,10 +293,17 @@ func (a appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	originAttr := originKey.String(a.log.origin)
 	operationAttr := operationKey.String(a.name)
 	attrs := []attribute.KeyValue{originAttr, operationAttr}
+	clientID, authenticated := ClientIDFromContext(r.Context())
+	if authenticated {
+		attrs = append(attrs, clientIDKey.String(clientID))
+	}
 
 	reqCounter.Add(logCtx, 1, metric.WithAttributes(attrs...))
 	startTime := time.Now()
 	a.opts.RequestLog.origin(logCtx, a.log.origin)
+	if authenticated {
+		a.opts.RequestLog.clientID(logCtx, clientID)
+	}
 	defer func() {
 		latency := time.Since(startTime).Seconds()
 		reqDuration.Record(r.Context(), latency, metric.WithAttributes(attrs...))
@@ -272,16 +373,65 @@ func (a appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // RateLimits knows how to apply configurable rate limits to submissions.
+//
+// Rate limits can be reconfigured while submissions are being served.
 type RateLimits struct {
+	mu             sync.RWMutex
 	notBeforeLimit time.Duration
 	notBefore      *rate.Limiter
 	dedup          *rate.Limiter
+	quotas         *quotas
+}
+
+// RateLimitsConfig describes the rate limits applied by RateLimits.
+type RateLimitsConfig struct {
+	// NotBefore limits submissions of old certificates, nil if disabled.
+	NotBefore *NotBeforeRateLimit `json:"not_before"`
+	// DedupQPS limits the number of duplicate entries resolved per second,
+	// nil if disabled.
+	DedupQPS *float64 `json:"dedup_qps"`
+	// Quotas limits submissions per client IP, client identity, and issuer,
+	// nil if disabled.
+	Quotas *QuotasConfig `json:"quotas"`
+}
+
+// NotBeforeRateLimit limits submissions whose notBefore date is at least
+// as old as Age to QPS entries per second.
+type NotBeforeRateLimit struct {
+	Age time.Duration
+	QPS float64
+}
+
+type notBeforeRateLimitJSON struct {
+	Age string  `json:"age"`
+	QPS float64 `json:"qps"`
+}
+
+// MarshalJSON encodes l with a human readable age, e.g. "28h0m0s".
+func (l NotBeforeRateLimit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(notBeforeRateLimitJSON{Age: l.Age.String(), QPS: l.QPS})
+}
+
+// UnmarshalJSON decodes l, with an age in time.ParseDuration format.
+func (l *NotBeforeRateLimit) UnmarshalJSON(b []byte) error {
+	var j notBeforeRateLimitJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	age, err := time.ParseDuration(j.Age)
+	if err != nil {
+		return fmt.Errorf("can't parse age %q: %v", j.Age, err)
+	}
+	l.Age, l.QPS = age, j.QPS
+	return nil
 }
 
 // NotBefore configures a rate limit on old certs.
 //
 // Submissions whose notBefore date is at least as old as age will be subject to the specified number of entries per second.
 func (r *RateLimits) NotBefore(age time.Duration, limit float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.notBeforeLimit = age
 	r.notBefore = rate.NewLimiter(rate.Limit(limit), int(math.Ceil(limit)))
 	slog.InfoContext(context.Background(), "Configured NotBefore limiter", slog.Float64("qps", limit), slog.Duration("min_age", age))
@@ -291,34 +441,121 @@ func (r *RateLimits) NotBefore(age time.Duration, limit float64) {
 //
 // Submissions will be subject to the specified number of entries per second.
 func (r *RateLimits) Dedup(limit float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.dedup = rate.NewLimiter(rate.Limit(limit), int(math.Ceil(limit)))
 	slog.InfoContext(context.Background(), "Configured DedupInFlight limiter", slog.Float64("qps", limit))
 }
 
+// Config returns the rate limits currently in effect.
+func (r *RateLimits) Config() RateLimitsConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var cfg RateLimitsConfig
+	if r.notBefore != nil {
+		cfg.NotBefore = &NotBeforeRateLimit{Age: r.notBeforeLimit, QPS: float64(r.notBefore.Limit())}
+	}
+	if r.dedup != nil {
+		qps := float64(r.dedup.Limit())
+		cfg.DedupQPS = &qps
+	}
+	if r.quotas != nil {
+		q := r.quotas.cfg
+		cfg.Quotas = &q
+	}
+	return cfg
+}
+
+// SetConfig replaces all the rate limits with the ones in cfg. Limits that
+// are nil in cfg are disabled.
+func (r *RateLimits) SetConfig(cfg RateLimitsConfig) error {
+	if l := cfg.NotBefore; l != nil && (l.Age < 0 || l.QPS < 0) {
+		return fmt.Errorf("invalid NotBefore rate limit: age=%s, qps=%v", l.Age, l.QPS)
+	}
+	if cfg.DedupQPS != nil && *cfg.DedupQPS < 0 {
+		return fmt.Errorf("invalid dedup rate limit: qps=%v", *cfg.DedupQPS)
+	}
+	var q *quotas
+	if cfg.Quotas != nil {
+		var err error
+		if q, err = newQuotas(*cfg.Quotas); err != nil {
+			return fmt.Errorf("invalid quotas: %v", err)
+		}
+	}
+	if l := cfg.NotBefore; l != nil {
+		r.NotBefore(l.Age, l.QPS)
+	} else {
+		r.mu.Lock()
+		r.notBefore = nil
+		r.mu.Unlock()
+		slog.InfoContext(context.Background(), "Disabled NotBefore limiter")
+	}
+	if cfg.DedupQPS != nil {
+		r.Dedup(*cfg.DedupQPS)
+	} else {
+		r.mu.Lock()
+		r.dedup = nil
+		r.mu.Unlock()
+		slog.InfoContext(context.Background(), "Disabled DedupInFlight limiter")
+	}
+	r.setQuotas(q)
+	return nil
+}
+
 // AcceptNotBefore returns true if the provided chain should be accepted, and false otherwise.
-func (r *RateLimits) AcceptNotBefore(ctx context.Context, chain []*x509.Certificate) bool {
+func (r *RateLimits) AcceptNotBefore(ctx context.Context, origin string, chain []*x509.Certificate) bool {
 	if len(chain) == 0 {
 		return false
 	}
-	if r.notBefore != nil {
-		if age := time.Since(chain[0].NotBefore); age >= r.notBeforeLimit {
-			if r.notBefore.Allow() {
+	return r.acceptNotBefore(ctx, origin, chain[0].NotBefore)
+}
+
+// acceptNotBefore returns true if a leaf with the given notBefore date should
+// be accepted, and false otherwise.
+func (r *RateLimits) acceptNotBefore(ctx context.Context, origin string, leafNotBefore time.Time) bool {
+	r.mu.RLock()
+	notBefore, notBeforeLimit := r.notBefore, r.notBeforeLimit
+	r.mu.RUnlock()
+	if notBefore != nil {
+		if age := time.Since(leafNotBefore); age >= notBeforeLimit {
+			if notBefore.Allow() {
 				return true
 			}
-			rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(rateLimitReasonKey.String("old_cert")))
+			rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rateLimitReasonKey.String("old_cert")))
 			return false
 		}
 	}
 	return true
 }
 
+// wouldAcceptNotBefore returns true if the provided chain would currently be
+// accepted by AcceptNotBefore. Unlike AcceptNotBefore, it does not count the
+// chain against the rate limit.
+func (r *RateLimits) wouldAcceptNotBefore(chain []*x509.Certificate) bool {
+	if len(chain) == 0 {
+		return false
+	}
+	r.mu.RLock()
+	notBefore, notBeforeLimit := r.notBefore, r.notBeforeLimit
+	r.mu.RUnlock()
+	if notBefore != nil {
+		if age := time.Since(chain[0].NotBefore); age >= notBeforeLimit {
+			return notBefore.Tokens() >= 1
+		}
+	}
+	return true
+}
+
 // AcceptDedup returns true if a duplicate entry is permitted to be resolved.
-func (r *RateLimits) AcceptDedup(ctx context.Context) bool {
-	if r.dedup != nil {
-		if r.dedup.Allow() {
+func (r *RateLimits) AcceptDedup(ctx context.Context, origin string) bool {
+	r.mu.RLock()
+	dedup := r.dedup
+	r.mu.RUnlock()
+	if dedup != nil {
+		if dedup.Allow() {
 			return true
 		}
-		rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(rateLimitReasonKey.String("dedup")))
+		rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rateLimitReasonKey.String("dedup")))
 		return false
 	}
 	return true
@@ -333,6 +570,9 @@ type HandlerOptions struct {
 	// MaskInternalErrors indicates if internal server errors should be masked
 	// or returned to the user containing the full error message.
 	MaskInternalErrors bool
+	// JSONErrors indicates if error responses should have an ErrorResponse
+	// JSON body rather than a plain text one.
+	JSONErrors bool
 	// TimeSource indicated the system time and can be injfected for testing.
 	// TODO(phbnf): hide inside the log
 	TimeSource TimeSource
@@ -340,16 +580,26 @@ type HandlerOptions struct {
 	PathPrefix string
 	// RateLimits describes optional rate limits to enforce.
 	RateLimits RateLimits
+	// Linter, if set, lints submitted certificates once their chain is
+	// validated.
+	Linter *Linter
+	// DedupCache, if set, answers resubmissions of recently submitted chains
+	// before validating them. It must not be shared between logs.
+	DedupCache *DedupCache
+	// AdaptivePushback, if set, sheds submissions when the log shows signs
+	// of overload. It must not be shared between logs.
+	AdaptivePushback *AdaptivePushback
+	// Lanes, if set, schedules submissions into storage by lane. It must not
+	// be shared between logs.
+	Lanes *Lanes
 }
 
 func NewPathHandlers(ctx context.Context, opts *HandlerOptions, log *log) pathHandlers {
 	once.Do(func() { setupMetrics() })
 	knownLogs.Record(ctx, 1, metric.WithAttributes(originKey.String(log.origin)))
+	recordLogState(ctx, log.origin, log.State())
 
-	prefix := strings.TrimRight(opts.PathPrefix, "/")
-	if prefix != "" && !strings.HasPrefix(prefix, "/") {
-		prefix = "/" + prefix
-	}
+	prefix := normalizePathPrefix(opts.PathPrefix)
 
 	// Bind each endpoint to an appHandler instance.
 	// TODO(phboneff): try and get rid of PathHandlers and appHandler
@@ -362,6 +612,16 @@ func NewPathHandlers(ctx context.Context, opts *HandlerOptions, log *log) pathHa
 	return ph
 }
 
+// normalizePathPrefix returns p with a leading slash and no trailing slash,
+// or an empty string if p is empty.
+func normalizePathPrefix(p string) string {
+	prefix := strings.TrimRight(p, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
 // sendHTTPError generates a custom error page to give more information on why something didn't work
 func (opts *HandlerOptions) sendHTTPError(w http.ResponseWriter, statusCode int, err error) {
 	var errorBody string
@@ -370,7 +630,16 @@ func (opts *HandlerOptions) sendHTTPError(w http.ResponseWriter, statusCode int,
 	} else {
 		errorBody = http.StatusText(statusCode)
 	}
-	if !opts.MaskInternalErrors || statusCode != http.StatusInternalServerError {
+	masked := opts.MaskInternalErrors && statusCode == http.StatusInternalServerError
+	if opts.JSONErrors {
+		msg := errorBody
+		if !masked {
+			msg = err.Error()
+		}
+		writeJSONError(w, statusCode, errorCode(statusCode, err), msg)
+		return
+	}
+	if !masked {
 		errorBody += fmt.Sprintf("\n%v", err)
 	}
 	http.Error(w, errorBody, statusCode)
@@ -438,40 +707,104 @@ func addChainInternal(ctx context.Context, opts *HandlerOptions, log *log, w htt
 		method = addChainName
 	}
 
+	if st := log.State(); st != LogStateUsable {
+		return rejectSubmission(log.origin, st)
+	}
+
+	if ok, quota := opts.RateLimits.AcceptClient(ctx, log.origin, r); !ok {
+		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+		return http.StatusTooManyRequests,
+			[]attribute.KeyValue{tooManyRequestsReasonKey.String("rate_limit_" + quota)},
+			withCode(quotaErrorCodes[quota], errors.New(http.StatusText(http.StatusTooManyRequests)))
+	}
+
 	// Check the contents of the request and convert to slice of certificates.
 	addChainReq, err := parseBodyAsJSONChain(r)
 	if err != nil {
 		var maxBytesErr *http.MaxBytesError
 		if errors.As(err, &maxBytesErr) {
-			return http.StatusRequestEntityTooLarge, nil, fmt.Errorf("%s: %v", log.origin, err)
+			return http.StatusRequestEntityTooLarge, nil, withCode(ErrorCodeRequestTooLarge, fmt.Errorf("%s: %v", log.origin, err))
 		}
-		return http.StatusBadRequest, nil, fmt.Errorf("%s: failed to parse add-chain body: %s", log.origin, err)
+		return http.StatusBadRequest, nil, withCode(ErrorCodeMalformedRequest, fmt.Errorf("%s: failed to parse add-chain body: %s", log.origin, err))
 	}
 	// Log the DERs now because they might not parse as valid X.509.
 	for _, der := range addChainReq.Chain {
 		opts.RequestLog.addDERToChain(ctx, der)
 	}
+
+	var dedupKey [sha256.Size]byte
+	if opts.DedupCache != nil {
+		// The generation is read before validating the chain, so that chains
+		// validated while roots or policies change are cached under a stale
+		// key.
+		dedupKey = opts.DedupCache.key(addChainReq.Chain, isPrecert, log.chainValidator.Generation())
+		if e, ok := opts.DedupCache.get(ctx, dedupKey, time.Now()); ok {
+			attrs := []attribute.KeyValue{duplicateKey.Bool(true), dedupCacheResultKey.String("hit")}
+			if ok := opts.RateLimits.acceptNotBefore(ctx, log.origin, e.notBefore); !ok {
+				w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+				return http.StatusTooManyRequests, append(attrs, tooManyRequestsReasonKey.String("rate_limit_old_cert")), withCode(ErrorCodeRateLimitedOldCert, errors.New(http.StatusText(http.StatusTooManyRequests)))
+			}
+			if ok := opts.RateLimits.AcceptDedup(ctx, log.origin); !ok {
+				w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+				return http.StatusTooManyRequests, append(attrs, tooManyRequestsReasonKey.String("rate_limit_dedup")), withCode(ErrorCodeRateLimitedDedup, errors.New(http.StatusText(http.StatusTooManyRequests)))
+			}
+			if e.issuer != "" && !opts.RateLimits.acceptIssuerKey(ctx, log.origin, e.issuer) {
+				w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+				return http.StatusTooManyRequests, append(attrs, tooManyRequestsReasonKey.String("rate_limit_issuer")), withCode(ErrorCodeRateLimitedIssuer, errors.New(http.StatusText(http.StatusTooManyRequests)))
+			}
+			if _, err := writeSCT(ctx, opts, log, w, e.sctInput, method); err != nil {
+				return http.StatusInternalServerError, attrs, err
+			}
+			return http.StatusOK, attrs, nil
+		}
+	}
+	if opts.AdaptivePushback != nil && !opts.AdaptivePushback.Admit(ctx) {
+		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+		return http.StatusTooManyRequests,
+			[]attribute.KeyValue{tooManyRequestsReasonKey.String("adaptive_pushback")},
+			withCode(ErrorCodePushbackAdaptive, errors.New(http.StatusText(http.StatusTooManyRequests)))
+	}
 	chain, err := parseChain(addChainReq.Chain)
 	if err != nil {
-		return http.StatusBadRequest, nil, fmt.Errorf("failed to parse add-chain contents: %s", err)
+		return http.StatusBadRequest, nil, withCode(ErrorCodeInvalidCertificate, fmt.Errorf("failed to parse add-chain contents: %s", err))
 	}
 
 	notBeforeAgeUnverified.Record(ctx, time.Since(chain[0].NotBefore).Seconds())
-	if ok := opts.RateLimits.AcceptNotBefore(ctx, chain); !ok {
+	if ok := opts.RateLimits.AcceptNotBefore(ctx, log.origin, chain); !ok {
 		opts.RequestLog.addCertToChain(ctx, chain[0])
 		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
 		return http.StatusTooManyRequests,
 			[]attribute.KeyValue{tooManyRequestsReasonKey.String("rate_limit_old_cert")},
-			errors.New(http.StatusText(http.StatusTooManyRequests))
+			withCode(ErrorCodeRateLimitedOldCert, errors.New(http.StatusText(http.StatusTooManyRequests)))
 	}
 
 	chain, err = log.chainValidator.Validate(chain, isPrecert)
 	if err != nil {
-		return http.StatusBadRequest, nil, fmt.Errorf("failed to verify add-chain contents: %s", err)
+		err = withCode(validationErrorCode(err), err)
+		var ire *IssuerRejectedError
+		if errors.As(err, &ire) {
+			issuerRejections.Add(ctx, 1, metric.WithAttributes(originKey.String(log.origin), issuerRejectionReasonKey.String(ire.Reason)))
+			return http.StatusBadRequest, []attribute.KeyValue{issuerRejectionReasonKey.String(ire.Reason)}, fmt.Errorf("failed to verify add-chain contents: %w", err)
+		}
+		var pve *PrecertValidationError
+		if errors.As(err, &pve) {
+			precertRejections.Add(ctx, 1, metric.WithAttributes(originKey.String(log.origin), precertRejectionReasonKey.String(pve.Reason)))
+			return http.StatusBadRequest, []attribute.KeyValue{precertRejectionReasonKey.String(pve.Reason)}, fmt.Errorf("failed to verify add-chain contents: %w", err)
+		}
+		return http.StatusBadRequest, nil, fmt.Errorf("failed to verify add-chain contents: %w", err)
 	}
 	for _, cert := range chain {
 		opts.RequestLog.addCertToChain(ctx, cert)
 	}
+	if code, attrs, err := checkLints(ctx, opts, log.origin, chain[0]); err != nil {
+		return code, attrs, err
+	}
+	if ok := opts.RateLimits.AcceptIssuer(ctx, log.origin, chain); !ok {
+		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+		return http.StatusTooManyRequests,
+			[]attribute.KeyValue{tooManyRequestsReasonKey.String("rate_limit_issuer")},
+			withCode(ErrorCodeRateLimitedIssuer, errors.New(http.StatusText(http.StatusTooManyRequests)))
+	}
 
 	// Get the current time in the form used throughout RFC6962, namely milliseconds since Unix
 	// epoch, and use this throughout.
@@ -480,7 +813,7 @@ func addChainInternal(ctx context.Context, opts *HandlerOptions, log *log, w htt
 
 	entry, err := x509util.EntryFromChain(chain, isPrecert, timeMillis)
 	if err != nil {
-		return http.StatusBadRequest, nil, fmt.Errorf("failed to build MerkleTreeLeaf: %s", err)
+		return http.StatusBadRequest, nil, withCode(ErrorCodeInvalidChain, fmt.Errorf("failed to build MerkleTreeLeaf: %s", err))
 	}
 	defer x509util.ReturnEntry(entry) // Return entry to the pool once we're done with it.
 
@@ -488,37 +821,77 @@ func addChainInternal(ctx context.Context, opts *HandlerOptions, log *log, w htt
 		return http.StatusInternalServerError, nil, fmt.Errorf("failed to store issuer chain: %s", err)
 	}
 
+	// Wait for a storage slot before holding the state, so that state
+	// changes don't wait for queued submissions.
+	releaseLane := func() {}
+	if opts.Lanes != nil {
+		name := opts.Lanes.laneFor(chain, isPrecert)
+		release, err := opts.Lanes.acquire(ctx, name)
+		if err != nil {
+			return laneRejected(w, name, err)
+		}
+		releaseLane = sync.OnceFunc(release)
+		defer releaseLane()
+	}
+
+	// Hold the state until the entry is sequenced, so that state changes
+	// wait for in-flight submissions.
+	log.stateMu.RLock()
+	releaseState := sync.OnceFunc(log.stateMu.RUnlock)
+	defer releaseState()
+	if st := log.State(); st != LogStateUsable {
+		return rejectSubmission(log.origin, st)
+	}
+
 	logger.DebugExtraContext(ctx, "storage.Add", slog.String("origin", log.origin), slog.String("method", method))
 	future, err := log.storage.Add(ctx, entry)
-	// helper function to return a 429
-	tooManyRequests := func(reason string) (int, []attribute.KeyValue, error) {
+	// helper function to return a 429 on Tessera pushback
+	tooManyRequests := func(reason string, code ErrorCode) (int, []attribute.KeyValue, error) {
+		if opts.AdaptivePushback != nil {
+			opts.AdaptivePushback.observePushback()
+		}
 		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
-		return http.StatusTooManyRequests, []attribute.KeyValue{tooManyRequestsReasonKey.String(reason)}, errors.New(http.StatusText(http.StatusTooManyRequests))
+		return http.StatusTooManyRequests, []attribute.KeyValue{tooManyRequestsReasonKey.String(reason)}, withCode(code, errors.New(http.StatusText(http.StatusTooManyRequests)))
 	}
 	if err != nil {
 		switch {
 		// Record the fact there was pushback, if any.
 		case errors.Is(err, tessera.ErrPushbackAntispam):
-			return tooManyRequests("tessera_pushback_antispam")
+			return tooManyRequests("tessera_pushback_antispam", ErrorCodePushbackAntispam)
 		case errors.Is(err, tessera.ErrPushbackIntegration):
-			return tooManyRequests("tessera_pushback_integration")
+			return tooManyRequests("tessera_pushback_integration", ErrorCodePushbackIntegration)
 		case errors.Is(err, tessera.ErrPushback):
-			return tooManyRequests("tessera_pushback_other")
+			return tooManyRequests("tessera_pushback_other", ErrorCodePushbackOther)
 		}
 		// If it's not a pushback, just flag that it's an errored request to avoid high cardinality of attribute values.
 		return http.StatusInternalServerError, nil, fmt.Errorf("couldn't store the leaf: %v", err)
 	}
 
 	index, err := future()
+	releaseState()
+	// Release the storage slot once the entry is sequenced, so that waiting
+	// for publication doesn't cap throughput to the number of slots.
+	releaseLane()
 	if err != nil {
 		return http.StatusInternalServerError, nil, fmt.Errorf("couldn't resolve tessera future: %v", err)
 	}
+	if err := log.storage.AwaitPublication(ctx, future); err != nil {
+		return http.StatusInternalServerError, []attribute.KeyValue{duplicateKey.Bool(index.IsDup)}, fmt.Errorf("couldn't await publication: %v", err)
+	}
 
 	var sctInput rfc6962.CertificateTimestamp
 	if index.IsDup {
-		if ok := opts.RateLimits.AcceptDedup(ctx); !ok {
+		if ok := opts.RateLimits.AcceptDedup(ctx, log.origin); !ok {
 			w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
-			return http.StatusTooManyRequests, []attribute.KeyValue{duplicateKey.Bool(index.IsDup), tooManyRequestsReasonKey.String("rate_limit_dedup")}, errors.New(http.StatusText(http.StatusTooManyRequests))
+			return http.StatusTooManyRequests, []attribute.KeyValue{duplicateKey.Bool(index.IsDup), tooManyRequestsReasonKey.String("rate_limit_dedup")}, withCode(ErrorCodeRateLimitedDedup, errors.New(http.StatusText(http.StatusTooManyRequests)))
+		}
+		if opts.Lanes != nil {
+			release, err := opts.Lanes.acquire(ctx, LaneDuplicate)
+			if err != nil {
+				status, attrs, err := laneRejected(w, LaneDuplicate, err)
+				return status, append(attrs, duplicateKey.Bool(index.IsDup)), err
+			}
+			defer release()
 		}
 		var err error
 		sctInput, err = log.storage.DedupFuture(ctx, future)
@@ -537,28 +910,42 @@ func addChainInternal(ctx context.Context, opts *HandlerOptions, log *log, w htt
 			return http.StatusInternalServerError, nil, fmt.Errorf("failed to extract SCT input from leaf: %v", err)
 		}
 	}
+	sct, err := writeSCT(ctx, opts, log, w, sctInput, method)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	if opts.DedupCache != nil {
+		opts.DedupCache.add(dedupKey, newDedupEntry(chain, sctInput, time.Now()))
+	}
+	if !index.IsDup {
+		lastSCTTimestamp.Record(ctx, otel.Clamp64(sct.Timestamp), metric.WithAttributes(originKey.String(log.origin)))
+		lastSCTIndex.Record(ctx, otel.Clamp64(index.Index), metric.WithAttributes(originKey.String(log.origin)))
+		log.recordIndex(index.Index)
+	}
+
+	return http.StatusOK, []attribute.KeyValue{duplicateKey.Bool(index.IsDup)}, nil
+}
+
+// writeSCT signs an SCT over sctInput, and writes it to w as an add-chain
+// response.
+func writeSCT(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, sctInput rfc6962.CertificateTimestamp, method entrypointName) (*rfc6962.SignedCertificateTimestamp, error) {
 	sct, err := log.signSCT(sctInput)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("failed to generate SCT: %s", err)
+		return nil, fmt.Errorf("failed to generate SCT: %s", err)
 	}
 	sctBytes, err := tls.Marshal(*sct)
 	if err != nil {
-		return http.StatusInternalServerError, nil, fmt.Errorf("failed to marshall SCT: %s", err)
+		return nil, fmt.Errorf("failed to marshall SCT: %s", err)
 	}
 	// We could possibly fail to issue the SCT after this but it's v. unlikely.
 	opts.RequestLog.issueSCT(ctx, sctBytes)
 	err = marshalAndWriteAddChainResponse(sct, w)
 	if err != nil {
 		// reason is logged and http status is already set
-		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write response: %s", err)
+		return nil, fmt.Errorf("failed to write response: %s", err)
 	}
 	logger.DebugExtraContext(ctx, "SCT issued", slog.String("origin", log.origin), slog.String("method", method))
-	if !index.IsDup {
-		lastSCTTimestamp.Record(ctx, otel.Clamp64(sct.Timestamp), metric.WithAttributes(originKey.String(log.origin)))
-		lastSCTIndex.Record(ctx, otel.Clamp64(index.Index), metric.WithAttributes(originKey.String(log.origin)))
-	}
-
-	return http.StatusOK, []attribute.KeyValue{duplicateKey.Bool(index.IsDup)}, nil
+	return sct, nil
 }
 
 // sctMatchesEntry checks that sctInput fields match with an entry.
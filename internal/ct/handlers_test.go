@@ -18,6 +18,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
@@ -109,13 +110,24 @@ func (f *fixedTimeSource) Add1m() {
 // It returns the log and the path to the storage directory.
 func setupTestLog(t *testing.T) (*log, string) {
 	t.Helper()
-	storageDir := t.TempDir()
 
 	sctSigner, err := setupSCTSigner(fakeSignature)
 	if err != nil {
 		t.Fatalf("Failed to create test signer: %v", err)
 	}
 
+	return setupTestLogWithSigner(t, sctSigner.signer, false)
+}
+
+// setupTestLogWithSigner creates a test TesseraCT log using a POSIX backend,
+// and signer to sign SCTs and checkpoints. If leafIndex is set, the log
+// maintains a leaf index.
+//
+// It returns the log and the path to the storage directory.
+func setupTestLogWithSigner(t *testing.T, signer crypto.Signer, leafIndex bool) (*log, string) {
+	t.Helper()
+	storageDir := t.TempDir()
+
 	roots, err := x509util.NewPEMCertPool(nil)
 	if err != nil {
 		t.Fatalf("NewPEMCertPool() err=%v", err)
@@ -130,7 +142,7 @@ func setupTestLog(t *testing.T) (*log, string) {
 		rejectUnexpired: false,
 	}
 
-	log, err := NewLog(t.Context(), origin, sctSigner.signer, cv, newPOSIXStorageFunc(t, storageDir), timeSource)
+	log, err := NewLog(t.Context(), origin, signer, cv, newPOSIXStorageFunc(t, storageDir, leafIndex), timeSource)
 	if err != nil {
 		t.Fatalf("newLog(): %v", err)
 	}
@@ -155,9 +167,10 @@ func setupTestServer(t *testing.T, log *log, path string, hOpts *HandlerOptions)
 //   - a POSIX Tessera storage driver
 //   - a Badger Tessera antispam database
 //   - a POSIX issuer storage system
+//   - a POSIX leaf index, if leafIndex is set
 //
 // It also prepares directories to host the log and the deduplication database.
-func newPOSIXStorageFunc(t *testing.T, root string) storage.CreateStorage {
+func newPOSIXStorageFunc(t *testing.T, root string, leafIndex bool) storage.CreateStorage {
 	t.Helper()
 
 	return func(ctx context.Context, signer note.Signer) (*storage.CTStorage, error) {
@@ -198,6 +211,20 @@ func newPOSIXStorageFunc(t *testing.T, root string) storage.CreateStorage {
 			AwaiterPollInterval: 20 * time.Millisecond,
 			EnablePubAwaiter:    false,
 		}
+		if leafIndex {
+			lis, err := posix.NewLeafIndexStorage(ctx, path.Join(root, "leafindex"))
+			if err != nil {
+				t.Fatalf("Failed to initialize POSIX leaf index: %v", err)
+			}
+			t.Cleanup(func() { _ = lis.Close() })
+			sopts.LeafIndexStorage = lis
+			sopts.LeafIndexFollowInterval = 50 * time.Millisecond
+		}
+		lss, err := posix.NewLogStateStorage(ctx, root)
+		if err != nil {
+			t.Fatalf("Failed to initialize POSIX log state storage: %v", err)
+		}
+		sopts.LogStateStorage = lss
 		s, err := storage.NewCTStorage(t.Context(), &sopts)
 		if err != nil {
 			t.Fatalf("Failed to initialize CTStorage: %v", err)
@@ -862,7 +889,7 @@ func TestRateLimiter(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			r := RateLimits{}
 			r.NotBefore(test.age, test.rate)
-			if got := r.AcceptNotBefore(t.Context(), chain); got != test.wantAccept {
+			if got := r.AcceptNotBefore(t.Context(), "example.com", chain); got != test.wantAccept {
 				t.Fatalf("Got %t want %t", got, test.wantAccept)
 			}
 		})
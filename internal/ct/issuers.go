@@ -0,0 +1,164 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// Reasons for which an IssuerFilter rejects chains.
+const (
+	// IssuerDenied is the reason for chains with a denied intermediate.
+	IssuerDenied = "intermediate_denied"
+	// IssuerNotAllowed is the reason for chains with an intermediate that
+	// is not allowed.
+	IssuerNotAllowed = "intermediate_not_allowed"
+)
+
+// IssuerRejectedError is returned by IssuerFilter for rejected chains.
+type IssuerRejectedError struct {
+	// Reason is either IssuerDenied or IssuerNotAllowed.
+	Reason string
+	// Fingerprint is the SHA-256 fingerprint of the rejected intermediate.
+	Fingerprint [sha256.Size]byte
+}
+
+func (e *IssuerRejectedError) Error() string {
+	switch e.Reason {
+	case IssuerDenied:
+		return fmt.Sprintf("intermediate %x is denied", e.Fingerprint)
+	default:
+		return fmt.Sprintf("intermediate %x is not allowed", e.Fingerprint)
+	}
+}
+
+// issuerLists holds the fingerprints and SPKI hashes of intermediates that
+// are allowed or denied.
+type issuerLists struct {
+	allowFingerprints map[[sha256.Size]byte]bool
+	allowSPKIs        map[[sha256.Size]byte]bool
+	denyFingerprints  map[[sha256.Size]byte]bool
+	denySPKIs         map[[sha256.Size]byte]bool
+}
+
+// IssuerFilter is a Policy allowing or denying chains based on their
+// intermediates. Intermediates are identified by the SHA-256 fingerprint of
+// their DER encoding, or by the SHA-256 hash of their DER encoded
+// SubjectPublicKeyInfo, which covers all the certificates of a key.
+//
+// A chain is rejected if any of its intermediates is denied. If the allow
+// lists are not both empty, a chain is also rejected if any of its
+// intermediates is not allowed. Leaves and roots are not checked, so chains
+// without intermediates are always accepted.
+//
+// Lists can be replaced at any time with Set.
+type IssuerFilter struct {
+	lists atomic.Pointer[issuerLists]
+	// generation is incremented every time lists is set.
+	generation atomic.Uint64
+}
+
+// ParseIssuerFilter parses an IssuerFilter from JSON:
+//
+//	{
+//	  "allow_fingerprints": ["<hex SHA-256>", ...],
+//	  "allow_spki_hashes": ["<hex SHA-256>", ...],
+//	  "deny_fingerprints": ["<hex SHA-256>", ...],
+//	  "deny_spki_hashes": ["<hex SHA-256>", ...]
+//	}
+//
+// All fields are optional.
+func ParseIssuerFilter(data []byte) (*IssuerFilter, error) {
+	f := &IssuerFilter{}
+	if err := f.Set(data); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Set atomically replaces the lists of f with the ones parsed from data, in
+// the format of ParseIssuerFilter. f is left untouched if data is invalid.
+func (f *IssuerFilter) Set(data []byte) error {
+	var cfg struct {
+		AllowFingerprints []string `json:"allow_fingerprints"`
+		AllowSPKIHashes   []string `json:"allow_spki_hashes"`
+		DenyFingerprints  []string `json:"deny_fingerprints"`
+		DenySPKIHashes    []string `json:"deny_spki_hashes"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return fmt.Errorf("failed to parse issuer filter: %v", err)
+	}
+	l := &issuerLists{}
+	for _, list := range []struct {
+		name string
+		in   []string
+		out  *map[[sha256.Size]byte]bool
+	}{
+		{"allow_fingerprints", cfg.AllowFingerprints, &l.allowFingerprints},
+		{"allow_spki_hashes", cfg.AllowSPKIHashes, &l.allowSPKIs},
+		{"deny_fingerprints", cfg.DenyFingerprints, &l.denyFingerprints},
+		{"deny_spki_hashes", cfg.DenySPKIHashes, &l.denySPKIs},
+	} {
+		*list.out = make(map[[sha256.Size]byte]bool, len(list.in))
+		for _, h := range list.in {
+			fp, err := parseFingerprint(h)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", list.name, err)
+			}
+			(*list.out)[fp] = true
+		}
+	}
+	f.lists.Store(l)
+	f.generation.Add(1)
+	return nil
+}
+
+// Generation changes every time the lists of f are set.
+func (f *IssuerFilter) Generation() uint64 {
+	return f.generation.Load()
+}
+
+// Name implements Policy.
+func (f *IssuerFilter) Name() string {
+	return "issuer_filter"
+}
+
+// Check implements Policy. It returns an *IssuerRejectedError if chain is
+// rejected.
+func (f *IssuerFilter) Check(chain []*x509.Certificate, _ bool) error {
+	l := f.lists.Load()
+	if l == nil || len(chain) < 3 {
+		return nil
+	}
+	checkAllow := len(l.allowFingerprints) > 0 || len(l.allowSPKIs) > 0
+	for _, c := range chain[1 : len(chain)-1] {
+		fp := sha256.Sum256(c.Raw)
+		spki := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+		if l.denyFingerprints[fp] || l.denySPKIs[spki] {
+			return &IssuerRejectedError{Reason: IssuerDenied, Fingerprint: fp}
+		}
+		if checkAllow && !l.allowFingerprints[fp] && !l.allowSPKIs[spki] {
+			return &IssuerRejectedError{Reason: IssuerNotAllowed, Fingerprint: fp}
+		}
+	}
+	return nil
+}
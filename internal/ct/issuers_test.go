@@ -0,0 +1,227 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+// issuerFilterJSON returns an issuer filter file content with a single list.
+func issuerFilterJSON(list string, hashes ...[sha256.Size]byte) []byte {
+	hs := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		hs = append(hs, fmt.Sprintf("%q", fmt.Sprintf("%x", h)))
+	}
+	return fmt.Appendf(nil, "{%q: [%s]}", list, strings.Join(hs, ","))
+}
+
+func TestParseIssuerFilter(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		data    string
+		wantErr string
+	}{
+		{
+			desc: "empty",
+			data: "{}",
+		},
+		{
+			desc: "all-lists",
+			data: fmt.Sprintf(`{"allow_fingerprints": [%[1]q], "allow_spki_hashes": [%[1]q], "deny_fingerprints": [%[1]q], "deny_spki_hashes": [%[1]q]}`, strings.Repeat("00", sha256.Size)),
+		},
+		{
+			desc:    "invalid-json",
+			data:    "{",
+			wantErr: "failed to parse",
+		},
+		{
+			desc:    "unknown-field",
+			data:    `{"allow": []}`,
+			wantErr: "unknown field",
+		},
+		{
+			desc:    "invalid-hex",
+			data:    `{"deny_spki_hashes": ["not-hex"]}`,
+			wantErr: "invalid deny_spki_hashes",
+		},
+		{
+			desc:    "invalid-length",
+			data:    `{"allow_fingerprints": ["0011"]}`,
+			wantErr: "invalid allow_fingerprints",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseIssuerFilter([]byte(tc.data))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseIssuerFilter()=%v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("ParseIssuerFilter()=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssuerFilterCheck(t *testing.T) {
+	chain := mustParsePEMs(t, testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM)
+	intermediateFP := sha256.Sum256(chain[1].Raw)
+	intermediateSPKI := sha256.Sum256(chain[1].RawSubjectPublicKeyInfo)
+	rootFP := sha256.Sum256(chain[2].Raw)
+	other := sha256.Sum256([]byte("other"))
+
+	for _, tc := range []struct {
+		desc       string
+		data       []byte
+		wantReason string
+	}{
+		{
+			desc: "no-lists",
+			data: []byte("{}"),
+		},
+		{
+			desc:       "deny-fingerprint",
+			data:       issuerFilterJSON("deny_fingerprints", other, intermediateFP),
+			wantReason: IssuerDenied,
+		},
+		{
+			desc:       "deny-spki",
+			data:       issuerFilterJSON("deny_spki_hashes", intermediateSPKI),
+			wantReason: IssuerDenied,
+		},
+		{
+			desc: "deny-root-ignored",
+			data: issuerFilterJSON("deny_fingerprints", rootFP),
+		},
+		{
+			desc: "allow-fingerprint",
+			data: issuerFilterJSON("allow_fingerprints", intermediateFP),
+		},
+		{
+			desc: "allow-spki",
+			data: issuerFilterJSON("allow_spki_hashes", intermediateSPKI),
+		},
+		{
+			desc:       "not-allowed",
+			data:       issuerFilterJSON("allow_fingerprints", other),
+			wantReason: IssuerNotAllowed,
+		},
+		{
+			desc:       "not-allowed-root-ignored",
+			data:       issuerFilterJSON("allow_fingerprints", rootFP),
+			wantReason: IssuerNotAllowed,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, err := ParseIssuerFilter(tc.data)
+			if err != nil {
+				t.Fatalf("ParseIssuerFilter()=%v", err)
+			}
+			err = f.Check(chain, false)
+			if tc.wantReason == "" {
+				if err != nil {
+					t.Errorf("Check()=%v, want nil", err)
+				}
+				return
+			}
+			var ire *IssuerRejectedError
+			if !errors.As(err, &ire) || ire.Reason != tc.wantReason || ire.Fingerprint != intermediateFP {
+				t.Errorf("Check()=%v, want %s rejection of the intermediate", err, tc.wantReason)
+			}
+		})
+	}
+
+	// Chains without intermediates are always accepted.
+	f, err := ParseIssuerFilter(issuerFilterJSON("allow_fingerprints", other))
+	if err != nil {
+		t.Fatalf("ParseIssuerFilter()=%v", err)
+	}
+	if err := f.Check(mustParsePEMs(t, testdata.TestCertPEM, testdata.CACertPEM), false); err != nil {
+		t.Errorf("Check() without intermediates=%v, want nil", err)
+	}
+}
+
+func TestIssuerFilterSet(t *testing.T) {
+	chain := mustParsePEMs(t, testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM)
+	f, err := ParseIssuerFilter([]byte("{}"))
+	if err != nil {
+		t.Fatalf("ParseIssuerFilter()=%v", err)
+	}
+	if err := f.Set(issuerFilterJSON("deny_fingerprints", sha256.Sum256(chain[1].Raw))); err != nil {
+		t.Fatalf("Set()=%v", err)
+	}
+	if err := f.Check(chain, false); err == nil {
+		t.Errorf("Check() after denying the intermediate=nil, want err")
+	}
+	if err := f.Set([]byte("{")); err == nil {
+		t.Errorf("Set() with invalid data=nil, want err")
+	}
+	if err := f.Check(chain, false); err == nil {
+		t.Errorf("Check() after an invalid Set()=nil, want err from the previous lists")
+	}
+	if err := f.Set([]byte("{}")); err != nil {
+		t.Fatalf("Set()=%v", err)
+	}
+	if err := f.Check(chain, false); err != nil {
+		t.Errorf("Check() after clearing the lists=%v, want nil", err)
+	}
+}
+
+func TestAddChainIssuerFilter(t *testing.T) {
+	log, _ := setupTestLog(t)
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	intermediate := mustParsePEMs(t, testdata.IntermediateFromRoot)[0]
+
+	for _, tc := range []struct {
+		desc     string
+		data     []byte
+		wantCode int
+	}{
+		{desc: "allowed", data: issuerFilterJSON("allow_spki_hashes", sha256.Sum256(intermediate.RawSubjectPublicKeyInfo)), wantCode: http.StatusOK},
+		{desc: "denied", data: issuerFilterJSON("deny_fingerprints", sha256.Sum256(intermediate.Raw)), wantCode: http.StatusBadRequest},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			f, err := ParseIssuerFilter(tc.data)
+			if err != nil {
+				t.Fatalf("ParseIssuerFilter()=%v", err)
+			}
+			cv := log.chainValidator.(chainValidator)
+			cv.policies = []Policy{f}
+			log.chainValidator = cv
+			server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), hOpts())
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+			if err != nil {
+				t.Fatalf("http.Post(): %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+		})
+	}
+}
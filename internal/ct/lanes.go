@@ -0,0 +1,305 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"container/list"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Lane is a class of submissions, with its own admission queue into storage.
+type Lane string
+
+// Lanes of submissions.
+const (
+	// LaneFresh holds certificates issued recently.
+	LaneFresh Lane = "fresh"
+	// LanePrecert holds precertificates.
+	LanePrecert Lane = "precert"
+	// LaneBackfill holds certificates issued before the backfill age, such
+	// as the ones submitted by crawlers.
+	LaneBackfill Lane = "backfill"
+	// LaneDuplicate holds duplicate submissions, whose SCT is fetched from
+	// storage. Submissions are only known to be duplicates once sequenced,
+	// so they first go through the lane of their certificate, and only then
+	// wait in this one.
+	LaneDuplicate Lane = "duplicate"
+)
+
+// allLanes lists lanes in the order they are scheduled in on ties.
+var allLanes = []Lane{LanePrecert, LaneFresh, LaneDuplicate, LaneBackfill}
+
+const (
+	// DefaultLaneBackfillAge is the default notBefore age from which
+	// certificates go to the backfill lane.
+	DefaultLaneBackfillAge = 28 * time.Hour
+	// DefaultLaneMaxQueue is the default number of submissions that can wait
+	// in each lane.
+	DefaultLaneMaxQueue = 1000
+)
+
+// errLaneFull is returned when a submission is rejected because its lane is
+// full.
+var errLaneFull = errors.New("lane is full")
+
+// LanesConfig configures Lanes.
+type LanesConfig struct {
+	// Concurrency is the number of submissions that can be in storage at
+	// once, across all lanes. When it is reached, submissions wait in their
+	// lane.
+	Concurrency int
+	// Weights sets the share of storage each lane gets when submissions are
+	// waiting in several lanes. Lanes missing from Weights get a weight of 1.
+	Weights map[Lane]int
+	// MaxQueue is the number of submissions that can wait in each lane.
+	// Submissions to a full lane are rejected. Defaults to
+	// DefaultLaneMaxQueue.
+	MaxQueue int
+	// BackfillAge is the notBefore age from which certificates go to the
+	// backfill lane. Defaults to DefaultLaneBackfillAge.
+	BackfillAge time.Duration
+}
+
+// ParseLaneWeights parses a comma separated list of <lane>=<weight>, such as
+// "precert=8,fresh=8,duplicate=2,backfill=1", into LanesConfig.Weights.
+func ParseLaneWeights(s string) (map[Lane]int, error) {
+	weights := make(map[Lane]int)
+	if s == "" {
+		return weights, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		name, w, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid lane weight %q, want <lane>=<weight>", kv)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(w))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for lane %q: %v", name, err)
+		}
+		weights[Lane(strings.TrimSpace(name))] = weight
+	}
+	return weights, nil
+}
+
+// Lanes schedules submissions into storage, so that bulk submissions can't
+// delay others.
+//
+// Each lane has its own queue. When submissions are waiting in several lanes,
+// storage slots are handed out with smooth weighted round robin, so that each
+// lane gets a share of storage proportional to its weight.
+type Lanes struct {
+	cfg    LanesConfig
+	origin string
+
+	mu sync.Mutex
+	// free is the number of storage slots nobody holds.
+	free  int
+	lanes map[Lane]*lane
+}
+
+// lane is the admission queue of a Lane.
+type lane struct {
+	weight int
+	// current is the smooth weighted round robin score of the lane.
+	current int
+	// waiters holds *laneWaiter, in arrival order.
+	waiters *list.List
+}
+
+// laneWaiter is a submission waiting for a storage slot.
+type laneWaiter struct {
+	// ready is closed when the waiter is handed a slot.
+	ready chan struct{}
+	// granted is set when the waiter is handed a slot, guarded by Lanes.mu.
+	granted bool
+}
+
+// NewLanes returns Lanes configured by cfg, for the log identified by
+// origin in metrics.
+func NewLanes(origin string, cfg LanesConfig) (*Lanes, error) {
+	once.Do(func() { setupMetrics() })
+	if cfg.MaxQueue == 0 {
+		cfg.MaxQueue = DefaultLaneMaxQueue
+	}
+	if cfg.BackfillAge == 0 {
+		cfg.BackfillAge = DefaultLaneBackfillAge
+	}
+	switch {
+	case cfg.Concurrency <= 0:
+		return nil, fmt.Errorf("invalid concurrency %d, must be positive", cfg.Concurrency)
+	case cfg.MaxQueue < 0:
+		return nil, fmt.Errorf("invalid max queue %d", cfg.MaxQueue)
+	case cfg.BackfillAge < 0:
+		return nil, fmt.Errorf("invalid backfill age %s", cfg.BackfillAge)
+	}
+	l := &Lanes{
+		cfg:    cfg,
+		origin: origin,
+		free:   cfg.Concurrency,
+		lanes:  make(map[Lane]*lane, len(allLanes)),
+	}
+	for _, name := range allLanes {
+		l.lanes[name] = &lane{weight: 1, waiters: list.New()}
+	}
+	for name, w := range cfg.Weights {
+		ln, ok := l.lanes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown lane %q", name)
+		}
+		if w <= 0 {
+			return nil, fmt.Errorf("invalid weight %d for lane %q, must be positive", w, name)
+		}
+		ln.weight = w
+	}
+	slog.InfoContext(context.Background(), "Configured priority lanes", slog.String("origin", origin), slog.Int("concurrency", cfg.Concurrency), slog.Any("weights", cfg.Weights), slog.Int("max_queue", cfg.MaxQueue), slog.Duration("backfill_age", cfg.BackfillAge))
+	return l, nil
+}
+
+// laneFor returns the lane of a new submission.
+func (l *Lanes) laneFor(chain []*x509.Certificate, isPrecert bool) Lane {
+	switch {
+	case isPrecert:
+		return LanePrecert
+	case time.Since(chain[0].NotBefore) >= l.cfg.BackfillAge:
+		return LaneBackfill
+	default:
+		return LaneFresh
+	}
+}
+
+// acquire waits for a storage slot in lane name, and returns a function
+// releasing it.
+//
+// It returns errLaneFull if the lane is full, or an error wrapping ctx.Err()
+// if ctx is done first.
+func (l *Lanes) acquire(ctx context.Context, name Lane) (func(), error) {
+	attrs := metric.WithAttributes(originKey.String(l.origin), laneKey.String(string(name)))
+	start := time.Now()
+	l.mu.Lock()
+	ln := l.lanes[name]
+	if l.free > 0 && l.idle() {
+		l.free--
+		l.mu.Unlock()
+		laneWaitDuration.Record(ctx, 0, attrs)
+		return l.release, nil
+	}
+	if ln.waiters.Len() >= l.cfg.MaxQueue {
+		l.mu.Unlock()
+		laneRejections.Add(ctx, 1, attrs)
+		return nil, errLaneFull
+	}
+	w := &laneWaiter{ready: make(chan struct{})}
+	e := ln.waiters.PushBack(w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		laneWaitDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+		return l.release, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		granted := w.granted
+		if !granted {
+			ln.waiters.Remove(e)
+		}
+		l.mu.Unlock()
+		if granted {
+			// The slot was handed over concurrently, pass it on.
+			l.release()
+		}
+		laneRejections.Add(ctx, 1, attrs)
+		return nil, fmt.Errorf("timed out waiting in the %s lane: %w", name, ctx.Err())
+	}
+}
+
+// idle returns true if no submission is waiting in any lane.
+// l.mu must be held.
+func (l *Lanes) idle() bool {
+	for _, ln := range l.lanes {
+		if ln.waiters.Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// release hands a storage slot over to the next waiting submission, or frees
+// it if there is none.
+func (l *Lanes) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next := l.next()
+	if next == nil {
+		l.free++
+		return
+	}
+	w := next.waiters.Remove(next.waiters.Front()).(*laneWaiter)
+	if next.waiters.Len() == 0 {
+		// Lanes start from scratch every time they get busy.
+		next.current = 0
+	}
+	w.granted = true
+	close(w.ready)
+}
+
+// next returns the lane to hand the next storage slot to, using smooth
+// weighted round robin over the lanes with waiting submissions, or nil if
+// there are none.
+// l.mu must be held.
+func (l *Lanes) next() *lane {
+	var best *lane
+	total := 0
+	for _, name := range allLanes {
+		ln := l.lanes[name]
+		if ln.waiters.Len() == 0 {
+			continue
+		}
+		ln.current += ln.weight
+		total += ln.weight
+		if best == nil || ln.current > best.current {
+			best = ln
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// laneRejected returns a 429 for a submission which didn't get a storage slot
+// in lane name.
+func laneRejected(w http.ResponseWriter, name Lane, err error) (int, []attribute.KeyValue, error) {
+	reason := "lane_timeout"
+	if errors.Is(err, errLaneFull) {
+		reason = "lane_full"
+	}
+	w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+	return http.StatusTooManyRequests,
+		[]attribute.KeyValue{tooManyRequestsReasonKey.String(reason), laneKey.String(string(name))},
+		withCode(ErrorCodePushbackLane, fmt.Errorf("%s: %w", http.StatusText(http.StatusTooManyRequests), err))
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+func TestParseLaneWeights(t *testing.T) {
+	got, err := ParseLaneWeights("precert=8, fresh=4,backfill=1")
+	if err != nil {
+		t.Fatalf("ParseLaneWeights(): %v", err)
+	}
+	want := map[Lane]int{LanePrecert: 8, LaneFresh: 4, LaneBackfill: 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseLaneWeights() diff (-want +got):\n%s", diff)
+	}
+	for _, s := range []string{"fresh", "fresh=high"} {
+		if _, err := ParseLaneWeights(s); err == nil {
+			t.Errorf("ParseLaneWeights(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestNewLanes(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		cfg     LanesConfig
+		wantErr string
+	}{
+		{desc: "valid", cfg: LanesConfig{Concurrency: 1, Weights: map[Lane]int{LaneFresh: 2}}},
+		{desc: "no-concurrency", cfg: LanesConfig{}, wantErr: "invalid concurrency"},
+		{desc: "unknown-lane", cfg: LanesConfig{Concurrency: 1, Weights: map[Lane]int{"slow": 2}}, wantErr: "unknown lane"},
+		{desc: "zero-weight", cfg: LanesConfig{Concurrency: 1, Weights: map[Lane]int{LaneFresh: 0}}, wantErr: "invalid weight"},
+		{desc: "negative-queue", cfg: LanesConfig{Concurrency: 1, MaxQueue: -1}, wantErr: "invalid max queue"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := NewLanes("example.com", tc.cfg)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewLanes(): %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("NewLanes() err=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLaneFor(t *testing.T) {
+	l, err := NewLanes("example.com", LanesConfig{Concurrency: 1, BackfillAge: time.Hour})
+	if err != nil {
+		t.Fatalf("NewLanes(): %v", err)
+	}
+	fresh := []*x509.Certificate{{NotBefore: time.Now().Add(-time.Minute)}}
+	old := []*x509.Certificate{{NotBefore: time.Now().Add(-2 * time.Hour)}}
+	for _, tc := range []struct {
+		desc      string
+		chain     []*x509.Certificate
+		isPrecert bool
+		want      Lane
+	}{
+		{desc: "fresh", chain: fresh, want: LaneFresh},
+		{desc: "backfill", chain: old, want: LaneBackfill},
+		{desc: "precert", chain: fresh, isPrecert: true, want: LanePrecert},
+		{desc: "old-precert", chain: old, isPrecert: true, want: LanePrecert},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := l.laneFor(tc.chain, tc.isPrecert); got != tc.want {
+				t.Errorf("laneFor()=%s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// waitForQueue waits until n submissions are waiting in lane name.
+func waitForQueue(t *testing.T, l *Lanes, name Lane, n int) {
+	t.Helper()
+	for i := 0; ; i++ {
+		l.mu.Lock()
+		got := l.lanes[name].waiters.Len()
+		l.mu.Unlock()
+		if got == n {
+			return
+		}
+		if i == 1000 {
+			t.Fatalf("%d submissions waiting in lane %s, want %d", got, name, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLanesWeightedScheduling(t *testing.T) {
+	l, err := NewLanes("example.com", LanesConfig{Concurrency: 1, Weights: map[Lane]int{LaneFresh: 2, LaneBackfill: 1}})
+	if err != nil {
+		t.Fatalf("NewLanes(): %v", err)
+	}
+	if _, err := l.acquire(t.Context(), LaneFresh); err != nil {
+		t.Fatalf("acquire(): %v", err)
+	}
+
+	granted := make(chan Lane)
+	queue := func(name Lane, n int) {
+		for i := range n {
+			go func() {
+				if _, err := l.acquire(t.Context(), name); err != nil {
+					t.Errorf("acquire(%s): %v", name, err)
+					return
+				}
+				granted <- name
+			}()
+			waitForQueue(t, l, name, i+1)
+		}
+	}
+	queue(LaneBackfill, 3)
+	queue(LaneFresh, 3)
+
+	var got []Lane
+	for range 6 {
+		l.release()
+		got = append(got, <-granted)
+	}
+	want := []Lane{LaneFresh, LaneBackfill, LaneFresh, LaneFresh, LaneBackfill, LaneBackfill}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("scheduling order diff (-want +got):\n%s", diff)
+	}
+
+	l.release()
+	if l.free != 1 {
+		t.Errorf("%d free slots once all released, want 1", l.free)
+	}
+}
+
+func TestLanesRejections(t *testing.T) {
+	l, err := NewLanes("example.com", LanesConfig{Concurrency: 1, MaxQueue: 1})
+	if err != nil {
+		t.Fatalf("NewLanes(): %v", err)
+	}
+	if _, err := l.acquire(t.Context(), LaneFresh); err != nil {
+		t.Fatalf("acquire(): %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	errc := make(chan error)
+	go func() {
+		_, err := l.acquire(ctx, LaneBackfill)
+		errc <- err
+	}()
+	waitForQueue(t, l, LaneBackfill, 1)
+
+	if _, err := l.acquire(t.Context(), LaneBackfill); !errors.Is(err, errLaneFull) {
+		t.Errorf("acquire() on a full lane: err=%v, want %v", err, errLaneFull)
+	}
+	// Other lanes have their own queue.
+	go func() { _, _ = l.acquire(ctx, LaneFresh) }()
+	waitForQueue(t, l, LaneFresh, 1)
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() with a cancelled context: err=%v, want %v", err, context.Canceled)
+	}
+	waitForQueue(t, l, LaneBackfill, 0)
+	waitForQueue(t, l, LaneFresh, 0)
+	l.release()
+	if l.free != 1 {
+		t.Errorf("%d free slots once all released, want 1", l.free)
+	}
+}
+
+func TestAddChainLanes(t *testing.T) {
+	log, _ := setupTestLog(t)
+	lanes, err := NewLanes("example.com", LanesConfig{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("NewLanes(): %v", err)
+	}
+	opts := hOpts()
+	opts.JSONErrors = true
+	opts.Deadline = 500 * time.Millisecond
+	opts.Lanes = lanes
+	server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+	defer server.Close()
+
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	post := func() *http.Response {
+		t.Helper()
+		resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+		if err != nil {
+			t.Fatalf("http.Post(): %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	// The second submission is a duplicate.
+	for range 2 {
+		if resp := post(); resp.StatusCode != http.StatusOK {
+			t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+	if lanes.free != 1 {
+		t.Errorf("%d free slots after submissions, want 1", lanes.free)
+	}
+
+	// Hold the only slot, so that submissions time out in their lane.
+	if _, err := lanes.acquire(t.Context(), LaneFresh); err != nil {
+		t.Fatalf("acquire(): %v", err)
+	}
+	resp := post()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	var rsp ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+		t.Fatalf("json.Decode(): %v", err)
+	}
+	if rsp.Code != ErrorCodePushbackLane {
+		t.Errorf("got error code %q, want %q", rsp.Code, ErrorCodePushbackLane)
+	}
+}
+
+// slotCheckingAwaiter records the number of free storage slots while
+// awaiting publication.
+type slotCheckingAwaiter struct {
+	Storage
+	lanes *Lanes
+	free  []int
+}
+
+func (a *slotCheckingAwaiter) AwaitPublication(context.Context, tessera.IndexFuture) error {
+	a.lanes.mu.Lock()
+	defer a.lanes.mu.Unlock()
+	a.free = append(a.free, a.lanes.free)
+	return nil
+}
+
+func TestAddChainLanesReleasedBeforePublication(t *testing.T) {
+	log, _ := setupTestLog(t)
+	lanes, err := NewLanes("example.com", LanesConfig{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("NewLanes(): %v", err)
+	}
+	awaiter := &slotCheckingAwaiter{Storage: log.storage, lanes: lanes}
+	log.storage = awaiter
+	opts := hOpts()
+	opts.Lanes = lanes
+	server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+	defer server.Close()
+
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+	if err != nil {
+		t.Fatalf("http.Post(): %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if want := []int{1}; !slices.Equal(awaiter.free, want) {
+		t.Errorf("free slots while awaiting publication: %v, want %v", awaiter.free, want)
+	}
+}
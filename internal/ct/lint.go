@@ -0,0 +1,353 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// LintSeverity defines what happens to submissions failing a lint.
+type LintSeverity string
+
+const (
+	// LintOff disables a lint.
+	LintOff LintSeverity = "off"
+	// LintWarn logs, and counts submissions failing a lint, but accepts them.
+	LintWarn LintSeverity = "warn"
+	// LintReject rejects submissions failing a lint.
+	LintReject LintSeverity = "reject"
+)
+
+// allLints can be used in place of a lint name to configure all lints.
+const allLints = "all"
+
+// lint checks that a certificate follows a requirement of RFC 5280, or of the
+// CA/Browser Forum Baseline Requirements.
+type lint struct {
+	name string
+	// check returns an error describing why cert does not follow the
+	// requirement.
+	check func(cert *x509.Certificate) error
+}
+
+// maxSubscriberValidity is the maximum validity period of subscriber
+// certificates, as per the CA/Browser Forum Baseline Requirements 6.3.2.
+const maxSubscriberValidity = 398 * 24 * time.Hour
+
+// lints lists all the built-in lints, in the order in which they run.
+var lints = []lint{
+	// RFC 5280 4.1.2.2.
+	{name: "serial_not_positive", check: func(c *x509.Certificate) error {
+		if c.SerialNumber.Sign() <= 0 {
+			return fmt.Errorf("serial number %v is not positive", c.SerialNumber)
+		}
+		return nil
+	}},
+	// RFC 5280 4.1.2.2.
+	{name: "serial_too_long", check: func(c *x509.Certificate) error {
+		// DER encoded positive integers need a leading 0 byte if their top bit is set.
+		if n := c.SerialNumber.BitLen()/8 + 1; n > 20 {
+			return fmt.Errorf("serial number is encoded with %d octets, want at most 20", n)
+		}
+		return nil
+	}},
+	// RFC 5280 4.1.2.5.
+	{name: "validity_inverted", check: func(c *x509.Certificate) error {
+		if c.NotAfter.Before(c.NotBefore) {
+			return fmt.Errorf("NotAfter (%v) < NotBefore (%v)", c.NotAfter, c.NotBefore)
+		}
+		return nil
+	}},
+	// RFC 5280 4.1.2.5.
+	{name: "validity_time_encoding", check: checkValidityEncoding},
+	// CA/Browser Forum Baseline Requirements 6.3.2.
+	{name: "validity_too_long", check: func(c *x509.Certificate) error {
+		if v := c.NotAfter.Sub(c.NotBefore); !c.IsCA && v > maxSubscriberValidity {
+			return fmt.Errorf("subscriber certificate is valid for %v, want at most %v", v, maxSubscriberValidity)
+		}
+		return nil
+	}},
+	// RFC 5280 4.2.
+	{name: "unknown_critical_extension", check: func(c *x509.Certificate) error {
+		for _, id := range c.UnhandledCriticalExtensions {
+			if !id.Equal(rfc6962.OIDExtensionCTPoison) {
+				return fmt.Errorf("unknown critical extension %v", id)
+			}
+		}
+		return nil
+	}},
+	// RFC 5280 4.2.1.1.
+	{name: "authority_key_id_missing", check: func(c *x509.Certificate) error {
+		if len(c.AuthorityKeyId) == 0 && !bytes.Equal(c.RawIssuer, c.RawSubject) {
+			return errors.New("certificate without an authority key identifier")
+		}
+		return nil
+	}},
+	// CA/Browser Forum Baseline Requirements 7.1.2.7.12.
+	{name: "san_missing", check: func(c *x509.Certificate) error {
+		if !c.IsCA && sanExtension(c) == nil {
+			return errors.New("subscriber certificate without a subject alternative name extension")
+		}
+		return nil
+	}},
+	// CA/Browser Forum Baseline Requirements 7.1.2.7.12.
+	{name: "san_invalid_type", check: checkSANTypes},
+	// RFC 5280 4.2.1.6.
+	{name: "san_dns_malformed", check: func(c *x509.Certificate) error {
+		for _, n := range c.DNSNames {
+			if err := checkDNSName(n); err != nil {
+				return fmt.Errorf("invalid DNS name %q: %v", n, err)
+			}
+		}
+		return nil
+	}},
+	// CA/Browser Forum Baseline Requirements 7.1.4.3.
+	{name: "cn_not_in_san", check: func(c *x509.Certificate) error {
+		cn := c.Subject.CommonName
+		if cn == "" || c.IsCA {
+			return nil
+		}
+		if slices.ContainsFunc(c.DNSNames, func(n string) bool { return strings.EqualFold(n, cn) }) {
+			return nil
+		}
+		if ip := net.ParseIP(cn); ip != nil && slices.ContainsFunc(c.IPAddresses, ip.Equal) {
+			return nil
+		}
+		return fmt.Errorf("common name %q is not a subject alternative name", cn)
+	}},
+}
+
+// oidExtensionSubjectAltName is the OID of the subject alternative name extension.
+var oidExtensionSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// sanExtension returns the subject alternative name extension of c, if any.
+func sanExtension(c *x509.Certificate) []byte {
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oidExtensionSubjectAltName) {
+			return ext.Value
+		}
+	}
+	return nil
+}
+
+// checkSANTypes returns an error if the subject alternative names of a
+// subscriber certificate are not all DNS names or IP addresses.
+func checkSANTypes(c *x509.Certificate) error {
+	v := sanExtension(c)
+	if v == nil || c.IsCA {
+		return nil
+	}
+	der := cryptobyte.String(v)
+	var names cryptobyte.String
+	if !der.ReadASN1(&names, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("malformed subject alternative name extension")
+	}
+	for !names.Empty() {
+		var name cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !names.ReadAnyASN1(&name, &tag) {
+			return errors.New("malformed subject alternative name")
+		}
+		switch tag {
+		case cryptobyte_asn1.Tag(2).ContextSpecific(), cryptobyte_asn1.Tag(7).ContextSpecific():
+			// dNSName, iPAddress.
+		default:
+			return fmt.Errorf("subject alternative name of type [%d], want dNSName or iPAddress", tag&0x1f)
+		}
+	}
+	return nil
+}
+
+// checkDNSName returns an error if n is not a preferred name syntax DNS
+// name, optionally with a wildcard first label.
+func checkDNSName(n string) error {
+	if len(n) == 0 || len(n) > 253 {
+		return fmt.Errorf("%d characters", len(n))
+	}
+	labels := strings.Split(n, ".")
+	for i, l := range labels {
+		if i == 0 && l == "*" && len(labels) > 1 {
+			continue
+		}
+		if len(l) == 0 || len(l) > 63 {
+			return fmt.Errorf("label %d has %d characters", i, len(l))
+		}
+		if l[0] == '-' || l[len(l)-1] == '-' {
+			return fmt.Errorf("label %q starts or ends with a hyphen", l)
+		}
+		for _, r := range l {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-') {
+				return fmt.Errorf("label %q has invalid character %q", l, r)
+			}
+		}
+	}
+	return nil
+}
+
+// checkValidityEncoding returns an error if the validity dates of c are not
+// encoded as UTCTime through 2049, and as GeneralizedTime from 2050.
+func checkValidityEncoding(c *x509.Certificate) error {
+	tbs := cryptobyte.String(c.RawTBSCertificate)
+	var validity cryptobyte.String
+	if !tbs.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) ||
+		!tbs.SkipASN1(cryptobyte_asn1.INTEGER) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!tbs.ReadASN1(&validity, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("malformed TBSCertificate")
+	}
+	for _, t := range []struct {
+		name string
+		time time.Time
+	}{{"NotBefore", c.NotBefore}, {"NotAfter", c.NotAfter}} {
+		want := cryptobyte_asn1.UTCTime
+		if t.time.Year() >= 2050 {
+			want = cryptobyte_asn1.GeneralizedTime
+		}
+		var v cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !validity.ReadAnyASN1(&v, &tag) {
+			return errors.New("malformed validity")
+		}
+		if tag != want {
+			return fmt.Errorf("%s (%v) encoded with tag %d, want %d", t.name, t.time, tag, want)
+		}
+	}
+	return nil
+}
+
+// LintNames returns the names of all the built-in lints.
+func LintNames() []string {
+	names := make([]string, 0, len(lints))
+	for _, l := range lints {
+		names = append(names, l.name)
+	}
+	return names
+}
+
+// ParseLintSeverities parses a comma separated list of <lint>=<severity>,
+// such as "all=warn,serial_not_positive=reject". The special "all" lint
+// name sets the severity of all lints, and can be overridden by
+// subsequent entries.
+func ParseLintSeverities(s string) (map[string]LintSeverity, error) {
+	sevs := make(map[string]LintSeverity)
+	if s == "" {
+		return sevs, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		name, sev, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid lint severity %q, want <lint>=<severity>", kv)
+		}
+		sevs[strings.TrimSpace(name)] = LintSeverity(strings.TrimSpace(sev))
+	}
+	return sevs, nil
+}
+
+// LintFinding is a lint that a certificate fails.
+type LintFinding struct {
+	Lint     string
+	Severity LintSeverity
+	Err      error
+}
+
+// Linter runs the built-in lints on submitted certificates.
+type Linter struct {
+	// lints are the lints to run, with their severity.
+	lints []lint
+	sevs  []LintSeverity
+}
+
+// NewLinter returns a Linter running lints with the given severities.
+// Lints that are not in severities are off, unless severities sets the
+// severity of "all" lints.
+func NewLinter(severities map[string]LintSeverity) (*Linter, error) {
+	def := LintOff
+	if s, ok := severities[allLints]; ok {
+		def = s
+	}
+	for name, s := range severities {
+		if name != allLints && !slices.Contains(LintNames(), name) {
+			return nil, fmt.Errorf("unknown lint %q, want one of %v", name, LintNames())
+		}
+		switch s {
+		case LintOff, LintWarn, LintReject:
+		default:
+			return nil, fmt.Errorf("invalid severity %q for lint %q, want one of %v", s, name, []LintSeverity{LintOff, LintWarn, LintReject})
+		}
+	}
+	l := &Linter{}
+	for _, lt := range lints {
+		s, ok := severities[lt.name]
+		if !ok {
+			s = def
+		}
+		if s == LintOff {
+			continue
+		}
+		l.lints = append(l.lints, lt)
+		l.sevs = append(l.sevs, s)
+	}
+	return l, nil
+}
+
+// Lint returns the lints that cert fails. It is safe to call on a nil Linter,
+// which does not run any lint.
+func (l *Linter) Lint(cert *x509.Certificate) []LintFinding {
+	if l == nil {
+		return nil
+	}
+	var fs []LintFinding
+	for i, lt := range l.lints {
+		if err := lt.check(cert); err != nil {
+			fs = append(fs, LintFinding{Lint: lt.name, Severity: l.sevs[i], Err: err})
+		}
+	}
+	return fs
+}
+
+// checkLints runs the linter on a submitted leaf, records the lints it fails,
+// and returns an error for the first one that has a reject severity.
+func checkLints(ctx context.Context, opts *HandlerOptions, origin string, leaf *x509.Certificate) (int, []attribute.KeyValue, error) {
+	var rejected *LintFinding
+	for _, f := range opts.Linter.Lint(leaf) {
+		opts.RequestLog.lintFinding(ctx, f)
+		lintFindings.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), lintKey.String(f.Lint), lintSeverityKey.String(string(f.Severity))))
+		if f.Severity == LintReject && rejected == nil {
+			rejected = &f
+		}
+	}
+	if rejected != nil {
+		return http.StatusBadRequest, []attribute.KeyValue{lintKey.String(rejected.Lint)}, withCode(ErrorCodeLintRejected, fmt.Errorf("certificate failed lint %s: %v", rejected.Lint, rejected.Err))
+	}
+	return http.StatusOK, nil, nil
+}
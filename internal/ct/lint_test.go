@@ -0,0 +1,290 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// newLintCert returns a subscriber certificate which passes all lints, after
+// modify has been applied to its template.
+func newLintCert(t *testing.T, modify func(*x509.Certificate)) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey(): %v", err)
+	}
+	issuer := &x509.Certificate{Subject: pkix.Name{CommonName: "issuer"}, SubjectKeyId: []byte{1, 2, 3, 4}}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		DNSNames:     []string{"example.com", "*.example.com"},
+		IPAddresses:  []net.IP{net.IPv4(192, 0, 2, 1)},
+	}
+	if modify != nil {
+		modify(tmpl)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, key.Public(), key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate(): %v", err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(): %v", err)
+	}
+	return c
+}
+
+// findingNames returns the names of the lints that cert fails.
+func findingNames(t *testing.T, cert *x509.Certificate) []string {
+	t.Helper()
+	l, err := NewLinter(map[string]LintSeverity{allLints: LintWarn})
+	if err != nil {
+		t.Fatalf("NewLinter(): %v", err)
+	}
+	var names []string
+	for _, f := range l.Lint(cert) {
+		names = append(names, f.Lint)
+	}
+	return names
+}
+
+func TestLints(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		modify func(*x509.Certificate)
+		want   []string
+	}{
+		{desc: "clean"},
+		{
+			desc:   "long-serial",
+			modify: func(c *x509.Certificate) { c.SerialNumber = new(big.Int).Lsh(big.NewInt(1), 159) },
+			want:   []string{"serial_too_long"},
+		},
+		{
+			desc:   "longest-serial",
+			modify: func(c *x509.Certificate) { c.SerialNumber = new(big.Int).Lsh(big.NewInt(1), 158) },
+		},
+		{
+			desc:   "inverted-validity",
+			modify: func(c *x509.Certificate) { c.NotAfter = c.NotBefore.Add(-time.Hour) },
+			want:   []string{"validity_inverted"},
+		},
+		{
+			desc:   "long-validity",
+			modify: func(c *x509.Certificate) { c.NotAfter = c.NotBefore.Add(400 * 24 * time.Hour) },
+			want:   []string{"validity_too_long"},
+		},
+		{
+			desc: "long-validity-ca",
+			modify: func(c *x509.Certificate) {
+				c.NotAfter = c.NotBefore.Add(400 * 24 * time.Hour)
+				c.IsCA, c.BasicConstraintsValid = true, true
+			},
+		},
+		{
+			desc: "unknown-critical-extension",
+			modify: func(c *x509.Certificate) {
+				c.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: asn1.NullBytes}}
+			},
+			want: []string{"unknown_critical_extension"},
+		},
+		{
+			desc: "poison",
+			modify: func(c *x509.Certificate) {
+				c.ExtraExtensions = []pkix.Extension{{Id: rfc6962.OIDExtensionCTPoison, Critical: true, Value: asn1.NullBytes}}
+			},
+		},
+		{
+			desc:   "no-san",
+			modify: func(c *x509.Certificate) { c.DNSNames, c.IPAddresses, c.Subject.CommonName = nil, nil, "" },
+			want:   []string{"san_missing"},
+		},
+		{
+			desc:   "email-san",
+			modify: func(c *x509.Certificate) { c.EmailAddresses = []string{"a@example.com"} },
+			want:   []string{"san_invalid_type"},
+		},
+		{
+			desc:   "uri-san",
+			modify: func(c *x509.Certificate) { c.URIs = []*url.URL{{Scheme: "https", Host: "example.com"}} },
+			want:   []string{"san_invalid_type"},
+		},
+		{
+			desc:   "malformed-dns",
+			modify: func(c *x509.Certificate) { c.DNSNames = append(c.DNSNames, "-bad.example.com") },
+			want:   []string{"san_dns_malformed"},
+		},
+		{
+			desc:   "cn-not-in-san",
+			modify: func(c *x509.Certificate) { c.Subject.CommonName = "other.example.com" },
+			want:   []string{"cn_not_in_san"},
+		},
+		{
+			desc:   "ip-cn",
+			modify: func(c *x509.Certificate) { c.Subject.CommonName = "192.0.2.1" },
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := findingNames(t, newLintCert(t, tc.modify)); !slices.Equal(got, tc.want) {
+				t.Errorf("got findings %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLintSelfSignedWithoutAKID(t *testing.T) {
+	// Certificates issued by newLintCert have an authority key ID.
+	c := newLintCert(t, nil)
+	c.AuthorityKeyId = nil
+	if got := findingNames(t, c); !slices.Equal(got, []string{"authority_key_id_missing"}) {
+		t.Errorf("got findings %v, want [authority_key_id_missing]", got)
+	}
+	c.RawIssuer = c.RawSubject
+	if got := findingNames(t, c); len(got) != 0 {
+		t.Errorf("self-issued certificate: got findings %v, want none", got)
+	}
+}
+
+func TestCheckValidityEncoding(t *testing.T) {
+	// tbs returns a TBSCertificate with validity dates encoded with the given tags.
+	tbs := func(notBefore, notAfter cryptobyte_asn1.Tag) []byte {
+		var b cryptobyte.Builder
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) { b.AddASN1Int64(2) })
+			b.AddASN1Int64(1)
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {})
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {})
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1(notBefore, func(b *cryptobyte.Builder) {})
+				b.AddASN1(notAfter, func(b *cryptobyte.Builder) {})
+			})
+		})
+		return b.BytesOrPanic()
+	}
+	t2049 := time.Date(2049, 12, 31, 0, 0, 0, 0, time.UTC)
+	t2050 := time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		desc         string
+		notBefore    cryptobyte_asn1.Tag
+		notAfter     cryptobyte_asn1.Tag
+		notAfterTime time.Time
+		wantErr      bool
+	}{
+		{desc: "utc-utc", notBefore: cryptobyte_asn1.UTCTime, notAfter: cryptobyte_asn1.UTCTime, notAfterTime: t2049},
+		{desc: "utc-generalized", notBefore: cryptobyte_asn1.UTCTime, notAfter: cryptobyte_asn1.GeneralizedTime, notAfterTime: t2050},
+		{desc: "generalized-before-2050", notBefore: cryptobyte_asn1.GeneralizedTime, notAfter: cryptobyte_asn1.UTCTime, notAfterTime: t2049, wantErr: true},
+		{desc: "utc-after-2050", notBefore: cryptobyte_asn1.UTCTime, notAfter: cryptobyte_asn1.UTCTime, notAfterTime: t2050, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &x509.Certificate{RawTBSCertificate: tbs(tc.notBefore, tc.notAfter), NotBefore: t2049, NotAfter: tc.notAfterTime}
+			if err := checkValidityEncoding(c); (err != nil) != tc.wantErr {
+				t.Errorf("checkValidityEncoding()=%v, want err %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewLinter(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		sevs      string
+		wantLints []string
+		wantErr   string
+	}{
+		{desc: "empty"},
+		{desc: "one", sevs: "san_missing=warn", wantLints: []string{"san_missing"}},
+		{desc: "all", sevs: "all=reject", wantLints: LintNames()},
+		{desc: "all-but-one", sevs: "all=warn,serial_not_positive=off", wantLints: LintNames()[1:]},
+		{desc: "unknown-lint", sevs: "nope=warn", wantErr: "unknown lint"},
+		{desc: "unknown-severity", sevs: "san_missing=error", wantErr: "invalid severity"},
+		{desc: "malformed", sevs: "san_missing", wantErr: "invalid lint severity"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			sevs, err := ParseLintSeverities(tc.sevs)
+			if err == nil {
+				var l *Linter
+				if l, err = NewLinter(sevs); err == nil {
+					var got []string
+					for _, lt := range l.lints {
+						got = append(got, lt.name)
+					}
+					if !slices.Equal(got, tc.wantLints) {
+						t.Errorf("got lints %v, want %v", got, tc.wantLints)
+					}
+				}
+			}
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("got err %v, want nil", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("got err %v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddChainLints(t *testing.T) {
+	log, _ := setupTestLog(t)
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	// The test leaf passes all built-in lints.
+	fail := lint{name: "fail", check: func(*x509.Certificate) error { return errors.New("failed") }}
+
+	for _, tc := range []struct {
+		severity LintSeverity
+		wantCode int
+	}{
+		{severity: LintWarn, wantCode: http.StatusOK},
+		{severity: LintReject, wantCode: http.StatusBadRequest},
+	} {
+		t.Run(string(tc.severity), func(t *testing.T) {
+			opts := hOpts()
+			opts.Linter = &Linter{lints: []lint{fail}, sevs: []LintSeverity{tc.severity}}
+			server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+			if err != nil {
+				t.Fatalf("http.Post(): %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.wantCode {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+		})
+	}
+}
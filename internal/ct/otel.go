@@ -31,12 +31,24 @@ var (
 )
 
 var (
-	codeKey                  = attribute.Key("http.response.status_code")
-	operationKey             = attribute.Key("tesseract.operation")
-	originKey                = attribute.Key("tesseract.origin")
-	duplicateKey             = attribute.Key("tesseract.duplicate")
-	tooManyRequestsReasonKey = attribute.Key("tesseract.too_many_requests")
-	rateLimitReasonKey       = attribute.Key("tesseract.rate_limit")
+	codeKey                   = attribute.Key("http.response.status_code")
+	operationKey              = attribute.Key("tesseract.operation")
+	originKey                 = attribute.Key("tesseract.origin")
+	duplicateKey              = attribute.Key("tesseract.duplicate")
+	tooManyRequestsReasonKey  = attribute.Key("tesseract.too_many_requests")
+	rateLimitReasonKey        = attribute.Key("tesseract.rate_limit")
+	rootsReloadResultKey      = attribute.Key("tesseract.roots.reload.result")
+	logStateKey               = attribute.Key("tesseract.log.state")
+	lintKey                   = attribute.Key("tesseract.lint")
+	lintSeverityKey           = attribute.Key("tesseract.lint.severity")
+	issuerRejectionReasonKey  = attribute.Key("tesseract.issuers.rejection_reason")
+	pathCacheResultKey        = attribute.Key("tesseract.path_cache.result")
+	dedupCacheResultKey       = attribute.Key("tesseract.dedup_cache.result")
+	precertRejectionReasonKey = attribute.Key("tesseract.precert.rejection_reason")
+	validateChainAcceptedKey  = attribute.Key("tesseract.validate_chain.accepted")
+	laneKey                   = attribute.Key("tesseract.lane")
+	clientIDKey               = attribute.Key("tesseract.client_id")
+	authResultKey             = attribute.Key("tesseract.auth.result")
 )
 
 func mustCreate[T any](t T, err error) T {
@@ -0,0 +1,129 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// pathKey identifies the issuers of a submitted chain, and the trusted roots
+// they were verified with.
+type pathKey struct {
+	// issuers is the SHA-256 hash of the concatenated fingerprints of the
+	// certificates following the leaf in the submitted chain.
+	issuers [sha256.Size]byte
+	// generation is the generation of the trusted roots pool.
+	generation uint64
+}
+
+// verifiedPath is a path from the issuer of a leaf to a trusted root.
+type verifiedPath struct {
+	// path starts with the issuer of the leaf, and ends with a trusted root.
+	path []*x509.Certificate
+	// constraint is the constraint of the root, if any. It depends on the
+	// leaf, so it is checked on every cache hit.
+	constraint func([]*x509.Certificate) error
+}
+
+// VerifiedPathCache is a bounded LRU cache of issuer to root paths that have
+// already been verified. It lets chain validation only check the leaf of
+// chains sharing their issuers with a chain that was verified earlier.
+//
+// Entries are keyed by the fingerprints of the submitted issuers and by the
+// generation of the trusted roots, so that paths verified with a different
+// set of roots, or roots constraints, are never used.
+type VerifiedPathCache struct {
+	origin string
+	paths  *lru[pathKey, *verifiedPath]
+}
+
+// NewVerifiedPathCache returns a cache holding up to size paths. origin is
+// only used to attribute metrics.
+func NewVerifiedPathCache(origin string, size int) *VerifiedPathCache {
+	once.Do(func() { setupMetrics() })
+	return &VerifiedPathCache{
+		origin: origin,
+		paths:  newLRU[pathKey, *verifiedPath](size),
+	}
+}
+
+// key returns the key of the issuers of a chain, for a roots generation.
+func (c *VerifiedPathCache) key(issuers []*x509.Certificate, generation uint64) pathKey {
+	h := sha256.New()
+	for _, cert := range issuers {
+		fp := sha256.Sum256(cert.Raw)
+		h.Write(fp[:])
+	}
+	k := pathKey{generation: generation}
+	h.Sum(k.issuers[:0])
+	return k
+}
+
+// get returns the path cached under k, or nil if there is none.
+func (c *VerifiedPathCache) get(k pathKey) *verifiedPath {
+	p, _ := c.paths.get(k)
+	return p
+}
+
+// add caches p under k.
+func (c *VerifiedPathCache) add(k pathKey, p *verifiedPath) {
+	c.paths.add(k, p)
+}
+
+// recordLookup counts cache lookups, by result.
+func (c *VerifiedPathCache) recordLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	pathCacheLookups.Add(context.Background(), 1, metric.WithAttributes(originKey.String(c.origin), pathCacheResultKey.String(result)))
+}
+
+// verifyLeaf returns the chain made of leaf and p if leaf is signed by the
+// issuer of p, and if the root constraint accepts it. It returns nil
+// otherwise, in which case the chain has to be fully verified.
+//
+// Like lax509.Verify, it doesn't check the validity period of the leaf, nor
+// its key usages.
+func (p *verifiedPath) verifyLeaf(leaf *x509.Certificate) []*x509.Certificate {
+	// Leaves sharing a subject with their issuers need the loop detection
+	// of lax509.Verify.
+	for _, cert := range p.path {
+		if string(leaf.RawSubject) == string(cert.RawSubject) {
+			return nil
+		}
+	}
+	if string(leaf.RawIssuer) != string(p.path[0].RawSubject) {
+		return nil
+	}
+	// SHA-1 signatures are rejected here, chains using them are fully
+	// verified if they are accepted.
+	if err := leaf.CheckSignatureFrom(p.path[0]); err != nil {
+		return nil
+	}
+	chain := make([]*x509.Certificate, 0, len(p.path)+1)
+	chain = append(chain, leaf)
+	chain = append(chain, p.path...)
+	if p.constraint != nil {
+		if err := p.constraint(chain[:len(chain)-1]); err != nil {
+			return nil
+		}
+	}
+	return chain
+}
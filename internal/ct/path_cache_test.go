@@ -0,0 +1,206 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+func TestVerifiedPathCacheLRU(t *testing.T) {
+	c := NewVerifiedPathCache("example.com", 2)
+	certs := mustParsePEMs(t, testdata.IntermediateFromRoot, testdata.PreIntermediateFromRoot, testdata.CACertPEM)
+	keys := make([]pathKey, len(certs))
+	for i, cert := range certs {
+		keys[i] = c.key([]*x509.Certificate{cert}, 1)
+	}
+	if keys[0] == keys[1] {
+		t.Fatalf("key() returned the same key for different issuers")
+	}
+	if k := c.key(certs[:1], 2); k == keys[0] {
+		t.Fatalf("key() returned the same key for different generations")
+	}
+
+	c.add(keys[0], &verifiedPath{path: certs[:1]})
+	c.add(keys[1], &verifiedPath{path: certs[1:2]})
+	// Use the first path, so that the second one is evicted.
+	if p := c.get(keys[0]); p == nil || p.path[0] != certs[0] {
+		t.Errorf("get(keys[0])=%v, want the first path", p)
+	}
+	c.add(keys[2], &verifiedPath{path: certs[2:]})
+	for i, want := range []bool{true, false, true} {
+		if got := c.get(keys[i]) != nil; got != want {
+			t.Errorf("get(keys[%d]) found=%t, want %t", i, got, want)
+		}
+	}
+	if got := c.paths.len(); got != 2 {
+		t.Errorf("cache has %d paths, want 2", got)
+	}
+}
+
+func TestValidateWithPathCache(t *testing.T) {
+	newValidator := func(t *testing.T) (chainValidator, *x509util.PEMCertPool) {
+		t.Helper()
+		roots, err := x509util.NewPEMCertPool(nil)
+		if err != nil {
+			t.Fatalf("NewPEMCertPool() err=%v", err)
+		}
+		roots.AddCerts(mustParsePEMs(t, testdata.CACertPEM))
+		return chainValidator{trustedRoots: roots, pathCache: NewVerifiedPathCache("example.com", 10)}, roots
+	}
+	// Cache hits return the cached issuers, rather than the submitted ones.
+	validate := func(t *testing.T, cv chainValidator, isPrecert bool, pems ...string) (chain []*x509.Certificate, hit bool, err error) {
+		t.Helper()
+		submitted := mustParsePEMs(t, pems...)
+		chain, err = cv.Validate(submitted, isPrecert)
+		if err != nil {
+			return nil, false, err
+		}
+		return chain, chain[1] != submitted[1], nil
+	}
+
+	t.Run("hits", func(t *testing.T) {
+		cv, _ := newValidator(t)
+		for i, tc := range []struct {
+			pems      []string
+			isPrecert bool
+			wantHit   bool
+		}{
+			{pems: []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot}},
+			{pems: []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot}, wantHit: true},
+			{pems: []string{testdata.PreCertFromIntermediate, testdata.IntermediateFromRoot}, isPrecert: true, wantHit: true},
+			// The root is part of the key.
+			{pems: []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}},
+			{pems: []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}, wantHit: true},
+		} {
+			chain, hit, err := validate(t, cv, tc.isPrecert, tc.pems...)
+			if err != nil {
+				t.Fatalf("%d: Validate()=%v", i, err)
+			}
+			if len(chain) != 3 {
+				t.Errorf("%d: Validate() returned %d certs, want 3", i, len(chain))
+			}
+			if hit != tc.wantHit {
+				t.Errorf("%d: Validate() hit=%t, want %t", i, hit, tc.wantHit)
+			}
+		}
+	})
+
+	t.Run("leaf-not-signed-by-issuer", func(t *testing.T) {
+		cv, _ := newValidator(t)
+		if _, _, err := validate(t, cv, false, testdata.CertFromIntermediate, testdata.IntermediateFromRoot); err != nil {
+			t.Fatalf("Validate()=%v", err)
+		}
+		if _, _, err := validate(t, cv, false, testdata.TestCertPEM, testdata.IntermediateFromRoot); err == nil {
+			t.Errorf("Validate() with a leaf not issued by the cached issuer succeeded, want err")
+		}
+	})
+
+	t.Run("roots-changed", func(t *testing.T) {
+		cv, roots := newValidator(t)
+		if _, _, err := validate(t, cv, false, testdata.CertFromIntermediate, testdata.IntermediateFromRoot); err != nil {
+			t.Fatalf("Validate()=%v", err)
+		}
+		if _, _, err := roots.SetCerts(mustParsePEMs(t, testdata.FakeCACertPEM)); err != nil {
+			t.Fatalf("SetCerts()=%v", err)
+		}
+		if _, _, err := validate(t, cv, false, testdata.CertFromIntermediate, testdata.IntermediateFromRoot); err == nil {
+			t.Errorf("Validate() after the root was removed succeeded, want err")
+		}
+	})
+
+	t.Run("name-constraints", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("ecdsa.GenerateKey(): %v", err)
+		}
+		rootTmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true}
+		root := issueTestCert(t, rootTmpl, rootTmpl, key)
+		intermediate := issueTestCert(t, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "intermediate"}, IsCA: true, BasicConstraintsValid: true, PermittedDNSDomains: []string{"example.com"}}, root, key)
+		permitted := issueTestCert(t, &x509.Certificate{SerialNumber: big.NewInt(3), DNSNames: []string{"www.example.com"}}, intermediate, key)
+		notPermitted := issueTestCert(t, &x509.Certificate{SerialNumber: big.NewInt(4), DNSNames: []string{"www.example.org"}}, intermediate, key)
+
+		roots, err := x509util.NewPEMCertPool(nil)
+		if err != nil {
+			t.Fatalf("NewPEMCertPool() err=%v", err)
+		}
+		roots.AddCerts([]*x509.Certificate{root})
+		cv := chainValidator{trustedRoots: roots, pathCache: NewVerifiedPathCache("example.com", 10), enforceNameConstraints: true}
+		if _, err := cv.Validate([]*x509.Certificate{permitted, intermediate}, false); err != nil {
+			t.Fatalf("Validate()=%v", err)
+		}
+		// The path is cached, but name constraints still apply to the leaf.
+		if _, err := cv.Validate([]*x509.Certificate{notPermitted, intermediate}, false); err == nil {
+			t.Errorf("Validate() with a name not permitted by the cached issuer succeeded, want err")
+		}
+	})
+
+	t.Run("root-constraint", func(t *testing.T) {
+		cv, roots := newValidator(t)
+		if _, _, err := validate(t, cv, false, testdata.CertFromIntermediate, testdata.IntermediateFromRoot); err != nil {
+			t.Fatalf("Validate()=%v", err)
+		}
+		root := mustParsePEMs(t, testdata.CACertPEM)[0]
+		reject := func([]*x509.Certificate) error { return errors.New("distrusted") }
+		roots.SetConstraints(map[[sha256.Size]byte]func([]*x509.Certificate) error{sha256.Sum256(root.Raw): reject})
+		if _, _, err := validate(t, cv, false, testdata.CertFromIntermediate, testdata.IntermediateFromRoot); err == nil {
+			t.Errorf("Validate() with a rejecting root constraint succeeded, want err")
+		}
+	})
+}
+
+// BenchmarkValidateChainCached to see how much the verified path cache saves,
+// compared to BenchmarkValidateChain.
+//
+// go test --bench=BenchmarkValidateChain -run='^$' ./internal/ct -v=1 --benchtime=3s
+// goos: linux
+// goarch: amd64
+// pkg: github.com/transparency-dev/tesseract/internal/ct
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkValidateChain              1548           2363290 ns/op
+// BenchmarkValidateChainCached        4648            808400 ns/op
+func BenchmarkValidateChainCached(b *testing.B) {
+	rawChain := pemsToDERChain(b, []string{testdata.PreCertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM})
+	chain, err := parseChain(rawChain)
+	if err != nil {
+		b.Fatalf("parseChain: %v", err)
+	}
+	r, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		b.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	r.AddCerts([]*x509.Certificate{chain[2]})
+	cv := chainValidator{
+		trustedRoots: r,
+		pathCache:    NewVerifiedPathCache("example.com", 10),
+	}
+
+	for b.Loop() {
+		_, err := cv.Validate(chain, true)
+		if err != nil {
+			b.Fatalf("Validate: %v", err)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+// Policy decides whether a log accepts a certificate chain.
+//
+// Built-in policies, configured by chainValidator fields, only look at the
+// leaf and run before path building, on the submitted chain. Other policies
+// run after path building, on the verified chain: it starts with the leaf,
+// and ends with a trusted root.
+type Policy interface {
+	// Name identifies the policy.
+	Name() string
+	// Check returns an error if the log must not accept chain. isPrecert is
+	// set for chains submitted to add-pre-chain.
+	Check(chain []*x509.Certificate, isPrecert bool) error
+}
+
+// PolicyRejectedError is returned when a policy rejects a chain.
+type PolicyRejectedError struct {
+	// Policy is the name of the policy which rejected the chain.
+	Policy string
+	Err    error
+}
+
+func (e *PolicyRejectedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PolicyRejectedError) Unwrap() error {
+	return e.Err
+}
+
+// checkPolicies runs policies in order, and returns the first error.
+func checkPolicies(policies []Policy, chain []*x509.Certificate, isPrecert bool) error {
+	for _, p := range policies {
+		if err := p.Check(chain, isPrecert); err != nil {
+			return &PolicyRejectedError{Policy: p.Name(), Err: err}
+		}
+	}
+	return nil
+}
+
+// builtinPolicies returns the policies enabled by the chainValidator fields,
+// in the order in which they have to run.
+func (cv chainValidator) builtinPolicies() []Policy {
+	var ps []Policy
+	if cv.notAfterStart != nil || cv.notAfterLimit != nil {
+		ps = append(ps, notAfterPolicy{start: cv.notAfterStart, limit: cv.notAfterLimit})
+	}
+	if cv.rejectExpired || cv.rejectUnexpired {
+		now := cv.currentTime
+		if now.IsZero() {
+			now = time.Now()
+		}
+		ps = append(ps, expiryPolicy{now: now, rejectExpired: cv.rejectExpired, rejectUnexpired: cv.rejectUnexpired})
+	}
+	if len(cv.rejectExtIds) > 0 {
+		ps = append(ps, newRejectExtensionsPolicy(cv.rejectExtIds))
+	}
+	if len(cv.extKeyUsages) > 0 {
+		ps = append(ps, newExtKeyUsagesPolicy(cv.extKeyUsages))
+	}
+	return ps
+}
+
+// notAfterPolicy rejects leaves with a NotAfter date outside of [start, limit).
+// A nil bound means no bound.
+type notAfterPolicy struct {
+	start *time.Time
+	limit *time.Time
+}
+
+func (p notAfterPolicy) Name() string { return "not_after" }
+
+func (p notAfterPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	cert := chain[0]
+	if p.start != nil && cert.NotAfter.Before(*p.start) {
+		return fmt.Errorf("certificate NotAfter (%v) < %v", cert.NotAfter, *p.start)
+	}
+	if p.limit != nil && !cert.NotAfter.Before(*p.limit) {
+		return fmt.Errorf("certificate NotAfter (%v) >= %v", cert.NotAfter, *p.limit)
+	}
+	return nil
+}
+
+// expiryPolicy rejects expired, or unexpired leaves.
+type expiryPolicy struct {
+	now             time.Time
+	rejectExpired   bool
+	rejectUnexpired bool
+}
+
+func (p expiryPolicy) Name() string { return "expiry" }
+
+func (p expiryPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	expired := p.now.After(chain[0].NotAfter)
+	if p.rejectExpired && expired {
+		return errors.New("rejecting expired certificate")
+	}
+	if p.rejectUnexpired && !expired {
+		return errors.New("rejecting unexpired certificate")
+	}
+	return nil
+}
+
+// rejectExtensionsPolicy rejects leaves with any of the given extensions.
+type rejectExtensionsPolicy struct {
+	ids map[string]bool
+}
+
+func newRejectExtensionsPolicy(ids []asn1.ObjectIdentifier) rejectExtensionsPolicy {
+	p := rejectExtensionsPolicy{ids: make(map[string]bool, len(ids))}
+	for _, id := range ids {
+		p.ids[id.String()] = true
+	}
+	return p
+}
+
+func (p rejectExtensionsPolicy) Name() string { return "reject_extensions" }
+
+func (p rejectExtensionsPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	for idx, ext := range chain[0].Extensions {
+		extOid := ext.Id.String()
+		if p.ids[extOid] {
+			return fmt.Errorf("rejecting certificate containing extension %v at index %d", extOid, idx)
+		}
+	}
+	return nil
+}
+
+// extKeyUsagesPolicy rejects leaves without any of the given EKUs.
+type extKeyUsagesPolicy struct {
+	ekus   []x509.ExtKeyUsage
+	accept map[x509.ExtKeyUsage]bool
+}
+
+func newExtKeyUsagesPolicy(ekus []x509.ExtKeyUsage) extKeyUsagesPolicy {
+	p := extKeyUsagesPolicy{ekus: ekus, accept: make(map[x509.ExtKeyUsage]bool, len(ekus))}
+	for _, eku := range ekus {
+		p.accept[eku] = true
+	}
+	return p
+}
+
+func (p extKeyUsagesPolicy) Name() string { return "ext_key_usages" }
+
+func (p extKeyUsagesPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	for _, certEKU := range chain[0].ExtKeyUsage {
+		if p.accept[certEKU] {
+			return nil
+		}
+	}
+	return fmt.Errorf("rejecting certificate without EKU in %v", p.ekus)
+}
+
+// issuersPolicy only accepts leaves issued by one of the given issuers.
+//
+// The issuer of a precertificate issued by a precertificate signing
+// certificate is the issuer of the precertificate signing certificate.
+type issuersPolicy struct {
+	fingerprints map[[sha256.Size]byte]bool
+}
+
+func (p issuersPolicy) Name() string { return "issuers" }
+
+func (p issuersPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	// The leaf is a trusted root if chain only has one certificate.
+	issuer := chain[len(chain)-1]
+	if len(chain) > 1 {
+		issuer = chain[1]
+	}
+	if len(chain) > 2 && x509util.IsPreIssuer(issuer) {
+		issuer = chain[2]
+	}
+	if fp := sha256.Sum256(issuer.Raw); !p.fingerprints[fp] {
+		return fmt.Errorf("policy %s: issuer %x is not allowed", p.Name(), fp)
+	}
+	return nil
+}
+
+// minKeySizePolicy rejects leaves with an RSA or ECDSA public key smaller
+// than the given sizes. A size of 0 accepts any key of that type.
+type minKeySizePolicy struct {
+	rsaBits   int
+	ecdsaBits int
+}
+
+func (p minKeySizePolicy) Name() string { return "min_key_size" }
+
+func (p minKeySizePolicy) Check(chain []*x509.Certificate, _ bool) error {
+	switch k := chain[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		if n := k.N.BitLen(); n < p.rsaBits {
+			return fmt.Errorf("policy %s: %d bit RSA key, want at least %d bits", p.Name(), n, p.rsaBits)
+		}
+	case *ecdsa.PublicKey:
+		if n := k.Curve.Params().BitSize; n < p.ecdsaBits {
+			return fmt.Errorf("policy %s: %d bit ECDSA key, want at least %d bits", p.Name(), n, p.ecdsaBits)
+		}
+	}
+	return nil
+}
+
+// maxValidityPolicy rejects leaves valid for longer than max.
+type maxValidityPolicy struct {
+	max time.Duration
+}
+
+func (p maxValidityPolicy) Name() string { return "max_validity" }
+
+func (p maxValidityPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	if v := chain[0].NotAfter.Sub(chain[0].NotBefore); v > p.max {
+		return fmt.Errorf("policy %s: certificate is valid for %v, want at most %v", p.Name(), v, p.max)
+	}
+	return nil
+}
+
+// maxSANsPolicy rejects leaves with more than max Subject Alternative Names.
+type maxSANsPolicy struct {
+	max int
+}
+
+func (p maxSANsPolicy) Name() string { return "max_sans" }
+
+func (p maxSANsPolicy) Check(chain []*x509.Certificate, _ bool) error {
+	c := chain[0]
+	if n := len(c.DNSNames) + len(c.IPAddresses) + len(c.EmailAddresses) + len(c.URIs); n > p.max {
+		return fmt.Errorf("policy %s: certificate has %d SANs, want at most %d", p.Name(), n, p.max)
+	}
+	return nil
+}
+
+// policyConfig configures a single policy. Fields which are not used by the
+// policy Type must be left unset.
+type policyConfig struct {
+	Type string `json:"type"`
+	// Fingerprints lists hex-encoded SHA-256 fingerprints of the ASN.1 DER
+	// encoded issuers accepted by the issuers policy.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// RSABits and ECDSABits are the minimum key sizes of the min_key_size
+	// policy.
+	RSABits   int `json:"rsa_bits,omitempty"`
+	ECDSABits int `json:"ecdsa_bits,omitempty"`
+	// MaxValidity is the maximum validity period of the max_validity policy,
+	// in the format of time.ParseDuration.
+	MaxValidity string `json:"max_validity,omitempty"`
+	// MaxSANs is the maximum number of SANs of the max_sans policy.
+	MaxSANs int `json:"max_sans,omitempty"`
+}
+
+// ParsePolicies parses a JSON policy file, such as:
+//
+//	{"policies": [
+//	  {"type": "issuers", "fingerprints": ["<hex SHA-256>"]},
+//	  {"type": "min_key_size", "rsa_bits": 2048, "ecdsa_bits": 256},
+//	  {"type": "max_validity", "max_validity": "9528h"},
+//	  {"type": "max_sans", "max_sans": 100}
+//	]}
+//
+// Policies are returned in the order of the file.
+func ParsePolicies(data []byte) ([]Policy, error) {
+	var cfg struct {
+		Policies []policyConfig `json:"policies"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse policies: %v", err)
+	}
+	ps := make([]Policy, 0, len(cfg.Policies))
+	for i, pc := range cfg.Policies {
+		p, err := newPolicy(pc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy at index %d: %v", i, err)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// newPolicy returns the policy configured by pc.
+func newPolicy(pc policyConfig) (Policy, error) {
+	// used holds the fields of pc used by its policy, to detect others.
+	used := policyConfig{Type: pc.Type}
+	var p Policy
+	switch pc.Type {
+	case "issuers":
+		if len(pc.Fingerprints) == 0 {
+			return nil, errors.New("issuers policy without fingerprints")
+		}
+		ip := issuersPolicy{fingerprints: make(map[[sha256.Size]byte]bool, len(pc.Fingerprints))}
+		for _, f := range pc.Fingerprints {
+			fp, err := parseFingerprint(f)
+			if err != nil {
+				return nil, err
+			}
+			ip.fingerprints[fp] = true
+		}
+		used.Fingerprints = pc.Fingerprints
+		p = ip
+	case "min_key_size":
+		if pc.RSABits < 0 || pc.ECDSABits < 0 || pc.RSABits+pc.ECDSABits == 0 {
+			return nil, errors.New("min_key_size policy needs positive rsa_bits or ecdsa_bits")
+		}
+		used.RSABits, used.ECDSABits = pc.RSABits, pc.ECDSABits
+		p = minKeySizePolicy{rsaBits: pc.RSABits, ecdsaBits: pc.ECDSABits}
+	case "max_validity":
+		d, err := time.ParseDuration(pc.MaxValidity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_validity %q: %v", pc.MaxValidity, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("max_validity must be positive, got %v", d)
+		}
+		used.MaxValidity = pc.MaxValidity
+		p = maxValidityPolicy{max: d}
+	case "max_sans":
+		if pc.MaxSANs <= 0 {
+			return nil, fmt.Errorf("max_sans must be positive, got %d", pc.MaxSANs)
+		}
+		used.MaxSANs = pc.MaxSANs
+		p = maxSANsPolicy{max: pc.MaxSANs}
+	default:
+		return nil, fmt.Errorf("unknown policy type %q", pc.Type)
+	}
+	if !reflect.DeepEqual(pc, used) {
+		return nil, fmt.Errorf("%s policy with unexpected fields: %+v", pc.Type, pc)
+	}
+	return p, nil
+}
+
+// parseFingerprint parses a hex-encoded SHA-256 fingerprint.
+func parseFingerprint(f string) ([sha256.Size]byte, error) {
+	b, err := hex.DecodeString(f)
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("invalid fingerprint %q: %v", f, err)
+	}
+	if len(b) != sha256.Size {
+		return [sha256.Size]byte{}, fmt.Errorf("invalid fingerprint length %q: expected %d bytes, got %d", f, sha256.Size, len(b))
+	}
+	return [sha256.Size]byte(b), nil
+}
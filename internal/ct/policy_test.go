@@ -0,0 +1,295 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+func TestParsePolicies(t *testing.T) {
+	fp := strings.Repeat("ab", sha256.Size)
+	for _, tc := range []struct {
+		desc      string
+		data      string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			desc: "empty",
+			data: `{}`,
+		},
+		{
+			desc:      "all",
+			data:      `{"policies": [{"type": "max_sans", "max_sans": 10}, {"type": "issuers", "fingerprints": ["` + fp + `"]}, {"type": "min_key_size", "rsa_bits": 2048}, {"type": "max_validity", "max_validity": "9528h"}]}`,
+			wantNames: []string{"max_sans", "issuers", "min_key_size", "max_validity"},
+		},
+		{
+			desc:    "invalid-json",
+			data:    `{"policies": [`,
+			wantErr: "failed to parse policies",
+		},
+		{
+			desc:    "unknown-field",
+			data:    `{"policies": [{"type": "max_sans", "max_sanz": 10}]}`,
+			wantErr: "unknown field",
+		},
+		{
+			desc:    "unknown-type",
+			data:    `{"policies": [{"type": "nope"}]}`,
+			wantErr: "unknown policy type",
+		},
+		{
+			desc:    "unexpected-field",
+			data:    `{"policies": [{"type": "max_sans", "max_sans": 10, "rsa_bits": 2048}]}`,
+			wantErr: "unexpected fields",
+		},
+		{
+			desc:    "no-fingerprints",
+			data:    `{"policies": [{"type": "issuers"}]}`,
+			wantErr: "without fingerprints",
+		},
+		{
+			desc:    "short-fingerprint",
+			data:    `{"policies": [{"type": "issuers", "fingerprints": ["abab"]}]}`,
+			wantErr: "invalid fingerprint length",
+		},
+		{
+			desc:    "no-key-size",
+			data:    `{"policies": [{"type": "min_key_size"}]}`,
+			wantErr: "needs positive",
+		},
+		{
+			desc:    "invalid-validity",
+			data:    `{"policies": [{"type": "max_validity", "max_validity": "398d"}]}`,
+			wantErr: "invalid max_validity",
+		},
+		{
+			desc:    "negative-sans",
+			data:    `{"policies": [{"type": "max_sans", "max_sans": -1}]}`,
+			wantErr: "must be positive",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ps, err := ParsePolicies([]byte(tc.data))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ParsePolicies()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePolicies()=%v", err)
+			}
+			var names []string
+			for _, p := range ps {
+				names = append(names, p.Name())
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tc.wantNames) {
+				t.Errorf("ParsePolicies() returned policies %v, want %v", names, tc.wantNames)
+			}
+		})
+	}
+}
+
+func mustParsePEMs(t *testing.T, pems ...string) []*x509.Certificate {
+	t.Helper()
+	var chain []*x509.Certificate
+	for _, p := range pems {
+		c, err := x509util.CertificateFromPEM([]byte(p))
+		if err != nil {
+			t.Fatalf("CertificateFromPEM(): %v", err)
+		}
+		chain = append(chain, c)
+	}
+	return chain
+}
+
+// newPreIssuerChain returns a chain made of a leaf, a precertificate signing
+// certificate, and a root.
+func newPreIssuerChain(t *testing.T) []*x509.Certificate {
+	t.Helper()
+	return newTestChain(t,
+		&x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true},
+		&x509.Certificate{Subject: pkix.Name{CommonName: "pre-issuer"}, IsCA: true, BasicConstraintsValid: true, UnknownExtKeyUsage: []asn1.ObjectIdentifier{rfc6962.OIDExtKeyUsageCertificateTransparency}},
+		&x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}},
+	)
+}
+
+// newTestChain returns a chain issued from templates, which start with the
+// root. Each certificate is issued by the previous one. The returned chain
+// starts with the leaf.
+func newTestChain(t *testing.T, tmpls ...*x509.Certificate) []*x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey(): %v", err)
+	}
+	var chain []*x509.Certificate
+	var parent *x509.Certificate
+	for i, tmpl := range tmpls {
+		tmpl.SerialNumber = big.NewInt(int64(i + 1))
+		if parent == nil {
+			parent = tmpl
+		}
+		c := issueTestCert(t, tmpl, parent, key)
+		chain = append([]*x509.Certificate{c}, chain...)
+		parent = c
+	}
+	return chain
+}
+
+// issueTestCert issues tmpl from parent. All certificates share key.
+func issueTestCert(t *testing.T, tmpl, parent *x509.Certificate, key *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	tmpl.NotBefore = time.Now()
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate(): %v", err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(): %v", err)
+	}
+	return c
+}
+
+func TestIssuersPolicy(t *testing.T) {
+	chain := mustParsePEMs(t, testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM)
+	preChain := newPreIssuerChain(t)
+	fingerprint := func(c *x509.Certificate) [sha256.Size]byte { return sha256.Sum256(c.Raw) }
+
+	for _, tc := range []struct {
+		desc    string
+		allowed []*x509.Certificate
+		chain   []*x509.Certificate
+		wantErr bool
+	}{
+		{desc: "intermediate", allowed: chain[1:2], chain: chain},
+		{desc: "root-only", allowed: chain[2:3], chain: chain, wantErr: true},
+		{desc: "pre-issuer", allowed: preChain[2:3], chain: preChain},
+		{desc: "pre-issuer-itself", allowed: preChain[1:2], chain: preChain, wantErr: true},
+		{desc: "root-leaf", allowed: chain[2:3], chain: chain[2:3]},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := issuersPolicy{fingerprints: make(map[[sha256.Size]byte]bool)}
+			for _, c := range tc.allowed {
+				p.fingerprints[fingerprint(c)] = true
+			}
+			if err := p.Check(tc.chain, false); (err != nil) != tc.wantErr {
+				t.Errorf("Check()=%v, want err %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLeafPolicies(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey(): %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey(): %v", err)
+	}
+	now := time.Now()
+	for _, tc := range []struct {
+		desc    string
+		policy  Policy
+		leaf    *x509.Certificate
+		wantErr bool
+	}{
+		{desc: "rsa-too-small", policy: minKeySizePolicy{rsaBits: 2048}, leaf: &x509.Certificate{PublicKey: &rsaKey.PublicKey}, wantErr: true},
+		{desc: "rsa-ok", policy: minKeySizePolicy{rsaBits: 1024}, leaf: &x509.Certificate{PublicKey: &rsaKey.PublicKey}},
+		{desc: "ecdsa-too-small", policy: minKeySizePolicy{ecdsaBits: 384}, leaf: &x509.Certificate{PublicKey: &ecKey.PublicKey}, wantErr: true},
+		{desc: "ecdsa-ok", policy: minKeySizePolicy{rsaBits: 4096, ecdsaBits: 256}, leaf: &x509.Certificate{PublicKey: &ecKey.PublicKey}},
+		{desc: "validity-too-long", policy: maxValidityPolicy{max: time.Hour}, leaf: &x509.Certificate{NotBefore: now, NotAfter: now.Add(time.Hour + time.Second)}, wantErr: true},
+		{desc: "validity-ok", policy: maxValidityPolicy{max: time.Hour}, leaf: &x509.Certificate{NotBefore: now, NotAfter: now.Add(time.Hour)}},
+		{desc: "too-many-sans", policy: maxSANsPolicy{max: 2}, leaf: &x509.Certificate{DNSNames: []string{"a.com", "b.com"}, IPAddresses: []net.IP{net.IPv4(1, 2, 3, 4)}}, wantErr: true},
+		{desc: "sans-ok", policy: maxSANsPolicy{max: 2}, leaf: &x509.Certificate{DNSNames: []string{"a.com"}, EmailAddresses: []string{"a@a.com"}}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if err := tc.policy.Check([]*x509.Certificate{tc.leaf}, false); (err != nil) != tc.wantErr {
+				t.Errorf("Check()=%v, want err %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// rejectPrecertsPolicy is a custom policy rejecting all precertificates.
+type rejectPrecertsPolicy struct {
+	got []*x509.Certificate
+}
+
+func (p *rejectPrecertsPolicy) Name() string { return "reject_precerts" }
+
+func (p *rejectPrecertsPolicy) Check(chain []*x509.Certificate, isPrecert bool) error {
+	p.got = chain
+	if isPrecert {
+		return fmt.Errorf("policy %s: rejecting precertificate", p.Name())
+	}
+	return nil
+}
+
+func TestValidatePolicies(t *testing.T) {
+	roots, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool(): %v", err)
+	}
+	roots.AppendCertsFromPEMs([]byte(testdata.CACertPEM))
+	custom := &rejectPrecertsPolicy{}
+	cv := NewChainValidator(ChainValidatorOptions{TrustedRoots: roots, Policies: []Policy{custom}})
+
+	// The root is not submitted, but policies get the verified chain.
+	chain := mustParsePEMs(t, testdata.CertFromIntermediate, testdata.IntermediateFromRoot)
+	if _, err := cv.Validate(chain, false); err != nil {
+		t.Fatalf("Validate(cert)=%v", err)
+	}
+	if len(custom.got) != 3 {
+		t.Errorf("policy got a chain of %d certificates, want 3", len(custom.got))
+	}
+
+	preChain := mustParsePEMs(t, testdata.PreCertFromIntermediate, testdata.IntermediateFromRoot)
+	if _, err := cv.Validate(preChain, true); err == nil || !strings.Contains(err.Error(), "reject_precerts") {
+		t.Errorf("Validate(precert)=%v, want reject_precerts error", err)
+	}
+
+	// Built-in policies run before custom ones.
+	custom.got = nil
+	cv.notAfterLimit = &chain[0].NotAfter
+	if _, err := cv.Validate(chain, false); err == nil {
+		t.Errorf("Validate() with NotAfter limit=nil, want error")
+	}
+	if custom.got != nil {
+		t.Errorf("custom policy ran after a built-in policy rejected the chain")
+	}
+}
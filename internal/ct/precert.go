@@ -0,0 +1,104 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+// Reasons for which strict precertificate validation rejects chains.
+const (
+	// PrecertPoisonNotCritical is the reason for precertificates with a
+	// non-critical poison extension.
+	PrecertPoisonNotCritical = "poison_not_critical"
+	// PrecertPoisonInvalid is the reason for precertificates with a poison
+	// extension whose value is not ASN.1 NULL.
+	PrecertPoisonInvalid = "poison_invalid"
+	// PreIssuerNotCA is the reason for chains with a certificate that has
+	// the Certificate Transparency EKU, but is not a CA.
+	PreIssuerNotCA = "pre_issuer_not_ca"
+	// PreIssuerForCertificate is the reason for final certificates issued by
+	// a precertificate signing certificate.
+	PreIssuerForCertificate = "pre_issuer_for_certificate"
+	// PreIssuerNotDirect is the reason for precertificate signing
+	// certificates which do not directly issue the leaf.
+	PreIssuerNotDirect = "pre_issuer_not_direct"
+	// PreIssuerIsRoot is the reason for precertificate signing certificates
+	// which are not certified by the CA that will issue the final
+	// certificate, because they are trusted roots.
+	PreIssuerIsRoot = "pre_issuer_is_root"
+)
+
+// PrecertValidationError is returned by strict precertificate validation for
+// rejected chains.
+type PrecertValidationError struct {
+	// Reason is one of the Precert* and PreIssuer* constants.
+	Reason string
+	// Detail describes the failure.
+	Detail string
+}
+
+func (e *PrecertValidationError) Error() string {
+	return fmt.Sprintf("strict precertificate validation failed: %s: %s", e.Reason, e.Detail)
+}
+
+// checkPrecertStructure checks a verified chain against the structure of
+// precertificates and precertificate signing certificates defined by RFC 6962
+// section 3.1. isPrecert is set for chains submitted to add-pre-chain.
+//
+// It returns a *PrecertValidationError if chain is rejected.
+func checkPrecertStructure(chain []*x509.Certificate, isPrecert bool) error {
+	// Certificates with duplicated extensions don't parse, so there can only
+	// be one poison extension.
+	if isPrecert {
+		for _, ext := range chain[0].Extensions {
+			if !ext.Id.Equal(rfc6962.OIDExtensionCTPoison) {
+				continue
+			}
+			if !ext.Critical {
+				return &PrecertValidationError{Reason: PrecertPoisonNotCritical, Detail: "the poison extension must be critical"}
+			}
+			if !bytes.Equal(ext.Value, asn1.NullBytes) {
+				return &PrecertValidationError{Reason: PrecertPoisonInvalid, Detail: fmt.Sprintf("the poison extension value must be ASN.1 NULL, got %x", ext.Value)}
+			}
+		}
+	}
+
+	for i := 1; i < len(chain); i++ {
+		cert := chain[i]
+		if !x509util.HasPrecertSigningEKU(cert) {
+			continue
+		}
+		if !cert.IsCA {
+			return &PrecertValidationError{Reason: PreIssuerNotCA, Detail: fmt.Sprintf("certificate %d has the Certificate Transparency EKU, but is not a CA", i)}
+		}
+		if i > 1 {
+			return &PrecertValidationError{Reason: PreIssuerNotDirect, Detail: fmt.Sprintf("certificate %d is a precertificate signing certificate, but does not issue the leaf", i)}
+		}
+		if !isPrecert {
+			return &PrecertValidationError{Reason: PreIssuerForCertificate, Detail: "final certificates can't be issued by a precertificate signing certificate"}
+		}
+		if i == len(chain)-1 {
+			return &PrecertValidationError{Reason: PreIssuerIsRoot, Detail: "precertificate signing certificates must be issued by the CA issuing the final certificate, not be trusted roots"}
+		}
+	}
+	return nil
+}
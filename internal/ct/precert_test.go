@@ -0,0 +1,141 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+func TestCheckPrecertStructure(t *testing.T) {
+	poison := func(critical bool, value []byte) pkix.Extension {
+		return pkix.Extension{Id: rfc6962.OIDExtensionCTPoison, Critical: critical, Value: value}
+	}
+	ctEKU := []asn1.ObjectIdentifier{rfc6962.OIDExtKeyUsageCertificateTransparency}
+	root := func() *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true}
+	}
+	intermediate := func() *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "intermediate"}, IsCA: true, BasicConstraintsValid: true}
+	}
+	preIssuer := func() *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "pre-issuer"}, IsCA: true, BasicConstraintsValid: true, UnknownExtKeyUsage: ctEKU}
+	}
+	leaf := func(exts ...pkix.Extension) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}, ExtraExtensions: exts}
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		tmpls      []*x509.Certificate
+		isPrecert  bool
+		wantReason string
+	}{
+		{
+			desc:  "certificate",
+			tmpls: []*x509.Certificate{root(), intermediate(), leaf()},
+		},
+		{
+			desc:      "precert",
+			tmpls:     []*x509.Certificate{root(), intermediate(), leaf(poison(true, asn1.NullBytes))},
+			isPrecert: true,
+		},
+		{
+			desc:      "precert-from-pre-issuer",
+			tmpls:     []*x509.Certificate{root(), intermediate(), preIssuer(), leaf(poison(true, asn1.NullBytes))},
+			isPrecert: true,
+		},
+		{
+			desc:       "poison-not-critical",
+			tmpls:      []*x509.Certificate{root(), intermediate(), leaf(poison(false, asn1.NullBytes))},
+			isPrecert:  true,
+			wantReason: PrecertPoisonNotCritical,
+		},
+		{
+			desc:       "poison-invalid",
+			tmpls:      []*x509.Certificate{root(), intermediate(), leaf(poison(true, []byte{0x04, 0x00}))},
+			isPrecert:  true,
+			wantReason: PrecertPoisonInvalid,
+		},
+		{
+			desc:       "pre-issuer-not-ca",
+			tmpls:      []*x509.Certificate{root(), intermediate(), {Subject: pkix.Name{CommonName: "pre-issuer"}, BasicConstraintsValid: true, UnknownExtKeyUsage: ctEKU}, leaf(poison(true, asn1.NullBytes))},
+			isPrecert:  true,
+			wantReason: PreIssuerNotCA,
+		},
+		{
+			desc:       "pre-issuer-for-certificate",
+			tmpls:      []*x509.Certificate{root(), intermediate(), preIssuer(), leaf()},
+			wantReason: PreIssuerForCertificate,
+		},
+		{
+			desc:       "pre-issuer-not-direct",
+			tmpls:      []*x509.Certificate{root(), preIssuer(), intermediate(), leaf(poison(true, asn1.NullBytes))},
+			isPrecert:  true,
+			wantReason: PreIssuerNotDirect,
+		},
+		{
+			desc:       "pre-issuer-is-root",
+			tmpls:      []*x509.Certificate{preIssuer(), leaf(poison(true, asn1.NullBytes))},
+			isPrecert:  true,
+			wantReason: PreIssuerIsRoot,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			chain := newTestChain(t, tc.tmpls...)
+			err := checkPrecertStructure(chain, tc.isPrecert)
+			if tc.wantReason == "" {
+				if err != nil {
+					t.Errorf("checkPrecertStructure()=%v, want nil", err)
+				}
+				return
+			}
+			var pve *PrecertValidationError
+			if !errors.As(err, &pve) || pve.Reason != tc.wantReason {
+				t.Errorf("checkPrecertStructure()=%v, want %s", err, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestValidateStrictPrecerts(t *testing.T) {
+	chain := newTestChain(t,
+		&x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true},
+		&x509.Certificate{Subject: pkix.Name{CommonName: "pre-issuer"}, IsCA: true, BasicConstraintsValid: true, UnknownExtKeyUsage: []asn1.ObjectIdentifier{rfc6962.OIDExtKeyUsageCertificateTransparency}},
+		&x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+	)
+	roots, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	roots.AddCerts(chain[2:])
+
+	// A final certificate issued by a precertificate signing certificate is
+	// only rejected by strict validation.
+	for _, strict := range []bool{false, true} {
+		cv := NewChainValidator(ChainValidatorOptions{TrustedRoots: roots, StrictPrecerts: strict})
+		_, err := cv.Validate(chain[:2], false)
+		var pve *PrecertValidationError
+		if got := errors.As(err, &pve); got != strict {
+			t.Errorf("Validate() with strict=%t returned %v, want PrecertValidationError: %t", strict, err, strict)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultAdaptivePushbackInterval is the default interval between two
+	// adjustments of the admission rate.
+	DefaultAdaptivePushbackInterval = time.Second
+	// DefaultAdaptivePushbackDecrease is the default factor the admission
+	// rate is multiplied by when the log is overloaded.
+	DefaultAdaptivePushbackDecrease = 0.5
+)
+
+// AdaptivePushbackConfig configures AdaptivePushback.
+type AdaptivePushbackConfig struct {
+	// MaxQPS is the highest admission rate, in submissions per second. The
+	// admission rate starts there.
+	MaxQPS float64
+	// MinQPS is the lowest admission rate, in submissions per second.
+	MinQPS float64
+	// Increase is added to the admission rate at each interval when the log
+	// is not overloaded. Defaults to 5% of MaxQPS.
+	Increase float64
+	// Decrease multiplies the admission rate at each interval when the log
+	// is overloaded. It must be in (0, 1), and defaults to
+	// DefaultAdaptivePushbackDecrease.
+	Decrease float64
+	// Interval is the time between two adjustments of the admission rate.
+	// Defaults to DefaultAdaptivePushbackInterval.
+	Interval time.Duration
+
+	// MaxAwaitLatency is the time entries can take to be published, as
+	// observed by the storage publication awaiter, before the log is
+	// considered overloaded. Disabled if 0.
+	MaxAwaitLatency time.Duration
+	// MaxCheckpointAge is the time the published checkpoint can go without
+	// growing while entries are waiting to be integrated, before the log is
+	// considered overloaded. Disabled if 0.
+	MaxCheckpointAge time.Duration
+}
+
+// awaitLatencyReporter is implemented by storages which wait for entries to
+// be published, such as storage.CTStorage.
+type awaitLatencyReporter interface {
+	// AwaitLatency returns the largest time spent waiting for entries to be
+	// published since the previous call, or 0 if none were waited for.
+	AwaitLatency() time.Duration
+}
+
+// AdaptivePushback sheds submissions before the log falls behind.
+//
+// It is an additive increase, multiplicative decrease (AIMD) controller: at
+// every interval, it multiplicatively lowers the admission rate if the log
+// shows signs of overload, and additively raises it otherwise. Overload
+// signals are the publication awaiter latency, the age of the checkpoint while
+// entries are waiting to be integrated, and Tessera pushback, which includes
+// antispam lag.
+type AdaptivePushback struct {
+	cfg    AdaptivePushbackConfig
+	log    *log
+	origin string
+
+	limiter *rate.Limiter
+	// pushbacks counts Tessera pushbacks since the last adjustment.
+	pushbacks atomic.Int64
+
+	// mu guards the fields below, which are only used by adjustments.
+	mu sync.Mutex
+	// cpSize is the size of the latest checkpoint, and cpGrown the time it
+	// was first seen.
+	cpSize  uint64
+	cpGrown time.Time
+}
+
+// pushbackSignals are the overload signals observed over an interval.
+type pushbackSignals struct {
+	awaitLatency   time.Duration
+	checkpointAge  time.Duration
+	integrationLag uint64
+	pushbacks      int64
+}
+
+// NewAdaptivePushback returns an AdaptivePushback for log, which adjusts its
+// admission rate until ctx is done.
+func NewAdaptivePushback(ctx context.Context, log *log, cfg AdaptivePushbackConfig) (*AdaptivePushback, error) {
+	once.Do(func() { setupMetrics() })
+	if cfg.Increase == 0 {
+		cfg.Increase = cfg.MaxQPS / 20
+	}
+	if cfg.Decrease == 0 {
+		cfg.Decrease = DefaultAdaptivePushbackDecrease
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultAdaptivePushbackInterval
+	}
+	switch {
+	case cfg.MaxQPS <= 0:
+		return nil, fmt.Errorf("invalid max qps %v, must be positive", cfg.MaxQPS)
+	case cfg.MinQPS < 0 || cfg.MinQPS > cfg.MaxQPS:
+		return nil, fmt.Errorf("invalid min qps %v, must be in [0, %v]", cfg.MinQPS, cfg.MaxQPS)
+	case cfg.Increase < 0:
+		return nil, fmt.Errorf("invalid increase %v, must not be negative", cfg.Increase)
+	case cfg.Decrease <= 0 || cfg.Decrease >= 1:
+		return nil, fmt.Errorf("invalid decrease %v, must be in (0, 1)", cfg.Decrease)
+	case cfg.Interval < 0:
+		return nil, fmt.Errorf("invalid interval %s", cfg.Interval)
+	case cfg.MaxAwaitLatency < 0 || cfg.MaxCheckpointAge < 0:
+		return nil, errors.New("overload thresholds must not be negative")
+	}
+
+	p := &AdaptivePushback{
+		cfg:     cfg,
+		log:     log,
+		origin:  log.origin,
+		limiter: rate.NewLimiter(rate.Limit(cfg.MaxQPS), int(math.Ceil(cfg.MaxQPS))),
+		cpGrown: time.Now(),
+	}
+	go p.run(ctx)
+	return p, nil
+}
+
+// run adjusts the admission rate every interval, until ctx is done.
+func (p *AdaptivePushback) run(ctx context.Context) {
+	t := time.NewTicker(p.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			p.adjust(ctx, p.signals(ctx, time.Now()))
+		}
+	}
+}
+
+// Admit returns true if a submission is within the current admission rate.
+func (p *AdaptivePushback) Admit(ctx context.Context) bool {
+	if p.limiter.Allow() {
+		return true
+	}
+	rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(p.origin), rateLimitReasonKey.String("adaptive_pushback")))
+	return false
+}
+
+// observePushback records that Tessera pushed back a submission.
+func (p *AdaptivePushback) observePushback() {
+	p.pushbacks.Add(1)
+}
+
+// QPS returns the current admission rate.
+func (p *AdaptivePushback) QPS() float64 {
+	return float64(p.limiter.Limit())
+}
+
+// signals returns the overload signals observed since the previous call.
+func (p *AdaptivePushback) signals(ctx context.Context, now time.Time) pushbackSignals {
+	s := pushbackSignals{pushbacks: p.pushbacks.Swap(0)}
+	if r, ok := p.log.storage.(awaitLatencyReporter); ok {
+		s.awaitLatency = r.AwaitLatency()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cp, _, err := p.log.latestCheckpoint(ctx)
+	if err != nil {
+		// Keep the previous checkpoint, it will look older and older.
+		slog.WarnContext(ctx, "Adaptive pushback failed to read checkpoint", slog.String("origin", p.origin), slog.Any("error", err))
+	} else if cp.Size != p.cpSize {
+		p.cpSize, p.cpGrown = cp.Size, now
+	}
+	s.checkpointAge = now.Sub(p.cpGrown)
+	if next := p.log.nextIndex.Load(); next > p.cpSize {
+		s.integrationLag = next - p.cpSize
+	}
+	return s
+}
+
+// overloaded returns true if s shows that the log is overloaded.
+func (p *AdaptivePushback) overloaded(s pushbackSignals) bool {
+	if s.pushbacks > 0 {
+		return true
+	}
+	if p.cfg.MaxAwaitLatency > 0 && s.awaitLatency > p.cfg.MaxAwaitLatency {
+		return true
+	}
+	if p.cfg.MaxCheckpointAge > 0 && s.integrationLag > 0 && s.checkpointAge > p.cfg.MaxCheckpointAge {
+		return true
+	}
+	return false
+}
+
+// adjust updates the admission rate given the signals of the last interval,
+// and records the controller state.
+func (p *AdaptivePushback) adjust(ctx context.Context, s pushbackSignals) {
+	overloaded := p.overloaded(s)
+	qps := p.QPS()
+	if overloaded {
+		qps = max(qps*p.cfg.Decrease, p.cfg.MinQPS)
+	} else {
+		qps = min(qps+p.cfg.Increase, p.cfg.MaxQPS)
+	}
+	if qps != p.QPS() {
+		p.limiter.SetLimit(rate.Limit(qps))
+		p.limiter.SetBurst(int(math.Ceil(qps)))
+		slog.DebugContext(ctx, "Adaptive pushback adjusted admission rate", slog.String("origin", p.origin), slog.Float64("qps", qps), slog.Bool("overloaded", overloaded))
+	}
+
+	attrs := metric.WithAttributes(originKey.String(p.origin))
+	var o int64
+	if overloaded {
+		o = 1
+	}
+	pushbackOverloaded.Record(ctx, o, attrs)
+	pushbackAdmissionRate.Record(ctx, qps, attrs)
+	pushbackAwaitLatency.Record(ctx, s.awaitLatency.Seconds(), attrs)
+	pushbackCheckpointAge.Record(ctx, s.checkpointAge.Seconds(), attrs)
+	pushbackIntegrationLag.Record(ctx, int64(s.integrationLag), attrs)
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+// newTestAdaptivePushback returns an AdaptivePushback for a test log, which
+// only adjusts its admission rate when told to.
+func newTestAdaptivePushback(t *testing.T, cfg AdaptivePushbackConfig) *AdaptivePushback {
+	t.Helper()
+	log, _ := setupTestLog(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+	cfg.Interval = time.Hour
+	p, err := NewAdaptivePushback(ctx, log, cfg)
+	if err != nil {
+		t.Fatalf("NewAdaptivePushback(): %v", err)
+	}
+	return p
+}
+
+func TestNewAdaptivePushback(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		cfg     AdaptivePushbackConfig
+		wantErr string
+	}{
+		{desc: "valid", cfg: AdaptivePushbackConfig{MaxQPS: 100, MinQPS: 1}},
+		{desc: "no-max-qps", cfg: AdaptivePushbackConfig{}, wantErr: "invalid max qps"},
+		{desc: "min-over-max", cfg: AdaptivePushbackConfig{MaxQPS: 1, MinQPS: 2}, wantErr: "invalid min qps"},
+		{desc: "decrease-too-large", cfg: AdaptivePushbackConfig{MaxQPS: 1, Decrease: 1}, wantErr: "invalid decrease"},
+		{desc: "negative-increase", cfg: AdaptivePushbackConfig{MaxQPS: 1, Increase: -1}, wantErr: "invalid increase"},
+		{desc: "negative-threshold", cfg: AdaptivePushbackConfig{MaxQPS: 1, MaxCheckpointAge: -time.Second}, wantErr: "thresholds"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			p, err := NewAdaptivePushback(t.Context(), log, tc.cfg)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewAdaptivePushback(): %v", err)
+				}
+				if got, want := p.QPS(), tc.cfg.MaxQPS; got != want {
+					t.Errorf("QPS()=%v, want %v", got, want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("NewAdaptivePushback() err=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdaptivePushbackOverloaded(t *testing.T) {
+	p := newTestAdaptivePushback(t, AdaptivePushbackConfig{
+		MaxQPS:           100,
+		MaxAwaitLatency:  5 * time.Second,
+		MaxCheckpointAge: 30 * time.Second,
+	})
+	for _, tc := range []struct {
+		desc    string
+		signals pushbackSignals
+		want    bool
+	}{
+		{desc: "healthy", signals: pushbackSignals{awaitLatency: time.Second, checkpointAge: time.Second, integrationLag: 10}},
+		{desc: "tessera-pushback", signals: pushbackSignals{pushbacks: 1}, want: true},
+		{desc: "slow-awaiter", signals: pushbackSignals{awaitLatency: 6 * time.Second}, want: true},
+		{desc: "stale-checkpoint", signals: pushbackSignals{checkpointAge: time.Minute, integrationLag: 1}, want: true},
+		{desc: "stale-checkpoint-without-lag", signals: pushbackSignals{checkpointAge: time.Minute}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := p.overloaded(tc.signals); got != tc.want {
+				t.Errorf("overloaded()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdaptivePushbackAdjust(t *testing.T) {
+	p := newTestAdaptivePushback(t, AdaptivePushbackConfig{
+		MaxQPS:   100,
+		MinQPS:   10,
+		Increase: 20,
+		Decrease: 0.5,
+	})
+	overloaded := pushbackSignals{pushbacks: 1}
+	healthy := pushbackSignals{}
+	for i, step := range []struct {
+		signals pushbackSignals
+		want    float64
+	}{
+		{signals: healthy, want: 100},
+		{signals: overloaded, want: 50},
+		{signals: overloaded, want: 25},
+		{signals: overloaded, want: 12.5},
+		{signals: overloaded, want: 10},
+		{signals: healthy, want: 30},
+		{signals: healthy, want: 50},
+		{signals: healthy, want: 70},
+		{signals: healthy, want: 90},
+		{signals: healthy, want: 100},
+	} {
+		p.adjust(t.Context(), step.signals)
+		if got := p.QPS(); got != step.want {
+			t.Fatalf("step %d: QPS()=%v, want %v", i, got, step.want)
+		}
+	}
+}
+
+func TestAdaptivePushbackSignals(t *testing.T) {
+	p := newTestAdaptivePushback(t, AdaptivePushbackConfig{MaxQPS: 100})
+	p.observePushback()
+	p.observePushback()
+	p.log.recordIndex(4)
+
+	start := p.cpGrown
+	s := p.signals(t.Context(), start.Add(time.Minute))
+	if s.pushbacks != 2 {
+		t.Errorf("pushbacks=%d, want 2", s.pushbacks)
+	}
+	if s.integrationLag != 5 {
+		t.Errorf("integrationLag=%d, want 5", s.integrationLag)
+	}
+	if s.checkpointAge != time.Minute {
+		t.Errorf("checkpointAge=%s, want 1m", s.checkpointAge)
+	}
+	if s.awaitLatency != 0 {
+		t.Errorf("awaitLatency=%s, want 0", s.awaitLatency)
+	}
+	if s := p.signals(t.Context(), start.Add(time.Minute)); s.pushbacks != 0 {
+		t.Errorf("pushbacks=%d after being reported, want 0", s.pushbacks)
+	}
+}
+
+func TestAddChainAdaptivePushback(t *testing.T) {
+	log, _ := setupTestLog(t)
+	p, err := NewAdaptivePushback(t.Context(), log, AdaptivePushbackConfig{MaxQPS: 1, Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewAdaptivePushback(): %v", err)
+	}
+	opts := hOpts()
+	opts.JSONErrors = true
+	opts.AdaptivePushback = p
+	server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+	defer server.Close()
+
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	for i, wantStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+		if err != nil {
+			t.Fatalf("http.Post(): %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("submission %d: got status %d, want %d", i, resp.StatusCode, wantStatus)
+		}
+		if wantStatus != http.StatusTooManyRequests {
+			continue
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Error("429 without Retry-After header")
+		}
+		var rsp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+			t.Fatalf("json.Decode(): %v", err)
+		}
+		if rsp.Code != ErrorCodePushbackAdaptive {
+			t.Errorf("got error code %q, want %q", rsp.Code, ErrorCodePushbackAdaptive)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// Quota types, used as rate limit metric attributes.
+const (
+	quotaClientIP = "client_ip"
+	quotaClientID = "client_id"
+	quotaIssuer   = "issuer"
+)
+
+// quotaErrorCodes maps quota types to the error code of submissions exceeding
+// them.
+var quotaErrorCodes = map[string]ErrorCode{
+	quotaClientIP: ErrorCodeRateLimitedClientIP,
+	quotaClientID: ErrorCodeRateLimitedClientID,
+	quotaIssuer:   ErrorCodeRateLimitedIssuer,
+}
+
+const (
+	// defaultQuotaMaxKeys is the default number of keys tracked by each quota.
+	defaultQuotaMaxKeys = 10000
+	// defaultIPv4PrefixLen and defaultIPv6PrefixLen are the default sizes of
+	// the CIDRs client IPs are grouped in.
+	defaultIPv4PrefixLen = 32
+	defaultIPv6PrefixLen = 64
+)
+
+// QuotasConfig describes rate limits applied separately to each client IP,
+// client identity, or issuer.
+type QuotasConfig struct {
+	// ClientIP limits submissions from each client CIDR, nil if disabled.
+	// Overrides are keyed by CIDR, e.g. "192.0.2.0/24".
+	ClientIP *QuotaConfig `json:"client_ip,omitempty"`
+	// ClientID limits submissions from each authenticated client, nil if
+	// disabled. Overrides are keyed by client identity. Submissions without
+	// a client identity are not subject to this quota.
+	ClientID *QuotaConfig `json:"client_id,omitempty"`
+	// Issuer limits submissions issued by each intermediate, nil if
+	// disabled. Overrides are keyed by the hex encoded SHA-256 fingerprint of
+	// the issuer. This quota is applied after chain validation, so that a
+	// submitter can't use up the quota of an issuer with invalid chains.
+	Issuer *QuotaConfig `json:"issuer,omitempty"`
+
+	// IPv4PrefixLen and IPv6PrefixLen are the sizes of the CIDRs client IPs
+	// are grouped in: all IPs in a CIDR share the same quota. They default to
+	// 32 and 64.
+	IPv4PrefixLen int `json:"ipv4_prefix_len,omitempty"`
+	IPv6PrefixLen int `json:"ipv6_prefix_len,omitempty"`
+	// ClientIPHeader, if set, is the request header holding the client IP,
+	// for instance when TesseraCT runs behind a load balancer. The last IP of
+	// the header is used, so it must be set by a trusted proxy. Requests
+	// without this header fall back to their remote address.
+	ClientIPHeader string `json:"client_ip_header,omitempty"`
+	// MaxKeys is the maximum number of keys tracked by each quota. When it
+	// is reached, the least recently seen keys are forgotten, and get a full
+	// quota when seen again. Defaults to 10000.
+	MaxKeys int `json:"max_keys,omitempty"`
+}
+
+// QuotaConfig configures a per key rate limit.
+type QuotaConfig struct {
+	// QPS is the number of submissions per second allowed for each key.
+	QPS float64 `json:"qps"`
+	// Overrides sets the number of submissions per second allowed for
+	// specific keys.
+	Overrides map[string]float64 `json:"overrides,omitempty"`
+}
+
+// ParseQuotas parses a JSON quotas file, such as:
+//
+//	{
+//	  "client_ip": {"qps": 10, "overrides": {"192.0.2.0/24": 100}},
+//	  "issuer": {"qps": 50, "overrides": {"<hex SHA-256>": 500}},
+//	  "client_ip_header": "X-Real-IP"
+//	}
+func ParseQuotas(data []byte) (QuotasConfig, error) {
+	var cfg QuotasConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return QuotasConfig{}, fmt.Errorf("failed to parse quotas: %v", err)
+	}
+	if _, err := newQuotas(cfg); err != nil {
+		return QuotasConfig{}, err
+	}
+	return cfg, nil
+}
+
+// validate returns an error if c is not a valid quota.
+func (c *QuotaConfig) validate() error {
+	if c.QPS < 0 {
+		return fmt.Errorf("invalid qps: %v", c.QPS)
+	}
+	for k, qps := range c.Overrides {
+		if qps < 0 {
+			return fmt.Errorf("invalid qps for %q: %v", k, qps)
+		}
+	}
+	return nil
+}
+
+// quotas applies the rate limits of a QuotasConfig.
+type quotas struct {
+	cfg QuotasConfig
+
+	clientIP *keyedLimiter
+	clientID *keyedLimiter
+	issuer   *keyedLimiter
+	// ipOverrides holds the CIDRs with a ClientIP override, from the most to
+	// the least specific.
+	ipOverrides []netip.Prefix
+}
+
+// newQuotas returns the quotas described by cfg.
+func newQuotas(cfg QuotasConfig) (*quotas, error) {
+	if cfg.IPv4PrefixLen == 0 {
+		cfg.IPv4PrefixLen = defaultIPv4PrefixLen
+	}
+	if cfg.IPv6PrefixLen == 0 {
+		cfg.IPv6PrefixLen = defaultIPv6PrefixLen
+	}
+	if cfg.MaxKeys == 0 {
+		cfg.MaxKeys = defaultQuotaMaxKeys
+	}
+	if cfg.IPv4PrefixLen < 0 || cfg.IPv4PrefixLen > 32 {
+		return nil, fmt.Errorf("invalid IPv4 prefix length: %d", cfg.IPv4PrefixLen)
+	}
+	if cfg.IPv6PrefixLen < 0 || cfg.IPv6PrefixLen > 128 {
+		return nil, fmt.Errorf("invalid IPv6 prefix length: %d", cfg.IPv6PrefixLen)
+	}
+	if cfg.MaxKeys < 0 {
+		return nil, fmt.Errorf("invalid max keys: %d", cfg.MaxKeys)
+	}
+
+	q := &quotas{cfg: cfg}
+	if c := cfg.ClientIP; c != nil {
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid client IP quota: %v", err)
+		}
+		// Key overrides by their canonical CIDR, which is what clientIPKey
+		// returns for IPs they contain.
+		overrides := make(map[string]float64, len(c.Overrides))
+		for k, qps := range c.Overrides {
+			p, err := netip.ParsePrefix(k)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client IP quota override: %v", err)
+			}
+			p = p.Masked()
+			overrides[p.String()] = qps
+			q.ipOverrides = append(q.ipOverrides, p)
+		}
+		slices.SortFunc(q.ipOverrides, func(a, b netip.Prefix) int { return b.Bits() - a.Bits() })
+		q.clientIP = newKeyedLimiter(c.QPS, overrides, cfg.MaxKeys)
+	}
+	if c := cfg.ClientID; c != nil {
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid client ID quota: %v", err)
+		}
+		q.clientID = newKeyedLimiter(c.QPS, c.Overrides, cfg.MaxKeys)
+	}
+	if c := cfg.Issuer; c != nil {
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid issuer quota: %v", err)
+		}
+		overrides := make(map[string]float64, len(c.Overrides))
+		for k, qps := range c.Overrides {
+			fp, err := hex.DecodeString(k)
+			if err != nil || len(fp) != sha256.Size {
+				return nil, fmt.Errorf("invalid issuer quota override: %q is not a hex encoded SHA-256 fingerprint", k)
+			}
+			overrides[hex.EncodeToString(fp)] = qps
+		}
+		q.issuer = newKeyedLimiter(c.QPS, overrides, cfg.MaxKeys)
+	}
+	return q, nil
+}
+
+// clientIPKey returns the key of the client IP quota for r, and false if r
+// has no valid client IP.
+func (q *quotas) clientIPKey(r *http.Request) (string, bool) {
+	ip, ok := clientIP(r, q.cfg.ClientIPHeader)
+	if !ok {
+		return "", false
+	}
+	for _, p := range q.ipOverrides {
+		if p.Contains(ip) {
+			return p.String(), true
+		}
+	}
+	bits := q.cfg.IPv6PrefixLen
+	if ip.Is4() {
+		bits = q.cfg.IPv4PrefixLen
+	}
+	p, err := ip.Prefix(bits)
+	if err != nil {
+		return "", false
+	}
+	return p.String(), true
+}
+
+// clientIP returns the IP of the client that sent r, read from header if
+// set, and whether there is one.
+func clientIP(r *http.Request, header string) (netip.Addr, bool) {
+	if header != "" {
+		if v := r.Header.Values(header); len(v) > 0 {
+			ips := strings.Split(v[len(v)-1], ",")
+			if ip, err := netip.ParseAddr(strings.TrimSpace(ips[len(ips)-1])); err == nil {
+				return ip.Unmap(), true
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// issuerKey returns the key of the issuer quota for a validated chain.
+func issuerKey(chain []*x509.Certificate) (string, bool) {
+	if len(chain) < 2 {
+		return "", false
+	}
+	fp := sha256.Sum256(chain[1].Raw)
+	return hex.EncodeToString(fp[:]), true
+}
+
+type clientIDContextKey struct{}
+
+// ContextWithClientID returns a copy of ctx holding the authenticated
+// identity of the client making a request. Submissions are subject to the
+// client ID quota of this identity.
+func ContextWithClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDContextKey{}, id)
+}
+
+// ClientIDFromContext returns the client identity held by ctx, if any.
+func ClientIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(clientIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// keyedLimiter applies a separate rate limit to each key.
+type keyedLimiter struct {
+	qps       float64
+	overrides map[string]float64
+	// limiters holds the limiters of the most recently seen keys.
+	limiters *lru[string, *rate.Limiter]
+}
+
+func newKeyedLimiter(qps float64, overrides map[string]float64, maxKeys int) *keyedLimiter {
+	return &keyedLimiter{
+		qps:       qps,
+		overrides: overrides,
+		limiters:  newLRU[string, *rate.Limiter](maxKeys),
+	}
+}
+
+// allow returns true if a submission with this key is within its quota, and
+// counts it against the quota.
+func (k *keyedLimiter) allow(key string) bool {
+	l := k.limiters.getOrAdd(key, func() *rate.Limiter {
+		qps, ok := k.overrides[key]
+		if !ok {
+			qps = k.qps
+		}
+		return rate.NewLimiter(rate.Limit(qps), int(math.Ceil(qps)))
+	})
+	return l.Allow()
+}
+
+// Quotas configures per client IP, client identity, and issuer rate limits.
+func (r *RateLimits) Quotas(cfg QuotasConfig) error {
+	q, err := newQuotas(cfg)
+	if err != nil {
+		return err
+	}
+	r.setQuotas(q)
+	return nil
+}
+
+// setQuotas replaces the quotas with q, and disables them if q is nil.
+func (r *RateLimits) setQuotas(q *quotas) {
+	r.mu.Lock()
+	r.quotas = q
+	r.mu.Unlock()
+	if q == nil {
+		slog.InfoContext(context.Background(), "Disabled quotas")
+		return
+	}
+	slog.InfoContext(context.Background(), "Configured quotas", slog.Bool("client_ip", q.clientIP != nil), slog.Bool("client_id", q.clientID != nil), slog.Bool("issuer", q.issuer != nil), slog.Int("max_keys", q.cfg.MaxKeys))
+}
+
+// AcceptClient returns true if a submission sent with req is within the
+// quotas of its client IP and client identity. Otherwise, it returns false
+// and the type of the exhausted quota.
+func (r *RateLimits) AcceptClient(ctx context.Context, origin string, req *http.Request) (bool, string) {
+	r.mu.RLock()
+	q := r.quotas
+	r.mu.RUnlock()
+	if q == nil {
+		return true, ""
+	}
+	if q.clientIP != nil {
+		if key, ok := q.clientIPKey(req); ok && !q.clientIP.allow(key) {
+			rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rateLimitReasonKey.String(quotaClientIP)))
+			return false, quotaClientIP
+		}
+	}
+	if q.clientID != nil {
+		if id, ok := ClientIDFromContext(req.Context()); ok && !q.clientID.allow(id) {
+			rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rateLimitReasonKey.String(quotaClientID)))
+			return false, quotaClientID
+		}
+	}
+	return true, ""
+}
+
+// AcceptIssuer returns true if a validated chain is within the quota of its
+// issuer, and false otherwise.
+func (r *RateLimits) AcceptIssuer(ctx context.Context, origin string, chain []*x509.Certificate) bool {
+	key, ok := issuerKey(chain)
+	return !ok || r.acceptIssuerKey(ctx, origin, key)
+}
+
+// acceptIssuerKey is like AcceptIssuer, for the issuer whose key is key.
+func (r *RateLimits) acceptIssuerKey(ctx context.Context, origin, key string) bool {
+	r.mu.RLock()
+	q := r.quotas
+	r.mu.RUnlock()
+	if q == nil || q.issuer == nil {
+		return true
+	}
+	if !q.issuer.allow(key) {
+		rateLimitedRequests.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rateLimitReasonKey.String(quotaIssuer)))
+		return false
+	}
+	return true
+}
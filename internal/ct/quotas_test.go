@@ -0,0 +1,283 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+func TestParseQuotas(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		data    string
+		wantErr string
+	}{
+		{
+			desc: "valid",
+			data: `{"client_ip": {"qps": 10, "overrides": {"192.0.2.0/24": 100, "2001:db8::/32": 0}}, "client_id": {"qps": 5}, "issuer": {"qps": 1, "overrides": {"` + strings.Repeat("ab", 32) + `": 2}}, "ipv4_prefix_len": 24, "max_keys": 10}`,
+		},
+		{
+			desc:    "unknown-field",
+			data:    `{"client_ips": {"qps": 10}}`,
+			wantErr: "unknown field",
+		},
+		{
+			desc:    "negative-qps",
+			data:    `{"client_id": {"qps": -1}}`,
+			wantErr: "invalid qps",
+		},
+		{
+			desc:    "negative-override",
+			data:    `{"client_id": {"qps": 1, "overrides": {"alice": -1}}}`,
+			wantErr: "invalid qps",
+		},
+		{
+			desc:    "invalid-cidr",
+			data:    `{"client_ip": {"qps": 1, "overrides": {"192.0.2.1": 2}}}`,
+			wantErr: "invalid client IP quota override",
+		},
+		{
+			desc:    "invalid-fingerprint",
+			data:    `{"issuer": {"qps": 1, "overrides": {"abcd": 2}}}`,
+			wantErr: "invalid issuer quota override",
+		},
+		{
+			desc:    "invalid-prefix-len",
+			data:    `{"client_ip": {"qps": 1}, "ipv4_prefix_len": 33}`,
+			wantErr: "invalid IPv4 prefix length",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseQuotas([]byte(tc.data))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseQuotas(): %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("ParseQuotas() err=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientIPKey(t *testing.T) {
+	q, err := newQuotas(QuotasConfig{
+		ClientIP:       &QuotaConfig{QPS: 1, Overrides: map[string]float64{"192.0.2.0/24": 10, "192.0.2.128/25": 20}},
+		IPv4PrefixLen:  16,
+		ClientIPHeader: "X-Forwarded-For",
+	})
+	if err != nil {
+		t.Fatalf("newQuotas(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc       string
+		remoteAddr string
+		header     string
+		want       string
+	}{
+		{desc: "ipv4", remoteAddr: "198.51.100.7:1234", want: "198.51.0.0/16"},
+		{desc: "ipv6", remoteAddr: "[2001:db8:1:2:3::4]:1234", want: "2001:db8:1:2::/64"},
+		{desc: "ipv4-mapped", remoteAddr: "[::ffff:198.51.100.7]:1234", want: "198.51.0.0/16"},
+		{desc: "override", remoteAddr: "192.0.2.1:1234", want: "192.0.2.0/24"},
+		{desc: "most-specific-override", remoteAddr: "192.0.2.200:1234", want: "192.0.2.128/25"},
+		{desc: "header", remoteAddr: "10.0.0.1:1234", header: "203.0.113.1, 198.51.100.7", want: "198.51.0.0/16"},
+		{desc: "invalid-header", remoteAddr: "10.0.0.1:1234", header: "unknown", want: "10.0.0.0/16"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.header != "" {
+				r.Header.Set("X-Forwarded-For", tc.header)
+			}
+			got, ok := q.clientIPKey(r)
+			if !ok || got != tc.want {
+				t.Errorf("clientIPKey()=(%q, %t), want (%q, true)", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestAcceptClient(t *testing.T) {
+	var r RateLimits
+	if err := r.Quotas(QuotasConfig{
+		ClientIP: &QuotaConfig{QPS: 1, Overrides: map[string]float64{"192.0.2.0/24": 0}},
+		ClientID: &QuotaConfig{QPS: 1},
+	}); err != nil {
+		t.Fatalf("Quotas(): %v", err)
+	}
+	req := func(remoteAddr, id string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = remoteAddr
+		if id != "" {
+			r = r.WithContext(ContextWithClientID(r.Context(), id))
+		}
+		return r
+	}
+	for _, tc := range []struct {
+		desc      string
+		req       *http.Request
+		wantOK    bool
+		wantQuota string
+	}{
+		{desc: "first", req: req("198.51.100.7:1", ""), wantOK: true},
+		{desc: "same-ip", req: req("198.51.100.7:2", ""), wantQuota: quotaClientIP},
+		{desc: "other-ip", req: req("198.51.100.8:1", "alice"), wantOK: true},
+		{desc: "same-id", req: req("198.51.100.9:1", "alice"), wantQuota: quotaClientID},
+		{desc: "other-id", req: req("198.51.100.10:1", "bob"), wantOK: true},
+		{desc: "override", req: req("192.0.2.1:1", ""), wantQuota: quotaClientIP},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ok, quota := r.AcceptClient(t.Context(), "example.com", tc.req)
+			if ok != tc.wantOK || quota != tc.wantQuota {
+				t.Errorf("AcceptClient()=(%t, %q), want (%t, %q)", ok, quota, tc.wantOK, tc.wantQuota)
+			}
+		})
+	}
+}
+
+func TestAcceptIssuer(t *testing.T) {
+	issuer := &x509.Certificate{Raw: []byte("issuer")}
+	other := &x509.Certificate{Raw: []byte("other")}
+	fp := sha256.Sum256(issuer.Raw)
+
+	var r RateLimits
+	if err := r.Quotas(QuotasConfig{
+		Issuer: &QuotaConfig{QPS: 0, Overrides: map[string]float64{hex.EncodeToString(fp[:]): 1}},
+	}); err != nil {
+		t.Fatalf("Quotas(): %v", err)
+	}
+	leaf := &x509.Certificate{}
+	if !r.AcceptIssuer(t.Context(), "example.com", []*x509.Certificate{leaf, issuer}) {
+		t.Error("AcceptIssuer()=false for the first submission of an issuer within its override")
+	}
+	if r.AcceptIssuer(t.Context(), "example.com", []*x509.Certificate{leaf, issuer}) {
+		t.Error("AcceptIssuer()=true for an issuer over its quota")
+	}
+	if r.AcceptIssuer(t.Context(), "example.com", []*x509.Certificate{leaf, other}) {
+		t.Error("AcceptIssuer()=true for an issuer without quota")
+	}
+}
+
+func TestQuotasMaxKeys(t *testing.T) {
+	k := newKeyedLimiter(1, nil, 2)
+	for _, key := range []string{"a", "b", "c"} {
+		if !k.allow(key) {
+			t.Fatalf("allow(%q)=false, want true", key)
+		}
+	}
+	if got := k.limiters.len(); got != 2 {
+		t.Errorf("tracking %d keys, want 2", got)
+	}
+	// "a" was evicted, so it gets a full quota again.
+	if !k.allow("a") {
+		t.Error("allow(\"a\")=false after being evicted, want true")
+	}
+	if k.allow("c") {
+		t.Error("allow(\"c\")=true over its quota, want false")
+	}
+}
+
+func TestRateLimitsConfigQuotas(t *testing.T) {
+	var r RateLimits
+	cfg := RateLimitsConfig{Quotas: &QuotasConfig{ClientID: &QuotaConfig{QPS: 3}}}
+	if err := r.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig(): %v", err)
+	}
+	got := r.Config().Quotas
+	if got == nil || got.ClientID == nil || got.ClientID.QPS != 3 || got.MaxKeys != defaultQuotaMaxKeys {
+		t.Errorf("Config().Quotas=%+v, want the client ID quota with defaults", got)
+	}
+	if err := r.SetConfig(RateLimitsConfig{Quotas: &QuotasConfig{MaxKeys: -1}}); err == nil {
+		t.Error("SetConfig() with invalid quotas succeeded")
+	}
+	if r.Config().Quotas == nil {
+		t.Error("invalid SetConfig() disabled quotas")
+	}
+	if err := r.SetConfig(RateLimitsConfig{}); err != nil {
+		t.Fatalf("SetConfig(): %v", err)
+	}
+	if got := r.Config().Quotas; got != nil {
+		t.Errorf("Config().Quotas=%+v, want nil", got)
+	}
+}
+
+func TestAddChainQuotas(t *testing.T) {
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	for _, tc := range []struct {
+		desc   string
+		quotas QuotasConfig
+		want   ErrorCode
+	}{
+		{
+			desc:   "client-ip",
+			quotas: QuotasConfig{ClientIP: &QuotaConfig{QPS: 1}},
+			want:   ErrorCodeRateLimitedClientIP,
+		},
+		{
+			desc:   "issuer",
+			quotas: QuotasConfig{Issuer: &QuotaConfig{QPS: 1}},
+			want:   ErrorCodeRateLimitedIssuer,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			opts := hOpts()
+			opts.JSONErrors = true
+			if err := opts.RateLimits.Quotas(tc.quotas); err != nil {
+				t.Fatalf("Quotas(): %v", err)
+			}
+			server := setupTestServer(t, log, path.Join(prefix, rfc6962.AddChainPath), opts)
+			defer server.Close()
+
+			for i, wantStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+				resp, err := http.Post(server.URL+path.Join(prefix, rfc6962.AddChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+				if err != nil {
+					t.Fatalf("http.Post(): %v", err)
+				}
+				var rsp ErrorResponse
+				if wantStatus != http.StatusOK {
+					if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+						t.Fatalf("json.Decode(): %v", err)
+					}
+				}
+				_ = resp.Body.Close()
+				if resp.StatusCode != wantStatus {
+					t.Fatalf("submission %d: got status %d, want %d", i, resp.StatusCode, wantStatus)
+				}
+				if wantStatus == http.StatusTooManyRequests {
+					if resp.Header.Get("Retry-After") == "" {
+						t.Error("429 without Retry-After header")
+					}
+					if rsp.Code != tc.want {
+						t.Errorf("got error code %q, want %q", rsp.Code, tc.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -37,6 +37,9 @@ type requestLog interface {
 	start(context.Context) context.Context
 	// origin will be called once per request to set the log prefix.
 	origin(context.Context, string)
+	// clientID will be called once per request from an authenticated client,
+	// with its identity.
+	clientID(context.Context, string)
 	// addDERToChain will be called once for each certificate in a submitted
 	// chain. It's called early in request processing so the supplied bytes
 	// have not been checked for validity. Calls will be in order of the
@@ -46,6 +49,9 @@ type requestLog interface {
 	// after it has been parsed and verified. Calls will be in order of the
 	// certificates as presented in the request with the root last.
 	addCertToChain(context.Context, *x509.Certificate)
+	// lintFinding will be called once for each lint that the submitted
+	// certificate fails.
+	lintFinding(context.Context, LintFinding)
 	// issueSCT will be called once when the server is about to issue an SCT to a
 	// client. This should not be called if the submission process fails before an
 	// SCT could be presented to a client, even if this is unrelated to
@@ -73,6 +79,11 @@ func (dlr *DefaultRequestLog) origin(ctx context.Context, p string) {
 	logger.ExtremeContext(ctx, "RL: LogOrigin", slog.String("origin", p))
 }
 
+// clientID logs the identity of the authenticated client making the request.
+func (dlr *DefaultRequestLog) clientID(ctx context.Context, id string) {
+	logger.ExtremeContext(ctx, "RL: ClientID", slog.String("client_id", id))
+}
+
 // addDERToChain logs the raw bytes of a submitted certificate.
 func (dlr *DefaultRequestLog) addDERToChain(ctx context.Context, d []byte) {
 	// Explicit hex encoding below to satisfy CodeQL:
@@ -89,6 +100,14 @@ func (dlr *DefaultRequestLog) addCertToChain(ctx context.Context, cert *x509.Cer
 		slog.String("not_after", cert.NotAfter.Format(time.RFC1123Z)))
 }
 
+// lintFinding logs a lint that a submitted certificate fails.
+func (dlr *DefaultRequestLog) lintFinding(ctx context.Context, f LintFinding) {
+	logger.ExtremeContext(ctx, "RL: Lint",
+		slog.String("lint", f.Lint),
+		slog.String("severity", string(f.Severity)),
+		slog.Any("error", f.Err))
+}
+
 // issueSCT logs an SCT that will be issued to a client.
 func (dlr *DefaultRequestLog) issueSCT(ctx context.Context, sct []byte) {
 	logger.ExtremeContext(ctx, "RL: Issuing SCT", slog.String("sct", hex.EncodeToString(sct)))
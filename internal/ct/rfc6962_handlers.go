@@ -0,0 +1,442 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	tfl "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	hasher "github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tesseract/internal/client"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/types/staticct"
+	"github.com/transparency-dev/tesseract/internal/types/tls"
+	"github.com/transparency-dev/tesseract/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Constants for RFC 6962 read entrypoint names, as exposed in statistics/logging.
+const (
+	getSTHName            = entrypointName("GetSTH")
+	getSTHConsistencyName = entrypointName("GetSTHConsistency")
+	getProofByHashName    = entrypointName("GetProofByHash")
+	getEntriesName        = entrypointName("GetEntries")
+	getEntryAndProofName  = entrypointName("GetEntryAndProof")
+)
+
+// readEntrypoints is a list of RFC 6962 read entrypoint names as exposed in statistics/logging.
+var readEntrypoints = []entrypointName{getSTHName, getSTHConsistencyName, getProofByHashName, getEntriesName, getEntryAndProofName}
+
+// maxGetEntries is the maximum number of entries returned by a single
+// get-entries request. Responses never span more than one entry bundle.
+const maxGetEntries = layout.EntryBundleWidth
+
+// NewReadPathHandlers returns handlers serving the RFC 6962 read API from
+// static-ct-api log data.
+//
+// These endpoints are not part of https://c2sp.org/static-ct-api, they are
+// served to help clients that have not migrated to it yet.
+func NewReadPathHandlers(ctx context.Context, opts *HandlerOptions, log *log) pathHandlers {
+	once.Do(func() { setupMetrics() })
+
+	prefix := normalizePathPrefix(opts.PathPrefix)
+
+	return pathHandlers{
+		prefix + rfc6962.GetSTHPath:            appHandler{opts: opts, log: log, handler: getSTH, name: getSTHName, method: http.MethodGet},
+		prefix + rfc6962.GetSTHConsistencyPath: appHandler{opts: opts, log: log, handler: getSTHConsistency, name: getSTHConsistencyName, method: http.MethodGet},
+		prefix + rfc6962.GetProofByHashPath:    appHandler{opts: opts, log: log, handler: getProofByHash, name: getProofByHashName, method: http.MethodGet},
+		prefix + rfc6962.GetEntriesPath:        appHandler{opts: opts, log: log, handler: getEntries, name: getEntriesName, method: http.MethodGet},
+		prefix + rfc6962.GetEntryAndProofPath:  appHandler{opts: opts, log: log, handler: getEntryAndProof, name: getEntryAndProofName, method: http.MethodGet},
+	}
+}
+
+func getSTH(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, _ *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.getSTH")
+	defer span.End()
+
+	cp, n, err := log.latestCheckpoint(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	sig, err := cpNoteSignature(n.Sigs, log.cpVerifier.KeyHash())
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to extract checkpoint signature: %v", err)
+	}
+	sigBytes, err := tls.Marshal(sig.Signature)
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to marshal checkpoint signature: %v", err)
+	}
+
+	rsp := rfc6962.GetSTHResponse{
+		TreeSize:          cp.Size,
+		Timestamp:         sig.Timestamp,
+		SHA256RootHash:    cp.Hash,
+		TreeHeadSignature: sigBytes,
+	}
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write get-sth response: %v", err)
+	}
+	return http.StatusOK, nil, nil
+}
+
+func getSTHConsistency(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, r *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.getSTHConsistency")
+	defer span.End()
+
+	first, err := parseUintParam(r, "first")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	second, err := parseUintParam(r, "second")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if first > second {
+		return http.StatusBadRequest, nil, fmt.Errorf("first %d is greater than second %d", first, second)
+	}
+
+	cp, _, err := log.latestCheckpoint(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	if second > cp.Size {
+		return http.StatusBadRequest, nil, fmt.Errorf("second %d is greater than the current tree size %d", second, cp.Size)
+	}
+
+	rsp := rfc6962.GetSTHConsistencyResponse{Consistency: [][]byte{}}
+	if first > 0 && first < second {
+		pb, err := log.proofBuilder(ctx, second, cp)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		rsp.Consistency, err = pb.ConsistencyProof(ctx, first, second)
+		if err != nil {
+			return http.StatusInternalServerError, nil, fmt.Errorf("failed to build consistency proof between %d and %d: %v", first, second, err)
+		}
+	}
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write get-sth-consistency response: %v", err)
+	}
+	return http.StatusOK, nil, nil
+}
+
+func getProofByHash(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, r *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.getProofByHash")
+	defer span.End()
+
+	leafHash, err := base64.StdEncoding.DecodeString(r.FormValue("hash"))
+	if err != nil {
+		return http.StatusBadRequest, nil, fmt.Errorf("failed to decode hash parameter: %v", err)
+	}
+	if len(leafHash) != hasher.DefaultHasher.Size() {
+		return http.StatusBadRequest, nil, fmt.Errorf("hash parameter has %d bytes, want %d", len(leafHash), hasher.DefaultHasher.Size())
+	}
+	treeSize, err := parseUintParam(r, "tree_size")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if treeSize == 0 {
+		return http.StatusBadRequest, nil, errors.New("tree_size must be greater than 0")
+	}
+
+	cp, _, err := log.latestCheckpoint(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	if treeSize > cp.Size {
+		return http.StatusBadRequest, nil, fmt.Errorf("tree_size %d is greater than the current tree size %d", treeSize, cp.Size)
+	}
+
+	idx, err := log.findLeafIndex(ctx, leafHash, treeSize)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return http.StatusNotFound, nil, err
+		}
+		if errors.Is(err, storage.ErrNoLeafIndex) {
+			return http.StatusNotImplemented, nil, errors.New("get-proof-by-hash requires a leaf index, which this log doesn't maintain")
+		}
+		return http.StatusInternalServerError, nil, err
+	}
+
+	pb, err := log.proofBuilder(ctx, treeSize, cp)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	auditPath, err := pb.InclusionProof(ctx, idx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to build inclusion proof for index %d: %v", idx, err)
+	}
+
+	rsp := rfc6962.GetProofByHashResponse{
+		LeafIndex: int64(idx),
+		AuditPath: auditPath,
+	}
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write get-proof-by-hash response: %v", err)
+	}
+	return http.StatusOK, nil, nil
+}
+
+func getEntries(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, r *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.getEntries")
+	defer span.End()
+
+	start, err := parseUintParam(r, "start")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	end, err := parseUintParam(r, "end")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if start > end {
+		return http.StatusBadRequest, nil, fmt.Errorf("start %d is greater than end %d", start, end)
+	}
+
+	cp, _, err := log.latestCheckpoint(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	if start >= cp.Size {
+		return http.StatusBadRequest, nil, fmt.Errorf("start %d is beyond the current tree size %d", start, cp.Size)
+	}
+
+	// Only serve entries from a single bundle, clients will come back for more.
+	end = min(end, cp.Size-1, (start/layout.EntryBundleWidth+1)*layout.EntryBundleWidth-1, start+maxGetEntries-1)
+
+	bundle, err := client.GetEntryBundle(ctx, log.reader.ReadEntryBundle, start/layout.EntryBundleWidth, cp.Size)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	issuers := map[[32]byte][]byte{}
+	rsp := rfc6962.GetEntriesResponse{Entries: make([]rfc6962.LeafEntry, 0, end-start+1)}
+	for i := start; i <= end; i++ {
+		leaf, extraData, err := log.leafEntry(ctx, bundle, i, issuers)
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		rsp.Entries = append(rsp.Entries, rfc6962.LeafEntry{LeafInput: leaf, ExtraData: extraData})
+	}
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write get-entries response: %v", err)
+	}
+	return http.StatusOK, nil, nil
+}
+
+func getEntryAndProof(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, r *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.getEntryAndProof")
+	defer span.End()
+
+	leafIndex, err := parseUintParam(r, "leaf_index")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	treeSize, err := parseUintParam(r, "tree_size")
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if leafIndex >= treeSize {
+		return http.StatusBadRequest, nil, fmt.Errorf("leaf_index %d is not lower than tree_size %d", leafIndex, treeSize)
+	}
+
+	cp, _, err := log.latestCheckpoint(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	if treeSize > cp.Size {
+		return http.StatusBadRequest, nil, fmt.Errorf("tree_size %d is greater than the current tree size %d", treeSize, cp.Size)
+	}
+
+	bundle, err := client.GetEntryBundle(ctx, log.reader.ReadEntryBundle, leafIndex/layout.EntryBundleWidth, cp.Size)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	leaf, extraData, err := log.leafEntry(ctx, bundle, leafIndex, map[[32]byte][]byte{})
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	pb, err := log.proofBuilder(ctx, treeSize, cp)
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	auditPath, err := pb.InclusionProof(ctx, leafIndex)
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to build inclusion proof for index %d: %v", leafIndex, err)
+	}
+
+	rsp := rfc6962.GetEntryAndProofResponse{
+		LeafInput: leaf,
+		ExtraData: extraData,
+		AuditPath: auditPath,
+	}
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write get-entry-and-proof response: %v", err)
+	}
+	return http.StatusOK, nil, nil
+}
+
+// latestCheckpoint reads the latest checkpoint from storage, and verifies it.
+func (l *log) latestCheckpoint(ctx context.Context) (*tfl.Checkpoint, *note.Note, error) {
+	cp, _, n, err := client.FetchCheckpoint(ctx, l.reader.ReadCheckpoint, l.cpVerifier, l.origin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	return cp, n, nil
+}
+
+// proofBuilder returns a ProofBuilder for a tree of the given size.
+//
+// latest must be the latest checkpoint, size must not be greater than its size.
+func (l *log) proofBuilder(ctx context.Context, size uint64, latest *tfl.Checkpoint) (*client.ProofBuilder, error) {
+	f := l.tileFetcher(latest.Size)
+	if size == latest.Size {
+		pb, err := client.NewProofBuilder(ctx, *latest, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proof builder for size %d: %v", size, err)
+		}
+		return pb, nil
+	}
+
+	// There is no signed root hash for older tree sizes, recompute it from the tiles.
+	hashes, err := client.FetchRangeNodes(ctx, size, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch range nodes for size %d: %v", size, err)
+	}
+	rng, err := (&compact.RangeFactory{Hash: hasher.DefaultHasher.HashChildren}).NewRange(0, size, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compact range for size %d: %v", size, err)
+	}
+	root, err := rng.GetRootHash(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute root hash for size %d: %v", size, err)
+	}
+	pb, err := client.NewProofBuilder(ctx, tfl.Checkpoint{Origin: l.origin, Size: size, Hash: root}, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof builder for size %d: %v", size, err)
+	}
+	return pb, nil
+}
+
+// tileFetcher returns a TileFetcherFunc for a log whose latest size is latestSize.
+//
+// Partial tiles for smaller tree sizes might not exist anymore. Since tiles
+// only ever grow, they fall back to the tile at latestSize.
+func (l *log) tileFetcher(latestSize uint64) client.TileFetcherFunc {
+	return func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+		tile, err := l.reader.ReadTile(ctx, level, index, p)
+		if errors.Is(err, os.ErrNotExist) && p > 0 {
+			if latestP := layout.PartialTileSize(level, index, latestSize); latestP != p {
+				return l.reader.ReadTile(ctx, level, index, latestP)
+			}
+		}
+		return tile, err
+	}
+}
+
+// findLeafIndex returns the index of the leaf whose hash is leafHash, in a
+// tree of size treeSize.
+//
+// It returns an error wrapping os.ErrNotExist if there is no such leaf, and
+// storage.ErrNoLeafIndex if the log doesn't maintain a leaf index: scanning
+// leaf hashes instead would let any client read the whole tree.
+func (l *log) findLeafIndex(ctx context.Context, leafHash []byte, treeSize uint64) (uint64, error) {
+	idx, err := l.reader.LeafIndex(ctx, [32]byte(leafHash))
+	switch {
+	case errors.Is(err, storage.ErrNoLeafIndex):
+		return 0, err
+	case err != nil:
+		return 0, fmt.Errorf("failed to look up leaf hash %x: %w", leafHash, err)
+	case idx >= treeSize:
+		return 0, fmt.Errorf("leaf hash %x is at index %d, not in tree of size %d: %w", leafHash, idx, treeSize, os.ErrNotExist)
+	default:
+		return idx, nil
+	}
+}
+
+// leafEntry returns the TLS-encoded MerkleTreeLeaf and extra_data of the entry
+// at index i, which must be in bundle.
+//
+// issuers caches issuer certificates across calls.
+func (l *log) leafEntry(ctx context.Context, bundle staticct.EntryBundle, i uint64, issuers map[[32]byte][]byte) ([]byte, []byte, error) {
+	bIdx := i % layout.EntryBundleWidth
+	if bIdx >= uint64(len(bundle.Entries)) {
+		return nil, nil, fmt.Errorf("entry %d not found in bundle of %d entries", i, len(bundle.Entries))
+	}
+	var e staticct.Entry
+	if err := e.UnmarshalText(bundle.Entries[bIdx]); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse entry %d: %v", i, err)
+	}
+	if e.LeafIndex != i {
+		return nil, nil, fmt.Errorf("entry %d has leaf index %d", i, e.LeafIndex)
+	}
+
+	chain := make([][]byte, 0, len(e.FingerprintsChain))
+	for _, fp := range e.FingerprintsChain {
+		issuer, ok := issuers[fp]
+		if !ok {
+			var err error
+			issuer, err = l.reader.ReadIssuer(ctx, fp)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read issuer %x: %v", fp, err)
+			}
+			issuers[fp] = issuer
+		}
+		chain = append(chain, issuer)
+	}
+
+	rle, err := e.RawLogEntry(chain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build log entry %d: %v", i, err)
+	}
+	leaf, err := tls.Marshal(rle.Leaf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal leaf %d: %v", i, err)
+	}
+	extraData, err := staticct.ExtraData(rle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build extra data for entry %d: %v", i, err)
+	}
+	return leaf, extraData, nil
+}
+
+// parseUintParam parses the named form value of r as a uint64.
+func parseUintParam(r *http.Request, name string) (uint64, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return 0, fmt.Errorf("missing %s parameter", name)
+	}
+	i, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s parameter %q: %v", name, v, err)
+	}
+	return i, nil
+}
+
+// writeJSONResponse writes v to w as a JSON response.
+func writeJSONResponse(w http.ResponseWriter, v any) error {
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	return json.NewEncoder(w).Encode(v)
+}
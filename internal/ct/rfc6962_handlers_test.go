@@ -0,0 +1,303 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/transparency-dev/merkle/proof"
+	hasher "github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/types/tls"
+)
+
+// setupReadTestServer creates a test TesseraCT server serving both
+// static-ct-api submission endpoints and RFC 6962 read endpoints.
+//
+// The log signs with a real key, since read endpoints verify checkpoints.
+func setupReadTestServer(t *testing.T, leafIndex bool) (*log, *httptest.Server) {
+	t.Helper()
+	signer, err := loadPEMPrivateKey("../testdata/test_ct_server_ecdsa_private_key.pem")
+	if err != nil {
+		t.Fatalf("Can't open key: %v", err)
+	}
+	log, _ := setupTestLogWithSigner(t, signer, leafIndex)
+
+	mux := http.NewServeMux()
+	for p, h := range NewPathHandlers(t.Context(), hOpts(), log) {
+		mux.Handle(p, h)
+	}
+	for p, h := range NewReadPathHandlers(t.Context(), hOpts(), log) {
+		mux.Handle(p, h)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return log, server
+}
+
+// getJSON sends a GET request to path with params, and decodes the JSON response into rsp.
+func getJSON(t *testing.T, server *httptest.Server, path string, params url.Values, rsp any) int {
+	t.Helper()
+	resp, err := http.Get(server.URL + prefix + path + "?" + params.Encode())
+	if err != nil {
+		t.Fatalf("http.Get(%s)=(_,%q); want (_,nil)", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusOK && rsp != nil {
+		if err := json.NewDecoder(resp.Body).Decode(rsp); err != nil {
+			t.Fatalf("json.Decode()=%v; want nil", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// waitForSTH polls get-sth until the log has integrated size entries.
+func waitForSTH(t *testing.T, server *httptest.Server, size uint64) rfc6962.GetSTHResponse {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var sth rfc6962.GetSTHResponse
+		if code := getJSON(t, server, rfc6962.GetSTHPath, nil, &sth); code == http.StatusOK && sth.TreeSize >= size {
+			return sth
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for a tree of size %d", size)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestNewReadPathHandlers(t *testing.T) {
+	log, _ := setupTestLog(t)
+	handlers := NewReadPathHandlers(t.Context(), &HandlerOptions{PathPrefix: prefix}, log)
+	if got, want := len(handlers), len(readEntrypoints); got != want {
+		t.Fatalf("len(handlers)=%d; want %d", got, want)
+	}
+
+	var hNames []entrypointName
+	var hPaths []string
+	for p, v := range handlers {
+		hNames = append(hNames, v.name)
+		hPaths = append(hPaths, p)
+		if v.method != http.MethodGet {
+			t.Errorf("handler %s has method %s; want %s", v.name, v.method, http.MethodGet)
+		}
+	}
+	if !cmp.Equal(readEntrypoints, hNames, cmpopts.SortSlices(func(n1, n2 entrypointName) bool {
+		return n1 < n2
+	})) {
+		t.Errorf("Handler names mismatch got: %v, want: %v", hNames, readEntrypoints)
+	}
+
+	entrypaths := []string{prefix + rfc6962.GetSTHPath, prefix + rfc6962.GetSTHConsistencyPath, prefix + rfc6962.GetProofByHashPath, prefix + rfc6962.GetEntriesPath, prefix + rfc6962.GetEntryAndProofPath}
+	if !cmp.Equal(entrypaths, hPaths, cmpopts.SortSlices(func(n1, n2 string) bool {
+		return n1 < n2
+	})) {
+		t.Errorf("Handler paths mismatch got: %v, want: %v", hPaths, entrypaths)
+	}
+}
+
+func TestReadHandlers(t *testing.T) {
+	for _, leafIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("leafIndex=%t", leafIndex), func(t *testing.T) {
+			testReadHandlers(t, leafIndex)
+		})
+	}
+}
+
+func testReadHandlers(t *testing.T, leafIndex bool) {
+	log, server := setupReadTestServer(t, leafIndex)
+	defer timeSource.Reset()
+
+	chains := [][]string{
+		{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM},
+		{testdata.TestCertPEM, testdata.CACertPEM},
+	}
+	for _, c := range chains {
+		timeSource.Add1m()
+		resp, err := http.Post(server.URL+prefix+rfc6962.AddChainPath, "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, c)))
+		if err != nil {
+			t.Fatalf("http.Post(%s)=(_,%q); want (_,nil)", rfc6962.AddChainPath, err)
+		}
+		if got, want := resp.StatusCode, http.StatusOK; got != want {
+			t.Fatalf("http.Post(%s)=(%d,nil); want (%d,nil)", rfc6962.AddChainPath, got, want)
+		}
+	}
+
+	sth := waitForSTH(t, server, uint64(len(chains)))
+
+	t.Run("get-sth", func(t *testing.T) {
+		if got, want := sth.TreeSize, uint64(len(chains)); got != want {
+			t.Fatalf("sth.TreeSize=%d; want %d", got, want)
+		}
+		var ds rfc6962.DigitallySigned
+		if rest, err := tls.Unmarshal(sth.TreeHeadSignature, &ds); err != nil || len(rest) != 0 {
+			t.Fatalf("tls.Unmarshal(TreeHeadSignature)=(%x,%v); want (nil,nil)", rest, err)
+		}
+		input := rfc6962.SignedTreeHead{Version: rfc6962.V1, TreeSize: sth.TreeSize, Timestamp: sth.Timestamp}
+		copy(input.SHA256RootHash[:], sth.SHA256RootHash)
+		b, err := serializeSTHSignatureInput(input)
+		if err != nil {
+			t.Fatalf("serializeSTHSignatureInput(): %v", err)
+		}
+		h := sha256.Sum256(b)
+		if !ecdsa.VerifyASN1(log.cpVerifier.(*cpVerifier).pubKey.(*ecdsa.PublicKey), h[:], ds.Signature) {
+			t.Error("STH signature does not verify")
+		}
+	})
+
+	// Fetch all entries, and check that they commit to the STH.
+	var entries rfc6962.GetEntriesResponse
+	if code := getJSON(t, server, rfc6962.GetEntriesPath, url.Values{"start": {"0"}, "end": {"100"}}, &entries); code != http.StatusOK {
+		t.Fatalf("get-entries: got status %d; want %d", code, http.StatusOK)
+	}
+	if got, want := len(entries.Entries), len(chains); got != want {
+		t.Fatalf("get-entries: got %d entries; want %d", got, want)
+	}
+	leafHashes := make([][]byte, 0, len(entries.Entries))
+	for _, e := range entries.Entries {
+		leafHashes = append(leafHashes, hasher.DefaultHasher.HashLeaf(e.LeafInput))
+	}
+	if got, want := hasher.DefaultHasher.HashChildren(leafHashes[0], leafHashes[1]), sth.SHA256RootHash; !bytes.Equal(got, want) {
+		t.Fatalf("get-entries: leaves commit to root %x; want %x", got, want)
+	}
+
+	t.Run("get-entries", func(t *testing.T) {
+		for i, e := range entries.Entries {
+			var leaf rfc6962.MerkleTreeLeaf
+			if _, err := tls.Unmarshal(e.LeafInput, &leaf); err != nil {
+				t.Fatalf("%d: tls.Unmarshal(LeafInput): %v", i, err)
+			}
+			if got, want := leaf.TimestampedEntry.EntryType, rfc6962.X509LogEntryType; got != want {
+				t.Errorf("%d: EntryType=%v; want %v", i, got, want)
+			}
+			var cc rfc6962.CertificateChain
+			if _, err := tls.Unmarshal(e.ExtraData, &cc); err != nil {
+				t.Fatalf("%d: tls.Unmarshal(ExtraData): %v", i, err)
+			}
+			if got, want := len(cc.Entries), len(chains[i])-1; got != want {
+				t.Errorf("%d: got %d extra_data certificates; want %d", i, got, want)
+			}
+		}
+
+		var single rfc6962.GetEntriesResponse
+		if code := getJSON(t, server, rfc6962.GetEntriesPath, url.Values{"start": {"1"}, "end": {"1"}}, &single); code != http.StatusOK {
+			t.Fatalf("get-entries: got status %d; want %d", code, http.StatusOK)
+		}
+		if diff := cmp.Diff(entries.Entries[1:], single.Entries); diff != "" {
+			t.Errorf("get-entries [1, 1] diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get-sth-consistency", func(t *testing.T) {
+		var rsp rfc6962.GetSTHConsistencyResponse
+		if code := getJSON(t, server, rfc6962.GetSTHConsistencyPath, url.Values{"first": {"1"}, "second": {"2"}}, &rsp); code != http.StatusOK {
+			t.Fatalf("get-sth-consistency: got status %d; want %d", code, http.StatusOK)
+		}
+		if err := proof.VerifyConsistency(hasher.DefaultHasher, 1, 2, rsp.Consistency, leafHashes[0], sth.SHA256RootHash); err != nil {
+			t.Errorf("VerifyConsistency(): %v", err)
+		}
+	})
+
+	t.Run("get-proof-by-hash", func(t *testing.T) {
+		if !leafIndex {
+			params := url.Values{"hash": {base64.StdEncoding.EncodeToString(leafHashes[0])}, "tree_size": {"2"}}
+			if code := getJSON(t, server, rfc6962.GetProofByHashPath, params, nil); code != http.StatusNotImplemented {
+				t.Errorf("get-proof-by-hash without a leaf index: got status %d; want %d", code, http.StatusNotImplemented)
+			}
+			return
+		}
+		for _, treeSize := range []uint64{1, 2} {
+			var rsp rfc6962.GetProofByHashResponse
+			params := url.Values{"hash": {base64.StdEncoding.EncodeToString(leafHashes[0])}, "tree_size": {fmt.Sprint(treeSize)}}
+			if code := getJSON(t, server, rfc6962.GetProofByHashPath, params, &rsp); code != http.StatusOK {
+				t.Fatalf("get-proof-by-hash: got status %d; want %d", code, http.StatusOK)
+			}
+			if got, want := rsp.LeafIndex, int64(0); got != want {
+				t.Errorf("get-proof-by-hash: LeafIndex=%d; want %d", got, want)
+			}
+			root := sth.SHA256RootHash
+			if treeSize == 1 {
+				root = leafHashes[0]
+			}
+			if err := proof.VerifyInclusion(hasher.DefaultHasher, 0, treeSize, leafHashes[0], rsp.AuditPath, root); err != nil {
+				t.Errorf("VerifyInclusion(tree_size=%d): %v", treeSize, err)
+			}
+		}
+	})
+
+	t.Run("get-entry-and-proof", func(t *testing.T) {
+		var rsp rfc6962.GetEntryAndProofResponse
+		if code := getJSON(t, server, rfc6962.GetEntryAndProofPath, url.Values{"leaf_index": {"1"}, "tree_size": {"2"}}, &rsp); code != http.StatusOK {
+			t.Fatalf("get-entry-and-proof: got status %d; want %d", code, http.StatusOK)
+		}
+		if !bytes.Equal(rsp.LeafInput, entries.Entries[1].LeafInput) || !bytes.Equal(rsp.ExtraData, entries.Entries[1].ExtraData) {
+			t.Error("get-entry-and-proof: entry does not match get-entries")
+		}
+		if err := proof.VerifyInclusion(hasher.DefaultHasher, 1, 2, leafHashes[1], rsp.AuditPath, sth.SHA256RootHash); err != nil {
+			t.Errorf("VerifyInclusion(): %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		unknownHash := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+		for _, test := range []struct {
+			descr  string
+			path   string
+			params url.Values
+			want   int
+			// leafIndexOnly is set for cases which need a leaf index.
+			leafIndexOnly bool
+		}{
+			{descr: "consistency-missing-param", path: rfc6962.GetSTHConsistencyPath, params: url.Values{"first": {"1"}}, want: http.StatusBadRequest},
+			{descr: "consistency-first-after-second", path: rfc6962.GetSTHConsistencyPath, params: url.Values{"first": {"2"}, "second": {"1"}}, want: http.StatusBadRequest},
+			{descr: "consistency-second-too-large", path: rfc6962.GetSTHConsistencyPath, params: url.Values{"first": {"1"}, "second": {"3"}}, want: http.StatusBadRequest},
+			{descr: "consistency-negative", path: rfc6962.GetSTHConsistencyPath, params: url.Values{"first": {"-1"}, "second": {"1"}}, want: http.StatusBadRequest},
+			{descr: "proof-by-hash-bad-hash", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {"not base64"}, "tree_size": {"1"}}, want: http.StatusBadRequest},
+			{descr: "proof-by-hash-short-hash", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {"AAAA"}, "tree_size": {"1"}}, want: http.StatusBadRequest},
+			{descr: "proof-by-hash-zero-tree-size", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {unknownHash}, "tree_size": {"0"}}, want: http.StatusBadRequest},
+			{descr: "proof-by-hash-tree-size-too-large", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {unknownHash}, "tree_size": {"3"}}, want: http.StatusBadRequest},
+			{descr: "proof-by-hash-unknown", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {unknownHash}, "tree_size": {"2"}}, want: http.StatusNotFound, leafIndexOnly: true},
+			{descr: "proof-by-hash-not-in-tree-size", path: rfc6962.GetProofByHashPath, params: url.Values{"hash": {base64.StdEncoding.EncodeToString(leafHashes[1])}, "tree_size": {"1"}}, want: http.StatusNotFound, leafIndexOnly: true},
+			{descr: "entries-start-after-end", path: rfc6962.GetEntriesPath, params: url.Values{"start": {"1"}, "end": {"0"}}, want: http.StatusBadRequest},
+			{descr: "entries-start-too-large", path: rfc6962.GetEntriesPath, params: url.Values{"start": {"2"}, "end": {"3"}}, want: http.StatusBadRequest},
+			{descr: "entries-missing-param", path: rfc6962.GetEntriesPath, params: url.Values{"start": {"0"}}, want: http.StatusBadRequest},
+			{descr: "entry-and-proof-index-too-large", path: rfc6962.GetEntryAndProofPath, params: url.Values{"leaf_index": {"2"}, "tree_size": {"2"}}, want: http.StatusBadRequest},
+			{descr: "entry-and-proof-tree-size-too-large", path: rfc6962.GetEntryAndProofPath, params: url.Values{"leaf_index": {"0"}, "tree_size": {"3"}}, want: http.StatusBadRequest},
+		} {
+			t.Run(test.descr, func(t *testing.T) {
+				if test.leafIndexOnly && !leafIndex {
+					t.Skip("requires a leaf index")
+				}
+				if got := getJSON(t, server, test.path, test.params, nil); got != test.want {
+					t.Errorf("GET %s?%s: got status %d; want %d", test.path, test.params.Encode(), got, test.want)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/transparency-dev/tesseract/internal/x509util"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RootsLoader returns the full set of roots that a log should trust.
+type RootsLoader func(ctx context.Context) ([]*x509.Certificate, error)
+
+// ReloadRoots atomically replaces the roots in pool with the ones returned by
+// load, including removals. Fingerprints rejected by pool are never added.
+//
+// The outcome is reported through logs and metrics attributed to origin. On
+// error, including when no root is left once rejected ones are skipped, pool
+// is left untouched.
+func ReloadRoots(ctx context.Context, origin string, pool *x509util.PEMCertPool, load RootsLoader) error {
+	once.Do(func() { setupMetrics() })
+	attrs := metric.WithAttributes(originKey.String(origin))
+
+	var added, removed []*x509.Certificate
+	certs, err := load(ctx)
+	if err == nil && len(certs) == 0 {
+		err = errors.New("no roots loaded")
+	}
+	if err == nil {
+		added, removed, err = pool.SetCerts(certs)
+	}
+	if err != nil {
+		rootsReloads.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rootsReloadResultKey.String("failed")))
+		slog.ErrorContext(ctx, "Failed to reload roots, keeping the previous ones", slog.String("origin", origin), slog.Any("error", err))
+		return fmt.Errorf("failed to reload roots: %v", err)
+	}
+
+	total := len(pool.RawCertificates())
+	rootsCount.Record(ctx, int64(total), attrs)
+	if len(added) == 0 && len(removed) == 0 {
+		rootsReloads.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rootsReloadResultKey.String("unchanged")))
+		slog.DebugContext(ctx, "Reloaded roots, no change", slog.String("origin", origin), slog.Int("total", total))
+		return nil
+	}
+
+	rootsReloads.Add(ctx, 1, metric.WithAttributes(originKey.String(origin), rootsReloadResultKey.String("changed")))
+	rootsAdded.Add(ctx, int64(len(added)), attrs)
+	rootsRemoved.Add(ctx, int64(len(removed)), attrs)
+	for _, c := range added {
+		slog.InfoContext(ctx, "Added root", slog.String("origin", origin), slog.String("subject", c.Subject.String()), slog.String("fingerprint", rootFingerprint(c)))
+	}
+	for _, c := range removed {
+		slog.InfoContext(ctx, "Removed root", slog.String("origin", origin), slog.String("subject", c.Subject.String()), slog.String("fingerprint", rootFingerprint(c)))
+	}
+	slog.InfoContext(ctx, "Reloaded roots", slog.String("origin", origin), slog.Int("added", len(added)), slog.Int("removed", len(removed)), slog.Int("total", total))
+	return nil
+}
+
+func rootFingerprint(c *x509.Certificate) string {
+	f := sha256.Sum256(c.Raw)
+	return hex.EncodeToString(f[:])
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+func TestReloadRoots(t *testing.T) {
+	ca := pemToCert(t, testdata.FakeCACertPEM)
+	root := pemToCert(t, testdata.FakeRootCACertPEM)
+	load := func(certs ...*x509.Certificate) RootsLoader {
+		return func(context.Context) ([]*x509.Certificate, error) {
+			return certs, nil
+		}
+	}
+
+	pool, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool(): %v", err)
+	}
+	pool.AddCerts([]*x509.Certificate{ca})
+	cv := NewChainValidator(ChainValidatorOptions{TrustedRoots: pool})
+
+	// Note: tests are cumulative
+	for _, tc := range []struct {
+		desc      string
+		load      RootsLoader
+		wantErr   bool
+		wantRoots []*x509.Certificate
+	}{
+		{
+			desc:      "add",
+			load:      load(ca, root),
+			wantRoots: []*x509.Certificate{ca, root},
+		},
+		{
+			desc:      "remove",
+			load:      load(root),
+			wantRoots: []*x509.Certificate{root},
+		},
+		{
+			desc:      "unchanged",
+			load:      load(root),
+			wantRoots: []*x509.Certificate{root},
+		},
+		{
+			desc: "load-error",
+			load: func(context.Context) ([]*x509.Certificate, error) {
+				return nil, errors.New("boom")
+			},
+			wantErr:   true,
+			wantRoots: []*x509.Certificate{root},
+		},
+		{
+			desc:      "empty",
+			load:      load(),
+			wantErr:   true,
+			wantRoots: []*x509.Certificate{root},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := ReloadRoots(t.Context(), "example.com", pool, tc.load)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ReloadRoots()=%v, want err %t", err, tc.wantErr)
+			}
+			// get-roots is served from the chain validator.
+			roots := cv.Roots()
+			if len(roots) != len(tc.wantRoots) {
+				t.Fatalf("got %d roots, want %d", len(roots), len(tc.wantRoots))
+			}
+			for i, r := range tc.wantRoots {
+				if !roots[i].Equal(r) {
+					t.Errorf("root %d is %s, want %s", i, roots[i].Subject, r.Subject)
+				}
+			}
+		})
+	}
+	t.Run("all-rejected", func(t *testing.T) {
+		pool, err := x509util.NewPEMCertPool([]string{rootFingerprint(root)})
+		if err != nil {
+			t.Fatalf("NewPEMCertPool(): %v", err)
+		}
+		pool.AddCerts([]*x509.Certificate{ca})
+		if err := ReloadRoots(t.Context(), "example.com", pool, load(root)); err == nil {
+			t.Error("ReloadRoots() with only rejected roots succeeded, want err")
+		}
+		if roots := pool.RawCertificates(); len(roots) != 1 || !roots[0].Equal(ca) {
+			t.Errorf("got %d roots, want the previous root only", len(roots))
+		}
+	})
+}
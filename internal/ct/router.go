@@ -0,0 +1,255 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RouterShard is a log of a temporally sharded log set, to which a router
+// forwards submissions.
+type RouterShard struct {
+	// Origin identifies the shard in logs and metrics.
+	Origin string
+	// PathPrefix prefixes the shard's static-ct-api endpoint paths.
+	PathPrefix string
+	// NotAfterStart is the start of the shard's NotAfter range, inclusive.
+	// nil means no lower bound.
+	NotAfterStart *time.Time
+	// NotAfterLimit is the end of the shard's NotAfter range, exclusive.
+	// nil means no upper bound.
+	NotAfterLimit *time.Time
+	// Handler serves the shard's submission endpoints, either in-process or
+	// by forwarding requests to the shard's server.
+	Handler http.Handler
+}
+
+// contains returns true if t is within the shard's NotAfter range.
+func (s RouterShard) contains(t time.Time) bool {
+	return (s.NotAfterStart == nil || !t.Before(*s.NotAfterStart)) && (s.NotAfterLimit == nil || t.Before(*s.NotAfterLimit))
+}
+
+// routerHandler routes add-chain and add-pre-chain requests to the shard
+// whose NotAfter range contains the submitted certificate's NotAfter.
+type routerHandler struct {
+	opts   *HandlerOptions
+	shards []RouterShard
+	name   entrypointName
+	path   string
+}
+
+// NewRouterPathHandlers returns add-chain and add-pre-chain handlers, served
+// under pathPrefix, which route submissions to the shard whose NotAfter range
+// contains the submitted certificate's NotAfter. The shard's response, and
+// therefore its SCT, is returned as is.
+//
+// Requests are subject to opts.Deadline, and errors are reported as
+// configured by opts.JSONErrors and opts.MaskInternalErrors.
+//
+// Shard NotAfter ranges must not overlap.
+func NewRouterPathHandlers(ctx context.Context, opts *HandlerOptions, pathPrefix string, shards []RouterShard) (map[string]http.Handler, error) {
+	once.Do(func() { setupMetrics() })
+
+	if len(shards) == 0 {
+		return nil, errors.New("no shard to route to")
+	}
+	shards = slices.Clone(shards)
+	// Sort shards by NotAfterStart, unbounded first, to check that ranges
+	// don't overlap.
+	slices.SortFunc(shards, func(a, b RouterShard) int {
+		switch {
+		case a.NotAfterStart == nil && b.NotAfterStart == nil:
+			return 0
+		case a.NotAfterStart == nil:
+			return -1
+		case b.NotAfterStart == nil:
+			return 1
+		}
+		return a.NotAfterStart.Compare(*b.NotAfterStart)
+	})
+	for i, s := range shards {
+		if s.Handler == nil {
+			return nil, fmt.Errorf("shard %q has no handler", s.Origin)
+		}
+		if s.NotAfterStart != nil && s.NotAfterLimit != nil && s.NotAfterLimit.Before(*s.NotAfterStart) {
+			return nil, fmt.Errorf("shard %q 'Not After' limit %q before start %q", s.Origin, s.NotAfterLimit.Format(time.RFC3339), s.NotAfterStart.Format(time.RFC3339))
+		}
+		if i == 0 {
+			continue
+		}
+		prev := shards[i-1]
+		if prev.NotAfterLimit == nil || s.NotAfterStart == nil || s.NotAfterStart.Before(*prev.NotAfterLimit) {
+			return nil, fmt.Errorf("shards %q and %q have overlapping 'Not After' ranges", prev.Origin, s.Origin)
+		}
+	}
+
+	prefix := normalizePathPrefix(pathPrefix)
+	return map[string]http.Handler{
+		prefix + rfc6962.AddChainPath:    &routerHandler{opts: opts, shards: shards, name: addChainName, path: rfc6962.AddChainPath},
+		prefix + rfc6962.AddPreChainPath: &routerHandler{opts: opts, shards: shards, name: addPreChainName, path: rfc6962.AddPreChainPath},
+	}, nil
+}
+
+// ServeHTTP parses the leaf certificate of the submitted chain and forwards
+// the request to the matching shard.
+func (h *routerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), fmt.Sprintf("tesseract.router.ServeHTTP.%s", h.name))
+	defer span.End()
+	if h.opts.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.opts.Deadline)
+		defer cancel()
+	}
+
+	// origin is the origin of the shard the request is routed to, if any.
+	var origin string
+	sw := &statusWriter{ResponseWriter: w}
+	w = sw
+	defer func() {
+		routedRequests.Add(ctx, 1, metric.WithAttributes(operationKey.String(h.name), originKey.String(origin), codeKey.Int(sw.code())))
+	}()
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.sendHTTPError(ctx, w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.sendHTTPError(ctx, w, http.StatusRequestEntityTooLarge, fmt.Errorf("certificate chain exceeds %d-byte limit: %w", maxBytesErr.Limit, err))
+			return
+		}
+		h.sendHTTPError(ctx, w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %v", err))
+		return
+	}
+	var req rfc6962.AddChainRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		h.sendHTTPError(ctx, w, http.StatusBadRequest, fmt.Errorf("failed to parse add-chain body: %v", err))
+		return
+	}
+	if len(req.Chain) == 0 {
+		h.sendHTTPError(ctx, w, http.StatusBadRequest, errors.New("cert chain was empty"))
+		return
+	}
+	// Only the leaf is needed to pick a shard, which fully validates the chain.
+	chain, err := parseChain(req.Chain[:1])
+	if err != nil {
+		h.sendHTTPError(ctx, w, http.StatusBadRequest, fmt.Errorf("failed to parse add-chain contents: %s", err))
+		return
+	}
+	notAfter := chain[0].NotAfter
+
+	i := slices.IndexFunc(h.shards, func(s RouterShard) bool { return s.contains(notAfter) })
+	if i < 0 {
+		h.sendHTTPError(ctx, w, http.StatusBadRequest, fmt.Errorf("no shard accepts certificates with NotAfter %v", notAfter))
+		return
+	}
+	shard := h.shards[i]
+	origin = shard.Origin
+
+	fr := r.Clone(ctx)
+	fr.URL.Path = normalizePathPrefix(shard.PathPrefix) + h.path
+	fr.URL.RawPath = ""
+	fr.Body = io.NopCloser(bytes.NewReader(body))
+	fr.ContentLength = int64(len(body))
+	fr.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	slog.DebugContext(ctx, "routing submission", slog.String("name", h.name), slog.String("origin", shard.Origin), slog.Time("notAfter", notAfter))
+	shard.Handler.ServeHTTP(w, fr)
+}
+
+func (h *routerHandler) sendHTTPError(ctx context.Context, w http.ResponseWriter, statusCode int, err error) {
+	slog.DebugContext(ctx, "router failed to route request", slog.String("name", h.name), slog.Int("statusCode", statusCode), slog.Any("error", err))
+	h.opts.sendHTTPError(w, statusCode, err)
+}
+
+// NewRemoteShardHandler returns a handler which forwards requests to the
+// shard served at target, for use as RouterShard.Handler.
+//
+// Waiting for the shard's response headers is bounded by opts.Deadline.
+// Failures to reach the shard are reported as configured by opts.JSONErrors
+// and opts.MaskInternalErrors.
+func NewRemoteShardHandler(opts *HandlerOptions, target *url.URL) http.Handler {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = opts.Deadline
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.WarnContext(r.Context(), "router failed to reach shard", slog.String("target", target.String()), slog.Any("error", err))
+			statusCode := http.StatusBadGateway
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				statusCode = http.StatusGatewayTimeout
+			}
+			opts.sendHTTPError(w, statusCode, fmt.Errorf("failed to reach shard: %v", err))
+		},
+	}
+}
+
+// statusWriter records the status code of the response written to it.
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// code returns the status code of the response, http.StatusOK if none was
+// written.
+func (w *statusWriter) code() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+)
+
+// recordingHandler records the path and body of the last request it served.
+type recordingHandler struct {
+	path string
+	body string
+}
+
+func (h *recordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.path, h.body = r.URL.Path, string(body)
+	_, _ = w.Write([]byte("sct"))
+}
+
+func TestNewRouterPathHandlers(t *testing.T) {
+	t100 := time.Unix(100, 0)
+	t200 := time.Unix(200, 0)
+	t300 := time.Unix(300, 0)
+	h := &recordingHandler{}
+
+	for _, tc := range []struct {
+		desc    string
+		shards  []RouterShard
+		wantErr string
+	}{
+		{
+			desc:    "no-shard",
+			wantErr: "no shard",
+		},
+		{
+			desc:    "no-handler",
+			shards:  []RouterShard{{Origin: "a"}},
+			wantErr: "has no handler",
+		},
+		{
+			desc:    "limit-before-start",
+			shards:  []RouterShard{{Origin: "a", NotAfterStart: &t200, NotAfterLimit: &t100, Handler: h}},
+			wantErr: "before start",
+		},
+		{
+			desc: "overlapping",
+			shards: []RouterShard{
+				{Origin: "a", NotAfterStart: &t100, NotAfterLimit: &t300, Handler: h},
+				{Origin: "b", NotAfterStart: &t200, Handler: h},
+			},
+			wantErr: "overlapping",
+		},
+		{
+			desc: "overlapping-unbounded",
+			shards: []RouterShard{
+				{Origin: "a", Handler: h},
+				{Origin: "b", NotAfterStart: &t200, Handler: h},
+			},
+			wantErr: "overlapping",
+		},
+		{
+			desc: "ok",
+			shards: []RouterShard{
+				{Origin: "b", NotAfterStart: &t200, Handler: h},
+				{Origin: "a", NotAfterLimit: &t100, Handler: h},
+				{Origin: "c", NotAfterStart: &t100, NotAfterLimit: &t200, Handler: h},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			handlers, err := NewRouterPathHandlers(t.Context(), &HandlerOptions{}, "router", tc.shards)
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("NewRouterPathHandlers()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRouterPathHandlers()=%v", err)
+			}
+			for _, path := range []string{"/router" + rfc6962.AddChainPath, "/router" + rfc6962.AddPreChainPath} {
+				if _, ok := handlers[path]; !ok {
+					t.Errorf("%q path not registered", path)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterHandler(t *testing.T) {
+	block, _ := pem.Decode([]byte(testdata.CertFromIntermediate))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse test certificate: %v", err)
+	}
+	before := cert.NotAfter.Add(-time.Hour)
+	after := cert.NotAfter.Add(time.Hour)
+
+	past, current, future := &recordingHandler{}, &recordingHandler{}, &recordingHandler{}
+	handlers, err := NewRouterPathHandlers(t.Context(), &HandlerOptions{}, "", []RouterShard{
+		{Origin: "past", PathPrefix: "past", NotAfterLimit: &before, Handler: past},
+		{Origin: "current", PathPrefix: "/current/", NotAfterStart: &before, NotAfterLimit: &after, Handler: current},
+		{Origin: "future", PathPrefix: "future", NotAfterStart: &after, Handler: future},
+	})
+	if err != nil {
+		t.Fatalf("NewRouterPathHandlers()=%v", err)
+	}
+
+	chain, err := json.Marshal(rfc6962.AddChainRequest{Chain: [][]byte{cert.Raw}})
+	if err != nil {
+		t.Fatalf("Failed to marshal chain: %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantPath   string
+	}{
+		{
+			desc:       "add-chain",
+			method:     http.MethodPost,
+			path:       rfc6962.AddChainPath,
+			body:       string(chain),
+			wantStatus: http.StatusOK,
+			wantPath:   "/current" + rfc6962.AddChainPath,
+		},
+		{
+			desc:       "add-pre-chain",
+			method:     http.MethodPost,
+			path:       rfc6962.AddPreChainPath,
+			body:       string(chain),
+			wantStatus: http.StatusOK,
+			wantPath:   "/current" + rfc6962.AddPreChainPath,
+		},
+		{
+			desc:       "wrong-method",
+			method:     http.MethodGet,
+			path:       rfc6962.AddChainPath,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:       "invalid-json",
+			method:     http.MethodPost,
+			path:       rfc6962.AddChainPath,
+			body:       "not json",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc:       "empty-chain",
+			method:     http.MethodPost,
+			path:       rfc6962.AddChainPath,
+			body:       `{"chain":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc:       "invalid-cert",
+			method:     http.MethodPost,
+			path:       rfc6962.AddChainPath,
+			body:       `{"chain":["AAAA"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			*past, *current, *future = recordingHandler{}, recordingHandler{}, recordingHandler{}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			handlers[tc.path].ServeHTTP(w, req)
+			if got := w.Code; got != tc.wantStatus {
+				t.Fatalf("ServeHTTP() returned status %d, want %d, body: %s", got, tc.wantStatus, w.Body.String())
+			}
+			if past.path != "" || future.path != "" {
+				t.Errorf("request routed to the wrong shard: past=%q, future=%q", past.path, future.path)
+			}
+			if got, want := current.path, tc.wantPath; got != want {
+				t.Errorf("routed request path=%q, want %q", got, want)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if got, want := current.body, tc.body; got != want {
+				t.Errorf("routed request body=%q, want %q", got, want)
+			}
+			if got, want := w.Body.String(), "sct"; got != want {
+				t.Errorf("response body=%q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("no-shard", func(t *testing.T) {
+		handlers, err := NewRouterPathHandlers(t.Context(), &HandlerOptions{}, "", []RouterShard{
+			{Origin: "future", NotAfterStart: &after, Handler: future},
+		})
+		if err != nil {
+			t.Fatalf("NewRouterPathHandlers()=%v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, rfc6962.AddChainPath, strings.NewReader(string(chain)))
+		w := httptest.NewRecorder()
+		handlers[rfc6962.AddChainPath].ServeHTTP(w, req)
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("ServeHTTP() returned status %d, want %d", got, want)
+		}
+	})
+}
+
+func TestRemoteShardHandler(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for _, tc := range []struct {
+		desc       string
+		target     string
+		wantStatus int
+	}{
+		{
+			desc:       "unreachable",
+			target:     closed.URL,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			desc:       "timeout",
+			target:     slow.URL,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			target, err := url.Parse(tc.target)
+			if err != nil {
+				t.Fatalf("url.Parse()=%v", err)
+			}
+			h := NewRemoteShardHandler(&HandlerOptions{Deadline: 100 * time.Millisecond, JSONErrors: true}, target)
+			req := httptest.NewRequest(http.MethodPost, rfc6962.AddChainPath, strings.NewReader("{}"))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if got := w.Code; got != tc.wantStatus {
+				t.Fatalf("ServeHTTP() returned status %d, want %d, body: %s", got, tc.wantStatus, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse error response %q: %v", w.Body.String(), err)
+			}
+			if got, want := resp.Code, ErrorCodeInternalError; got != want {
+				t.Errorf("error code=%q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -16,10 +16,14 @@ package ct
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"time"
 
@@ -31,6 +35,11 @@ import (
 
 const nanosPerMilli int64 = int64(time.Millisecond / time.Nanosecond)
 
+// minRSAKeyBits is the minimum size of RSA log keys, as per RFC 6962 section 2.1.4.
+const minRSAKeyBits = 2048
+
+// sctSigner signs SCTs. ECDSA keys produce ECDSA signatures, and RSA keys
+// produce RSASSA-PKCS1-v1_5 signatures, both over SHA-256.
 type sctSigner struct {
 	signer crypto.Signer
 }
@@ -178,9 +187,101 @@ func (cts *cpSigner) KeyHash() uint32 {
 // NewCpSigner returns a new note signer that can sign https://c2sp.org/static-ct-api checkpoints.
 // TODO(phboneff): add tests
 func NewCpSigner(cs crypto.Signer, origin string, timeSource TimeSource) (note.Signer, error) {
-	logID, err := getCTLogID(cs.Public())
+	keyHash, err := cpKeyHash(cs.Public(), origin)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get logID for signing: %v", err)
+		return nil, err
+	}
+
+	ns := &cpSigner{
+		sthSigner:  cs,
+		origin:     origin,
+		keyHash:    keyHash,
+		timeSource: timeSource,
+	}
+
+	return ns, nil
+}
+
+// cpVerifier implements note.Verifier. It can verify https://c2sp.org/static-ct-api
+// checkpoint signatures.
+type cpVerifier struct {
+	pubKey  crypto.PublicKey
+	origin  string
+	keyHash uint32
+}
+
+// Verify checks that sig is a valid https://c2sp.org/static-ct-api signature
+// over the checkpoint msg.
+func (cv *cpVerifier) Verify(msg, sig []byte) bool {
+	ckpt := &tfl.Checkpoint{}
+	rest, err := ckpt.Unmarshal(msg)
+	if err != nil || len(rest) != 0 || ckpt.Origin != cv.origin {
+		return false
+	}
+
+	var rfc6962Note rfc6962NoteSignature
+	if rest, err := tls.Unmarshal(sig, &rfc6962Note); err != nil || len(rest) != 0 {
+		return false
+	}
+	if rfc6962Note.Signature.Algorithm.Hash != tls.SHA256 {
+		return false
+	}
+
+	sth := rfc6962.SignedTreeHead{
+		Version:   rfc6962.V1,
+		TreeSize:  ckpt.Size,
+		Timestamp: rfc6962Note.Timestamp,
+	}
+	copy(sth.SHA256RootHash[:], ckpt.Hash)
+	sthBytes, err := serializeSTHSignatureInput(sth)
+	if err != nil {
+		return false
+	}
+	h := sha256.Sum256(sthBytes)
+
+	switch pk := cv.pubKey.(type) {
+	case *ecdsa.PublicKey:
+		if rfc6962Note.Signature.Algorithm.Signature != tls.ECDSA {
+			return false
+		}
+		return ecdsa.VerifyASN1(pk, h[:], rfc6962Note.Signature.Signature)
+	case *rsa.PublicKey:
+		if rfc6962Note.Signature.Algorithm.Signature != tls.RSA {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pk, crypto.SHA256, h[:], rfc6962Note.Signature.Signature) == nil
+	default:
+		return false
+	}
+}
+
+func (cv *cpVerifier) Name() string {
+	return cv.origin
+}
+
+func (cv *cpVerifier) KeyHash() uint32 {
+	return cv.keyHash
+}
+
+// NewCpVerifier returns a new note verifier for https://c2sp.org/static-ct-api
+// checkpoints signed by the private key matching pk.
+func NewCpVerifier(pk crypto.PublicKey, origin string) (note.Verifier, error) {
+	keyHash, err := cpKeyHash(pk, origin)
+	if err != nil {
+		return nil, err
+	}
+	return &cpVerifier{
+		pubKey:  pk,
+		origin:  origin,
+		keyHash: keyHash,
+	}, nil
+}
+
+// cpKeyHash returns the note key hash of a https://c2sp.org/static-ct-api log.
+func cpKeyHash(pk crypto.PublicKey, origin string) (uint32, error) {
+	logID, err := getCTLogID(pk)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get logID for signing: %v", err)
 	}
 
 	h := sha256.New()
@@ -190,14 +291,32 @@ func NewCpSigner(cs crypto.Signer, origin string, timeSource TimeSource) (note.S
 	h.Write(logID[:])
 	sum := h.Sum(nil)
 
-	ns := &cpSigner{
-		sthSigner:  cs,
-		origin:     origin,
-		keyHash:    binary.BigEndian.Uint32(sum),
-		timeSource: timeSource,
-	}
+	return binary.BigEndian.Uint32(sum), nil
+}
 
-	return ns, nil
+// cpNoteSignature extracts the RFC 6962 signature of a https://c2sp.org/static-ct-api
+// checkpoint from the note signatures matching keyHash.
+func cpNoteSignature(sigs []note.Signature, keyHash uint32) (rfc6962NoteSignature, error) {
+	for _, s := range sigs {
+		if s.Hash != keyHash {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(s.Base64)
+		if err != nil {
+			return rfc6962NoteSignature{}, fmt.Errorf("failed to decode note signature: %v", err)
+		}
+		if len(raw) < 4 {
+			return rfc6962NoteSignature{}, errors.New("note signature too short")
+		}
+		var rfc6962Note rfc6962NoteSignature
+		if rest, err := tls.Unmarshal(raw[4:], &rfc6962Note); err != nil {
+			return rfc6962NoteSignature{}, fmt.Errorf("failed to decode RFC6962NoteSignature: %v", err)
+		} else if len(rest) != 0 {
+			return rfc6962NoteSignature{}, errors.New("trailing data after RFC6962NoteSignature")
+		}
+		return rfc6962Note, nil
+	}
+	return rfc6962NoteSignature{}, fmt.Errorf("no signature with key hash %08x", keyHash)
 }
 
 // getCTLogID takes a log public key and returns the LogID. (see RFC 6962 S3.2)
@@ -16,20 +16,26 @@ package ct
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/kylelemons/godebug/pretty"
+	tfl "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tesseract/internal/remotesigner"
 	"github.com/transparency-dev/tesseract/internal/testdata"
 	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
 	"github.com/transparency-dev/tesseract/internal/types/tls"
 	"github.com/transparency-dev/tesseract/internal/x509util"
 	"github.com/transparency-dev/tesseract/internal/types/staticct"
+	"golang.org/x/mod/sumdb/note"
 )
 
 var (
@@ -366,55 +372,238 @@ func setupSCTSigner(fakeSig []byte) (*sctSigner, error) {
 	return &sctSigner{testdata.NewSignerWithFixedSig(key, fakeSig)}, nil
 }
 
+// verifySignature checks that ds is a valid RFC 6962 signature over data by
+// the private key matching pk.
+func verifySignature(t *testing.T, pk crypto.PublicKey, data []byte, ds rfc6962.DigitallySigned) {
+	t.Helper()
+	if got, want := ds.Algorithm.Hash, tls.SHA256; got != want {
+		t.Errorf("signature hash algorithm=%v, want %v", got, want)
+	}
+	h := sha256.Sum256(data)
+	switch pk := pk.(type) {
+	case *ecdsa.PublicKey:
+		if got, want := ds.Algorithm.Signature, tls.ECDSA; got != want {
+			t.Errorf("signature algorithm=%v, want %v", got, want)
+		}
+		if !ecdsa.VerifyASN1(pk, h[:], ds.Signature) {
+			t.Error("invalid ECDSA signature")
+		}
+	case *rsa.PublicKey:
+		if got, want := ds.Algorithm.Signature, tls.RSA; got != want {
+			t.Errorf("signature algorithm=%v, want %v", got, want)
+		}
+		if err := rsa.VerifyPKCS1v15(pk, crypto.SHA256, h[:], ds.Signature); err != nil {
+			t.Errorf("invalid RSA signature: %v", err)
+		}
+	default:
+		t.Fatalf("unsupported public key type %T", pk)
+	}
+}
+
+// testSigners are the supported log key types.
+var testSigners = map[string]string{
+	"ecdsa": "../testdata/test_ct_server_ecdsa_private_key.pem",
+	"rsa":   "../testdata/test_ct_server_rsa_private_key.pem",
+}
+
+func TestSCTSigner(t *testing.T) {
+	for name, keyPath := range testSigners {
+		t.Run(name, func(t *testing.T) {
+			signer, err := loadPEMPrivateKey(keyPath)
+			if err != nil {
+				t.Fatalf("Can't open key: %v", err)
+			}
+			logID, err := getCTLogID(signer.Public())
+			if err != nil {
+				t.Fatalf("getCTLogID(): %v", err)
+			}
+			cert, err := x509util.CertificateFromPEM([]byte(testdata.TestCertPEM))
+			if err != nil {
+				t.Fatalf("failed to set up test cert: %v", err)
+			}
+			input := rfc6962.CertificateTimestamp{
+				SCTVersion:    rfc6962.V1,
+				SignatureType: rfc6962.CertificateTimestampSignatureType,
+				Timestamp:     fixedTimeMillis,
+				EntryType:     rfc6962.X509LogEntryType,
+				X509Entry:     &rfc6962.ASN1Cert{Data: cert.Raw},
+				Extensions:    rfc6962.CTExtensions(fakeExtension),
+			}
+
+			sct, err := (&sctSigner{signer: signer}).Sign(input)
+			if err != nil {
+				t.Fatalf("Sign(): %v", err)
+			}
+			if got, want := sct.LogID.KeyID, logID; got != want {
+				t.Errorf("sct.LogID=%x, want %x", got, want)
+			}
+			data, err := tls.Marshal(input)
+			if err != nil {
+				t.Fatalf("tls.Marshal(): %v", err)
+			}
+			verifySignature(t, signer.Public(), data, sct.Signature)
+		})
+	}
+}
+
 func TestBuildCp(t *testing.T) {
-	// Create a test signer.
-	ecdsaSigner, err := loadPEMPrivateKey("../testdata/test_ct_server_ecdsa_private_key.pem")
+	for name, keyPath := range testSigners {
+		t.Run(name, func(t *testing.T) {
+			// Create a test signer.
+			signer, err := loadPEMPrivateKey(keyPath)
+			if err != nil {
+				t.Fatalf("Can't open key: %v", err)
+			}
+
+			// Define test data.
+			size := uint64(12345)
+			hash := []byte("test_hash_value_12345678901234567890")
+
+			// Build the checkpoint which is in the RFC6962NoteSignature format.
+			checkpoint, err := buildCp(signer, size, fixedTimeMillis, hash)
+			if err != nil {
+				t.Errorf("buildCp failed: %v", err)
+			}
+
+			// Verify whether the checkpoint is empty.
+			if len(checkpoint) == 0 {
+				t.Errorf("buildCp returned an empty checkpoint")
+			}
+
+			// Verify that the checkpoint can be parsed.
+			var sig rfc6962NoteSignature
+			_, err = tls.Unmarshal(checkpoint, &sig)
+			if err != nil {
+				t.Errorf("failed to unmarshal checkpoint: %v", err)
+			}
+			// Verify the timestamp in the note signature.
+			if sig.Timestamp != fixedTimeMillis {
+				t.Errorf("buildCp returned wrong timestamp, got %d, want %d", sig.Timestamp, fixedTimeMillis)
+			}
+
+			// Verify the signature using the public key.
+			sth := rfc6962.SignedTreeHead{
+				Version:   rfc6962.V1,
+				TreeSize:  size,
+				Timestamp: fixedTimeMillis,
+			}
+			copy(sth.SHA256RootHash[:], hash)
+
+			sthBytes, err := serializeSTHSignatureInput(sth)
+			if err != nil {
+				t.Fatalf("serializeSTHSignatureInput(): %v", err)
+			}
+			verifySignature(t, signer.Public(), sthBytes, sig.Signature)
+		})
+	}
+}
+
+func TestCpVerifier(t *testing.T) {
+	for name, keyPath := range testSigners {
+		t.Run(name, func(t *testing.T) {
+			testCpVerifier(t, keyPath)
+		})
+	}
+}
+
+func testCpVerifier(t *testing.T, keyPath string) {
+	logSigner, err := loadPEMPrivateKey(keyPath)
 	if err != nil {
 		t.Fatalf("Can't open key: %v", err)
 	}
+	ts := newFakeTimeSource(fixedTime)
+	signer, err := NewCpSigner(logSigner, "example.com", ts)
+	if err != nil {
+		t.Fatalf("NewCpSigner(): %v", err)
+	}
+	verifier, err := NewCpVerifier(logSigner.Public(), "example.com")
+	if err != nil {
+		t.Fatalf("NewCpVerifier(): %v", err)
+	}
+	if got, want := verifier.KeyHash(), signer.KeyHash(); got != want {
+		t.Errorf("verifier.KeyHash()=%08x, want %08x", got, want)
+	}
 
-	// Define test data.
-	size := uint64(12345)
-	hash := []byte("test_hash_value_12345678901234567890")
-
-	// Build the checkpoint which is in the RFC6962NoteSignature format.
-	checkpoint, err := buildCp(ecdsaSigner, size, fixedTimeMillis, hash)
+	cp := tfl.Checkpoint{Origin: "example.com", Size: 12345, Hash: bytes.Repeat([]byte{0x42}, sha256.Size)}
+	msg, err := note.Sign(&note.Note{Text: string(cp.Marshal())}, signer)
 	if err != nil {
-		t.Errorf("buildCp failed: %v", err)
+		t.Fatalf("note.Sign(): %v", err)
 	}
 
-	// Verify whether the checkpoint is empty.
-	if len(checkpoint) == 0 {
-		t.Errorf("buildCp returned an empty checkpoint")
+	n, err := note.Open(msg, note.VerifierList(verifier))
+	if err != nil {
+		t.Fatalf("note.Open(): %v", err)
+	}
+	sig, err := cpNoteSignature(n.Sigs, verifier.KeyHash())
+	if err != nil {
+		t.Fatalf("cpNoteSignature(): %v", err)
+	}
+	if got, want := sig.Timestamp, fixedTimeMillis; got != want {
+		t.Errorf("sig.Timestamp=%d, want %d", got, want)
 	}
 
-	// Verify that the checkpoint can be parsed.
-	var sig rfc6962NoteSignature
-	_, err = tls.Unmarshal(checkpoint, &sig)
+	otherVerifier, err := NewCpVerifier(logSigner.Public(), "other.example.com")
 	if err != nil {
-		t.Errorf("failed to unmarshal checkpoint: %v", err)
+		t.Fatalf("NewCpVerifier(): %v", err)
 	}
-	// Verify the timestamp in the note signature.
-	if sig.Timestamp != fixedTimeMillis {
-		t.Errorf("buildCp returned wrong timestamp, got %d, want %d", sig.Timestamp, fixedTimeMillis)
+	if _, err := note.Open(msg, note.VerifierList(otherVerifier)); err == nil {
+		t.Error("note.Open() with a verifier for another origin: got nil error, want error")
 	}
 
-	// Verify the signature using the public key.
-	sth := rfc6962.SignedTreeHead{
-		Version:   rfc6962.V1,
-		TreeSize:  size,
-		Timestamp: fixedTimeMillis,
+	tampered := bytes.Replace(msg, []byte("12345"), []byte("12346"), 1)
+	if _, err := note.Open(tampered, note.VerifierList(verifier)); err == nil {
+		t.Error("note.Open() on a tampered checkpoint: got nil error, want error")
 	}
-	copy(sth.SHA256RootHash[:], hash)
+}
 
-	sthBytes, err := serializeSTHSignatureInput(sth)
+// TestRemoteSigner checks that SCTs and checkpoints signed through a remote
+// signing service are identical to the ones signed with the key directly.
+// This relies on RSASSA-PKCS1-v1_5 signatures being deterministic.
+func TestRemoteSigner(t *testing.T) {
+	logSigner, err := loadPEMPrivateKey(testSigners["rsa"])
 	if err != nil {
-		t.Fatalf("serializeSTHSignatureInput(): %v", err)
+		t.Fatalf("Can't open key: %v", err)
+	}
+	h, err := remotesigner.NewHandler(logSigner, 0, nil)
+	if err != nil {
+		t.Fatalf("remotesigner.NewHandler(): %v", err)
+	}
+	s := httptest.NewServer(h)
+	defer s.Close()
+	remote, err := remotesigner.NewClient(t.Context(), s.URL, remotesigner.ClientOpts{})
+	if err != nil {
+		t.Fatalf("remotesigner.NewClient(): %v", err)
 	}
+	defer func() { _ = remote.Close() }()
 
-	h := sha256.Sum256(sthBytes)
-	valid := ecdsa.VerifyASN1(ecdsaSigner.Public().(*ecdsa.PublicKey), h[:], sig.Signature.Signature)
-	if !valid {
-		t.Errorf("buildCp returned an invalid signature")
+	input := *defaultCertificateSCTInput()
+	input.Timestamp = fixedTimeMillis
+	want, err := (&sctSigner{signer: logSigner}).Sign(input)
+	if err != nil {
+		t.Fatalf("local sctSigner.Sign(): %v", err)
+	}
+	got, err := (&sctSigner{signer: remote}).Sign(input)
+	if err != nil {
+		t.Fatalf("remote sctSigner.Sign(): %v", err)
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("remote SCT differs from local SCT (-got +want):\n%s", diff)
+	}
+
+	cp := tfl.Checkpoint{Origin: "example.com", Size: 12345, Hash: bytes.Repeat([]byte{0x42}, sha256.Size)}
+	var sigs [][]byte
+	for _, cs := range []crypto.Signer{logSigner, remote} {
+		signer, err := NewCpSigner(cs, "example.com", newFakeTimeSource(fixedTime))
+		if err != nil {
+			t.Fatalf("NewCpSigner(): %v", err)
+		}
+		sig, err := signer.Sign(cp.Marshal())
+		if err != nil {
+			t.Fatalf("cpSigner.Sign(): %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	if !bytes.Equal(sigs[0], sigs[1]) {
+		t.Errorf("remote checkpoint signature %x differs from local signature %x", sigs[1], sigs[0])
 	}
 }
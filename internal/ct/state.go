@@ -0,0 +1,268 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tesseract/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// LogState is the lifecycle state of a log, as defined by CT log policies.
+type LogState string
+
+const (
+	// LogStateUsable logs accept submissions.
+	LogStateUsable LogState = "usable"
+	// LogStateReadOnly logs reject submissions, but keep publishing
+	// checkpoints and serving their data.
+	LogStateReadOnly LogState = "read_only"
+	// LogStateRetired logs reject submissions, but keep publishing
+	// checkpoints and serving their data. Retired logs can't be brought back.
+	LogStateRetired LogState = "retired"
+)
+
+// logStates lists all the valid log states.
+var logStates = []LogState{LogStateUsable, LogStateReadOnly, LogStateRetired}
+
+// ParseLogState returns the LogState named s.
+func ParseLogState(s string) (LogState, error) {
+	for _, st := range logStates {
+		if s == string(st) {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown log state %q, want one of %v", s, logStates)
+}
+
+// StateStorage persists the lifecycle state of a log.
+type StateStorage interface {
+	// ReadLogState returns the stored state. It returns an error wrapping
+	// os.ErrNotExist if no state has ever been stored.
+	ReadLogState(ctx context.Context) ([]byte, error)
+	// WriteLogState atomically replaces the stored state.
+	WriteLogState(ctx context.Context, state []byte) error
+}
+
+// storedLogState is the format in which log states are persisted.
+type storedLogState struct {
+	State   LogState  `json:"state"`
+	Updated time.Time `json:"updated"`
+}
+
+// logStateRefreshInterval is the interval between two reads of the stored
+// log state, to pick up changes made by other frontends of the same log.
+var logStateRefreshInterval = 10 * time.Second
+
+// appendStopper is implemented by storages which can stop accepting new
+// entries, once a log is retired.
+type appendStopper interface {
+	// StopAppending stops accepting new entries, and waits for those which
+	// were already added to be integrated.
+	StopAppending(ctx context.Context) error
+}
+
+// readState reads the state of the log from its storage. Logs which have
+// never stored a state are usable.
+func (l *log) readState(ctx context.Context) (storedLogState, error) {
+	b, err := l.stateStorage.ReadLogState(ctx)
+	switch {
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, storage.ErrNoLogStateStorage):
+		return storedLogState{State: LogStateUsable}, nil
+	case err != nil:
+		return storedLogState{}, fmt.Errorf("failed to read log state: %v", err)
+	}
+	var s storedLogState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return storedLogState{}, fmt.Errorf("failed to parse log state %q: %v", b, err)
+	}
+	if _, err := ParseLogState(string(s.State)); err != nil {
+		return storedLogState{}, fmt.Errorf("invalid stored log state: %v", err)
+	}
+	return s, nil
+}
+
+// loadState reads the state of the log from its storage.
+func (l *log) loadState(ctx context.Context) error {
+	s, err := l.readState(ctx)
+	if err != nil {
+		return err
+	}
+	l.state.Store(s.State)
+	if s.State == LogStateRetired {
+		l.stopAppending(ctx)
+	}
+	slog.InfoContext(ctx, "Loaded log state", slog.String("origin", l.origin), slog.String("state", string(s.State)), slog.Time("updated", s.Updated))
+	return nil
+}
+
+// watchState reads the state of the log from its storage every interval,
+// until ctx is done, so that state changes made through another frontend
+// are applied to this one too.
+func (l *log) watchState(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := l.refreshState(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to refresh log state", slog.String("origin", l.origin), slog.Any("error", err))
+		}
+	}
+}
+
+// refreshState applies the stored state of the log, if it differs from the
+// current one. Like with SetState, retired logs can't change state.
+func (l *log) refreshState(ctx context.Context) error {
+	s, err := l.readState(ctx)
+	if err != nil {
+		return err
+	}
+	if s.State == l.State() {
+		return nil
+	}
+	l.stateMu.Lock()
+	defer l.stateMu.Unlock()
+
+	// Read the state again, now that SetState can't be changing it.
+	s, err = l.readState(ctx)
+	if err != nil {
+		return err
+	}
+	l.applyStoredState(ctx, s)
+	return nil
+}
+
+// applyStoredState applies s, the stored state of the log, if it differs
+// from the current one. Retired logs can't change state.
+//
+// l.stateMu must be held for writing.
+func (l *log) applyStoredState(ctx context.Context, s storedLogState) {
+	cur := l.State()
+	if cur == s.State || cur == LogStateRetired {
+		return
+	}
+	l.state.Store(s.State)
+	recordLogState(ctx, l.origin, s.State)
+	if s.State == LogStateRetired {
+		l.stopAppending(ctx)
+	}
+	slog.InfoContext(ctx, "Applied stored log state", slog.String("origin", l.origin), slog.String("from", string(cur)), slog.String("to", string(s.State)), slog.Time("updated", s.Updated))
+}
+
+// stopAppending stops the storage of a retired log from accepting new
+// entries, in the background. Entries that were already added keep being
+// integrated, and checkpoints keep being published.
+func (l *log) stopAppending(ctx context.Context) {
+	as, ok := l.storage.(appendStopper)
+	if !ok {
+		return
+	}
+	l.stopAppendingOnce.Do(func() {
+		ctx := context.WithoutCancel(ctx)
+		go func() {
+			if err := as.StopAppending(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to stop appending to retired log", slog.String("origin", l.origin), slog.Any("error", err))
+				return
+			}
+			slog.InfoContext(ctx, "Stopped appending to retired log", slog.String("origin", l.origin))
+		}()
+	})
+}
+
+// State returns the lifecycle state of the log.
+func (l *log) State() LogState {
+	return l.state.Load().(LogState)
+}
+
+// SetState persists, and then applies a new lifecycle state to the log.
+//
+// It waits for submissions which are being sequenced to be assigned an
+// index, so that once SetState returns, no new entry is sequenced by a log
+// which is not usable. Entries that were already sequenced keep being
+// integrated. Retired logs then stop accepting entries in their storage
+// altogether.
+//
+// Retired logs can't change state, including when they were retired through
+// another frontend since this one last refreshed its state.
+func (l *log) SetState(ctx context.Context, st LogState) error {
+	if _, err := ParseLogState(string(st)); err != nil {
+		return err
+	}
+	l.stateMu.Lock()
+	defer l.stateMu.Unlock()
+
+	// The current state may be stale if another frontend changed it, apply
+	// the stored one first.
+	s, err := l.readState(ctx)
+	if err != nil {
+		return err
+	}
+	l.applyStoredState(ctx, s)
+	cur := l.State()
+	if cur == st {
+		return nil
+	}
+	if cur == LogStateRetired {
+		return fmt.Errorf("log is %s, and can't become %s", cur, st)
+	}
+	b, err := json.Marshal(storedLogState{State: st, Updated: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal log state: %v", err)
+	}
+	if err := l.stateStorage.WriteLogState(ctx, b); err != nil {
+		return fmt.Errorf("failed to persist log state: %v", err)
+	}
+	l.state.Store(st)
+	recordLogState(ctx, l.origin, st)
+	if st == LogStateRetired {
+		l.stopAppending(ctx)
+	}
+	slog.InfoContext(ctx, "Changed log state", slog.String("origin", l.origin), slog.String("from", string(cur)), slog.String("to", string(st)))
+	return nil
+}
+
+// recordLogState sets the log state gauge to 1 for st, and 0 for others.
+func recordLogState(ctx context.Context, origin string, st LogState) {
+	for _, s := range logStates {
+		v := int64(0)
+		if s == st {
+			v = 1
+		}
+		logStateGauge.Record(ctx, v, metric.WithAttributes(originKey.String(origin), logStateKey.String(string(s))))
+	}
+}
+
+// rejectSubmission returns the response to a submission to a log in state
+// st, which does not accept submissions.
+func rejectSubmission(origin string, st LogState) (int, []attribute.KeyValue, error) {
+	code, errCode := http.StatusForbidden, ErrorCodeLogReadOnly
+	if st == LogStateRetired {
+		code, errCode = http.StatusGone, ErrorCodeLogRetired
+	}
+	return code, []attribute.KeyValue{logStateKey.String(string(st))}, withCode(errCode, fmt.Errorf("%s: log is %s and does not accept submissions", origin, st))
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tesseract/storage/posix"
+)
+
+func TestParseLogState(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    LogState
+		wantErr bool
+	}{
+		{in: "usable", want: LogStateUsable},
+		{in: "read_only", want: LogStateReadOnly},
+		{in: "retired", want: LogStateRetired},
+		{in: "", wantErr: true},
+		{in: "READ_ONLY", wantErr: true},
+		{in: "frozen", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLogState(tc.in)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseLogState(%q)=%v, want err %t", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseLogState(%q)=%q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetState(t *testing.T) {
+	l, storageDir := setupTestLog(t)
+	if got := l.State(); got != LogStateUsable {
+		t.Fatalf("State()=%q, want %q", got, LogStateUsable)
+	}
+
+	// reload returns the state a new log using the same storage would load.
+	reload := func() LogState {
+		t.Helper()
+		lss, err := posix.NewLogStateStorage(t.Context(), storageDir)
+		if err != nil {
+			t.Fatalf("NewLogStateStorage(): %v", err)
+		}
+		rl := &log{origin: origin, stateStorage: lss}
+		if err := rl.loadState(t.Context()); err != nil {
+			t.Fatalf("loadState(): %v", err)
+		}
+		return rl.State()
+	}
+
+	for _, tc := range []struct {
+		st      LogState
+		want    LogState
+		wantErr bool
+	}{
+		{st: LogStateReadOnly, want: LogStateReadOnly},
+		{st: LogStateReadOnly, want: LogStateReadOnly},
+		{st: "frozen", want: LogStateReadOnly, wantErr: true},
+		{st: LogStateUsable, want: LogStateUsable},
+		{st: LogStateRetired, want: LogStateRetired},
+		{st: LogStateUsable, want: LogStateRetired, wantErr: true},
+		{st: LogStateReadOnly, want: LogStateRetired, wantErr: true},
+	} {
+		err := l.SetState(t.Context(), tc.st)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Fatalf("SetState(%q)=%v, want err %t", tc.st, err, tc.wantErr)
+		}
+		if got := l.State(); got != tc.want {
+			t.Errorf("SetState(%q): State()=%q, want %q", tc.st, got, tc.want)
+		}
+		if got := reload(); got != tc.want {
+			t.Errorf("SetState(%q): reloaded State()=%q, want %q", tc.st, got, tc.want)
+		}
+	}
+}
+
+// freshProcessEnv is set when a test runs in a fresh process, where no
+// earlier test has set metrics up.
+const freshProcessEnv = "TESSERACT_TEST_FRESH_PROCESS"
+
+func TestSetStateFreshProcess(t *testing.T) {
+	if os.Getenv(freshProcessEnv) != "" {
+		l, _ := setupTestLog(t)
+		if err := l.SetState(t.Context(), LogStateReadOnly); err != nil {
+			t.Fatalf("SetState(%q): %v", LogStateReadOnly, err)
+		}
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSetStateFreshProcess$")
+	cmd.Env = append(os.Environ(), freshProcessEnv+"=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("SetState() in a fresh process: %v\n%s", err, out)
+	}
+}
+
+// fakeAppendStopper records calls to StopAppending.
+type fakeAppendStopper struct {
+	Storage
+	stopped chan struct{}
+}
+
+func (f *fakeAppendStopper) StopAppending(ctx context.Context) error {
+	close(f.stopped)
+	return nil
+}
+
+func TestRefreshState(t *testing.T) {
+	l, storageDir := setupTestLog(t)
+
+	// other is another frontend of the same log.
+	lss, err := posix.NewLogStateStorage(t.Context(), storageDir)
+	if err != nil {
+		t.Fatalf("NewLogStateStorage(): %v", err)
+	}
+	stopper := &fakeAppendStopper{stopped: make(chan struct{})}
+	other := &log{origin: origin, stateStorage: lss, storage: stopper}
+	if err := other.loadState(t.Context()); err != nil {
+		t.Fatalf("loadState(): %v", err)
+	}
+
+	for _, st := range []LogState{LogStateReadOnly, LogStateUsable, LogStateRetired} {
+		if err := l.SetState(t.Context(), st); err != nil {
+			t.Fatalf("SetState(%q): %v", st, err)
+		}
+		if err := other.refreshState(t.Context()); err != nil {
+			t.Fatalf("refreshState(): %v", err)
+		}
+		if got := other.State(); got != st {
+			t.Errorf("after SetState(%q) on another frontend: State()=%q, want %q", st, got, st)
+		}
+	}
+
+	select {
+	case <-stopper.stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("storage of the retired log still accepts entries")
+	}
+}
+
+func TestSetStateStale(t *testing.T) {
+	l, storageDir := setupTestLog(t)
+
+	// other is another frontend of the same log, which hasn't refreshed its
+	// state yet.
+	lss, err := posix.NewLogStateStorage(t.Context(), storageDir)
+	if err != nil {
+		t.Fatalf("NewLogStateStorage(): %v", err)
+	}
+	other := &log{origin: origin, stateStorage: lss}
+	if err := other.loadState(t.Context()); err != nil {
+		t.Fatalf("loadState(): %v", err)
+	}
+
+	if err := l.SetState(t.Context(), LogStateRetired); err != nil {
+		t.Fatalf("SetState(%q): %v", LogStateRetired, err)
+	}
+	if err := other.SetState(t.Context(), LogStateUsable); err == nil {
+		t.Errorf("SetState(%q) on a stale frontend of a retired log succeeded, want err", LogStateUsable)
+	}
+	if got := other.State(); got != LogStateRetired {
+		t.Errorf("stale frontend State()=%q, want %q", got, LogStateRetired)
+	}
+	stored := &log{origin: origin, stateStorage: lss}
+	if err := stored.loadState(t.Context()); err != nil {
+		t.Fatalf("loadState(): %v", err)
+	}
+	if got := stored.State(); got != LogStateRetired {
+		t.Errorf("stored State()=%q after SetState() on a stale frontend, want %q", got, LogStateRetired)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+
+	"github.com/transparency-dev/tesseract/internal/logger"
+	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/x509util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ValidateChainPath is the path of the validate-chain endpoint.
+const ValidateChainPath = "/ct/v1/validate-chain"
+
+// validateChainName is the validate-chain entrypoint name, as exposed in
+// statistics/logging.
+const validateChainName = entrypointName("ValidateChain")
+
+// Rules reported by validate-chain failures.
+const (
+	RuleLogState         = "log_state"
+	RuleParseChain       = "parse_chain"
+	RuleRateLimitOldCert = "rate_limit_old_cert"
+	RuleChainValidation  = "chain_validation"
+	RulePrecertStructure = "precert_structure"
+	RuleLint             = "lint"
+	RuleEntry            = "entry"
+)
+
+// ValidateChainResponse is the verdict returned by validate-chain.
+type ValidateChainResponse struct {
+	// Accepted is true if add-chain, or add-pre-chain for precertificates,
+	// would currently accept the chain.
+	Accepted bool `json:"accepted"`
+	// Precert is true if the chain was validated as a precertificate chain.
+	Precert bool `json:"precert"`
+	// Root is the DER encoding of the root the chain was verified against.
+	Root []byte `json:"root,omitempty"`
+	// RootSHA256 is the hex encoded SHA-256 fingerprint of Root.
+	RootSHA256 string `json:"root_sha256,omitempty"`
+	// Chain is the verified chain, from the leaf to Root, DER encoded.
+	Chain [][]byte `json:"chain,omitempty"`
+	// Failures lists the rules which the chain failed.
+	Failures []ValidateChainFailure `json:"failures"`
+}
+
+// ValidateChainFailure describes a rule which a chain failed.
+type ValidateChainFailure struct {
+	// Rule identifies the rule: one of the Rule constants, or the name of the
+	// policy which rejected the chain.
+	Rule string `json:"rule"`
+	// Reason refines Rule, e.g. with the name of the failed lint.
+	Reason string `json:"reason,omitempty"`
+	// Detail is a human readable description of the failure.
+	Detail string `json:"detail"`
+}
+
+// NewValidateChainPathHandlers returns a handler serving validate-chain.
+//
+// validate-chain is not part of https://c2sp.org/static-ct-api. It takes an
+// add-chain request body, runs the same checks as add-chain or add-pre-chain
+// without adding anything to the log, and returns a ValidateChainResponse.
+func NewValidateChainPathHandlers(ctx context.Context, opts *HandlerOptions, log *log) pathHandlers {
+	once.Do(func() { setupMetrics() })
+
+	prefix := normalizePathPrefix(opts.PathPrefix)
+
+	return pathHandlers{
+		prefix + ValidateChainPath: appHandler{opts: opts, log: log, handler: validateChain, name: validateChainName, method: http.MethodPost},
+	}
+}
+
+// validateChain answers validate-chain requests. Chains are validated as
+// add-pre-chain submissions if their leaf has a CT poison extension, and as
+// add-chain submissions otherwise.
+//
+// Checks that can run independently of each other all run, so that the
+// response lists as many failures as possible. It does not record submission
+// metrics. Requests count against the client quotas, since validating a chain
+// costs as much as submitting it, but not against the other rate limits.
+func validateChain(ctx context.Context, opts *HandlerOptions, log *log, w http.ResponseWriter, r *http.Request) (int, []attribute.KeyValue, error) {
+	ctx, span := tracer.Start(ctx, "tesseract.validateChain")
+	defer span.End()
+
+	if ok, quota := opts.RateLimits.AcceptClient(ctx, log.origin, r); !ok {
+		w.Header().Add("Retry-After", strconv.Itoa(rand.IntN(5)+1)) // random retry within [1,6) seconds
+		return http.StatusTooManyRequests,
+			[]attribute.KeyValue{tooManyRequestsReasonKey.String("rate_limit_" + quota)},
+			withCode(quotaErrorCodes[quota], errors.New(http.StatusText(http.StatusTooManyRequests)))
+	}
+
+	addChainReq, err := parseBodyAsJSONChain(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return http.StatusRequestEntityTooLarge, nil, withCode(ErrorCodeRequestTooLarge, fmt.Errorf("%s: %v", log.origin, err))
+		}
+		return http.StatusBadRequest, nil, withCode(ErrorCodeMalformedRequest, fmt.Errorf("%s: failed to parse validate-chain body: %s", log.origin, err))
+	}
+
+	rsp := verdict(ctx, opts, log, addChainReq.Chain)
+	if err := writeJSONResponse(w, &rsp); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to write validate-chain response: %v", err)
+	}
+	return http.StatusOK, []attribute.KeyValue{validateChainAcceptedKey.Bool(rsp.Accepted)}, nil
+}
+
+// verdict runs submission checks on rawChain.
+func verdict(ctx context.Context, opts *HandlerOptions, log *log, rawChain [][]byte) ValidateChainResponse {
+	rsp := ValidateChainResponse{Failures: []ValidateChainFailure{}}
+	fail := func(rule, reason string, err error) {
+		rsp.Failures = append(rsp.Failures, ValidateChainFailure{Rule: rule, Reason: reason, Detail: err.Error()})
+	}
+
+	if st := log.State(); st != LogStateUsable {
+		fail(RuleLogState, string(st), fmt.Errorf("log is %s and does not accept submissions", st))
+	}
+
+	chain, err := parseChain(rawChain)
+	if err != nil {
+		fail(RuleParseChain, "", err)
+		return rsp
+	}
+	rsp.Precert = hasPoisonExtension(chain[0])
+
+	if !opts.RateLimits.wouldAcceptNotBefore(chain) {
+		fail(RuleRateLimitOldCert, "", errors.New("the rate limit on old certificates is currently exhausted, retry later"))
+	}
+
+	verified, err := log.chainValidator.Validate(chain, rsp.Precert)
+	if err != nil {
+		var pre *PolicyRejectedError
+		var pve *PrecertValidationError
+		switch {
+		case errors.As(err, &pre):
+			var reason string
+			var ire *IssuerRejectedError
+			if errors.As(err, &ire) {
+				reason = ire.Reason
+			}
+			fail(pre.Policy, reason, err)
+		case errors.As(err, &pve):
+			fail(RulePrecertStructure, pve.Reason, err)
+		default:
+			fail(RuleChainValidation, "", err)
+		}
+	} else {
+		root := verified[len(verified)-1]
+		fp := sha256.Sum256(root.Raw)
+		rsp.Root = root.Raw
+		rsp.RootSHA256 = fmt.Sprintf("%x", fp)
+		rsp.Chain = make([][]byte, 0, len(verified))
+		for _, c := range verified {
+			rsp.Chain = append(rsp.Chain, c.Raw)
+		}
+		entry, err := x509util.EntryFromChain(verified, rsp.Precert, uint64(opts.TimeSource.Now().UnixMilli()))
+		if err != nil {
+			fail(RuleEntry, "", err)
+		} else {
+			x509util.ReturnEntry(entry)
+		}
+	}
+
+	for _, f := range opts.Linter.Lint(chain[0]) {
+		if f.Severity == LintReject {
+			fail(RuleLint, f.Lint, f.Err)
+		}
+	}
+
+	rsp.Accepted = len(rsp.Failures) == 0
+	logger.DebugExtraContext(ctx, "validate-chain verdict", slog.String("origin", log.origin), slog.Bool("accepted", rsp.Accepted), slog.Any("failures", rsp.Failures))
+	return rsp
+}
+
+// hasPoisonExtension returns true if cert has a CT poison extension, valid or
+// not.
+func hasPoisonExtension(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(rfc6962.OIDExtensionCTPoison) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ct
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/testdata"
+)
+
+func TestNewValidateChainPathHandlers(t *testing.T) {
+	log, _ := setupTestLog(t)
+	handlers := NewValidateChainPathHandlers(t.Context(), &HandlerOptions{PathPrefix: prefix}, log)
+	h, ok := handlers[prefix+ValidateChainPath]
+	if len(handlers) != 1 || !ok {
+		t.Fatalf("NewValidateChainPathHandlers() returned %d handlers, want one at %s", len(handlers), prefix+ValidateChainPath)
+	}
+	if h.name != validateChainName || h.method != http.MethodPost {
+		t.Errorf("handler has name %s and method %s, want %s and %s", h.name, h.method, validateChainName, http.MethodPost)
+	}
+}
+
+func TestValidateChain(t *testing.T) {
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	intermediate := mustParsePEMs(t, testdata.IntermediateFromRoot)[0]
+	root := mustParsePEMs(t, testdata.CACertPEM)[0]
+
+	for _, tc := range []struct {
+		desc        string
+		body        io.Reader
+		setup       func(t *testing.T, log *log, opts *HandlerOptions)
+		wantCode    int
+		wantPrecert bool
+		// wantFailures lists the rules of the wanted failures, in order.
+		wantFailures []string
+	}{
+		{
+			desc:     "cert",
+			body:     createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)),
+			wantCode: http.StatusOK,
+		},
+		{
+			desc:        "precert",
+			body:        createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.PreCertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM})),
+			wantCode:    http.StatusOK,
+			wantPrecert: true,
+		},
+		{
+			desc:     "invalid-body",
+			body:     bytes.NewBufferString("not json"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "unparsable-chain",
+			body:         bytes.NewBufferString(`{"chain": ["AAAA"]}`),
+			wantCode:     http.StatusOK,
+			wantFailures: []string{RuleParseChain},
+		},
+		{
+			desc:         "unknown-root",
+			body:         createJSONChain(t, loadCertsIntoPoolOrDie(t, []string{testdata.LeafSignedByFakeIntermediateCertPEM, testdata.FakeIntermediateCertPEM})),
+			wantCode:     http.StatusOK,
+			wantFailures: []string{RuleChainValidation},
+		},
+		{
+			desc: "every-failure",
+			body: createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)),
+			setup: func(t *testing.T, log *log, opts *HandlerOptions) {
+				t.Helper()
+				if err := log.SetState(t.Context(), LogStateReadOnly); err != nil {
+					t.Fatalf("SetState(): %v", err)
+				}
+				opts.RateLimits.NotBefore(0, 0)
+				filter, err := ParseIssuerFilter(issuerFilterJSON("deny_fingerprints", sha256.Sum256(intermediate.Raw)))
+				if err != nil {
+					t.Fatalf("ParseIssuerFilter(): %v", err)
+				}
+				cv := log.chainValidator.(chainValidator)
+				cv.policies = []Policy{filter}
+				log.chainValidator = cv
+			},
+			wantCode:     http.StatusOK,
+			wantFailures: []string{RuleLogState, RuleRateLimitOldCert, "issuer_filter"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			log, _ := setupTestLog(t)
+			opts := hOpts()
+			if tc.setup != nil {
+				tc.setup(t, log, opts)
+			}
+			server := httptest.NewServer(NewValidateChainPathHandlers(t.Context(), opts, log)[prefix+ValidateChainPath])
+			defer server.Close()
+
+			resp, err := http.Post(server.URL+path.Join(prefix, ValidateChainPath), "application/json", tc.body)
+			if err != nil {
+				t.Fatalf("http.Post(): %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != tc.wantCode {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+			var rsp ValidateChainResponse
+			if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+				t.Fatalf("json.Decode(): %v", err)
+			}
+
+			var rules []string
+			for _, f := range rsp.Failures {
+				rules = append(rules, f.Rule)
+			}
+			if got, want := fmt.Sprint(rules), fmt.Sprint(tc.wantFailures); got != want {
+				t.Errorf("got failures %+v, want rules %s", rsp.Failures, want)
+			}
+			if got, want := rsp.Accepted, len(tc.wantFailures) == 0; got != want {
+				t.Errorf("got accepted=%t, want %t", got, want)
+			}
+			if rsp.Precert != tc.wantPrecert {
+				t.Errorf("got precert=%t, want %t", rsp.Precert, tc.wantPrecert)
+			}
+			if rsp.Accepted {
+				if !bytes.Equal(rsp.Root, root.Raw) || rsp.RootSHA256 != fmt.Sprintf("%x", sha256.Sum256(root.Raw)) {
+					t.Errorf("got root %x with fingerprint %s, want %s", rsp.Root, rsp.RootSHA256, root.Subject)
+				}
+				if len(rsp.Chain) != 3 {
+					t.Errorf("got a chain of %d certificates, want 3", len(rsp.Chain))
+				}
+			}
+		})
+	}
+}
+
+func TestValidateChainQuotas(t *testing.T) {
+	chain := []string{testdata.CertFromIntermediate, testdata.IntermediateFromRoot, testdata.CACertPEM}
+	log, _ := setupTestLog(t)
+	opts := hOpts()
+	if err := opts.RateLimits.Quotas(QuotasConfig{ClientIP: &QuotaConfig{QPS: 1}}); err != nil {
+		t.Fatalf("Quotas(): %v", err)
+	}
+	server := httptest.NewServer(NewValidateChainPathHandlers(t.Context(), opts, log)[prefix+ValidateChainPath])
+	defer server.Close()
+
+	for i, wantStatus := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := http.Post(server.URL+path.Join(prefix, ValidateChainPath), "application/json", createJSONChain(t, loadCertsIntoPoolOrDie(t, chain)))
+		if err != nil {
+			t.Fatalf("http.Post(): %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("request %d: got status %d, want %d", i, resp.StatusCode, wantStatus)
+		}
+		if wantStatus == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" {
+			t.Error("429 without Retry-After header")
+		}
+	}
+}
+
+func TestValidateChainLints(t *testing.T) {
+	log, _ := setupTestLog(t)
+	opts := hOpts()
+	linter, err := NewLinter(map[string]LintSeverity{"all": LintReject})
+	if err != nil {
+		t.Fatalf("NewLinter(): %v", err)
+	}
+	opts.Linter = linter
+	// The leaf has no SAN, and is not issued by a trusted root.
+	chain := newTestChain(t,
+		&x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true},
+		&x509.Certificate{Subject: pkix.Name{CommonName: "leaf"}},
+	)
+	findings := linter.Lint(chain[0])
+	if len(findings) == 0 {
+		t.Fatalf("Lint() found nothing, the test needs a leaf failing lints")
+	}
+
+	rsp := verdict(t.Context(), opts, log, [][]byte{chain[0].Raw, chain[1].Raw})
+	if rsp.Accepted {
+		t.Errorf("verdict() accepted a chain failing lints")
+	}
+	// Lints run even if the chain does not validate.
+	if got, want := len(rsp.Failures), len(findings)+1; got != want {
+		t.Fatalf("verdict() returned failures %+v, want %d", rsp.Failures, want)
+	}
+	if rsp.Failures[0].Rule != RuleChainValidation {
+		t.Errorf("failure 0: got %s, want %s", rsp.Failures[0].Rule, RuleChainValidation)
+	}
+	for i, f := range rsp.Failures[1:] {
+		if f.Rule != RuleLint || f.Reason != findings[i].Lint {
+			t.Errorf("failure %d: got %s/%s, want %s/%s", i+1, f.Rule, f.Reason, RuleLint, findings[i].Lint)
+		}
+	}
+}
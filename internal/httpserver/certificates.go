@@ -0,0 +1,176 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// selfSignedValidity is the validity period of self-signed certificates.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// Certificates holds the TLS certificate of a server, and reloads it from
+// disk when it changes.
+type Certificates struct {
+	certFile string
+	keyFile  string
+
+	cert atomic.Pointer[tls.Certificate]
+
+	// mu guards modTime, and serializes reloads.
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewCertificates returns Certificates loaded from the PEM encoded
+// certificate chain and private key in certFile and keyFile.
+func NewCertificates(certFile, keyFile string) (*Certificates, error) {
+	c := &Certificates{certFile: certFile, keyFile: keyFile}
+	if _, err := c.Reload(true); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewSelfSignedCertificate returns Certificates holding a self-signed
+// certificate valid for hosts, which are DNS names or IP addresses. It is
+// never reloaded.
+func NewSelfSignedCertificate(hosts []string) (*Certificates, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %v", err)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create self-signed certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse self-signed certificate: %v", err)
+	}
+	c := &Certificates{}
+	c.set(&tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf})
+	return c, nil
+}
+
+// GetCertificate returns the current certificate. It has the signature of
+// tls.Config.GetCertificate.
+func (c *Certificates) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Reload loads the certificate and key files again if either was modified
+// since they were last loaded, or if force is set. It returns whether they
+// were loaded. If they can't be, the current certificate is kept.
+func (c *Certificates) Reload(force bool) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTime, err := c.lastModified()
+	if err != nil {
+		return false, err
+	}
+	if !force && modTime.Equal(c.modTime) {
+		return false, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	c.set(&cert)
+	c.modTime = modTime
+	return true, nil
+}
+
+// Run reloads the certificate every interval, and every time reload receives
+// a value, until ctx is done.
+func (c *Certificates) Run(ctx context.Context, interval time.Duration, reload <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		force := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-reload:
+			force = true
+		}
+		if _, err := c.Reload(force); err != nil {
+			slog.ErrorContext(ctx, "Failed to reload TLS certificate, keeping the current one", slog.Any("error", err))
+		}
+	}
+}
+
+// lastModified returns the latest modification time of the certificate and
+// key files.
+func (c *Certificates) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %v", f, err)
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// set makes cert the current certificate, and logs the hash of its public
+// key so that clients can pin it.
+func (c *Certificates) set(cert *tls.Certificate) {
+	c.cert.Store(cert)
+	h := sha256.Sum256(cert.Leaf.RawSubjectPublicKeyInfo)
+	slog.Info("Serving TLS certificate", slog.Any("dns_names", cert.Leaf.DNSNames), slog.String("spki_sha256", hex.EncodeToString(h[:])), slog.Time("not_after", cert.Leaf.NotAfter))
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpserver builds the HTTP servers of TesseraCT binaries, which can
+// terminate TLS themselves, with certificates reloaded from disk.
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultReadHeaderTimeout is the default timeout for reading request
+	// headers, to avoid slowloris attacks.
+	DefaultReadHeaderTimeout = 5 * time.Second
+	// DefaultMaxHeaderBytes is the default maximum size of request headers.
+	DefaultMaxHeaderBytes = 8 << 10 // 8 KiB
+	// DefaultTLSReloadInterval is the default interval between two checks
+	// for new TLS certificate and key files.
+	DefaultTLSReloadInterval = time.Minute
+)
+
+// Opts configures a Server.
+//
+// Zero timeouts and HTTP/2 settings use the net/http defaults, except for
+// ReadHeaderTimeout and MaxHeaderBytes which default to
+// DefaultReadHeaderTimeout and DefaultMaxHeaderBytes.
+type Opts struct {
+	// ReadHeaderTimeout is the maximum duration for reading request headers.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout is the maximum duration for reading a whole request,
+	// including its body.
+	ReadTimeout time.Duration
+	// WriteTimeout is the maximum duration before timing out writes of a
+	// response. It must leave enough time for add-chain requests waiting for
+	// their entry to be published.
+	WriteTimeout time.Duration
+	// IdleTimeout is the maximum duration to wait for the next request on a
+	// keep-alive connection.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum size of request headers.
+	MaxHeaderBytes int
+
+	// HTTP2MaxConcurrentStreams is the maximum number of concurrent streams
+	// per HTTP/2 connection.
+	HTTP2MaxConcurrentStreams int
+	// HTTP2MaxReadFrameSize is the largest HTTP/2 frame the server reads.
+	HTTP2MaxReadFrameSize int
+	// HTTP2SendPingTimeout is how long an HTTP/2 connection can stay idle
+	// before the server sends a ping to check its health.
+	HTTP2SendPingTimeout time.Duration
+	// UnencryptedHTTP2 serves HTTP/2 without TLS, also known as h2c, in
+	// addition to HTTP/1. This is useful behind load balancers talking
+	// HTTP/2 to their backends.
+	UnencryptedHTTP2 bool
+
+	// TLS, if set, makes the server terminate TLS.
+	TLS *TLSOpts
+}
+
+// TLSOpts configures TLS termination.
+//
+// Exactly one of CertFile and SelfSignedHosts must be set.
+type TLSOpts struct {
+	// CertFile and KeyFile are the paths to the PEM encoded certificate
+	// chain and private key of the server. They are reloaded when they
+	// change on disk.
+	CertFile string
+	KeyFile  string
+	// SelfSignedHosts are the DNS names and IP addresses of a self-signed
+	// certificate generated at startup, for deployments where clients pin
+	// the key of the server rather than trusting a CA. The certificate and
+	// its key only live in memory, and change at every restart.
+	SelfSignedHosts []string
+	// ClientCAFile is the path to PEM encoded CA certificates. When set,
+	// client certificates are verified against them if clients present one.
+	ClientCAFile string
+	// RequestClientCert asks clients for a certificate without verifying
+	// it, e.g. for clients identified by the hash of their public key. It
+	// is ignored if ClientCAFile is set.
+	RequestClientCert bool
+	// ReloadInterval is the interval between two checks for new CertFile and
+	// KeyFile. If not strictly positive, DefaultTLSReloadInterval is used.
+	ReloadInterval time.Duration
+	// Reload triggers a reload of CertFile and KeyFile every time it
+	// receives a value, e.g. on SIGHUP. It can be nil, and is never read
+	// from if CertFile is not set.
+	Reload <-chan struct{}
+}
+
+// Server is an http.Server which terminates TLS if configured to.
+type Server struct {
+	*http.Server
+}
+
+// New returns a Server listening on addr, serving h.
+//
+// If TLS is configured, certificates are reloaded until ctx is done.
+func New(ctx context.Context, addr string, h http.Handler, opts Opts) (*Server, error) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: opts.HTTP2MaxConcurrentStreams,
+			MaxReadFrameSize:     opts.HTTP2MaxReadFrameSize,
+			SendPingTimeout:      opts.HTTP2SendPingTimeout,
+		},
+	}
+	if srv.ReadHeaderTimeout <= 0 {
+		srv.ReadHeaderTimeout = DefaultReadHeaderTimeout
+	}
+	if srv.MaxHeaderBytes <= 0 {
+		srv.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	if opts.UnencryptedHTTP2 {
+		if opts.TLS != nil {
+			return nil, errors.New("unencrypted HTTP/2 can't be served with TLS")
+		}
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if opts.TLS != nil {
+		cfg, err := tlsConfig(ctx, *opts.TLS)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = cfg
+	}
+	return &Server{Server: srv}, nil
+}
+
+// ListenAndServe listens on the TCP address of s, and serves requests on it.
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.TLSConfig != nil {
+			addr = ":https"
+		}
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves requests on l, over TLS if s is configured to.
+func (s *Server) Serve(l net.Listener) error {
+	if s.TLSConfig != nil {
+		// The certificate is provided by TLSConfig.GetCertificate.
+		return s.Server.ServeTLS(l, "", "")
+	}
+	return s.Server.Serve(l)
+}
+
+// tlsConfig returns a TLS configuration for opts.
+func tlsConfig(ctx context.Context, opts TLSOpts) (*tls.Config, error) {
+	var certs *Certificates
+	var err error
+	switch {
+	case opts.CertFile != "" && len(opts.SelfSignedHosts) > 0:
+		return nil, errors.New("a TLS certificate file and self-signed hosts can't both be set")
+	case opts.CertFile != "":
+		if opts.KeyFile == "" {
+			return nil, errors.New("a TLS certificate file requires a key file")
+		}
+		certs, err = NewCertificates(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		interval := opts.ReloadInterval
+		if interval <= 0 {
+			interval = DefaultTLSReloadInterval
+		}
+		go certs.Run(ctx, interval, opts.Reload)
+	case len(opts.SelfSignedHosts) > 0:
+		certs, err = NewSelfSignedCertificate(opts.SelfSignedHosts)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("TLS requires a certificate file or self-signed hosts")
+	}
+
+	cfg := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+	switch {
+	case opts.ClientCAFile != "":
+		pem, err := os.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file %s: %v", opts.ClientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no CA certificate found in " + opts.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case opts.RequestClientCert:
+		cfg.ClientAuth = tls.RequestClientCert
+	}
+	return cfg, nil
+}
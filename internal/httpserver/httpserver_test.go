@@ -0,0 +1,235 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCertificatesReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeTestCert(t, certFile, keyFile, 1, time.Now().Add(-time.Minute))
+
+	c, err := NewCertificates(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertificates(): %v", err)
+	}
+	wantSerial := func(want int64) {
+		t.Helper()
+		cert, err := c.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate(): %v", err)
+		}
+		if got := cert.Leaf.SerialNumber.Int64(); got != want {
+			t.Errorf("got certificate with serial %d, want %d", got, want)
+		}
+	}
+	wantSerial(1)
+
+	if ok, err := c.Reload(false); ok || err != nil {
+		t.Errorf("Reload() of unmodified files=(%t, %v), want (false, nil)", ok, err)
+	}
+
+	writeTestCert(t, certFile, keyFile, 2, time.Now())
+	if ok, err := c.Reload(false); !ok || err != nil {
+		t.Errorf("Reload() of modified files=(%t, %v), want (true, nil)", ok, err)
+	}
+	wantSerial(2)
+
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	if err := os.Chtimes(certFile, time.Time{}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Chtimes(): %v", err)
+	}
+	if _, err := c.Reload(false); err == nil {
+		t.Error("Reload() of an invalid certificate: got nil error, want error")
+	}
+	wantSerial(2)
+}
+
+func TestServeTLS(t *testing.T) {
+	srv, err := New(t.Context(), "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+		if len(r.TLS.PeerCertificates) > 0 {
+			_, _ = io.WriteString(w, " "+r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}), Opts{TLS: &TLSOpts{SelfSignedHosts: []string{"127.0.0.1"}, RequestClientCert: true}})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	serverCert, err := srv.TLSConfig.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate(): %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeTestCert(t, certFile, keyFile, 1, time.Now())
+	clientCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair(): %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCert.Leaf)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCert}},
+		ForceAttemptHTTP2: true,
+	}}
+
+	if got, want := get(t, client, "https://"+l.Addr().String()), "HTTP/2.0 test-1"; got != want {
+		t.Errorf("got response %q, want %q", got, want)
+	}
+}
+
+func TestServeUnencryptedHTTP2(t *testing.T) {
+	srv, err := New(t.Context(), "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}), Opts{UnencryptedHTTP2: true})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	if got, want := get(t, client, "http://"+l.Addr().String()), "HTTP/2.0"; got != want {
+		t.Errorf("got response %q, want %q", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeTestCert(t, certFile, keyFile, 1, time.Now())
+
+	for _, tc := range []struct {
+		desc    string
+		opts    Opts
+		wantErr string
+	}{
+		{
+			desc:    "unencrypted-http2-with-tls",
+			opts:    Opts{UnencryptedHTTP2: true, TLS: &TLSOpts{CertFile: certFile, KeyFile: keyFile}},
+			wantErr: "unencrypted HTTP/2",
+		},
+		{
+			desc:    "no-certificate",
+			opts:    Opts{TLS: &TLSOpts{}},
+			wantErr: "requires a certificate file or self-signed hosts",
+		},
+		{
+			desc:    "certificate-and-self-signed",
+			opts:    Opts{TLS: &TLSOpts{CertFile: certFile, KeyFile: keyFile, SelfSignedHosts: []string{"localhost"}}},
+			wantErr: "can't both be set",
+		},
+		{
+			desc:    "no-key",
+			opts:    Opts{TLS: &TLSOpts{CertFile: certFile}},
+			wantErr: "requires a key file",
+		},
+		{
+			desc:    "missing-client-ca",
+			opts:    Opts{TLS: &TLSOpts{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "missing.crt")}},
+			wantErr: "failed to read client CA file",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := New(t.Context(), "", nil, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("New() err=%v, want err containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// get returns the body of a GET request to url.
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll(): %v", err)
+	}
+	return string(body)
+}
+
+// writeTestCert writes a self-signed certificate with the given serial number
+// and its key to certFile and keyFile, and sets their modification time.
+func writeTestCert(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test-1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey(): %v", err)
+	}
+	for f, b := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(f, pem.EncodeToMemory(b), 0o600); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+		if err := os.Chtimes(f, time.Time{}, modTime); err != nil {
+			t.Fatalf("Chtimes(): %v", err)
+		}
+	}
+}
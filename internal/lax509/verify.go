@@ -64,6 +64,14 @@ type VerifyOptions struct {
 	// CAUTION: This is a temporary solution and it will eventually be removed.
 	// DO NOT depend on it.
 	AcceptSHA1 bool
+	// EnforceNameConstraints specifies whether name constraints from
+	// intermediates and roots are applied to the names in the chain, as
+	// crypto/x509 does.
+	EnforceNameConstraints bool
+	// EnforcePathLength specifies whether the MaxPathLen of intermediates and
+	// roots is enforced, as crypto/x509 does. Precertificate signing
+	// certificates are not counted towards the path length.
+	EnforcePathLength bool
 }
 
 const (
@@ -74,7 +82,7 @@ const (
 
 // isValid performs validity checks on c given that it is a candidate to append
 // to the chain in currentChain.
-func isValid(c *x509.Certificate, certType int, currentChain []*x509.Certificate) error {
+func isValid(c *x509.Certificate, certType int, currentChain []*x509.Certificate, opts *VerifyOptions) error {
 	// UnhandledCriticalExtension check deleted.
 	// Precertificates have the poison extension which the Go library code does
 	// not recognize; also the Go library code does not support the standard
@@ -97,7 +105,8 @@ func isValid(c *x509.Certificate, certType int, currentChain []*x509.Certificate
 		}
 	}
 
-	// CANotAuthorizedForThisName check deleted.
+	// CANotAuthorizedForThisName check moved to Verify, and only runs if
+	// EnforceNameConstraints is set.
 	// Allow logging of all certificates, even if they have been issued by a CA that
 	// is not authorized to issue certs for a given domain.
 
@@ -122,9 +131,20 @@ func isValid(c *x509.Certificate, certType int, currentChain []*x509.Certificate
 		return x509.CertificateInvalidError{Cert: c, Reason: x509.NotAuthorizedToSign, Detail: ""}
 	}
 
-	// TooManyIntermediates check deleted.
+	// TooManyIntermediates check only runs if EnforcePathLength is set.
 	// Path length checks get confused by the presence of an additional
-	// pre-issuer intermediate.
+	// pre-issuer intermediate, which are therefore not counted.
+	if opts.EnforcePathLength && len(currentChain) > 0 && c.BasicConstraintsValid && c.MaxPathLen >= 0 {
+		numIntermediates := 0
+		for _, ic := range currentChain[1:] {
+			if !slices.ContainsFunc(ic.UnknownExtKeyUsage, oidExtKeyUsageCTPrecertSigning.Equal) {
+				numIntermediates++
+			}
+		}
+		if numIntermediates > c.MaxPathLen {
+			return x509.CertificateInvalidError{Cert: c, Reason: x509.TooManyIntermediates, Detail: ""}
+		}
+	}
 
 	return nil
 }
@@ -182,7 +202,7 @@ func Verify(c *x509.Certificate, opts VerifyOptions) (chains [][]*x509.Certifica
 		return nil, fmt.Errorf("opts.Roots == nil, roots MUST be provided")
 	}
 
-	err = isValid(c, leafCertificate, nil)
+	err = isValid(c, leafCertificate, nil, &opts)
 	if err != nil {
 		return
 	}
@@ -197,6 +217,22 @@ func Verify(c *x509.Certificate, opts VerifyOptions) (chains [][]*x509.Certifica
 		}
 	}
 
+	if opts.EnforceNameConstraints {
+		var constraintsHintErr error
+		candidateChains = slices.DeleteFunc(candidateChains, func(chain []*x509.Certificate) bool {
+			if err := CheckNameConstraints(chain); err != nil {
+				if constraintsHintErr == nil {
+					constraintsHintErr = err
+				}
+				return true
+			}
+			return false
+		})
+		if len(candidateChains) == 0 {
+			return nil, constraintsHintErr
+		}
+	}
+
 	if len(opts.KeyUsages) == 0 {
 		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
 	}
@@ -216,6 +252,16 @@ func Verify(c *x509.Certificate, opts VerifyOptions) (chains [][]*x509.Certifica
 	return candidateChains, nil
 }
 
+// CheckNameConstraints checks that the names in chain are allowed by the name
+// constraints of the certificates that issued them. chain starts with the
+// leaf.
+func CheckNameConstraints(chain []*x509.Certificate) error {
+	if err := checkChainConstraints(chain); err != nil {
+		return x509.CertificateInvalidError{Cert: chain[0], Reason: x509.CANotAuthorizedForThisName, Detail: err.Error()}
+	}
+	return nil
+}
+
 func appendToFreshChain(chain []*x509.Certificate, cert *x509.Certificate) []*x509.Certificate {
 	n := make([]*x509.Certificate, len(chain)+1)
 	copy(n, chain)
@@ -306,7 +352,7 @@ func buildChains(c *x509.Certificate, currentChain []*x509.Certificate, sigCheck
 			return
 		}
 
-		err = isValid(candidate.cert, certType, currentChain)
+		err = isValid(candidate.cert, certType, currentChain, opts)
 		if err != nil {
 			if hintErr == nil {
 				hintErr = err
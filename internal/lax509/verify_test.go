@@ -11,6 +11,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -1787,3 +1788,123 @@ func TestVerifyNilPubKey(t *testing.T) {
 		t.Fatalf("buildChains returned unexpected error, got: %v, want %v", err, UnknownAuthorityError{})
 	}
 }
+
+func TestVerifyEnforceConstraints(t *testing.T) {
+	preIssuer := func(t *x509.Certificate) {
+		t.UnknownExtKeyUsage = []asn1.ObjectIdentifier{oidExtKeyUsageCTPrecertSigning}
+	}
+	maxPathLenZero := func(t *x509.Certificate) {
+		t.MaxPathLen, t.MaxPathLenZero = 0, true
+	}
+	permitExampleCom := func(t *x509.Certificate) {
+		t.PermittedDNSDomains = []string{"example.com"}
+	}
+	exampleComLeaf := func(t *x509.Certificate) {
+		t.DNSNames = []string{"www.example.com"}
+	}
+
+	tests := []struct {
+		name           string
+		graph          trustGraphDescription
+		opts           VerifyOptions
+		expectedChains []string
+		expectedErr    string
+	}{
+		{
+			name: "name constraints ignored",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root"}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "inter", Type: intermediateCertificate, MutateTemplate: permitExampleCom},
+					{Issuer: "inter", Subject: "leaf", Type: leafCertificate},
+				},
+			},
+			expectedChains: []string{"CN=leaf -> CN=inter -> CN=root"},
+		},
+		{
+			name: "name constraints enforced, permitted name",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root"}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "inter", Type: intermediateCertificate, MutateTemplate: permitExampleCom},
+					{Issuer: "inter", Subject: "leaf", Type: leafCertificate, MutateTemplate: exampleComLeaf},
+				},
+			},
+			opts:           VerifyOptions{EnforceNameConstraints: true},
+			expectedChains: []string{"CN=leaf -> CN=inter -> CN=root"},
+		},
+		{
+			name: "name constraints enforced, not permitted name",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root"}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "inter", Type: intermediateCertificate, MutateTemplate: permitExampleCom},
+					{Issuer: "inter", Subject: "leaf", Type: leafCertificate},
+				},
+			},
+			opts:        VerifyOptions{EnforceNameConstraints: true},
+			expectedErr: "x509: a root or intermediate certificate is not authorized to sign for this name: DNS name \"localhost\" is not permitted by any constraint",
+		},
+		{
+			name: "path length ignored",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root", MutateTemplate: maxPathLenZero}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "inter", Type: intermediateCertificate},
+					{Issuer: "inter", Subject: "leaf", Type: leafCertificate},
+				},
+			},
+			expectedChains: []string{"CN=leaf -> CN=inter -> CN=root"},
+		},
+		{
+			name: "path length enforced, too many intermediates",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root", MutateTemplate: maxPathLenZero}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "inter", Type: intermediateCertificate},
+					{Issuer: "inter", Subject: "leaf", Type: leafCertificate},
+				},
+			},
+			opts:        VerifyOptions{EnforcePathLength: true},
+			expectedErr: "x509: too many intermediates for path length constraint",
+		},
+		{
+			name: "path length enforced, pre-issuer not counted",
+			graph: trustGraphDescription{
+				Roots: []rootDescription{{Subject: "root", MutateTemplate: maxPathLenZero}},
+				Leaf:  "leaf",
+				Graph: []trustGraphEdge{
+					{Issuer: "root", Subject: "pre-issuer", Type: intermediateCertificate, MutateTemplate: preIssuer},
+					{Issuer: "pre-issuer", Subject: "leaf", Type: leafCertificate},
+				},
+			},
+			opts:           VerifyOptions{EnforcePathLength: true},
+			expectedChains: []string{"CN=leaf -> CN=pre-issuer -> CN=root"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			roots, intermediates, leaf := buildTrustGraph(t, tc.graph)
+			tc.opts.Roots = roots
+			tc.opts.Intermediates = intermediates
+			tc.opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+			chains, err := Verify(leaf, tc.opts)
+			if gotErr := fmt.Sprint(err); err != nil && gotErr != tc.expectedErr {
+				t.Fatalf("unexpected error: got %q, want %q", gotErr, tc.expectedErr)
+			}
+			if err == nil && tc.expectedErr != "" {
+				t.Fatalf("expected error %q, got nil", tc.expectedErr)
+			}
+			gotChains := chainsToStrings(chains)
+			if len(tc.expectedChains) > 0 && !slices.Equal(gotChains, tc.expectedChains) {
+				t.Errorf("unexpected chains returned:\ngot:\n\t%s\nwant:\n\t%s", strings.Join(gotChains, "\n\t"), strings.Join(tc.expectedChains, "\n\t"))
+			}
+		})
+	}
+}
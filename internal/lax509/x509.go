@@ -2,10 +2,13 @@ package lax509
 
 import (
 	"crypto/x509"
+	"encoding/asn1"
 )
 
 var (
-	oidExtensionSubjectAltName = []int{2, 5, 29, 17}
+	oidExtensionSubjectAltName     = []int{2, 5, 29, 17}
+	oidExtensionNameConstraints    = []int{2, 5, 29, 30}
+	oidExtKeyUsageCTPrecertSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 4}
 )
 
 // checkSignatureFrom verifies that the signature on c is a valid signature from parent.
@@ -0,0 +1,185 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkcs11 provides a crypto.Signer backed by a key stored in a PKCS #11
+// token, such as an HSM, so that the log private key never leaves the token.
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/cryptobyte"
+	casn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Config identifies a private key in a PKCS #11 token.
+type Config struct {
+	// ModulePath is the path to the PKCS #11 module shared library.
+	ModulePath string
+	// TokenLabel is the label of the token holding the key.
+	TokenLabel string
+	// PIN is the user PIN of the token.
+	PIN string
+	// KeyLabel is the label of the key pair. Both the private and the public
+	// key objects must carry it.
+	KeyLabel string
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.ModulePath == "":
+		return errors.New("empty PKCS #11 module path")
+	case c.TokenLabel == "":
+		return errors.New("empty PKCS #11 token label")
+	case c.KeyLabel == "":
+		return errors.New("empty PKCS #11 key label")
+	}
+	return nil
+}
+
+// PINEnvVar is the environment variable ReadPIN falls back to.
+const PINEnvVar = "PKCS11_PIN"
+
+// ReadPIN returns the token user PIN stored in pinFile, with surrounding
+// whitespace removed. If pinFile is empty, it returns the value of the
+// PKCS11_PIN environment variable instead.
+func ReadPIN(pinFile string) (string, error) {
+	if pinFile == "" {
+		return os.Getenv(PINEnvVar), nil
+	}
+	p, err := os.ReadFile(pinFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PKCS #11 PIN file %s: %w", pinFile, err)
+	}
+	return strings.TrimSpace(string(p)), nil
+}
+
+// NewFromFlags returns a signer using the key pair labelled keyLabel, in the
+// token labelled tokenLabel of the PKCS #11 module at modulePath. The token
+// user PIN is read with ReadPIN(pinFile).
+//
+// It is shared by the server binaries, which take these as flags.
+func NewFromFlags(modulePath, tokenLabel, keyLabel, pinFile string) (*Signer, error) {
+	pin, err := ReadPIN(pinFile)
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		ModulePath: modulePath,
+		TokenLabel: tokenLabel,
+		PIN:        pin,
+		KeyLabel:   keyLabel,
+	})
+}
+
+var (
+	oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidNamedCurveP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+)
+
+// ecPublicKey builds an ECDSA public key from the DER encoded CKA_EC_PARAMS
+// and CKA_EC_POINT attributes of a PKCS #11 public key object.
+func ecPublicKey(params, point []byte) (*ecdsa.PublicKey, error) {
+	var oid asn1.ObjectIdentifier
+	if rest, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, fmt.Errorf("failed to parse EC params: %v", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after EC params")
+	}
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch {
+	case oid.Equal(oidNamedCurveP256):
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case oid.Equal(oidNamedCurveP384):
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case oid.Equal(oidNamedCurveP521):
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %v", oid)
+	}
+
+	// CKA_EC_POINT is a DER OCTET STRING wrapping the uncompressed point,
+	// although some modules return the raw point.
+	var raw []byte
+	if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) != 0 {
+		raw = point
+	}
+	// Let the ecdh package check that the point is on the curve.
+	pk, err := ecdhCurve.NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC point: %v", err)
+	}
+	pub := pk.Bytes()
+	byteLen := (curve.Params().BitSize + 7) / 8
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(pub[1 : 1+byteLen]),
+		Y:     new(big.Int).SetBytes(pub[1+byteLen:]),
+	}, nil
+}
+
+// rsaPublicKey builds an RSA public key from the CKA_MODULUS and
+// CKA_PUBLIC_EXPONENT attributes of a PKCS #11 public key object.
+func rsaPublicKey(modulus, exponent []byte) (*rsa.PublicKey, error) {
+	e := new(big.Int).SetBytes(exponent)
+	if !e.IsInt64() || e.Int64() > 1<<31-1 || e.Int64() < 3 {
+		return nil, fmt.Errorf("unsupported RSA public exponent %v", e)
+	}
+	n := new(big.Int).SetBytes(modulus)
+	if n.Sign() <= 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// ecdsaSignatureToASN1 converts a raw r||s CKM_ECDSA signature to the ASN.1
+// encoding used by crypto.Signer.
+func ecdsaSignatureToASN1(sig []byte) ([]byte, error) {
+	if len(sig) == 0 || len(sig)%2 != 0 {
+		return nil, fmt.Errorf("invalid ECDSA signature length %d", len(sig))
+	}
+	r := new(big.Int).SetBytes(sig[:len(sig)/2])
+	s := new(big.Int).SetBytes(sig[len(sig)/2:])
+	var b cryptobyte.Builder
+	b.AddASN1(casn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
+
+// sha256DigestInfoPrefix is the DER encoding of a SHA-256 DigestInfo, without
+// the digest, as per RFC 8017 section 9.2.
+var sha256DigestInfoPrefix = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+// rsaDigestInfo returns the DigestInfo to sign with CKM_RSA_PKCS to produce
+// a RSASSA-PKCS1-v1_5 signature of a SHA-256 digest.
+func rsaDigestInfo(digest []byte) ([]byte, error) {
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("digest bytes length %d does not match SHA-256 length %d", len(digest), crypto.SHA256.Size())
+	}
+	return append(append([]byte{}, sha256DigestInfoPrefix...), digest...), nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			desc:    "no-module",
+			cfg:     Config{TokenLabel: "t", KeyLabel: "k"},
+			wantErr: "module path",
+		},
+		{
+			desc:    "no-token",
+			cfg:     Config{ModulePath: "m", KeyLabel: "k"},
+			wantErr: "token label",
+		},
+		{
+			desc:    "no-key",
+			cfg:     Config{ModulePath: "m", TokenLabel: "t"},
+			wantErr: "key label",
+		},
+		{
+			desc: "ok",
+			cfg:  Config{ModulePath: "m", TokenLabel: "t", KeyLabel: "k"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.cfg.validate()
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("validate()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("validate()=%v", err)
+			}
+		})
+	}
+}
+
+func TestReadPIN(t *testing.T) {
+	t.Setenv(PINEnvVar, "env-pin")
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(pinFile, []byte("file-pin\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+
+	if got, err := ReadPIN(""); err != nil || got != "env-pin" {
+		t.Errorf("ReadPIN(\"\")=%q, %v, want %q, nil", got, err, "env-pin")
+	}
+	if got, err := ReadPIN(pinFile); err != nil || got != "file-pin" {
+		t.Errorf("ReadPIN(%q)=%q, %v, want %q, nil", pinFile, got, err, "file-pin")
+	}
+	if _, err := ReadPIN(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("ReadPIN() with a missing file: got nil error, want error")
+	}
+}
+
+func TestECPublicKey(t *testing.T) {
+	for _, tc := range []struct {
+		curve elliptic.Curve
+		oid   asn1.ObjectIdentifier
+	}{
+		{curve: elliptic.P256(), oid: oidNamedCurveP256},
+		{curve: elliptic.P384(), oid: oidNamedCurveP384},
+		{curve: elliptic.P521(), oid: oidNamedCurveP521},
+	} {
+		t.Run(tc.curve.Params().Name, func(t *testing.T) {
+			key, err := ecdsa.GenerateKey(tc.curve, rand.Reader)
+			if err != nil {
+				t.Fatalf("GenerateKey(): %v", err)
+			}
+			ecdhKey, err := key.PublicKey.ECDH()
+			if err != nil {
+				t.Fatalf("ECDH(): %v", err)
+			}
+			params, err := asn1.Marshal(tc.oid)
+			if err != nil {
+				t.Fatalf("asn1.Marshal(): %v", err)
+			}
+			point, err := asn1.Marshal(ecdhKey.Bytes())
+			if err != nil {
+				t.Fatalf("asn1.Marshal(): %v", err)
+			}
+
+			for name, p := range map[string][]byte{"der": point, "raw": ecdhKey.Bytes()} {
+				got, err := ecPublicKey(params, p)
+				if err != nil {
+					t.Fatalf("ecPublicKey() with %s point: %v", name, err)
+				}
+				if !got.Equal(&key.PublicKey) {
+					t.Errorf("ecPublicKey() with %s point returned a different key", name)
+				}
+			}
+		})
+	}
+
+	params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 3})
+	if err != nil {
+		t.Fatalf("asn1.Marshal(): %v", err)
+	}
+	if _, err := ecPublicKey(params, nil); err == nil {
+		t.Error("ecPublicKey() with an unknown curve: got nil error, want error")
+	}
+	params, err = asn1.Marshal(oidNamedCurveP256)
+	if err != nil {
+		t.Fatalf("asn1.Marshal(): %v", err)
+	}
+	if _, err := ecPublicKey(params, []byte{0x04, 0x01}); err == nil {
+		t.Error("ecPublicKey() with an invalid point: got nil error, want error")
+	}
+}
+
+func TestRSAPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	got, err := rsaPublicKey(key.N.Bytes(), big.NewInt(int64(key.E)).Bytes())
+	if err != nil {
+		t.Fatalf("rsaPublicKey(): %v", err)
+	}
+	if !got.Equal(&key.PublicKey) {
+		t.Error("rsaPublicKey() returned a different key")
+	}
+	if _, err := rsaPublicKey(key.N.Bytes(), []byte{1}); err == nil {
+		t.Error("rsaPublicKey() with exponent 1: got nil error, want error")
+	}
+	if _, err := rsaPublicKey(nil, big.NewInt(int64(key.E)).Bytes()); err == nil {
+		t.Error("rsaPublicKey() without a modulus: got nil error, want error")
+	}
+}
+
+func TestECDSASignatureToASN1(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	digest := sha256.Sum256([]byte("checkpoint"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	// CKM_ECDSA signatures are r||s, each padded to the curve size.
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	s.FillBytes(raw[32:])
+
+	sig, err := ecdsaSignatureToASN1(raw)
+	if err != nil {
+		t.Fatalf("ecdsaSignatureToASN1(): %v", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Error("converted signature doesn't verify")
+	}
+	if _, err := ecdsaSignatureToASN1(raw[:63]); err == nil {
+		t.Error("ecdsaSignatureToASN1() with an odd length: got nil error, want error")
+	}
+}
+
+func TestRSADigestInfo(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	digest := sha256.Sum256([]byte("checkpoint"))
+	di, err := rsaDigestInfo(digest[:])
+	if err != nil {
+		t.Fatalf("rsaDigestInfo(): %v", err)
+	}
+	// Signing the DigestInfo without a hash function is what CKM_RSA_PKCS
+	// does, and must produce a regular PKCS #1 v1.5 SHA-256 signature.
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.Hash(0), di)
+	if err != nil {
+		t.Fatalf("SignPKCS1v15(): %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("VerifyPKCS1v15(): %v", err)
+	}
+	if _, err := rsaDigestInfo(digest[:31]); err == nil {
+		t.Error("rsaDigestInfo() with a short digest: got nil error, want error")
+	}
+}
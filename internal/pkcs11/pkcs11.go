@@ -0,0 +1,357 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+// Signer is a crypto.Signer backed by a private key in a PKCS #11 token.
+//
+// It only signs SHA-256 digests: ECDSA keys produce ASN.1 encoded signatures,
+// and RSA keys produce RSASSA-PKCS1-v1_5 signatures.
+//
+// If the token drops its session, for instance because it was reset, the
+// signer logs in again and retries once.
+type Signer struct {
+	cfg  Config
+	ctx  *p11.Ctx
+	slot uint
+	pub  crypto.PublicKey
+
+	// mu guards the fields below, since PKCS #11 sessions can't run
+	// concurrent operations.
+	mu      sync.Mutex
+	session p11.SessionHandle
+	key     p11.ObjectHandle
+	// loggedIn is false if session needs to be opened and logged into.
+	loggedIn bool
+	closed   bool
+	// shared is true if the Signer is counted in initializedModules.
+	shared bool
+}
+
+var (
+	modulesMu sync.Mutex
+	// initializedModules counts, by module path, the Signers using a module
+	// initialized by this package. Modules are shared by all the Signers of
+	// the process, and only finalized once the last of them is closed.
+	// Modules initialized elsewhere are never finalized.
+	initializedModules = make(map[string]int)
+)
+
+// New loads the PKCS #11 module in cfg, logs into the token, and returns a
+// signer using the key pair labelled cfg.KeyLabel.
+//
+// Callers must call Close once they're done with it.
+func New(cfg Config) (*Signer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx := p11.New(cfg.ModulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS #11 module %q", cfg.ModulePath)
+	}
+	s := &Signer{cfg: cfg, ctx: ctx}
+	if err := s.initializeModule(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS #11 module %q: %v", cfg.ModulePath, err)
+	}
+	if err := s.init(); err != nil {
+		s.unload()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Signer) init() error {
+	slot, err := s.findSlot()
+	if err != nil {
+		return err
+	}
+	s.slot = slot
+	if err := s.login(); err != nil {
+		return err
+	}
+	pubObj, err := s.findObject(p11.CKO_PUBLIC_KEY)
+	if err != nil {
+		s.logout()
+		return fmt.Errorf("failed to find public key: %v", err)
+	}
+	if s.pub, err = s.publicKey(pubObj); err != nil {
+		s.logout()
+		return err
+	}
+	return nil
+}
+
+// findSlot returns the slot holding the token labelled s.cfg.TokenLabel.
+func (s *Signer) findSlot() (uint, error) {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PKCS #11 slots: %v", err)
+	}
+	if len(slots) == 0 {
+		return 0, errors.New("no PKCS #11 slot with a token")
+	}
+	for _, slot := range slots {
+		info, err := s.ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		// Token labels are padded with spaces.
+		if strings.TrimRight(info.Label, " ") == s.cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no PKCS #11 token labelled %q", s.cfg.TokenLabel)
+}
+
+// login opens a session, logs into the token, and finds the private key.
+// s.mu must be held, unless s is being created.
+func (s *Signer) login() error {
+	session, err := s.ctx.OpenSession(s.slot, p11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open PKCS #11 session: %v", err)
+	}
+	if err := s.ctx.Login(session, p11.CKU_USER, s.cfg.PIN); err != nil && !errors.Is(err, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN)) {
+		_ = s.ctx.CloseSession(session)
+		return fmt.Errorf("failed to log into PKCS #11 token %q: %v", s.cfg.TokenLabel, err)
+	}
+	s.session, s.loggedIn = session, true
+	key, err := s.findObject(p11.CKO_PRIVATE_KEY)
+	if err != nil {
+		s.logout()
+		return fmt.Errorf("failed to find private key: %v", err)
+	}
+	s.key = key
+	return nil
+}
+
+// logout closes the session, if any. s.mu must be held, unless s is being
+// created.
+func (s *Signer) logout() {
+	if !s.loggedIn {
+		return
+	}
+	// Closing the session logs it out, and fails if the token already
+	// dropped it.
+	_ = s.ctx.CloseSession(s.session)
+	s.loggedIn = false
+}
+
+// findObject returns the only object of class labelled s.cfg.KeyLabel.
+func (s *Signer) findObject(class uint) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_LABEL, s.cfg.KeyLabel),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, err
+	}
+	objs, _, err := s.ctx.FindObjects(s.session, 2)
+	if errF := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = errF
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch len(objs) {
+	case 0:
+		return 0, fmt.Errorf("no object labelled %q", s.cfg.KeyLabel)
+	case 1:
+		return objs[0], nil
+	default:
+		return 0, fmt.Errorf("multiple objects labelled %q", s.cfg.KeyLabel)
+	}
+}
+
+// publicKey reads the ECDSA or RSA public key from obj.
+func (s *Signer) publicKey(obj p11.ObjectHandle) (crypto.PublicKey, error) {
+	ecAttrs, err := s.ctx.GetAttributeValue(s.session, obj, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+		p11.NewAttribute(p11.CKA_EC_POINT, nil),
+	})
+	if err == nil && len(ecAttrs) == 2 && len(ecAttrs[0].Value) > 0 {
+		return ecPublicKey(ecAttrs[0].Value, ecAttrs[1].Value)
+	}
+	rsaAttrs, err := s.ctx.GetAttributeValue(s.session, obj, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_MODULUS, nil),
+		p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("public key is neither an EC nor an RSA key: %v", err)
+	}
+	if len(rsaAttrs) != 2 {
+		return nil, errors.New("failed to read RSA public key attributes")
+	}
+	return rsaPublicKey(rsaAttrs[0].Value, rsaAttrs[1].Value)
+}
+
+// Public returns the public key of the token's key pair.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign signs a SHA-256 digest with the token's private key.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() != crypto.SHA256 {
+		return nil, errors.New("only SHA-256 digests are supported")
+	}
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, errors.New("RSA-PSS signatures are not supported")
+	}
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("digest bytes length %d does not match SHA-256 length %d", len(digest), crypto.SHA256.Size())
+	}
+
+	var mechanism uint
+	data := digest
+	switch s.pub.(type) {
+	case *ecdsa.PublicKey:
+		mechanism = p11.CKM_ECDSA
+	case *rsa.PublicKey:
+		mechanism = p11.CKM_RSA_PKCS
+		var err error
+		if data, err = rsaDigestInfo(digest); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", s.pub)
+	}
+
+	sig, err := s.sign(mechanism, data)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS #11 signing failed: %v", err)
+	}
+	if mechanism == p11.CKM_ECDSA {
+		return ecdsaSignatureToASN1(sig)
+	}
+	return sig, nil
+}
+
+// sign signs data with mechanism. If the token dropped the session, it logs
+// in again and retries once.
+func (s *Signer) sign(mechanism uint, data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.New("signer is closed")
+	}
+
+	if !s.loggedIn {
+		if err := s.login(); err != nil {
+			return nil, err
+		}
+	}
+	sig, err := s.signInSession(mechanism, data)
+	if err == nil || !sessionLost(err) {
+		return sig, err
+	}
+	s.logout()
+	if errL := s.login(); errL != nil {
+		return nil, fmt.Errorf("%v, and failed to log in again: %v", err, errL)
+	}
+	return s.signInSession(mechanism, data)
+}
+
+func (s *Signer) signInSession(mechanism uint, data []byte) ([]byte, error) {
+	if err := s.ctx.SignInit(s.session, []*p11.Mechanism{p11.NewMechanism(mechanism, nil)}, s.key); err != nil {
+		return nil, err
+	}
+	return s.ctx.Sign(s.session, data)
+}
+
+// sessionLost returns true if err means that the session, or its login, is
+// gone, for instance because the token was reset.
+func sessionLost(err error) bool {
+	var rv p11.Error
+	if !errors.As(err, &rv) {
+		return false
+	}
+	switch rv {
+	case p11.CKR_USER_NOT_LOGGED_IN, p11.CKR_SESSION_HANDLE_INVALID, p11.CKR_SESSION_CLOSED,
+		p11.CKR_KEY_HANDLE_INVALID, p11.CKR_OBJECT_HANDLE_INVALID,
+		p11.CKR_DEVICE_REMOVED, p11.CKR_TOKEN_NOT_PRESENT:
+		return true
+	}
+	return false
+}
+
+// Close closes the PKCS #11 session and unloads the module, which is only
+// finalized once no other Signer uses it.
+func (s *Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.loggedIn {
+		if err = s.ctx.CloseSession(s.session); err != nil {
+			err = fmt.Errorf("failed to close PKCS #11 session: %v", err)
+		}
+		s.loggedIn = false
+	}
+	s.unload()
+	return err
+}
+
+// initializeModule initializes the module, unless it already is.
+func (s *Signer) initializeModule() error {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	err := s.ctx.Initialize()
+	switch {
+	case err == nil:
+		initializedModules[s.cfg.ModulePath] = 1
+		s.shared = true
+	case errors.Is(err, p11.Error(p11.CKR_CRYPTOKI_ALREADY_INITIALIZED)):
+		if _, ok := initializedModules[s.cfg.ModulePath]; ok {
+			initializedModules[s.cfg.ModulePath]++
+			s.shared = true
+		}
+	default:
+		return err
+	}
+	return nil
+}
+
+// unload finalizes the module if no other Signer uses it, and unloads it.
+func (s *Signer) unload() {
+	if s.shared {
+		modulesMu.Lock()
+		initializedModules[s.cfg.ModulePath]--
+		if initializedModules[s.cfg.ModulePath] == 0 {
+			delete(initializedModules, s.cfg.ModulePath)
+			_ = s.ctx.Finalize()
+		}
+		modulesMu.Unlock()
+	}
+	s.ctx.Destroy()
+}
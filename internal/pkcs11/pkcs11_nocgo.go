@@ -0,0 +1,51 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"errors"
+	"io"
+)
+
+// Signer is a crypto.Signer backed by a private key in a PKCS #11 token.
+//
+// PKCS #11 support requires cgo, this build doesn't have it.
+type Signer struct{}
+
+// New always fails, since PKCS #11 support requires cgo.
+func New(cfg Config) (*Signer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("PKCS #11 support requires a binary built with cgo")
+}
+
+// Public returns nil.
+func (s *Signer) Public() crypto.PublicKey {
+	return nil
+}
+
+// Sign always fails.
+func (s *Signer) Sign(_ io.Reader, _ []byte, _ crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("PKCS #11 support requires a binary built with cgo")
+}
+
+// Close is a no-op.
+func (s *Signer) Close() error {
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+// TestSigner runs against a real PKCS #11 token, e.g. SoftHSM:
+//
+//	softhsm2-util --init-token --free --label tesseract --pin 1234 --so-pin 1234
+//	pkcs11-tool --module /usr/lib/softhsm/libsofthsm2.so --token-label tesseract --login --pin 1234 \
+//	  --keypairgen --key-type EC:prime256v1 --label log-key
+//	PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so PKCS11_TOKEN_LABEL=tesseract PKCS11_PIN=1234 \
+//	  PKCS11_KEY_LABEL=log-key go test ./internal/pkcs11/
+func TestSigner(t *testing.T) {
+	cfg := Config{
+		ModulePath: os.Getenv("PKCS11_MODULE"),
+		TokenLabel: os.Getenv("PKCS11_TOKEN_LABEL"),
+		PIN:        os.Getenv("PKCS11_PIN"),
+		KeyLabel:   os.Getenv("PKCS11_KEY_LABEL"),
+	}
+	if cfg.ModulePath == "" {
+		t.Skip("PKCS11_MODULE not set, skipping PKCS #11 token test")
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("Close(): %v", err)
+		}
+	}()
+
+	// Sign concurrently, to exercise session locking.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			digest := sha256.Sum256([]byte{byte(i)})
+			sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+			if err != nil {
+				t.Errorf("Sign(): %v", err)
+				return
+			}
+			switch pk := s.Public().(type) {
+			case *ecdsa.PublicKey:
+				if !ecdsa.VerifyASN1(pk, digest[:], sig) {
+					t.Error("invalid ECDSA signature")
+				}
+			case *rsa.PublicKey:
+				if err := rsa.VerifyPKCS1v15(pk, crypto.SHA256, digest[:], sig); err != nil {
+					t.Errorf("invalid RSA signature: %v", err)
+				}
+			default:
+				t.Errorf("unexpected public key type %T", pk)
+			}
+		})
+	}
+	wg.Wait()
+
+	if _, err := s.Sign(rand.Reader, make([]byte, 48), crypto.SHA384); err == nil {
+		t.Error("Sign() with SHA-384: got nil error, want error")
+	}
+}
+
+// TestSharedModule checks that closing a Signer doesn't break other Signers
+// using the same module. It runs against a real PKCS #11 token, like
+// TestSigner.
+func TestSharedModule(t *testing.T) {
+	cfg := Config{
+		ModulePath: os.Getenv("PKCS11_MODULE"),
+		TokenLabel: os.Getenv("PKCS11_TOKEN_LABEL"),
+		PIN:        os.Getenv("PKCS11_PIN"),
+		KeyLabel:   os.Getenv("PKCS11_KEY_LABEL"),
+	}
+	if cfg.ModulePath == "" {
+		t.Skip("PKCS11_MODULE not set, skipping PKCS #11 token test")
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("Close(): %v", err)
+		}
+	}()
+	other, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() of a second signer: %v", err)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatalf("Close() of the second signer: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("shared"))
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Errorf("Sign() after closing another signer of the same module: %v", err)
+	}
+}
+
+func TestNewInvalidModule(t *testing.T) {
+	if _, err := New(Config{ModulePath: "/does/not/exist.so", TokenLabel: "t", KeyLabel: "k"}); err == nil {
+		t.Error("New() with a missing module: got nil error, want error")
+	}
+}
+
+func TestSessionLost(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: p11.Error(p11.CKR_USER_NOT_LOGGED_IN), want: true},
+		{err: p11.Error(p11.CKR_SESSION_HANDLE_INVALID), want: true},
+		{err: fmt.Errorf("wrapped: %w", p11.Error(p11.CKR_DEVICE_REMOVED)), want: true},
+		{err: p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN), want: false},
+		{err: errors.New("not a PKCS #11 error"), want: false},
+	} {
+		if got := sessionLost(tc.err); got != tc.want {
+			t.Errorf("sessionLost(%v)=%t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
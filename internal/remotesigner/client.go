@@ -0,0 +1,344 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the default maximum duration of a Sign call.
+	DefaultTimeout = 5 * time.Second
+)
+
+var errClosed = errors.New("remote signer client is closed")
+
+// ClientOpts configures a Client.
+type ClientOpts struct {
+	// HTTPClient is used to talk to the signing service. It should be
+	// configured with mutual TLS. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Timeout bounds each Sign call, including the time spent waiting for a
+	// batch to be sent. Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxBatchSize is the maximum number of digests sent in a single request.
+	// It must not exceed the service's own limit. Defaults to
+	// DefaultMaxBatchSize.
+	MaxBatchSize int
+	// BatchWindow is how long to wait for more digests before sending a
+	// batch. When zero, a batch is sent as soon as there is no more digest
+	// waiting to be signed.
+	BatchWindow time.Duration
+}
+
+// Client is a crypto.Signer which signs SHA-256 digests with a remote signing
+// service. Concurrent Sign calls are batched together.
+type Client struct {
+	url          string
+	hc           *http.Client
+	timeout      time.Duration
+	maxBatchSize int
+	batchWindow  time.Duration
+	pub          crypto.PublicKey
+
+	pending   chan *pendingSign
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type pendingSign struct {
+	digest []byte
+	res    chan signResult
+}
+
+type signResult struct {
+	sig []byte
+	err error
+}
+
+// NewClient returns a Client for the signing service at url, and fetches its
+// public key.
+//
+// The returned Client must be closed with Close once it is no longer used.
+func NewClient(ctx context.Context, url string, opts ClientOpts) (*Client, error) {
+	c := &Client{
+		url:          strings.TrimSuffix(url, "/"),
+		hc:           opts.HTTPClient,
+		timeout:      opts.Timeout,
+		maxBatchSize: opts.MaxBatchSize,
+		batchWindow:  opts.BatchWindow,
+		pending:      make(chan *pendingSign),
+		done:         make(chan struct{}),
+	}
+	if c.hc == nil {
+		c.hc = http.DefaultClient
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	if c.maxBatchSize <= 0 {
+		c.maxBatchSize = DefaultMaxBatchSize
+	}
+
+	pub, err := c.fetchPublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch pub.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	c.pub = pub
+
+	go c.batch()
+	return c, nil
+}
+
+// NewClientFromFlags returns a Client for the signing service at url, which
+// authenticates with the TLS client certificate in certFile and keyFile, and
+// trusts the service if it's signed by a CA in caFile.
+//
+// It is shared by the server binaries, which take these as flags.
+func NewClientFromFlags(ctx context.Context, url, certFile, keyFile, caFile string, timeout, batchWindow time.Duration) (*Client, error) {
+	tlsCfg, err := ClientTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up mTLS: %v", err)
+	}
+	return NewClient(ctx, url, ClientOpts{
+		HTTPClient:  &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}},
+		Timeout:     timeout,
+		BatchWindow: batchWindow,
+	})
+}
+
+func (c *Client) fetchPublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+PublicKeyPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create public key request: %v", err)
+	}
+	body, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public key: %v", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	return pub, nil
+}
+
+// Public returns the public key of the signing service.
+func (c *Client) Public() crypto.PublicKey {
+	return c.pub
+}
+
+// Sign signs a SHA-256 digest with the signing service.
+//
+// Signatures are checked against the service's public key before being
+// returned. The rand argument is ignored.
+func (c *Client) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	// A nil opts carries no hash function, like crypto.Hash(0).
+	var hash crypto.Hash
+	if opts != nil {
+		hash = opts.HashFunc()
+	}
+	if hash != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash function %v", hash)
+	}
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, errors.New("RSA-PSS signatures are not supported")
+	}
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("digest bytes length %d does not match SHA-256 length %d", len(digest), sha256.Size)
+	}
+
+	select {
+	case <-c.done:
+		return nil, errClosed
+	default:
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	p := &pendingSign{digest: digest, res: make(chan signResult, 1)}
+	select {
+	case c.pending <- p:
+	case <-timer.C:
+		return nil, errors.New("timed out waiting to send digest to the remote signer")
+	case <-c.done:
+		return nil, errClosed
+	}
+	select {
+	case r := <-p.res:
+		return r.sig, r.err
+	case <-timer.C:
+		return nil, errors.New("timed out waiting for a signature from the remote signer")
+	}
+}
+
+// Close stops the Client. Pending Sign calls still complete, but new ones fail.
+// It always returns nil.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// batch groups pending digests together, and sends them to the signing
+// service, until the Client is closed.
+func (c *Client) batch() {
+	for {
+		var b []*pendingSign
+		select {
+		case p := <-c.pending:
+			b = append(b, p)
+		case <-c.done:
+			return
+		}
+
+		var window <-chan time.Time
+		if c.batchWindow > 0 {
+			t := time.NewTimer(c.batchWindow)
+			window = t.C
+			b = c.collect(b, window)
+			t.Stop()
+		} else {
+			b = c.collect(b, nil)
+		}
+		go c.send(b)
+	}
+}
+
+// collect adds pending digests to b until it is full, or until window fires.
+// If window is nil, it only adds digests which are immediately available.
+func (c *Client) collect(b []*pendingSign, window <-chan time.Time) []*pendingSign {
+	for len(b) < c.maxBatchSize {
+		if window == nil {
+			select {
+			case p := <-c.pending:
+				b = append(b, p)
+			default:
+				return b
+			}
+			continue
+		}
+		select {
+		case p := <-c.pending:
+			b = append(b, p)
+		case <-window:
+			return b
+		case <-c.done:
+			return b
+		}
+	}
+	return b
+}
+
+// send signs a batch of digests, and delivers the results.
+func (c *Client) send(b []*pendingSign) {
+	sigs, err := c.signBatch(b)
+	for i, p := range b {
+		if err != nil {
+			p.res <- signResult{err: err}
+			continue
+		}
+		if err := c.verify(p.digest, sigs[i]); err != nil {
+			p.res <- signResult{err: err}
+			continue
+		}
+		p.res <- signResult{sig: sigs[i]}
+	}
+}
+
+func (c *Client) signBatch(b []*pendingSign) ([][]byte, error) {
+	sr := signRequest{Digests: make([][]byte, 0, len(b))}
+	for _, p := range b {
+		sr.Digests = append(sr.Digests, p.digest)
+	}
+	reqBody, err := json.Marshal(sr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sign request: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+SignPath, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the remote signer: %v", err)
+	}
+	var resp signResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse sign response: %v", err)
+	}
+	if got, want := len(resp.Signatures), len(b); got != want {
+		return nil, fmt.Errorf("remote signer returned %d signatures, want %d", got, want)
+	}
+	return resp.Signatures, nil
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got HTTP status %q: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// verify checks that sig is a valid signature of digest, so that a faulty
+// signing service doesn't make the log issue invalid SCTs or checkpoints.
+func (c *Client) verify(digest, sig []byte) error {
+	switch pub := c.pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, sig) {
+			return errors.New("remote signer returned an invalid ECDSA signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig); err != nil {
+			return fmt.Errorf("remote signer returned an invalid RSA signature: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestServer starts an in-process signing service for signer, wrapped
+// with wrap if not nil, and returns its URL.
+func newTestServer(t *testing.T, signer crypto.Signer, wrap func(http.Handler) http.Handler) string {
+	t.Helper()
+	h, err := NewHandler(signer, 0, nil)
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	if wrap != nil {
+		h = wrap(h)
+	}
+	s := httptest.NewServer(h)
+	t.Cleanup(s.Close)
+	return s.URL
+}
+
+func newTestClient(t *testing.T, url string, opts ClientOpts) *Client {
+	t.Helper()
+	c, err := NewClient(t.Context(), url, opts)
+	if err != nil {
+		t.Fatalf("NewClient(): %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClientSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc string
+		key  crypto.Signer
+	}{
+		{desc: "ecdsa", key: mustECDSAKey(t)},
+		{desc: "rsa", key: rsaKey},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newTestClient(t, newTestServer(t, tc.key, nil), ClientOpts{})
+			if !tc.key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(c.Public()) {
+				t.Fatal("Public() returned a different key")
+			}
+			digest := sha256.Sum256([]byte("digest"))
+			sig, err := c.Sign(rand.Reader, digest[:], crypto.SHA256)
+			if err != nil {
+				t.Fatalf("Sign(): %v", err)
+			}
+			switch k := tc.key.(type) {
+			case *ecdsa.PrivateKey:
+				if !ecdsa.VerifyASN1(&k.PublicKey, digest[:], sig) {
+					t.Error("invalid ECDSA signature")
+				}
+			case *rsa.PrivateKey:
+				// RSA PKCS #1 v1.5 signatures are deterministic, so they
+				// must match the local ones exactly.
+				want, err := k.Sign(rand.Reader, digest[:], crypto.SHA256)
+				if err != nil {
+					t.Fatalf("Sign(): %v", err)
+				}
+				if !bytes.Equal(sig, want) {
+					t.Error("remote signature differs from the local one")
+				}
+			}
+
+			if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA384); err == nil {
+				t.Error("Sign() with SHA-384: got nil error, want error")
+			}
+			if _, err := c.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256}); err == nil {
+				t.Error("Sign() with PSS: got nil error, want error")
+			}
+			if _, err := c.Sign(rand.Reader, digest[:16], crypto.SHA256); err == nil {
+				t.Error("Sign() with a short digest: got nil error, want error")
+			}
+		})
+	}
+}
+
+func TestClientBatching(t *testing.T) {
+	var requests atomic.Int64
+	count := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == SignPath {
+				requests.Add(1)
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+	key := mustECDSAKey(t)
+	c := newTestClient(t, newTestServer(t, key, count), ClientOpts{
+		MaxBatchSize: 8,
+		BatchWindow:  100 * time.Millisecond,
+	})
+
+	const numSigs = 16
+	var wg sync.WaitGroup
+	for i := range numSigs {
+		wg.Go(func() {
+			digest := sha256.Sum256([]byte{byte(i)})
+			sig, err := c.Sign(rand.Reader, digest[:], crypto.SHA256)
+			if err != nil {
+				t.Errorf("Sign(): %v", err)
+				return
+			}
+			if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+				t.Errorf("invalid signature for digest %d", i)
+			}
+		})
+	}
+	wg.Wait()
+	if got := requests.Load(); got >= numSigs {
+		t.Errorf("got %d sign requests for %d signatures, want batching", got, numSigs)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	key := mustECDSAKey(t)
+	digest := sha256.Sum256([]byte("digest"))
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		stall := func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == SignPath {
+					select {
+					case <-release:
+					case <-r.Context().Done():
+					}
+				}
+				h.ServeHTTP(w, r)
+			})
+		}
+		c := newTestClient(t, newTestServer(t, key, stall), ClientOpts{Timeout: 100 * time.Millisecond})
+		if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+			t.Error("Sign() with a stalled server: got nil error, want error")
+		}
+	})
+
+	t.Run("invalid-signature", func(t *testing.T) {
+		otherKey := mustECDSAKey(t)
+		// Serve the public key of key, but sign with otherKey.
+		pubURL := newTestServer(t, key, nil)
+		lie := func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == PublicKeyPath {
+					http.Redirect(w, r, pubURL+PublicKeyPath, http.StatusFound)
+					return
+				}
+				h.ServeHTTP(w, r)
+			})
+		}
+		c := newTestClient(t, newTestServer(t, otherKey, lie), ClientOpts{})
+		if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+			t.Error("Sign() with a bad signature: got nil error, want error")
+		}
+	})
+
+	t.Run("server-error", func(t *testing.T) {
+		c := newTestClient(t, newTestServer(t, failingSigner{key}, nil), ClientOpts{})
+		if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+			t.Error("Sign() with a failing server: got nil error, want error")
+		}
+	})
+
+	t.Run("nil-opts", func(t *testing.T) {
+		c := newTestClient(t, newTestServer(t, key, nil), ClientOpts{})
+		if _, err := c.Sign(rand.Reader, digest[:], nil); err == nil {
+			t.Error("Sign() with nil opts: got nil error, want error")
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		c := newTestClient(t, newTestServer(t, key, nil), ClientOpts{})
+		_ = c.Close()
+		if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+			t.Error("Sign() after Close(): got nil error, want error")
+		}
+	})
+
+	t.Run("no-server", func(t *testing.T) {
+		s := httptest.NewServer(http.NotFoundHandler())
+		s.Close()
+		if _, err := NewClient(t.Context(), s.URL, ClientOpts{}); err == nil {
+			t.Error("NewClient() without a server: got nil error, want error")
+		}
+	})
+}
+
+func TestMTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCA(t, dir, "ca")
+	otherCA, otherCAKey := writeTestCA(t, dir, "other-ca")
+	writeTestLeaf(t, dir, "server", ca, caKey, true)
+	writeTestLeaf(t, dir, "client", ca, caKey, false)
+	writeTestLeaf(t, dir, "rogue", otherCA, otherCAKey, false)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	serverTLS, err := ServerTLSConfig(path("server.crt"), path("server.key"), path("ca.crt"))
+	if err != nil {
+		t.Fatalf("ServerTLSConfig(): %v", err)
+	}
+	key := mustECDSAKey(t)
+	h, err := NewHandler(key, 0, nil)
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	s := httptest.NewUnstartedServer(h)
+	s.TLS = serverTLS
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	clientFor := func(name string) *http.Client {
+		t.Helper()
+		tlsCfg, err := ClientTLSConfig(path(name+".crt"), path(name+".key"), path("ca.crt"))
+		if err != nil {
+			t.Fatalf("ClientTLSConfig(): %v", err)
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+	}
+
+	c := newTestClient(t, s.URL, ClientOpts{HTTPClient: clientFor("client")})
+	digest := sha256.Sum256([]byte("digest"))
+	sig, err := c.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Error("invalid signature")
+	}
+
+	if _, err := NewClient(t.Context(), s.URL, ClientOpts{HTTPClient: clientFor("rogue")}); err == nil {
+		t.Error("NewClient() with an untrusted client certificate: got nil error, want error")
+	}
+	if _, err := NewClient(t.Context(), s.URL, ClientOpts{HTTPClient: s.Client()}); err == nil {
+		t.Error("NewClient() without a client certificate: got nil error, want error")
+	}
+
+	fc, err := NewClientFromFlags(t.Context(), s.URL, path("client.crt"), path("client.key"), path("ca.crt"), 0, 0)
+	if err != nil {
+		t.Fatalf("NewClientFromFlags(): %v", err)
+	}
+	defer func() { _ = fc.Close() }()
+	if _, err := fc.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Errorf("Sign() with a client from flags: %v", err)
+	}
+	if _, err := NewClientFromFlags(t.Context(), s.URL, path("missing.crt"), path("client.key"), path("ca.crt"), 0, 0); err == nil {
+		t.Error("NewClientFromFlags() with a missing certificate: got nil error, want error")
+	}
+}
+
+func TestMTLSAllowedClients(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCA(t, dir, "ca")
+	writeTestLeaf(t, dir, "server", ca, caKey, true)
+	writeTestLeaf(t, dir, "client", ca, caKey, false)
+	writeTestLeaf(t, dir, "other", ca, caKey, false)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	certPEM, err := os.ReadFile(path("client.crt"))
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	if _, err := NewHandler(mustECDSAKey(t), 0, []string{"not-a-hash"}); err == nil {
+		t.Error("NewHandler() with an invalid allowed client: got nil error, want error")
+	}
+
+	serverTLS, err := ServerTLSConfig(path("server.crt"), path("server.key"), path("ca.crt"))
+	if err != nil {
+		t.Fatalf("ServerTLSConfig(): %v", err)
+	}
+	h, err := NewHandler(mustECDSAKey(t), 0, []string{hex.EncodeToString(spkiHash[:])})
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	s := httptest.NewUnstartedServer(h)
+	s.TLS = serverTLS
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	digest := sha256.Sum256([]byte("digest"))
+	for _, tc := range []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "client"},
+		{name: "other", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClientFromFlags(t.Context(), s.URL, path(tc.name+".crt"), path(tc.name+".key"), path("ca.crt"), 0, 0)
+			if err != nil {
+				t.Fatalf("NewClientFromFlags(): %v", err)
+			}
+			defer func() { _ = c.Close() }()
+			if _, err := c.Sign(rand.Reader, digest[:], crypto.SHA256); (err != nil) != tc.wantErr {
+				t.Errorf("Sign()=%v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCA(t, dir, "ca")
+	writeTestLeaf(t, dir, "leaf", ca, caKey, true)
+	path := func(name string) string { return filepath.Join(dir, name) }
+	if err := os.WriteFile(path("empty.crt"), nil, 0o600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+
+	if _, err := ServerTLSConfig(path("missing.crt"), path("leaf.key"), path("ca.crt")); err == nil {
+		t.Error("ServerTLSConfig() with a missing certificate: got nil error, want error")
+	}
+	if _, err := ServerTLSConfig(path("leaf.crt"), path("leaf.key"), path("empty.crt")); err == nil {
+		t.Error("ServerTLSConfig() with an empty CA file: got nil error, want error")
+	}
+	if _, err := ClientTLSConfig(path("leaf.crt"), path("leaf.key"), path("missing.crt")); err == nil {
+		t.Error("ClientTLSConfig() with a missing CA file: got nil error, want error")
+	}
+}
+
+func writeTestCA(t *testing.T, dir, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key := mustECDSAKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	return cert, key
+}
+
+func writeTestLeaf(t *testing.T, dir, name string, ca *x509.Certificate, caKey crypto.Signer, server bool) {
+	t.Helper()
+	key := mustECDSAKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey(): %v", err)
+	}
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotesigner isolates the log private key from internet-facing
+// TesseraCT servers, by holding it in a separate signing service.
+//
+// The service exposes two HTTP endpoints:
+//   - GET /public-key returns the DER encoded PKIX public key of the log.
+//   - POST /sign takes a JSON signRequest holding a batch of SHA-256 digests,
+//     and returns a JSON signResponse holding their signatures, in order.
+//
+// Signatures are the exact output of the service's crypto.Signer, so that the
+// log can use a [Client] wherever it would use the key directly. Both sides
+// should be configured with mutual TLS, see [ServerTLSConfig] and
+// [ClientTLSConfig].
+package remotesigner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// PublicKeyPath is the path of the public key endpoint.
+	PublicKeyPath = "/public-key"
+	// SignPath is the path of the signing endpoint.
+	SignPath = "/sign"
+
+	// DefaultMaxBatchSize is the default maximum number of digests signed in a
+	// single request.
+	DefaultMaxBatchSize = 64
+)
+
+// signRequest is the body of a POST request to SignPath.
+type signRequest struct {
+	// Digests are SHA-256 digests to sign.
+	Digests [][]byte `json:"digests"`
+}
+
+// signResponse is the body of a successful response to a signRequest.
+type signResponse struct {
+	// Signatures holds one signature per requested digest, in the same order.
+	Signatures [][]byte `json:"signatures"`
+}
+
+// ServerTLSConfig returns a TLS configuration for the signing service which
+// requires clients to present a certificate signed by a CA in clientCAFile.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %v", err)
+	}
+	pool, err := certPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// ClientTLSConfig returns a TLS configuration for a Client which presents the
+// certificate in certFile, and trusts servers signed by a CA in caFile.
+func ClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %v", err)
+	}
+	pool, err := certPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+func certPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file %s: %v", caFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no CA certificate found in " + caFile)
+	}
+	return pool, nil
+}
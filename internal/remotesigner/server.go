@@ -0,0 +1,137 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// NewHandler returns an http.Handler serving the signing service endpoints
+// with signer.
+//
+// The service signs any digest it is sent, without knowing what it signs: any
+// client allowed to reach it can get arbitrary SCTs and checkpoints signed by
+// the log key. It must be served with mutual TLS, see [ServerTLSConfig], with
+// a client CA dedicated to the signing service. allowedClients further
+// restricts signing requests to clients whose certificate public key is
+// listed, as hex encoded SHA-256 hashes of their DER encoded
+// SubjectPublicKeyInfo. If allowedClients is empty, any client is allowed.
+//
+// Requests with more than maxBatchSize digests are rejected. If maxBatchSize
+// is not strictly positive, DefaultMaxBatchSize is used.
+func NewHandler(signer crypto.Signer, maxBatchSize int, allowedClients []string) (http.Handler, error) {
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+	allowed := make(map[[sha256.Size]byte]bool, len(allowedClients))
+	for _, c := range allowedClients {
+		h, err := hex.DecodeString(c)
+		if err != nil || len(h) != sha256.Size {
+			return nil, fmt.Errorf("allowed client %q is not a hex encoded SHA-256 hash", c)
+		}
+		allowed[[sha256.Size]byte(h)] = true
+	}
+	s := &server{signer: signer, publicKeyDER: der, maxBatchSize: maxBatchSize, allowedClients: allowed}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PublicKeyPath, s.publicKey)
+	mux.HandleFunc("POST "+SignPath, s.sign)
+	return mux, nil
+}
+
+type server struct {
+	signer       crypto.Signer
+	publicKeyDER []byte
+	maxBatchSize int
+	// allowedClients holds the SHA-256 hashes of the SubjectPublicKeyInfo of
+	// the clients allowed to request signatures, any client if empty.
+	allowedClients map[[sha256.Size]byte]bool
+}
+
+// allowed returns true if the client which sent r may request signatures.
+func (s *server) allowed(r *http.Request) bool {
+	if len(s.allowedClients) == 0 {
+		return true
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	return s.allowedClients[sha256.Sum256(r.TLS.PeerCertificates[0].RawSubjectPublicKeyInfo)]
+}
+
+func (s *server) publicKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := w.Write(s.publicKeyDER); err != nil {
+		slog.WarnContext(r.Context(), "Failed to write public key response", slog.Any("error", err))
+	}
+}
+
+func (s *server) sign(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(r) {
+		slog.WarnContext(r.Context(), "Rejected signing request from a client which is not allowed", slog.String("remoteAddr", r.RemoteAddr))
+		http.Error(w, "client is not allowed to request signatures", http.StatusForbidden)
+		return
+	}
+	// A full batch of base64 encoded digests fits comfortably in this.
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(s.maxBatchSize)*2*sha256.Size+1024))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var req signRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Digests) == 0 || len(req.Digests) > s.maxBatchSize {
+		http.Error(w, fmt.Sprintf("got %d digests, want between 1 and %d", len(req.Digests), s.maxBatchSize), http.StatusBadRequest)
+		return
+	}
+	resp := signResponse{Signatures: make([][]byte, 0, len(req.Digests))}
+	for i, d := range req.Digests {
+		if len(d) != sha256.Size {
+			http.Error(w, fmt.Sprintf("digest %d has length %d, want %d", i, len(d), sha256.Size), http.StatusBadRequest)
+			return
+		}
+		sig, err := s.signer.Sign(rand.Reader, d, crypto.SHA256)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to sign digest", slog.Any("error", err))
+			http.Error(w, "failed to sign digest", http.StatusInternalServerError)
+			return
+		}
+		resp.Signatures = append(resp.Signatures, sig)
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(out); err != nil {
+		slog.WarnContext(r.Context(), "Failed to write sign response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	return k
+}
+
+func TestHandlerPublicKey(t *testing.T) {
+	key := mustECDSAKey(t)
+	h, err := NewHandler(key, 0, nil)
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PublicKeyPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: got status %d, want %d", PublicKeyPath, w.Code, http.StatusOK)
+	}
+	pub, err := x509.ParsePKIXPublicKey(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePKIXPublicKey(): %v", err)
+	}
+	if !key.PublicKey.Equal(pub) {
+		t.Error("GET public key returned a different key")
+	}
+}
+
+func TestHandlerSign(t *testing.T) {
+	key := mustECDSAKey(t)
+	h, err := NewHandler(key, 2, nil)
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	d1 := sha256.Sum256([]byte("one"))
+	d2 := sha256.Sum256([]byte("two"))
+
+	for _, tc := range []struct {
+		desc       string
+		method     string
+		body       string
+		wantStatus int
+		wantSigs   int
+	}{
+		{
+			desc:       "ok",
+			method:     http.MethodPost,
+			body:       mustMarshal(t, signRequest{Digests: [][]byte{d1[:], d2[:]}}),
+			wantStatus: http.StatusOK,
+			wantSigs:   2,
+		},
+		{
+			desc:       "wrong-method",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:       "malformed",
+			method:     http.MethodPost,
+			body:       "not json",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc:       "empty",
+			method:     http.MethodPost,
+			body:       mustMarshal(t, signRequest{}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc:       "batch-too-large",
+			method:     http.MethodPost,
+			body:       mustMarshal(t, signRequest{Digests: [][]byte{d1[:], d2[:], d1[:]}}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc:       "short-digest",
+			method:     http.MethodPost,
+			body:       mustMarshal(t, signRequest{Digests: [][]byte{d1[:31]}}),
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, SignPath, bytes.NewReader([]byte(tc.body))))
+			if w.Code != tc.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tc.wantStatus, w.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var resp signResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal(): %v", err)
+			}
+			if len(resp.Signatures) != tc.wantSigs {
+				t.Fatalf("got %d signatures, want %d", len(resp.Signatures), tc.wantSigs)
+			}
+			for i, d := range [][]byte{d1[:], d2[:]} {
+				if !ecdsa.VerifyASN1(&key.PublicKey, d, resp.Signatures[i]) {
+					t.Errorf("signature %d doesn't verify", i)
+				}
+			}
+		})
+	}
+}
+
+type failingSigner struct {
+	crypto.Signer
+}
+
+func (failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, io.ErrUnexpectedEOF
+}
+
+func TestHandlerSignerError(t *testing.T) {
+	h, err := NewHandler(failingSigner{mustECDSAKey(t)}, 0, nil)
+	if err != nil {
+		t.Fatalf("NewHandler(): %v", err)
+	}
+	d := sha256.Sum256([]byte("one"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, SignPath, bytes.NewReader([]byte(mustMarshal(t, signRequest{Digests: [][]byte{d[:]}})))))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	return string(b)
+}
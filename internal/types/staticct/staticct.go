@@ -400,3 +400,66 @@ func (t *Entry) UnmarshalText(raw []byte) error {
 
 	return nil
 }
+
+// MerkleTreeLeaf returns the RFC 6962 MerkleTreeLeaf committed to by the entry.
+func (t *Entry) MerkleTreeLeaf() rfc6962.MerkleTreeLeaf {
+	te := &rfc6962.TimestampedEntry{
+		Timestamp:  t.Timestamp,
+		Extensions: rfc6962.CTExtensions(t.RawExtensions),
+	}
+	if t.IsPrecert {
+		te.EntryType = rfc6962.PrecertLogEntryType
+		te.PrecertEntry = &rfc6962.PreCert{
+			TBSCertificate: t.Certificate,
+		}
+		copy(te.PrecertEntry.IssuerKeyHash[:], t.IssuerKeyHash)
+	} else {
+		te.EntryType = rfc6962.X509LogEntryType
+		te.X509Entry = &rfc6962.ASN1Cert{Data: t.Certificate}
+	}
+	return rfc6962.MerkleTreeLeaf{
+		Version:          rfc6962.V1,
+		LeafType:         rfc6962.TimestampedEntryLeafType,
+		TimestampedEntry: te,
+	}
+}
+
+// RawLogEntry translates the entry into an RFC 6962 RawLogEntry.
+//
+// chain must hold the issuing certificates referenced by FingerprintsChain,
+// in the same order.
+func (t *Entry) RawLogEntry(chain [][]byte) (*rfc6962.RawLogEntry, error) {
+	if len(chain) != len(t.FingerprintsChain) {
+		return nil, fmt.Errorf("got %d chain certificates, want %d", len(chain), len(t.FingerprintsChain))
+	}
+	rle := &rfc6962.RawLogEntry{
+		Index: int64(t.LeafIndex),
+		Leaf:  t.MerkleTreeLeaf(),
+		Chain: make([]rfc6962.ASN1Cert, 0, len(chain)),
+	}
+	if t.IsPrecert {
+		rle.Cert = rfc6962.ASN1Cert{Data: t.Precertificate}
+	} else {
+		rle.Cert = rfc6962.ASN1Cert{Data: t.Certificate}
+	}
+	for _, c := range chain {
+		rle.Chain = append(rle.Chain, rfc6962.ASN1Cert{Data: c})
+	}
+	return rle, nil
+}
+
+// ExtraData returns the TLS-encoded RFC 6962 extra_data of a RawLogEntry, as
+// served by get-entries.
+func ExtraData(rle *rfc6962.RawLogEntry) ([]byte, error) {
+	switch rle.Leaf.TimestampedEntry.EntryType {
+	case rfc6962.X509LogEntryType:
+		return tls.Marshal(rfc6962.CertificateChain{Entries: rle.Chain})
+	case rfc6962.PrecertLogEntryType:
+		return tls.Marshal(rfc6962.PrecertChainEntry{
+			PreCertificate:   rle.Cert,
+			CertificateChain: rle.Chain,
+		})
+	default:
+		return nil, fmt.Errorf("unknown entry type %v", rle.Leaf.TimestampedEntry.EntryType)
+	}
+}
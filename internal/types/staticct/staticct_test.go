@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
+	"github.com/transparency-dev/tesseract/internal/types/tls"
 	"github.com/transparency-dev/tesseract/testdata"
 )
 
@@ -61,3 +62,82 @@ func TestExtractSCTInputFromBundle(t *testing.T) {
 		}
 	}
 }
+
+func TestRawLogEntry(t *testing.T) {
+	eb := EntryBundle{}
+	if err := eb.UnmarshalText(testdata.ExampleFullTile); err != nil {
+		t.Fatalf("failed to unmarshal full tile: %v", err)
+	}
+	for i := uint64(0); i < uint64(len(eb.Entries)); i++ {
+		e := Entry{}
+		if err := e.UnmarshalText(eb.Entries[i]); err != nil {
+			t.Fatalf("UnmarshalText(%d): %v", i, err)
+		}
+		chain := make([][]byte, len(e.FingerprintsChain))
+		for j, fp := range e.FingerprintsChain {
+			chain[j] = fp[:]
+		}
+		rle, err := e.RawLogEntry(chain)
+		if err != nil {
+			t.Fatalf("RawLogEntry(%d): %v", i, err)
+		}
+		if got, want := uint64(rle.Index), e.LeafIndex; got != want {
+			t.Errorf("%d: rle.Index=%d, want %d", i, got, want)
+		}
+
+		// The leaf must match the SCT input extracted from the bundle.
+		leaf, err := tls.Marshal(rle.Leaf)
+		if err != nil {
+			t.Fatalf("%d: tls.Marshal(): %v", i, err)
+		}
+		got, err := ExtractCertificateTimestampFromLeaf(leaf)
+		if err != nil {
+			t.Fatalf("%d: ExtractCertificateTimestampFromLeaf(): %v", i, err)
+		}
+		want, err := ExtractSCTInputFromBundle(testdata.ExampleFullTile, i)
+		if err != nil {
+			t.Fatalf("%d: ExtractSCTInputFromBundle(): %v", i, err)
+		}
+		gotB, err := tls.Marshal(got)
+		if err != nil {
+			t.Fatalf("%d: tls.Marshal(): %v", i, err)
+		}
+		wantB, err := tls.Marshal(want)
+		if err != nil {
+			t.Fatalf("%d: tls.Marshal(): %v", i, err)
+		}
+		if !bytes.Equal(gotB, wantB) {
+			t.Errorf("%d: leaf does not match the SCT input from the bundle", i)
+		}
+
+		extraData, err := ExtraData(rle)
+		if err != nil {
+			t.Fatalf("%d: ExtraData(): %v", i, err)
+		}
+		if e.IsPrecert {
+			var pce rfc6962.PrecertChainEntry
+			if _, err := tls.Unmarshal(extraData, &pce); err != nil {
+				t.Fatalf("%d: tls.Unmarshal(): %v", i, err)
+			}
+			if !bytes.Equal(pce.PreCertificate.Data, e.Precertificate) {
+				t.Errorf("%d: extra_data precertificate mismatch", i)
+			}
+			if got, want := len(pce.CertificateChain), len(chain); got != want {
+				t.Errorf("%d: extra_data chain has %d entries, want %d", i, got, want)
+			}
+		} else {
+			var cc rfc6962.CertificateChain
+			if _, err := tls.Unmarshal(extraData, &cc); err != nil {
+				t.Fatalf("%d: tls.Unmarshal(): %v", i, err)
+			}
+			if got, want := len(cc.Entries), len(chain); got != want {
+				t.Errorf("%d: extra_data chain has %d entries, want %d", i, got, want)
+			}
+		}
+	}
+
+	e := Entry{FingerprintsChain: [][32]byte{{}}}
+	if _, err := e.RawLogEntry(nil); err == nil {
+		t.Error("RawLogEntry() with a missing chain certificate: got nil error, want error")
+	}
+}
@@ -138,7 +138,7 @@ func BuildPrecertTBS(tbsData []byte, preIssuer *x509.Certificate) ([]byte, error
 		}
 
 		// TODO(phbnf): is this check really necessary?
-		if !isPreIssuer(preIssuer) {
+		if !IsPreIssuer(preIssuer) {
 			return nil, fmt.Errorf("issuer does not have CertificateTransparency extended key usage")
 		}
 
@@ -248,7 +248,7 @@ func EntryFromChain(chain []*x509.Certificate, isPrecert bool, timestamp uint64)
 	cert := chain[0]
 
 	var preIssuer *x509.Certificate
-	if isPreIssuer(issuer) {
+	if IsPreIssuer(issuer) {
 		// Replace the cert's issuance information with details from the pre-issuer.
 		preIssuer = issuer
 
@@ -279,14 +279,18 @@ func EntryFromChain(chain []*x509.Certificate, isPrecert bool, timestamp uint64)
 	return leaf, nil
 }
 
-// isPreIssuer indicates if a certificate is a precertificate signing cert.
+// IsPreIssuer indicates if a certificate is a precertificate signing cert.
 //
 // From RFC6962 s3.1, these certs should contain:
 // (CA:true, Extended Key Usage: Certificate Transparency, OID 1.3.6.1.4.1.11129.2.4.4)
-func isPreIssuer(cert *x509.Certificate) bool {
-	if !cert.IsCA {
-		return false
-	}
+func IsPreIssuer(cert *x509.Certificate) bool {
+	return cert.IsCA && HasPrecertSigningEKU(cert)
+}
+
+// HasPrecertSigningEKU indicates if a certificate has the Certificate
+// Transparency Extended Key Usage, OID 1.3.6.1.4.1.11129.2.4.4, whether it
+// is a CA or not.
+func HasPrecertSigningEKU(cert *x509.Certificate) bool {
 	// Look for the extension in the Extensions field and not in ExtKeyUsage
 	// since crypto/x509 does not recognize this extension as such.
 	// We cannot reliably check in UnknownExtKeyUsage either, since it might
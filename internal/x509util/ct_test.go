@@ -537,8 +537,8 @@ func TestIsPreIssuer(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if got := isPreIssuer(test.cert); got != test.want {
-				t.Errorf("isPreIssuer() = %v, want %v", got, test.want)
+			if got := IsPreIssuer(test.cert); got != test.want {
+				t.Errorf("IsPreIssuer() = %v, want %v", got, test.want)
 			}
 		})
 	}
@@ -0,0 +1,120 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509util_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/internal/x509util"
+)
+
+func TestPrivateKeyFromPEMBlock(t *testing.T) {
+	readBlock := func(t *testing.T, path string) *pem.Block {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %q: %v", path, err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			t.Fatalf("Failed to decode PEM from %q", path)
+		}
+		// Fix block type for testing keys.
+		block.Type = strings.ReplaceAll(block.Type, "TESTING KEY", "PRIVATE KEY")
+		return block
+	}
+	pkcs8Block := func(t *testing.T, key any) *pem.Block {
+		t.Helper()
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("MarshalPKCS8PrivateKey(): %v", err)
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc    string
+		block   *pem.Block
+		wantRSA bool
+		wantErr string
+	}{
+		{
+			desc:  "ecdsa",
+			block: readBlock(t, "../testdata/test_ct_server_ecdsa_private_key.pem"),
+		},
+		{
+			desc:    "rsa",
+			block:   readBlock(t, "../testdata/test_ct_server_rsa_private_key.pem"),
+			wantRSA: true,
+		},
+		{
+			desc:  "pkcs8-ecdsa",
+			block: pkcs8Block(t, ecKey),
+		},
+		{
+			desc:    "pkcs8-ed25519",
+			block:   pkcs8Block(t, edKey),
+			wantErr: "unsupported private key type",
+		},
+		{
+			desc:    "unknown-type",
+			block:   &pem.Block{Type: "CERTIFICATE"},
+			wantErr: "unsupported private key PEM type",
+		},
+		{
+			desc:    "nil",
+			wantErr: "nil",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			signer, err := x509util.PrivateKeyFromPEMBlock(tc.block)
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("PrivateKeyFromPEMBlock()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrivateKeyFromPEMBlock()=%v", err)
+			}
+			if _, isRSA := signer.(*rsa.PrivateKey); isRSA != tc.wantRSA {
+				t.Errorf("PrivateKeyFromPEMBlock() returned a %T", signer)
+			}
+			if err := x509util.MatchesPublicKey(signer, signer.Public()); err != nil {
+				t.Errorf("MatchesPublicKey() with its own public key: %v", err)
+			}
+			if err := x509util.MatchesPublicKey(signer, edKey.Public()); err == nil {
+				t.Error("MatchesPublicKey() with another public key: got nil error, want error")
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"sync"
 
 	"github.com/transparency-dev/tesseract/internal/lax509"
@@ -42,6 +43,10 @@ type PEMCertPool struct {
 	rawCerts             []*x509.Certificate
 	certPool             *lax509.CertPool
 	rejectedFingerprints map[[sha256.Size]byte]struct{}
+	// maps from sha-256 to the constraint chains rooted at this cert must meet
+	constraints map[[sha256.Size]byte]func([]*x509.Certificate) error
+	// generation is incremented every time certPool changes
+	generation uint64
 }
 
 // NewPEMCertPool creates a new, empty, instance of PEMCertPool.
@@ -67,6 +72,7 @@ func NewPEMCertPool(rejectedFingerprints []string) (*PEMCertPool, error) {
 		fingerprintToCertMap: make(map[[sha256.Size]byte]x509.Certificate),
 		certPool:             lax509.NewCertPool(),
 		rejectedFingerprints: rejected,
+		constraints:          make(map[[sha256.Size]byte]func([]*x509.Certificate) error),
 	}, nil
 }
 
@@ -76,6 +82,12 @@ func NewPEMCertPool(rejectedFingerprints []string) (*PEMCertPool, error) {
 // If any new certificates is detected, the underlying certPool is cloned,
 // new certs are added, and then pools are swapped.
 func (p *PEMCertPool) AddCerts(certs []*x509.Certificate) int {
+	return len(p.AddCertsReturningAdded(certs))
+}
+
+// AddCertsReturningAdded adds certificates to a pool like AddCerts, and
+// returns the certificates that were added.
+func (p *PEMCertPool) AddCertsReturningAdded(certs []*x509.Certificate) []*x509.Certificate {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -93,17 +105,47 @@ func (p *PEMCertPool) AddCerts(certs []*x509.Certificate) int {
 		}
 	}
 
-	oldN := len(p.rawCerts)
+	var added []*x509.Certificate
 	if len(newCerts) > 0 {
 		newPool := p.certPool.Clone()
 		for fingerprint, cert := range newCerts {
 			p.fingerprintToCertMap[fingerprint] = *cert
 			p.rawCerts = append(p.rawCerts, cert)
-			newPool.AddCert(cert)
+			p.addToCertPool(newPool, fingerprint, cert)
+			added = append(added, cert)
 		}
 		p.certPool = newPool
+		p.generation++
 	}
-	return len(p.rawCerts) - oldN
+	return added
+}
+
+// RemoveCert removes the certificate with the given SHA-256 fingerprint from
+// the pool, and returns it. It returns an error if there is no such
+// certificate, or if it is the last certificate of the pool.
+func (p *PEMCertPool) RemoveCert(fingerprint [sha256.Size]byte) (*x509.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.fingerprintToCertMap[fingerprint]; !ok {
+		return nil, fmt.Errorf("no certificate with fingerprint %x", fingerprint)
+	}
+	if len(p.rawCerts) == 1 {
+		return nil, errors.New("can't remove the last certificate")
+	}
+	var removed *x509.Certificate
+	rawCerts := make([]*x509.Certificate, 0, len(p.rawCerts)-1)
+	for _, cert := range p.rawCerts {
+		if sha256.Sum256(cert.Raw) == fingerprint {
+			removed = cert
+			continue
+		}
+		rawCerts = append(rawCerts, cert)
+	}
+	delete(p.fingerprintToCertMap, fingerprint)
+	p.rawCerts = rawCerts
+	p.rebuildCertPool()
+	return removed, nil
 }
 
 // Included indicates whether the given cert is included in the pool.
@@ -115,10 +157,103 @@ func (p *PEMCertPool) Included(cert *x509.Certificate) bool {
 	return ok
 }
 
-// AppendCertsFromPEMs adds certs to the pool from byte slices assumed to contain PEM encoded data.
-// Skips over non certificate blocks in the data, and certificates that don't parse.
-// Returns the total number of certificates that were parsed and added to the pool.
-func (p *PEMCertPool) AppendCertsFromPEMs(pems ...[]byte) (parsed, added int) {
+// SetCerts atomically replaces the certificates in the pool with certs,
+// skipping rejected and duplicate certificates.
+//
+// It returns the certificates that were added to and removed from the pool.
+// The pool is left untouched if they are both empty, or if no certificate is
+// left once rejected ones are skipped, in which case an error is returned.
+func (p *PEMCertPool) SetCerts(certs []*x509.Certificate) (added, removed []*x509.Certificate, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fingerprintToCertMap := make(map[[sha256.Size]byte]x509.Certificate)
+	rawCerts := make([]*x509.Certificate, 0, len(certs))
+	for _, cert := range certs {
+		fingerprint := sha256.Sum256(cert.Raw)
+		if _, exists := p.rejectedFingerprints[fingerprint]; exists {
+			slog.WarnContext(context.Background(), "Rejecting certificate", slog.String("fingerprint", hex.EncodeToString(fingerprint[:])))
+			continue
+		}
+		if _, ok := fingerprintToCertMap[fingerprint]; ok {
+			continue
+		}
+		fingerprintToCertMap[fingerprint] = *cert
+		rawCerts = append(rawCerts, cert)
+		if _, ok := p.fingerprintToCertMap[fingerprint]; !ok {
+			added = append(added, cert)
+		}
+	}
+	if len(rawCerts) == 0 {
+		return nil, nil, errors.New("no certificate left after skipping rejected ones")
+	}
+	for _, cert := range p.rawCerts {
+		if _, ok := fingerprintToCertMap[sha256.Sum256(cert.Raw)]; !ok {
+			removed = append(removed, cert)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+
+	p.fingerprintToCertMap = fingerprintToCertMap
+	p.rawCerts = rawCerts
+	p.rebuildCertPool()
+	return added, removed, nil
+}
+
+// SetConstraints sets the constraints that chains rooted at certificates with
+// the given SHA-256 fingerprints must meet, and removes constraints set to nil.
+// Constraints of other certificates are left untouched.
+//
+// Constraints are kept across calls to AddCerts and SetCerts, and apply to
+// certificates added to the pool later on. The underlying CertPool is only
+// rebuilt if a constraint is set or removed.
+func (p *PEMCertPool) SetConstraints(constraints map[[sha256.Size]byte]func([]*x509.Certificate) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	for fingerprint, c := range constraints {
+		if c == nil {
+			if _, ok := p.constraints[fingerprint]; ok {
+				delete(p.constraints, fingerprint)
+				changed = true
+			}
+			continue
+		}
+		p.constraints[fingerprint] = c
+		changed = true
+	}
+	if changed {
+		p.rebuildCertPool()
+	}
+}
+
+// rebuildCertPool replaces the underlying CertPool with a new one holding the
+// certificates of the pool. p.mu must be held.
+func (p *PEMCertPool) rebuildCertPool() {
+	certPool := lax509.NewCertPool()
+	for _, cert := range p.rawCerts {
+		p.addToCertPool(certPool, sha256.Sum256(cert.Raw), cert)
+	}
+	p.certPool = certPool
+	p.generation++
+}
+
+// addToCertPool adds cert to certPool, with its constraint if it has one.
+func (p *PEMCertPool) addToCertPool(certPool *lax509.CertPool, fingerprint [sha256.Size]byte, cert *x509.Certificate) {
+	if c, ok := p.constraints[fingerprint]; ok {
+		certPool.AddCertWithConstraint(cert, c)
+		return
+	}
+	certPool.AddCert(cert)
+}
+
+// ParsePEMCerts parses certificates from byte slices assumed to contain PEM
+// encoded data. Skips over non certificate blocks in the data, and
+// certificates that don't parse.
+func ParsePEMCerts(pems ...[]byte) []*x509.Certificate {
 	certs := []*x509.Certificate{}
 	for _, pemCerts := range pems {
 		for len(pemCerts) > 0 {
@@ -141,7 +276,14 @@ func (p *PEMCertPool) AppendCertsFromPEMs(pems ...[]byte) (parsed, added int) {
 			certs = append(certs, cert)
 		}
 	}
+	return certs
+}
 
+// AppendCertsFromPEMs adds certs to the pool from byte slices assumed to contain PEM encoded data.
+// Skips over non certificate blocks in the data, and certificates that don't parse.
+// Returns the total number of certificates that were parsed and added to the pool.
+func (p *PEMCertPool) AppendCertsFromPEMs(pems ...[]byte) (parsed, added int) {
+	certs := ParsePEMCerts(pems...)
 	return len(certs), p.AddCerts(certs)
 }
 
@@ -172,9 +314,25 @@ func (p *PEMCertPool) CertPool() *lax509.CertPool {
 	return p.certPool
 }
 
+// CertPoolGeneration returns the underlying CertPool, and its generation.
+// The generation changes every time the CertPool is replaced, for instance
+// when certificates or constraints are added or removed.
+func (p *PEMCertPool) CertPoolGeneration() (*lax509.CertPool, uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.certPool, p.generation
+}
+
+// Constraint returns the constraint of cert, or nil if it has none.
+func (p *PEMCertPool) Constraint(cert *x509.Certificate) func([]*x509.Certificate) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.constraints[sha256.Sum256(cert.Raw)]
+}
+
 // RawCertificates returns a list of the raw bytes of certificates that are in this pool
 func (p *PEMCertPool) RawCertificates() []*x509.Certificate {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return p.rawCerts
+	return slices.Clone(p.rawCerts)
 }
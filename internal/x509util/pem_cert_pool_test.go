@@ -18,10 +18,13 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/transparency-dev/tesseract/internal/lax509"
+	"github.com/transparency-dev/tesseract/internal/testdata"
 	"github.com/transparency-dev/tesseract/internal/x509util"
 )
 
@@ -204,6 +207,164 @@ func TestIncluded(t *testing.T) {
 	}
 }
 
+func TestSetCerts(t *testing.T) {
+	ca, fakeCA := parsePEM(t, pemCACert), parsePEM(t, pemFakeCACert)
+	fakeCAFingerprint := sha256.Sum256(fakeCA.Raw)
+
+	// Note: tests are cumulative
+	tests := []struct {
+		name        string
+		certs       []*x509.Certificate
+		wantAdded   int
+		wantRemoved int
+		wantErr     bool
+		wantCerts   []*x509.Certificate
+	}{
+		{
+			name:      "initial",
+			certs:     []*x509.Certificate{ca},
+			wantAdded: 1,
+			wantCerts: []*x509.Certificate{ca},
+		},
+		{
+			name:      "unchanged-with-duplicates",
+			certs:     []*x509.Certificate{ca, ca},
+			wantCerts: []*x509.Certificate{ca},
+		},
+		{
+			name:        "swap",
+			certs:       []*x509.Certificate{fakeCA},
+			wantAdded:   1,
+			wantRemoved: 1,
+			wantCerts:   []*x509.Certificate{fakeCA},
+		},
+		{
+			name:      "empty",
+			wantErr:   true,
+			wantCerts: []*x509.Certificate{fakeCA},
+		},
+	}
+
+	pool, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			added, removed, err := pool.SetCerts(test.certs)
+			if (err != nil) != test.wantErr {
+				t.Errorf("SetCerts() err=%v, want err %t", err, test.wantErr)
+			}
+			if len(added) != test.wantAdded || len(removed) != test.wantRemoved {
+				t.Errorf("SetCerts()=(%d, %d) certs, want (%d, %d)", len(added), len(removed), test.wantAdded, test.wantRemoved)
+			}
+			if got, want := len(pool.RawCertificates()), len(test.wantCerts); got != want {
+				t.Errorf("pool has %d certs, want %d", got, want)
+			}
+			if got, want := len(pool.Subjects()), len(test.wantCerts); got != want {
+				t.Errorf("pool has %d subjects, want %d", got, want)
+			}
+			for _, cert := range test.wantCerts {
+				if !pool.Included(cert) {
+					t.Errorf("pool.Included(%s)=false, want true", cert.Subject)
+				}
+			}
+		})
+	}
+
+	rejectingPool, err := x509util.NewPEMCertPool([]string{fmt.Sprintf("%x", fakeCAFingerprint)})
+	if err != nil {
+		t.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	added, _, err := rejectingPool.SetCerts([]*x509.Certificate{ca, fakeCA})
+	if err != nil || len(added) != 1 || rejectingPool.Included(fakeCA) {
+		t.Errorf("SetCerts() with a rejected cert added %d certs, err=%v, want 1 without the rejected cert", len(added), err)
+	}
+	if _, _, err := rejectingPool.SetCerts([]*x509.Certificate{fakeCA}); err == nil || !rejectingPool.Included(ca) {
+		t.Errorf("SetCerts() with only rejected certs err=%v, want err and the pool untouched", err)
+	}
+}
+
+func TestAddCertsReturningAddedAndRemoveCert(t *testing.T) {
+	ca, fakeCA := parsePEM(t, pemCACert), parsePEM(t, pemFakeCACert)
+
+	pool, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	if added := pool.AddCertsReturningAdded([]*x509.Certificate{ca}); len(added) != 1 || added[0] != ca {
+		t.Errorf("AddCertsReturningAdded()=%v, want [%s]", added, ca.Subject)
+	}
+	certs := pool.RawCertificates()
+	if added := pool.AddCertsReturningAdded([]*x509.Certificate{ca, fakeCA}); len(added) != 1 || added[0] != fakeCA {
+		t.Errorf("AddCertsReturningAdded()=%v, want [%s]", added, fakeCA.Subject)
+	}
+	if len(certs) != 1 {
+		t.Errorf("RawCertificates() returned before AddCerts changed from 1 to %d certs", len(certs))
+	}
+
+	if _, err := pool.RemoveCert([sha256.Size]byte{}); err == nil {
+		t.Error("RemoveCert() of an unknown cert err=nil, want err")
+	}
+	removed, err := pool.RemoveCert(sha256.Sum256(ca.Raw))
+	if err != nil || removed != ca {
+		t.Errorf("RemoveCert()=(%v, %v), want (%s, nil)", removed, err, ca.Subject)
+	}
+	if pool.Included(ca) || len(pool.Subjects()) != 1 {
+		t.Errorf("pool still includes removed cert %s", ca.Subject)
+	}
+	if _, err := pool.RemoveCert(sha256.Sum256(fakeCA.Raw)); err == nil {
+		t.Error("RemoveCert() of the last cert err=nil, want err")
+	}
+}
+
+func TestSetConstraints(t *testing.T) {
+	root, leaf := parsePEM(t, testdata.CACertPEM), parsePEM(t, testdata.TestCertPEM)
+	rootFingerprint := sha256.Sum256(root.Raw)
+	errConstraint := errors.New("constraint")
+	reject := func([]*x509.Certificate) error { return errConstraint }
+
+	pool, err := x509util.NewPEMCertPool(nil)
+	if err != nil {
+		t.Fatalf("NewPEMCertPool() err=%v", err)
+	}
+	verify := func() error {
+		_, err := lax509.Verify(leaf, lax509.VerifyOptions{Roots: pool.CertPool()})
+		return err
+	}
+
+	// Constraints apply to certs added before and after they are set, and
+	// survive SetCerts.
+	pool.AddCerts([]*x509.Certificate{root})
+	if err := verify(); err != nil {
+		t.Fatalf("Verify() without constraint err=%v", err)
+	}
+	pool.SetConstraints(map[[sha256.Size]byte]func([]*x509.Certificate) error{rootFingerprint: reject})
+	if err := verify(); err == nil {
+		t.Errorf("Verify() with a rejecting constraint err=nil, want err")
+	}
+	if _, _, err := pool.SetCerts([]*x509.Certificate{parsePEM(t, pemFakeCACert)}); err != nil {
+		t.Fatalf("SetCerts() err=%v", err)
+	}
+	if _, _, err := pool.SetCerts([]*x509.Certificate{root}); err != nil {
+		t.Fatalf("SetCerts() err=%v", err)
+	}
+	if err := verify(); err == nil {
+		t.Errorf("Verify() with a rejecting constraint after SetCerts err=nil, want err")
+	}
+	pool.SetConstraints(map[[sha256.Size]byte]func([]*x509.Certificate) error{rootFingerprint: nil})
+	if err := verify(); err != nil {
+		t.Errorf("Verify() with a removed constraint err=%v", err)
+	}
+
+	// Removing a constraint which isn't set leaves the pool untouched.
+	_, g := pool.CertPoolGeneration()
+	pool.SetConstraints(map[[sha256.Size]byte]func([]*x509.Certificate) error{rootFingerprint: nil})
+	if _, got := pool.CertPoolGeneration(); got != g {
+		t.Errorf("CertPoolGeneration() after removing an unset constraint=%d, want %d", got, g)
+	}
+}
+
 func parsePEM(t *testing.T, pemCert string) *x509.Certificate {
 	var block *pem.Block
 	block, _ = pem.Decode([]byte(pemCert))
@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"path"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
 	"github.com/google/go-cmp/cmp"
 	"github.com/transparency-dev/tesseract/internal/types/staticct"
@@ -143,6 +145,33 @@ func (s *IssuersStorage) LoadAll(ctx context.Context) ([]storage.KV, error) {
 	return kvs, errors.Join(errs...)
 }
 
+// Get returns the value stored under key.
+func (s *IssuersStorage) Get(ctx context.Context, key []byte) ([]byte, error) {
+	objName := s.keyToObjName(key)
+	resp, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objName),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, fmt.Errorf("object %q not found in bucket %q: %w", objName, s.bucket, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to get object %q: %w", objName, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.ErrorContext(ctx, "resp.Body.Close()", slog.Any("error", err))
+		}
+	}()
+
+	v, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object body %q: %w", objName, err)
+	}
+	return v, nil
+}
+
 // AddIfNotExist stores values under their Key if there isn't an object under Key already.
 func (s *IssuersStorage) AddIfNotExist(ctx context.Context, kv []storage.KV) error {
 	eg := errgroup.Group{}
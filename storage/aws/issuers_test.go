@@ -18,9 +18,11 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -391,3 +393,28 @@ func TestAllKVAreWritten(t *testing.T) {
 		})
 	}
 }
+
+func TestGet(t *testing.T) {
+	cfg, opts, done := newTestStorage(t, testBucket)
+	defer done()
+
+	s, err := NewIssuerStorage(t.Context(), Options{Bucket: testBucket, SDKConfig: cfg, S3Options: opts})
+	if err != nil {
+		t.Fatalf("NewIssuerStorage() failed: %v", err)
+	}
+	if err := s.AddIfNotExist(t.Context(), []storage.KV{{K: []byte("issuer1"), V: []byte("issuer1 data")}}); err != nil {
+		t.Fatalf("AddIfNotExist() failed: %v", err)
+	}
+
+	got, err := s.Get(t.Context(), []byte("issuer1"))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := []byte("issuer1 data"); !bytes.Equal(got, want) {
+		t.Errorf("Get() = %s, want %s", got, want)
+	}
+
+	if _, err := s.Get(t.Context(), []byte("issuer2")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Get() on a missing key error = %v, want %v", err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	// Register the MySQL driver.
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+// LeafIndexStorage is a MySQL backed leaf hash to index store.
+//
+// It is designed to live in the same database as the Tessera antispam tables.
+type LeafIndexStorage struct {
+	db *sql.DB
+}
+
+// NewLeafIndexStorage creates a new MySQL based leaf index, in the database
+// identified by dsn.
+//
+// It creates the required tables if they don't exist yet. Callers must call
+// Close once they're done with it.
+func NewLeafIndexStorage(ctx context.Context, dsn string) (*LeafIndexStorage, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leaf index database: %v", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to leaf index database: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS LeafIndex (h BINARY(32) NOT NULL, idx BIGINT UNSIGNED NOT NULL, PRIMARY KEY (h))",
+		"CREATE TABLE IF NOT EXISTS LeafIndexFollowCoord (id INT UNSIGNED NOT NULL, nextIdx BIGINT UNSIGNED NOT NULL, PRIMARY KEY (id))",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create leaf index tables: %v", err)
+		}
+	}
+	return &LeafIndexStorage{db: db}, nil
+}
+
+// AddLeafHashes stores the index of each leaf hash.
+func (s *LeafIndexStorage) AddLeafHashes(ctx context.Context, lhs []storage.LeafHash) error {
+	if len(lhs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(lhs))
+	args := make([]any, 0, 2*len(lhs))
+	for _, lh := range lhs {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, lh.Hash[:], lh.Index)
+	}
+	// Leaf hashes commit to their index, so existing rows can be ignored.
+	q := "INSERT IGNORE INTO LeafIndex (h, idx) VALUES " + strings.Join(placeholders, ", ")
+	if _, err := s.db.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to write leaf hashes: %v", err)
+	}
+	return nil
+}
+
+// LeafIndex returns the index of the entry whose leaf hash is h.
+//
+// It returns an error wrapping os.ErrNotExist if h is not stored.
+func (s *LeafIndexStorage) LeafIndex(ctx context.Context, h [32]byte) (uint64, error) {
+	var idx uint64
+	if err := s.db.QueryRowContext(ctx, "SELECT idx FROM LeafIndex WHERE h = ?", h[:]).Scan(&idx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("leaf hash %x not found: %w", h, os.ErrNotExist)
+		}
+		return 0, fmt.Errorf("failed to read leaf hash %x: %v", h, err)
+	}
+	return idx, nil
+}
+
+// NextIndex returns the index of the first entry that hasn't been indexed by
+// following the log yet.
+func (s *LeafIndexStorage) NextIndex(ctx context.Context) (uint64, error) {
+	var idx uint64
+	if err := s.db.QueryRowContext(ctx, "SELECT nextIdx FROM LeafIndexFollowCoord WHERE id = 0").Scan(&idx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read next index: %v", err)
+	}
+	return idx, nil
+}
+
+// SetNextIndex records that all entries before idx have been indexed.
+//
+// It is a no-op if idx is lower than the current position.
+func (s *LeafIndexStorage) SetNextIndex(ctx context.Context, idx uint64) error {
+	q := "INSERT INTO LeafIndexFollowCoord (id, nextIdx) VALUES (0, ?) ON DUPLICATE KEY UPDATE nextIdx = GREATEST(nextIdx, VALUES(nextIdx))"
+	if _, err := s.db.ExecContext(ctx, q, idx); err != nil {
+		return fmt.Errorf("failed to update next index: %v", err)
+	}
+	return nil
+}
+
+// Close closes the underlying database.
+func (s *LeafIndexStorage) Close() error {
+	return s.db.Close()
+}
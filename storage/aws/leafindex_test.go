@@ -0,0 +1,98 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+// newTestLeafIndex returns a LeafIndexStorage in the MySQL database
+// identified by the MYSQL_TEST_DSN environment variable, starting from empty
+// tables. It skips the test if MYSQL_TEST_DSN is not set.
+func newTestLeafIndex(t *testing.T) *LeafIndexStorage {
+	t.Helper()
+
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN not set, skipping MySQL leaf index test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open(): %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.ExecContext(t.Context(), "DROP TABLE IF EXISTS LeafIndex, LeafIndexFollowCoord"); err != nil {
+		t.Fatalf("failed to drop leaf index tables: %v", err)
+	}
+
+	s, err := NewLeafIndexStorage(t.Context(), dsn)
+	if err != nil {
+		t.Fatalf("NewLeafIndexStorage(): %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestLeafIndexStorage(t *testing.T) {
+	s := newTestLeafIndex(t)
+	ctx := t.Context()
+
+	if got, err := s.NextIndex(ctx); err != nil || got != 0 {
+		t.Errorf("NextIndex() on an empty index=(%d, %v), want (0, nil)", got, err)
+	}
+
+	lhs := []storage.LeafHash{{Index: 0}, {Index: 1}, {Index: 42}}
+	for i := range lhs {
+		lhs[i].Hash[0] = byte(i + 1)
+	}
+	if err := s.AddLeafHashes(ctx, lhs); err != nil {
+		t.Fatalf("AddLeafHashes(): %v", err)
+	}
+	// Leaf hashes can be added more than once, by Add and by the follower.
+	if err := s.AddLeafHashes(ctx, lhs[:1]); err != nil {
+		t.Fatalf("AddLeafHashes() with an existing leaf hash: %v", err)
+	}
+	if err := s.AddLeafHashes(ctx, nil); err != nil {
+		t.Fatalf("AddLeafHashes() with no leaf hash: %v", err)
+	}
+	for _, lh := range lhs {
+		if got, err := s.LeafIndex(ctx, lh.Hash); err != nil || got != lh.Index {
+			t.Errorf("LeafIndex(%x)=(%d, %v), want (%d, nil)", lh.Hash, got, err, lh.Index)
+		}
+	}
+	if _, err := s.LeafIndex(ctx, [32]byte{0xff}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LeafIndex() of a missing leaf hash: got err %v, want os.ErrNotExist", err)
+	}
+
+	for _, tc := range []struct {
+		set, want uint64
+	}{
+		{set: 10, want: 10},
+		{set: 5, want: 10},
+		{set: 256, want: 256},
+	} {
+		if err := s.SetNextIndex(ctx, tc.set); err != nil {
+			t.Fatalf("SetNextIndex(%d): %v", tc.set, err)
+		}
+		if got, err := s.NextIndex(ctx); err != nil || got != tc.want {
+			t.Errorf("NextIndex() after SetNextIndex(%d)=(%d, %v), want (%d, nil)", tc.set, got, err, tc.want)
+		}
+	}
+}
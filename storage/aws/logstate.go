@@ -0,0 +1,101 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+// LogStateStorage stores the lifecycle state of a log in an S3 object.
+type LogStateStorage struct {
+	s3Client *s3.Client
+	bucket   string
+	objName  string
+}
+
+// NewLogStateStorage creates a new S3 based log state storage.
+//
+// The state is stored in the storage.LogStatePath object of opts.Bucket.
+func NewLogStateStorage(ctx context.Context, opts Options) (*LogStateStorage, error) {
+	var sdkConfig aws.Config
+	if opts.SDKConfig != nil {
+		sdkConfig = *opts.SDKConfig
+	} else {
+		var err error
+		sdkConfig, err = config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load default AWS configuration: %v", err)
+		}
+		// We need a non-nil options func to pass in to s3.NewFromConfig below or it'll panic, so
+		// we'll use a "do nothing" placeholder.
+		opts.S3Options = func(_ *s3.Options) {}
+	}
+	return &LogStateStorage{
+		s3Client: s3.NewFromConfig(sdkConfig, opts.S3Options),
+		bucket:   opts.Bucket,
+		objName:  storage.LogStatePath,
+	}, nil
+}
+
+// ReadLogState returns the stored state.
+func (s *LogStateStorage) ReadLogState(ctx context.Context) ([]byte, error) {
+	resp, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objName),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, fmt.Errorf("object %q not found in bucket %q: %w", s.objName, s.bucket, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to get object %q: %w", s.objName, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.ErrorContext(ctx, "resp.Body.Close()", slog.Any("error", err))
+		}
+	}()
+
+	v, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object body %q: %w", s.objName, err)
+	}
+	return v, nil
+}
+
+// WriteLogState atomically replaces the stored state.
+func (s *LogStateStorage) WriteLogState(ctx context.Context, state []byte) error {
+	if _, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.objName),
+		Body:        bytes.NewReader(state),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return fmt.Errorf("failed to write object %q to bucket %q: %w", s.objName, s.bucket, err)
+	}
+	return nil
+}
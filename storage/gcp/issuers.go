@@ -22,6 +22,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
 	"path"
 	"strings"
 
@@ -121,6 +122,29 @@ func (s *IssuersStorage) LoadAll(ctx context.Context) ([]storage.KV, error) {
 	return kvs, errors.Join(errs...)
 }
 
+// Get returns the value stored under key.
+func (s *IssuersStorage) Get(ctx context.Context, key []byte) ([]byte, error) {
+	objName := s.keyToObjName(key)
+	r, err := s.bucket.Object(objName).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("object %q not found in bucket %q: %w", objName, s.bucket.BucketName(), os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to create reader for object %q in bucket %q: %w", objName, s.bucket.BucketName(), err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.ErrorContext(ctx, "r.Close()", slog.Any("error", err))
+		}
+	}()
+
+	v, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %v", objName, err)
+	}
+	return v, nil
+}
+
 // AddIfNotExist stores values under their Key if there isn't an object under Key already.
 func (s *IssuersStorage) AddIfNotExist(ctx context.Context, kv []storage.KV) error {
 	eg := errgroup.Group{}
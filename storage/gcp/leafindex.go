@@ -0,0 +1,155 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/transparency-dev/tesseract/storage"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	leafIndexTable      = "LeafIndex"
+	leafIndexCoordTable = "LeafIndexFollowCoord"
+)
+
+// leafIndexDDL creates the leaf index tables.
+var leafIndexDDL = []string{
+	"CREATE TABLE IF NOT EXISTS " + leafIndexTable + " (h BYTES(32) NOT NULL, idx INT64 NOT NULL) PRIMARY KEY (h)",
+	"CREATE TABLE IF NOT EXISTS " + leafIndexCoordTable + " (id INT64 NOT NULL, nextIdx INT64 NOT NULL) PRIMARY KEY (id)",
+}
+
+// LeafIndexStorage is a Spanner backed leaf hash to index store.
+//
+// It is designed to live in the same database as the Tessera antispam tables.
+type LeafIndexStorage struct {
+	client *spanner.Client
+}
+
+// NewLeafIndexStorage creates a new Spanner based leaf index, in the database
+// at spannerDB: projects/{projectId}/instances/{instanceId}/databases/{databaseId}.
+//
+// It creates the required tables if they don't exist yet. Callers must call
+// Close once they're done with it.
+func NewLeafIndexStorage(ctx context.Context, spannerDB string) (*LeafIndexStorage, error) {
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Spanner admin client: %v", err)
+	}
+	defer func() { _ = adminClient.Close() }()
+
+	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   spannerDB,
+		Statements: leafIndexDDL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leaf index tables: %v", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("failed to wait for leaf index tables creation: %v", err)
+	}
+
+	client, err := spanner.NewClient(ctx, spannerDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Spanner client: %v", err)
+	}
+	return &LeafIndexStorage{client: client}, nil
+}
+
+// AddLeafHashes stores the index of each leaf hash.
+func (s *LeafIndexStorage) AddLeafHashes(ctx context.Context, lhs []storage.LeafHash) error {
+	ms := make([]*spanner.Mutation, 0, len(lhs))
+	for _, lh := range lhs {
+		ms = append(ms, spanner.InsertOrUpdate(leafIndexTable, []string{"h", "idx"}, []any{lh.Hash[:], int64(lh.Index)}))
+	}
+	if _, err := s.client.Apply(ctx, ms); err != nil {
+		return fmt.Errorf("failed to write leaf hashes: %v", err)
+	}
+	return nil
+}
+
+// LeafIndex returns the index of the entry whose leaf hash is h.
+//
+// It returns an error wrapping os.ErrNotExist if h is not stored.
+func (s *LeafIndexStorage) LeafIndex(ctx context.Context, h [32]byte) (uint64, error) {
+	row, err := s.client.Single().ReadRow(ctx, leafIndexTable, spanner.Key{h[:]}, []string{"idx"})
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return 0, fmt.Errorf("leaf hash %x not found: %w", h, os.ErrNotExist)
+		}
+		return 0, fmt.Errorf("failed to read leaf hash %x: %v", h, err)
+	}
+	var idx int64
+	if err := row.Column(0, &idx); err != nil {
+		return 0, fmt.Errorf("failed to read index of leaf hash %x: %v", h, err)
+	}
+	return uint64(idx), nil
+}
+
+// NextIndex returns the index of the first entry that hasn't been indexed by
+// following the log yet.
+func (s *LeafIndexStorage) NextIndex(ctx context.Context) (uint64, error) {
+	row, err := s.client.Single().ReadRow(ctx, leafIndexCoordTable, spanner.Key{int64(0)}, []string{"nextIdx"})
+	if err != nil {
+		if spanner.ErrCode(err) == codes.NotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read next index: %v", err)
+	}
+	var idx int64
+	if err := row.Column(0, &idx); err != nil {
+		return 0, fmt.Errorf("failed to read next index: %v", err)
+	}
+	return uint64(idx), nil
+}
+
+// SetNextIndex records that all entries before idx have been indexed.
+//
+// It is a no-op if idx is lower than the current position.
+func (s *LeafIndexStorage) SetNextIndex(ctx context.Context, idx uint64) error {
+	_, err := s.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		row, err := txn.ReadRow(ctx, leafIndexCoordTable, spanner.Key{int64(0)}, []string{"nextIdx"})
+		switch {
+		case spanner.ErrCode(err) == codes.NotFound:
+		case err != nil:
+			return fmt.Errorf("failed to read next index: %v", err)
+		default:
+			var cur int64
+			if err := row.Column(0, &cur); err != nil {
+				return fmt.Errorf("failed to read next index: %v", err)
+			}
+			if uint64(cur) >= idx {
+				return nil
+			}
+		}
+		return txn.BufferWrite([]*spanner.Mutation{spanner.InsertOrUpdate(leafIndexCoordTable, []string{"id", "nextIdx"}, []any{int64(0), int64(idx)})})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update next index: %v", err)
+	}
+	return nil
+}
+
+// Close closes the underlying Spanner client.
+func (s *LeafIndexStorage) Close() error {
+	s.client.Close()
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/transparency-dev/tesseract/storage"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestLeafIndex returns a LeafIndexStorage backed by an in-memory Spanner
+// fake, with the leaf index tables created.
+func newTestLeafIndex(t *testing.T) *LeafIndexStorage {
+	t.Helper()
+
+	srv, err := spannertest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatalf("spannertest.NewServer(): %v", err)
+	}
+	t.Cleanup(srv.Close)
+	for _, stmt := range leafIndexDDL {
+		// Tables don't exist yet: don't depend on the fake supporting IF NOT EXISTS.
+		ddl, err := spansql.ParseDDL("", strings.Replace(stmt, " IF NOT EXISTS", "", 1))
+		if err != nil {
+			t.Fatalf("ParseDDL(%q): %v", stmt, err)
+		}
+		if err := srv.UpdateDDL(ddl); err != nil {
+			t.Fatalf("UpdateDDL(%q): %v", stmt, err)
+		}
+	}
+
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient(): %v", err)
+	}
+	client, err := spanner.NewClient(t.Context(), "projects/p/instances/i/databases/d", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("spanner.NewClient(): %v", err)
+	}
+	s := &LeafIndexStorage{client: client}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestLeafIndexStorage(t *testing.T) {
+	s := newTestLeafIndex(t)
+	ctx := t.Context()
+
+	if got, err := s.NextIndex(ctx); err != nil || got != 0 {
+		t.Errorf("NextIndex() on an empty index=(%d, %v), want (0, nil)", got, err)
+	}
+
+	lhs := []storage.LeafHash{{Index: 0}, {Index: 1}, {Index: 42}}
+	for i := range lhs {
+		lhs[i].Hash[0] = byte(i + 1)
+	}
+	if err := s.AddLeafHashes(ctx, lhs); err != nil {
+		t.Fatalf("AddLeafHashes(): %v", err)
+	}
+	// Leaf hashes can be added more than once, by Add and by the follower.
+	if err := s.AddLeafHashes(ctx, lhs[:1]); err != nil {
+		t.Fatalf("AddLeafHashes() with an existing leaf hash: %v", err)
+	}
+	for _, lh := range lhs {
+		if got, err := s.LeafIndex(ctx, lh.Hash); err != nil || got != lh.Index {
+			t.Errorf("LeafIndex(%x)=(%d, %v), want (%d, nil)", lh.Hash, got, err, lh.Index)
+		}
+	}
+	if _, err := s.LeafIndex(ctx, [32]byte{0xff}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LeafIndex() of a missing leaf hash: got err %v, want os.ErrNotExist", err)
+	}
+
+	for _, tc := range []struct {
+		set, want uint64
+	}{
+		{set: 10, want: 10},
+		{set: 5, want: 10},
+		{set: 256, want: 256},
+	} {
+		if err := s.SetNextIndex(ctx, tc.set); err != nil {
+			t.Fatalf("SetNextIndex(%d): %v", tc.set, err)
+		}
+		if got, err := s.NextIndex(ctx); err != nil || got != tc.want {
+			t.Errorf("NextIndex() after SetNextIndex(%d)=(%d, %v), want (%d, nil)", tc.set, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+// LogStateStorage stores the lifecycle state of a log in a GCS object.
+type LogStateStorage struct {
+	bucket  *gcs.BucketHandle
+	objName string
+}
+
+// NewLogStateStorage creates a new GCS based log state storage.
+//
+// The state is stored in the storage.LogStatePath object of bucket.
+func NewLogStateStorage(ctx context.Context, bucket string, gcsClient *gcs.Client) (*LogStateStorage, error) {
+	if gcsClient == nil {
+		c, err := gcs.NewClient(ctx, gcs.WithJSONReads())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %v", err)
+		}
+		gcsClient = c
+	}
+	return &LogStateStorage{bucket: gcsClient.Bucket(bucket), objName: storage.LogStatePath}, nil
+}
+
+// ReadLogState returns the stored state.
+func (s *LogStateStorage) ReadLogState(ctx context.Context) ([]byte, error) {
+	r, err := s.bucket.Object(s.objName).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("object %q not found in bucket %q: %w", s.objName, s.bucket.BucketName(), os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to create reader for object %q in bucket %q: %w", s.objName, s.bucket.BucketName(), err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			slog.ErrorContext(ctx, "r.Close()", slog.Any("error", err))
+		}
+	}()
+
+	v, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %v", s.objName, err)
+	}
+	return v, nil
+}
+
+// WriteLogState atomically replaces the stored state.
+func (s *LogStateStorage) WriteLogState(ctx context.Context, state []byte) error {
+	w := s.bucket.Object(s.objName).NewWriter(ctx)
+	w.ContentType = "application/json"
+	if _, err := w.Write(state); err != nil {
+		return fmt.Errorf("failed to write object %q to bucket %q: %w", s.objName, s.bucket.BucketName(), err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close write on %q: %v", s.objName, err)
+	}
+	return nil
+}
@@ -132,6 +132,28 @@ func createEx(name string, d []byte) error {
 	})
 }
 
+// overwrite atomically creates or replaces the file at the given path with
+// the provided data, and syncs the directory containing it.
+func overwrite(name string, d []byte) error {
+	dir := filepath.Dir(name)
+	if err := mkdirAll(dir, dirPerm); err != nil {
+		return fmt.Errorf("failed to make directory structure: %w", err)
+	}
+	return syncDir(dir, func() error {
+		tmpName, err := createTemp(name, d)
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %v", err)
+		}
+		if err := os.Rename(tmpName, name); err != nil {
+			if errR := os.Remove(tmpName); errR != nil {
+				slog.WarnContext(context.Background(), "Failed to remove temporary file", slog.String("name", tmpName), slog.Any("error", errR))
+			}
+			return fmt.Errorf("failed to rename temporary file to target %q: %v", name, err)
+		}
+		return nil
+	})
+}
+
 // createTemp creates a new temporary file in the directory dir, with a name based on the provided prefix,
 // and writes the provided data to it.
 //
@@ -82,6 +82,15 @@ func (s *IssuersStorage) LoadAll(ctx context.Context) ([]storage.KV, error) {
 	return kvs, nil
 }
 
+// Get returns the value stored under key.
+func (s *IssuersStorage) Get(ctx context.Context, key []byte) ([]byte, error) {
+	k := string(key)
+	if strings.ContainsRune(k, filepath.Separator) {
+		return nil, fmt.Errorf("%q is an invalid key", k)
+	}
+	return os.ReadFile(filepath.Join(s.dir, k))
+}
+
 // AddIfNotExist stores values under their Key if there isn't an object under Key already.
 func (s *IssuersStorage) AddIfNotExist(ctx context.Context, kv []storage.KV) error {
 	errs := make([]error, 0)
@@ -17,6 +17,7 @@ package posix
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -274,3 +275,25 @@ func TestAddIfNotExist(t *testing.T) {
 		})
 	}
 }
+
+func TestGet(t *testing.T) {
+	s, err := NewIssuerStorage(t.Context(), t.TempDir())
+	if err != nil {
+		t.Fatalf("NewIssuerStorage() failed: %v", err)
+	}
+	if err := s.AddIfNotExist(t.Context(), []storage.KV{{K: []byte("issuer1"), V: []byte("issuer1 data")}}); err != nil {
+		t.Fatalf("AddIfNotExist() failed: %v", err)
+	}
+
+	got, err := s.Get(t.Context(), []byte("issuer1"))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := []byte("issuer1 data"); !bytes.Equal(got, want) {
+		t.Errorf("Get() = %s, want %s", got, want)
+	}
+
+	if _, err := s.Get(t.Context(), []byte("issuer2")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Get() on a missing key error = %v, want %v", err, os.ErrNotExist)
+	}
+}
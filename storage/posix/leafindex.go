@@ -0,0 +1,145 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+var (
+	// leafHashPrefix prefixes leaf hash keys.
+	leafHashPrefix = []byte("h/")
+	// nextIndexKey is the key under which the follower position is stored.
+	nextIndexKey = []byte("nextIndex")
+)
+
+// LeafIndexStorage is a BadgerDB backed leaf hash to index store.
+type LeafIndexStorage struct {
+	db *badger.DB
+}
+
+// NewLeafIndexStorage opens, or creates, a leaf index database in dir.
+//
+// Callers must call Close once they're done with it.
+func NewLeafIndexStorage(ctx context.Context, dir string) (*LeafIndexStorage, error) {
+	if err := mkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to make directory structure: %w", err)
+	}
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leaf index database: %v", err)
+	}
+	return &LeafIndexStorage{db: db}, nil
+}
+
+// AddLeafHashes stores the index of each leaf hash.
+func (s *LeafIndexStorage) AddLeafHashes(_ context.Context, lhs []storage.LeafHash) error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, lh := range lhs {
+		if err := wb.Set(leafHashKey(lh.Hash), binary.BigEndian.AppendUint64(nil, lh.Index)); err != nil {
+			return fmt.Errorf("failed to add leaf hash %x: %v", lh.Hash, err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to write leaf hashes: %v", err)
+	}
+	return nil
+}
+
+// LeafIndex returns the index of the entry whose leaf hash is h.
+//
+// It returns an error wrapping os.ErrNotExist if h is not stored.
+func (s *LeafIndexStorage) LeafIndex(_ context.Context, h [32]byte) (uint64, error) {
+	idx, err := s.readUint64(leafHashKey(h))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, fmt.Errorf("leaf hash %x not found: %w", h, os.ErrNotExist)
+	}
+	return idx, err
+}
+
+// NextIndex returns the index of the first entry that hasn't been indexed by
+// following the log yet.
+func (s *LeafIndexStorage) NextIndex(_ context.Context) (uint64, error) {
+	idx, err := s.readUint64(nextIndexKey)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return idx, err
+}
+
+// SetNextIndex records that all entries before idx have been indexed.
+//
+// It is a no-op if idx is lower than the current position.
+func (s *LeafIndexStorage) SetNextIndex(_ context.Context, idx uint64) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(nextIndexKey)
+		switch {
+		case errors.Is(err, badger.ErrKeyNotFound):
+		case err != nil:
+			return fmt.Errorf("failed to read next index: %v", err)
+		default:
+			cur, err := uint64Value(item)
+			if err != nil {
+				return err
+			}
+			if cur >= idx {
+				return nil
+			}
+		}
+		return txn.Set(nextIndexKey, binary.BigEndian.AppendUint64(nil, idx))
+	})
+}
+
+// Close closes the underlying database.
+func (s *LeafIndexStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *LeafIndexStorage) readUint64(key []byte) (uint64, error) {
+	var v uint64
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		v, err = uint64Value(item)
+		return err
+	})
+	return v, err
+}
+
+func uint64Value(item *badger.Item) (uint64, error) {
+	var v uint64
+	err := item.Value(func(val []byte) error {
+		if len(val) != 8 {
+			return fmt.Errorf("invalid value length %d for key %q", len(val), item.Key())
+		}
+		v = binary.BigEndian.Uint64(val)
+		return nil
+	})
+	return v, err
+}
+
+func leafHashKey(h [32]byte) []byte {
+	return append(append(make([]byte, 0, len(leafHashPrefix)+len(h)), leafHashPrefix...), h[:]...)
+}
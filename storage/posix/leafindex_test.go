@@ -0,0 +1,93 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+func TestLeafIndexStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "leafindex")
+	s, err := NewLeafIndexStorage(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("NewLeafIndexStorage(): %v", err)
+	}
+
+	lhs := []storage.LeafHash{
+		{Hash: sha256.Sum256([]byte("leaf0")), Index: 0},
+		{Hash: sha256.Sum256([]byte("leaf1")), Index: 1},
+	}
+	if err := s.AddLeafHashes(t.Context(), lhs); err != nil {
+		t.Fatalf("AddLeafHashes(): %v", err)
+	}
+	// Adding the same leaf hashes again must be a no-op.
+	if err := s.AddLeafHashes(t.Context(), lhs); err != nil {
+		t.Fatalf("AddLeafHashes(): %v", err)
+	}
+	for _, lh := range lhs {
+		got, err := s.LeafIndex(t.Context(), lh.Hash)
+		if err != nil {
+			t.Fatalf("LeafIndex(%x): %v", lh.Hash, err)
+		}
+		if got != lh.Index {
+			t.Errorf("LeafIndex(%x)=%d, want %d", lh.Hash, got, lh.Index)
+		}
+	}
+	if _, err := s.LeafIndex(t.Context(), sha256.Sum256([]byte("unknown"))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LeafIndex(unknown): got err %v, want os.ErrNotExist", err)
+	}
+
+	for _, test := range []struct {
+		set  uint64
+		want uint64
+	}{
+		{set: 2, want: 2},
+		{set: 10, want: 10},
+		{set: 5, want: 10},
+	} {
+		if err := s.SetNextIndex(t.Context(), test.set); err != nil {
+			t.Fatalf("SetNextIndex(%d): %v", test.set, err)
+		}
+		got, err := s.NextIndex(t.Context())
+		if err != nil {
+			t.Fatalf("NextIndex(): %v", err)
+		}
+		if got != test.want {
+			t.Errorf("NextIndex() after SetNextIndex(%d)=%d, want %d", test.set, got, test.want)
+		}
+	}
+
+	// Data must persist across restarts.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	s, err = NewLeafIndexStorage(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("NewLeafIndexStorage(): %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	if got, err := s.LeafIndex(t.Context(), lhs[1].Hash); err != nil || got != lhs[1].Index {
+		t.Errorf("LeafIndex(%x) after reopening=(%d, %v), want (%d, nil)", lhs[1].Hash, got, err, lhs[1].Index)
+	}
+	if got, err := s.NextIndex(t.Context()); err != nil || got != 10 {
+		t.Errorf("NextIndex() after reopening=(%d, %v), want (10, nil)", got, err)
+	}
+}
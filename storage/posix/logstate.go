@@ -0,0 +1,55 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/transparency-dev/tesseract/storage"
+)
+
+// LogStateStorage stores the lifecycle state of a log in a file.
+type LogStateStorage struct {
+	path string
+}
+
+// NewLogStateStorage creates a new POSIX based log state storage.
+//
+// The state is stored in the storage.LogStatePath file within the provided
+// root directory.
+func NewLogStateStorage(ctx context.Context, root string) (*LogStateStorage, error) {
+	p := filepath.Join(root, storage.LogStatePath)
+	if err := mkdirAll(filepath.Dir(p), dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to make directory structure: %w", err)
+	}
+	return &LogStateStorage{path: p}, nil
+}
+
+// ReadLogState returns the stored state.
+func (s *LogStateStorage) ReadLogState(_ context.Context) ([]byte, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", s.path, err)
+	}
+	return b, nil
+}
+
+// WriteLogState atomically replaces the stored state.
+func (s *LogStateStorage) WriteLogState(_ context.Context, state []byte) error {
+	return overwrite(s.path, state)
+}
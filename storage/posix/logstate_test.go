@@ -0,0 +1,60 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLogStateStorage(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLogStateStorage(t.Context(), root)
+	if err != nil {
+		t.Fatalf("NewLogStateStorage(): %v", err)
+	}
+
+	if _, err := s.ReadLogState(t.Context()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadLogState()=%v, want os.ErrNotExist", err)
+	}
+
+	for _, state := range []string{"first", "second"} {
+		if err := s.WriteLogState(t.Context(), []byte(state)); err != nil {
+			t.Fatalf("WriteLogState(%q): %v", state, err)
+		}
+		// State survives a new storage instance.
+		s, err := NewLogStateStorage(t.Context(), root)
+		if err != nil {
+			t.Fatalf("NewLogStateStorage(): %v", err)
+		}
+		got, err := s.ReadLogState(t.Context())
+		if err != nil {
+			t.Fatalf("ReadLogState(): %v", err)
+		}
+		if string(got) != state {
+			t.Errorf("ReadLogState()=%q, want %q", got, state)
+		}
+	}
+
+	// No temporary file is left behind.
+	entries, err := os.ReadDir(root + "/.state")
+	if err != nil {
+		t.Fatalf("os.ReadDir(): %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files in .state, want 1", len(entries))
+	}
+}
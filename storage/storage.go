@@ -22,18 +22,22 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	hasher "github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/ctonly"
 	"github.com/transparency-dev/tesseract/internal/logger"
 	"github.com/transparency-dev/tesseract/internal/types/rfc6962"
 	"github.com/transparency-dev/tesseract/internal/types/staticct"
+	"github.com/transparency-dev/tesseract/internal/types/tls"
 
 	"golang.org/x/mod/sumdb/note"
 )
@@ -45,11 +49,20 @@ const (
 	// Each key is 64 bytes long, so this will take up to 64MB.
 	// A CT log references ~15k unique issuer certifiates in 2024, so this gives plenty of space
 	// if we ever run into this limit, we should re-think how it works.
-	maxCachedIssuerKeys        = 1 << 20
-	RootsPrefix                = "roots/"
-	DefaultAwaiterPollInterval = 200 * time.Millisecond
+	maxCachedIssuerKeys            = 1 << 20
+	RootsPrefix                    = "roots/"
+	LogStatePath                   = ".state/lifecycle.json"
+	DefaultAwaiterPollInterval     = 200 * time.Millisecond
+	DefaultLeafIndexFollowInterval = time.Second
 )
 
+// ErrNoLeafIndex is returned by leaf index lookups when no LeafIndexStorage is configured.
+var ErrNoLeafIndex = errors.New("no leaf index configured")
+
+// ErrNoLogStateStorage is returned by log state operations when no
+// LogStateStorage is configured.
+var ErrNoLogStateStorage = errors.New("no log state storage configured")
+
 type KV struct {
 	K []byte
 	V []byte
@@ -60,27 +73,91 @@ type IssuerStorage interface {
 	AddIfNotExist(ctx context.Context, kv []KV) error
 }
 
+// IssuerReader reads issuer certificates stored under their hex encoded sha256.
+//
+// Get must return an error wrapping os.ErrNotExist if no certificate is
+// stored under key.
+type IssuerReader interface {
+	Get(ctx context.Context, key []byte) ([]byte, error)
+}
+
+// LeafHash is the Merkle leaf hash of the log entry at Index.
+type LeafHash struct {
+	Hash  [32]byte
+	Index uint64
+}
+
+// LeafIndexStorage stores the index of log entries under their Merkle leaf hash.
+//
+// Leaf hashes commit to the entry index, so a given leaf hash is always stored
+// with the same index, and AddLeafHashes can safely be called multiple times.
+type LeafIndexStorage interface {
+	// AddLeafHashes stores the index of each leaf hash.
+	AddLeafHashes(ctx context.Context, lhs []LeafHash) error
+	// LeafIndex returns the index of the entry whose leaf hash is h.
+	// It must return an error wrapping os.ErrNotExist if h is not stored.
+	LeafIndex(ctx context.Context, h [32]byte) (uint64, error)
+	// NextIndex returns the index of the first entry that hasn't been
+	// indexed by following the log yet.
+	NextIndex(ctx context.Context) (uint64, error)
+	// SetNextIndex records that all entries before idx have been indexed.
+	// It must not decrease the value returned by NextIndex.
+	SetNextIndex(ctx context.Context, idx uint64) error
+}
+
 // RootsStorage stores root certificates under their hex encoded sha256.
 type RootsStorage interface {
 	AddIfNotExist(ctx context.Context, kv []KV) error
 	LoadAll(ctx context.Context) ([]KV, error)
 }
 
+// LogStateStorage persists the lifecycle state of a log.
+type LogStateStorage interface {
+	// ReadLogState returns the stored state. It must return an error
+	// wrapping os.ErrNotExist if no state has ever been stored.
+	ReadLogState(ctx context.Context) ([]byte, error)
+	// WriteLogState atomically replaces the stored state.
+	WriteLogState(ctx context.Context, state []byte) error
+}
+
 type CTStorageOptions struct {
 	Appender            *tessera.Appender
 	Reader              tessera.LogReader
 	IssuerStorage       IssuerStorage
 	AwaiterPollInterval time.Duration
 	EnablePubAwaiter    bool
+	// LeafIndexStorage optionally stores a leaf hash to index mapping of the
+	// log's entries, populated as entries are added, and by following the log.
+	LeafIndexStorage LeafIndexStorage
+	// LeafIndexFollowInterval is the interval between two checks for new
+	// entries to index. Defaults to DefaultLeafIndexFollowInterval.
+	LeafIndexFollowInterval time.Duration
+	// LogStateStorage optionally persists the lifecycle state of the log.
+	LogStateStorage LogStateStorage
+	// AppenderShutdown optionally stops Appender from accepting new entries,
+	// and waits for those already added to be integrated. It is called by
+	// StopAppending, once the log is retired.
+	AppenderShutdown func(context.Context) error
 }
 
 // CTStorage implements ct.Storage and tessera.LogReader.
 type CTStorage struct {
 	storeData        func(context.Context, *ctonly.Entry) tessera.IndexFuture
 	storeIssuers     func(context.Context, []KV) error
+	issuerReader     IssuerReader
+	leafIndex        LeafIndexStorage
+	logState         LogStateStorage
+	appenderShutdown func(context.Context) error
 	reader           tessera.LogReader
 	awaiter          *tessera.PublicationAwaiter
 	enablePubAwaiter bool
+	// stopFollowing stops the leaf index follower, which closes followDone
+	// once it has returned.
+	stopFollowing context.CancelFunc
+	followDone    chan struct{}
+	// awaitLatency is the largest publication awaiter latency since it was
+	// last reported, in nanoseconds.
+	awaitLatency atomic.Int64
 }
 
 // NewCTStorage instantiates a CTStorage object.
@@ -96,11 +173,58 @@ func NewCTStorage(ctx context.Context, opts *CTStorageOptions) (*CTStorage, erro
 		reader:           opts.Reader,
 		awaiter:          awaiter,
 		enablePubAwaiter: opts.EnablePubAwaiter,
+		logState:         opts.LogStateStorage,
+		appenderShutdown: opts.AppenderShutdown,
+	}
+	if r, ok := opts.IssuerStorage.(IssuerReader); ok {
+		ctStorage.issuerReader = r
+	}
+	if opts.LeafIndexStorage != nil {
+		ctStorage.leafIndex = opts.LeafIndexStorage
+		followInterval := opts.LeafIndexFollowInterval
+		if followInterval <= 0 {
+			followInterval = DefaultLeafIndexFollowInterval
+		}
+		followCtx, cancel := context.WithCancel(ctx)
+		ctStorage.stopFollowing = cancel
+		ctStorage.followDone = make(chan struct{})
+		go func() {
+			defer close(ctStorage.followDone)
+			ctStorage.followLeafIndex(followCtx, followInterval)
+		}()
 	}
 
 	return ctStorage, nil
 }
 
+// StopAppending stops the appender from accepting new entries, and waits for
+// those already added to be integrated. It's a no-op if no AppenderShutdown
+// is configured.
+func (cts *CTStorage) StopAppending(ctx context.Context) error {
+	if cts.appenderShutdown == nil {
+		return nil
+	}
+	return cts.appenderShutdown(ctx)
+}
+
+// Close stops following the log to populate the leaf index, and closes the
+// leaf index storage if it implements io.Closer.
+//
+// Close must be called after the last call to Add.
+func (cts *CTStorage) Close() error {
+	if cts.leafIndex == nil {
+		return nil
+	}
+	cts.stopFollowing()
+	<-cts.followDone
+	if c, ok := cts.leafIndex.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("failed to close leaf index: %v", err)
+		}
+	}
+	return nil
+}
+
 // DedupFuture returns the SCT input matching a future.
 //
 // It waits for the entry matching the future to be integrated, fetches it and
@@ -109,19 +233,14 @@ func NewCTStorage(ctx context.Context, opts *CTStorageOptions) (*CTStorage, erro
 // TODO(phbnf): cache entries (or more) to avoid reparsing the entire leaf bundle
 func (cts *CTStorage) DedupFuture(ctx context.Context, f tessera.IndexFuture) (rfc6962.CertificateTimestamp, error) {
 	return trace1(ctx, "tesseract.storage.DedupFuture", func(ctx context.Context) (rfc6962.CertificateTimestamp, error) {
-		idx, cpRaw, err := cts.awaiter.Await(ctx, f)
+		idx, cpRaw, err := cts.await(ctx, f)
 		if err != nil {
 			return rfc6962.CertificateTimestamp{}, fmt.Errorf("error waiting for Tessera index future and its integration: %w", err)
 		}
 
-		// A https://c2sp.org/static-ct-api logsize is on the second line
-		l := bytes.SplitN(cpRaw, []byte("\n"), 3)
-		if len(l) < 2 {
-			return rfc6962.CertificateTimestamp{}, errors.New("invalid checkpoint - no size")
-		}
-		ckptSize, err := strconv.ParseUint(string(l[1]), 10, 64)
+		ckptSize, err := checkpointSize(cpRaw)
 		if err != nil {
-			return rfc6962.CertificateTimestamp{}, fmt.Errorf("invalid checkpoint - can't extract size: %v", err)
+			return rfc6962.CertificateTimestamp{}, err
 		}
 
 		eBIdx := idx.Index / layout.EntryBundleWidth
@@ -149,21 +268,53 @@ func (cts *CTStorage) DedupFuture(ctx context.Context, f tessera.IndexFuture) (r
 }
 
 // Add stores CT entries.
+//
+// It doesn't wait for entries to be published: use AwaitPublication for
+// this.
 func (cts *CTStorage) Add(ctx context.Context, entry *ctonly.Entry) (tessera.IndexFuture, error) {
 	return trace1(ctx, "tesseract.storage.Add", func(ctx context.Context) (tessera.IndexFuture, error) {
 		future := cts.storeData(ctx, entry)
-
-		if cts.enablePubAwaiter {
-			_, _, err := cts.awaiter.Await(ctx, future)
-			if err != nil {
-				return future, fmt.Errorf("error waiting for Tessera index future and its integration: %w", err)
-			}
+		if cts.leafIndex != nil {
+			future = cts.indexLeafOnResolve(ctx, entry, future)
 		}
-
 		return future, nil
 	})
 }
 
+// AwaitPublication waits for the entry of a future returned by Add to be
+// published in a checkpoint, if the publication awaiter is enabled.
+func (cts *CTStorage) AwaitPublication(ctx context.Context, future tessera.IndexFuture) error {
+	if !cts.enablePubAwaiter {
+		return nil
+	}
+	return traceErr(ctx, "tesseract.storage.AwaitPublication", func(ctx context.Context) error {
+		if _, _, err := cts.await(ctx, future); err != nil {
+			return fmt.Errorf("error waiting for Tessera index future and its integration: %w", err)
+		}
+		return nil
+	})
+}
+
+// await waits for a future to be published, and records how long it took.
+func (cts *CTStorage) await(ctx context.Context, f tessera.IndexFuture) (tessera.Index, []byte, error) {
+	start := time.Now()
+	idx, cpRaw, err := cts.awaiter.Await(ctx, f)
+	latency := int64(time.Since(start))
+	for {
+		prev := cts.awaitLatency.Load()
+		if latency <= prev || cts.awaitLatency.CompareAndSwap(prev, latency) {
+			break
+		}
+	}
+	return idx, cpRaw, err
+}
+
+// AwaitLatency returns the largest time spent waiting for entries to be
+// published since the previous call, or 0 if none were waited for.
+func (cts *CTStorage) AwaitLatency() time.Duration {
+	return time.Duration(cts.awaitLatency.Swap(0))
+}
+
 // AddIssuerChain stores every chain certificate under its sha256.
 //
 // If an object is already stored under this hash, continues.
@@ -183,6 +334,199 @@ func (cts *CTStorage) AddIssuerChain(ctx context.Context, chain []*x509.Certific
 	})
 }
 
+// ReadCheckpoint returns the latest checkpoint published by the log.
+func (cts *CTStorage) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return trace1(ctx, "tesseract.storage.ReadCheckpoint", cts.reader.ReadCheckpoint)
+}
+
+// ReadTile returns the hash tile at the given level and index.
+//
+// p is the number of entries in a partial tile, or 0 for full tiles.
+func (cts *CTStorage) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return trace1(ctx, "tesseract.storage.ReadTile", func(ctx context.Context) ([]byte, error) {
+		return cts.reader.ReadTile(ctx, level, index, p)
+	})
+}
+
+// ReadEntryBundle returns the entry bundle at the given index.
+//
+// p is the number of entries in a partial bundle, or 0 for full bundles.
+func (cts *CTStorage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	return trace1(ctx, "tesseract.storage.ReadEntryBundle", func(ctx context.Context) ([]byte, error) {
+		return cts.reader.ReadEntryBundle(ctx, index, p)
+	})
+}
+
+// ReadIssuer returns the issuer certificate whose sha256 is fingerprint.
+//
+// It returns an error if the underlying IssuerStorage can't read issuers back.
+func (cts *CTStorage) ReadIssuer(ctx context.Context, fingerprint [32]byte) ([]byte, error) {
+	return trace1(ctx, "tesseract.storage.ReadIssuer", func(ctx context.Context) ([]byte, error) {
+		if cts.issuerReader == nil {
+			return nil, errors.New("issuer storage does not support reads")
+		}
+		return cts.issuerReader.Get(ctx, []byte(hex.EncodeToString(fingerprint[:])))
+	})
+}
+
+// LeafIndex returns the index of the entry whose Merkle leaf hash is leafHash.
+//
+// It returns an error wrapping os.ErrNotExist if the leaf hash is unknown,
+// and ErrNoLeafIndex if no LeafIndexStorage is configured.
+func (cts *CTStorage) LeafIndex(ctx context.Context, leafHash [32]byte) (uint64, error) {
+	return trace1(ctx, "tesseract.storage.LeafIndex", func(ctx context.Context) (uint64, error) {
+		if cts.leafIndex == nil {
+			return 0, ErrNoLeafIndex
+		}
+		return cts.leafIndex.LeafIndex(ctx, leafHash)
+	})
+}
+
+// ReadLogState returns the stored lifecycle state of the log.
+//
+// It returns an error wrapping os.ErrNotExist if no state has ever been
+// stored, and ErrNoLogStateStorage if no LogStateStorage is configured.
+func (cts *CTStorage) ReadLogState(ctx context.Context) ([]byte, error) {
+	return trace1(ctx, "tesseract.storage.ReadLogState", func(ctx context.Context) ([]byte, error) {
+		if cts.logState == nil {
+			return nil, ErrNoLogStateStorage
+		}
+		return cts.logState.ReadLogState(ctx)
+	})
+}
+
+// WriteLogState stores the lifecycle state of the log.
+//
+// It returns ErrNoLogStateStorage if no LogStateStorage is configured.
+func (cts *CTStorage) WriteLogState(ctx context.Context, state []byte) error {
+	return traceErr(ctx, "tesseract.storage.WriteLogState", func(ctx context.Context) error {
+		if cts.logState == nil {
+			return ErrNoLogStateStorage
+		}
+		return cts.logState.WriteLogState(ctx, state)
+	})
+}
+
+// indexLeafOnResolve returns a future which stores the leaf hash of entry
+// when it resolves to a new index.
+//
+// Failing to store the leaf hash doesn't fail the future, since the leaf index
+// follower will eventually index the entry.
+func (cts *CTStorage) indexLeafOnResolve(ctx context.Context, entry *ctonly.Entry, f tessera.IndexFuture) tessera.IndexFuture {
+	return sync.OnceValues(func() (tessera.Index, error) {
+		idx, err := f()
+		if err != nil || idx.IsDup {
+			return idx, err
+		}
+		lh := LeafHash{Index: idx.Index}
+		copy(lh.Hash[:], hasher.DefaultHasher.HashLeaf(entry.MerkleTreeLeaf(idx.Index)))
+		if err := cts.leafIndex.AddLeafHashes(ctx, []LeafHash{lh}); err != nil {
+			slog.WarnContext(ctx, "failed to add leaf hash to the leaf index", slog.Uint64("index", idx.Index), slog.Any("error", err))
+		}
+		return idx, nil
+	})
+}
+
+// followLeafIndex indexes entries published by the log every interval, until
+// ctx is done.
+//
+// This backfills entries that were added before the leaf index was enabled,
+// or that the Add path failed to index.
+func (cts *CTStorage) followLeafIndex(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := cts.indexLeaves(ctx); err != nil {
+			slog.WarnContext(ctx, "leaf index follower failed", slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// indexLeaves adds the leaf hash of every entry published by the log and not
+// yet followed to the leaf index.
+func (cts *CTStorage) indexLeaves(ctx context.Context) error {
+	return traceErr(ctx, "tesseract.storage.indexLeaves", func(ctx context.Context) error {
+		next, err := cts.leafIndex.NextIndex(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read leaf index position: %v", err)
+		}
+		cpRaw, err := cts.reader.ReadCheckpoint(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint: %v", err)
+		}
+		size, err := checkpointSize(cpRaw)
+		if err != nil {
+			return err
+		}
+
+		for next < size {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			eBIdx := next / layout.EntryBundleWidth
+			p := layout.PartialTileSize(0, eBIdx, size)
+			eBRaw, err := cts.reader.ReadEntryBundle(ctx, eBIdx, p)
+			if errors.Is(err, os.ErrNotExist) && p > 0 {
+				// The partial bundle might have been garbage collected since a full one was published.
+				eBRaw, err = cts.reader.ReadEntryBundle(ctx, eBIdx, 0)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to fetch entry bundle at index %d: %v", eBIdx, err)
+			}
+			eb := staticct.EntryBundle{}
+			if err := eb.UnmarshalText(eBRaw); err != nil {
+				return fmt.Errorf("failed to parse entry bundle at index %d: %v", eBIdx, err)
+			}
+
+			first := eBIdx * layout.EntryBundleWidth
+			lhs := make([]LeafHash, 0, len(eb.Entries))
+			for i := next - first; i < uint64(len(eb.Entries)) && first+i < size; i++ {
+				e := staticct.Entry{}
+				if err := e.UnmarshalText(eb.Entries[i]); err != nil {
+					return fmt.Errorf("failed to parse entry %d: %v", first+i, err)
+				}
+				leaf, err := tls.Marshal(e.MerkleTreeLeaf())
+				if err != nil {
+					return fmt.Errorf("failed to marshal leaf %d: %v", first+i, err)
+				}
+				lh := LeafHash{Index: first + i}
+				copy(lh.Hash[:], hasher.DefaultHasher.HashLeaf(leaf))
+				lhs = append(lhs, lh)
+			}
+			if len(lhs) == 0 {
+				return fmt.Errorf("entry bundle at index %d has no entry from index %d", eBIdx, next)
+			}
+			if err := cts.leafIndex.AddLeafHashes(ctx, lhs); err != nil {
+				return fmt.Errorf("failed to add leaf hashes: %v", err)
+			}
+			next += uint64(len(lhs))
+			if err := cts.leafIndex.SetNextIndex(ctx, next); err != nil {
+				return fmt.Errorf("failed to update leaf index position: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkpointSize returns the log size of a https://c2sp.org/static-ct-api checkpoint.
+func checkpointSize(cpRaw []byte) (uint64, error) {
+	// A https://c2sp.org/static-ct-api logsize is on the second line
+	l := bytes.SplitN(cpRaw, []byte("\n"), 3)
+	if len(l) < 2 {
+		return 0, errors.New("invalid checkpoint - no size")
+	}
+	size, err := strconv.ParseUint(string(l[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checkpoint - can't extract size: %v", err)
+	}
+	return size, nil
+}
+
 // cachedStoreIssuers returns a caching wrapper for an IssuerStorage
 //
 // This is intended to make querying faster. It does not keep a copy of the certs, only sha256.
@@ -0,0 +1,174 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	hasher "github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tesseract/internal/types/staticct"
+	"github.com/transparency-dev/tesseract/internal/types/tls"
+	"github.com/transparency-dev/tesseract/testdata"
+)
+
+// fakeLogReader serves testdata.ExampleFullTile as the first, full, entry
+// bundle of a log of the given size.
+type fakeLogReader struct {
+	tessera.LogReader
+	size uint64
+}
+
+func (f *fakeLogReader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return fmt.Appendf(nil, "example.com\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", f.size), nil
+}
+
+func (f *fakeLogReader) ReadEntryBundle(_ context.Context, index uint64, p uint8) ([]byte, error) {
+	if index != 0 || p != 0 {
+		return nil, os.ErrNotExist
+	}
+	return testdata.ExampleFullTile, nil
+}
+
+// memLeafIndex is an in-memory LeafIndexStorage.
+type memLeafIndex struct {
+	mu     sync.Mutex
+	m      map[[32]byte]uint64
+	next   uint64
+	closed bool
+}
+
+func (m *memLeafIndex) AddLeafHashes(_ context.Context, lhs []LeafHash) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, lh := range lhs {
+		m.m[lh.Hash] = lh.Index
+	}
+	return nil
+}
+
+func (m *memLeafIndex) LeafIndex(_ context.Context, h [32]byte) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	idx, ok := m.m[h]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return idx, nil
+}
+
+func (m *memLeafIndex) NextIndex(_ context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.next, nil
+}
+
+func (m *memLeafIndex) SetNextIndex(_ context.Context, idx uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next = max(m.next, idx)
+	return nil
+}
+
+func (m *memLeafIndex) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+func TestCloseStopsLeafIndex(t *testing.T) {
+	reader := &fakeLogReader{size: 100}
+	leafIndex := &memLeafIndex{m: map[[32]byte]uint64{}}
+	cts, err := NewCTStorage(t.Context(), &CTStorageOptions{
+		Reader:                  reader,
+		LeafIndexStorage:        leafIndex,
+		LeafIndexFollowInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCTStorage(): %v", err)
+	}
+	if err := cts.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	leafIndex.mu.Lock()
+	defer leafIndex.mu.Unlock()
+	if !leafIndex.closed {
+		t.Error("Close() didn't close the leaf index")
+	}
+
+	if err := (&CTStorage{}).Close(); err != nil {
+		t.Errorf("Close() without a leaf index: %v", err)
+	}
+}
+
+func TestIndexLeaves(t *testing.T) {
+	eb := staticct.EntryBundle{}
+	if err := eb.UnmarshalText(testdata.ExampleFullTile); err != nil {
+		t.Fatalf("failed to unmarshal full tile: %v", err)
+	}
+	leafHashes := make([][32]byte, 0, len(eb.Entries))
+	for i, raw := range eb.Entries {
+		e := staticct.Entry{}
+		if err := e.UnmarshalText(raw); err != nil {
+			t.Fatalf("UnmarshalText(%d): %v", i, err)
+		}
+		leaf, err := tls.Marshal(e.MerkleTreeLeaf())
+		if err != nil {
+			t.Fatalf("tls.Marshal(%d): %v", i, err)
+		}
+		leafHashes = append(leafHashes, [32]byte(hasher.DefaultHasher.HashLeaf(leaf)))
+	}
+
+	reader := &fakeLogReader{}
+	leafIndex := &memLeafIndex{m: map[[32]byte]uint64{}}
+	cts := &CTStorage{reader: reader, leafIndex: leafIndex}
+
+	// Index a log of increasing sizes. Partial bundles are never found, to
+	// exercise falling back to the full bundle.
+	for _, size := range []uint64{0, 100, 100, uint64(len(leafHashes))} {
+		reader.size = size
+		if err := cts.indexLeaves(t.Context()); err != nil {
+			t.Fatalf("indexLeaves() with size %d: %v", size, err)
+		}
+		if got, err := leafIndex.NextIndex(t.Context()); err != nil || got != size {
+			t.Errorf("NextIndex() after indexing size %d=(%d, %v), want (%d, nil)", size, got, err, size)
+		}
+		if got, want := len(leafIndex.m), int(size); got != want {
+			t.Errorf("got %d indexed leaves for size %d, want %d", got, size, want)
+		}
+	}
+
+	for i, lh := range leafHashes {
+		got, err := cts.LeafIndex(t.Context(), lh)
+		if err != nil {
+			t.Fatalf("LeafIndex(%x): %v", lh, err)
+		}
+		if got != uint64(i) {
+			t.Errorf("LeafIndex(%x)=%d, want %d", lh, got, i)
+		}
+	}
+
+	if _, err := (&CTStorage{}).LeafIndex(t.Context(), leafHashes[0]); !errors.Is(err, ErrNoLeafIndex) {
+		t.Errorf("LeafIndex() without a leaf index: got err %v, want ErrNoLeafIndex", err)
+	}
+}